@@ -5,7 +5,9 @@ import (
 	"os"
 
 	"github.com/neverprepared/shell-profile-manager/internal/cli"
+	"github.com/neverprepared/shell-profile-manager/internal/commands"
 	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
 )
 
 func main() {
@@ -17,12 +19,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	commands.SetBackupDir(cfg.BackupDir)
+
 	// Create CLI instance
 	app := cli.NewApp(cfg.ProfilesDir)
 
 	// Run the CLI
 	if err := app.Run(os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		app.PrintError(os.Stderr, err)
+		os.Exit(errs.ExitStatus(err))
 	}
 }
@@ -5,15 +5,25 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/neverprepared/shell-profile-manager/internal/commands"
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
 	"github.com/neverprepared/shell-profile-manager/internal/profile"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
+	"github.com/neverprepared/shell-profile-manager/internal/util"
 )
 
 type App struct {
 	profilesDir string
+
+	// profilesDirFlag is the global --profiles-dir override, if given
+	profilesDirFlag string
+
+	// jsonErrors is set by --json or --format json
+	jsonErrors bool
 }
 
 func NewApp(profilesDir string) *App {
@@ -27,12 +37,17 @@ func NewApp(profilesDir string) *App {
 func (a *App) requireDirenv() error {
 	_, err := exec.LookPath("direnv")
 	if err != nil {
-		return fmt.Errorf("direnv is required but not found in PATH\n\n  Install direnv:\n    brew install direnv    # macOS/Linux (Homebrew)\n    apt install direnv     # Debian/Ubuntu\n\n  Then add the shell hook to your shell config:\n    eval \"$(direnv hook bash)\"   # ~/.bashrc\n    eval \"$(direnv hook zsh)\"    # ~/.zshrc\n\n  See https://direnv.net/ for more details")
+		return errs.Wrapf(errs.ErrDirenvNotFound, "direnv is required but not found in PATH\n\n  Install direnv:\n    brew install direnv    # macOS/Linux (Homebrew)\n    apt install direnv     # Debian/Ubuntu\n\n  Then add the shell hook to your shell config:\n    eval \"$(direnv hook bash)\"   # ~/.bashrc\n    eval \"$(direnv hook zsh)\"    # ~/.zshrc\n\n  See https://direnv.net/ for more details")
 	}
 	return nil
 }
 
 func (a *App) Run(args []string) error {
+	args, err := a.parseGlobalFlags(args)
+	if err != nil {
+		return err
+	}
+
 	if len(args) == 0 {
 		a.showHelp()
 		return nil
@@ -43,7 +58,7 @@ func (a *App) Run(args []string) error {
 
 	// Commands that require direnv to be installed
 	switch command {
-	case "help", "--help", "-h", "init":
+	case "help", "--help", "-h", "init", "schema":
 		// These commands don't require direnv
 	default:
 		if err := a.requireDirenv(); err != nil {
@@ -58,6 +73,8 @@ func (a *App) Run(args []string) error {
 		return a.handleCreate(args)
 	case "update", "upgrade":
 		return a.handleUpdate(args)
+	case "apply":
+		return a.handleApply(args)
 	case "list", "ls":
 		return a.handleList(args)
 	case "select", "use":
@@ -68,12 +85,56 @@ func (a *App) Run(args []string) error {
 		return a.handleRestore(args)
 	case "info", "current", "show":
 		return a.handleInfo(args)
+	case "whoami":
+		return a.handleWhoami(args)
+	case "env":
+		return a.handleEnv(args)
+	case "secrets":
+		return a.handleSecrets(args)
+	case "recent":
+		return a.handleRecent(args)
+	case "doctor":
+		return a.handleDoctor(args)
+	case "set-identity":
+		return a.handleSetIdentity(args)
+	case "fix-perms":
+		return a.handleFixPerms(args)
+	case "conflicts":
+		return a.handleConflicts(args)
+	case "reset":
+		return a.handleReset(args)
 	case "status":
 		return a.handleStatus(args)
 	case "sync":
 		return a.handleSync(args)
+	case "collection":
+		return a.handleCollection(args)
 	case "dotfiles":
 		return a.handleDotfiles(args)
+	case "readme":
+		return a.handleReadme(args)
+	case "edit":
+		return a.handleEdit(args)
+	case "templates", "template":
+		return a.handleTemplates(args)
+	case "tag", "tags":
+		return a.handleTag(args)
+	case "path":
+		return a.handlePath(args)
+	case "exists":
+		return a.handleExists(args)
+	case "export-all":
+		return a.handleExportAll(args)
+	case "import-all":
+		return a.handleImportAll(args)
+	case "schema":
+		return commands.ShowSchema()
+	case "archive":
+		return a.handleArchive(args, false)
+	case "unarchive":
+		return a.handleArchive(args, true)
+	case "rename", "mv":
+		return a.handleRename(args)
 	case "help", "--help", "-h":
 		a.showHelp()
 		return nil
@@ -84,8 +145,64 @@ func (a *App) Run(args []string) error {
 	}
 }
 
+// parseGlobalFlags applies flags accepted by every command and returns the
+// remaining arguments. --profiles-dir and --backup-dir take precedence over
+// the config file.
+func (a *App) parseGlobalFlags(args []string) ([]string, error) {
+	remaining := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--yes", "-y":
+			ui.SetAssumeYes(true)
+		case "--no-color":
+			ui.SetColorEnabled(false)
+		case "--ascii":
+			ui.SetASCIISymbols(true)
+		case "--symbols":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--symbols requires ascii or unicode")
+			}
+			switch args[i+1] {
+			case "ascii":
+				ui.SetASCIISymbols(true)
+			case "unicode":
+				ui.SetASCIISymbols(false)
+			default:
+				return nil, fmt.Errorf("unknown symbols: %s (use ascii or unicode)", args[i+1])
+			}
+			i++
+		case "--json":
+			a.jsonErrors = true
+		case "--format", "--format=json":
+			// Left for the command to handle; JSON output implies JSON errors
+			if arg == "--format=json" || (i+1 < len(args) && args[i+1] == "json") {
+				a.jsonErrors = true
+			}
+			remaining = append(remaining, arg)
+		case "--profiles-dir":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--profiles-dir requires a path")
+			}
+			a.profilesDirFlag = args[i+1]
+			a.profilesDir = config.ExpandPath(args[i+1])
+			i++
+		case "--backup-dir":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--backup-dir requires a path")
+			}
+			commands.SetBackupDir(config.ExpandPath(args[i+1]))
+			i++
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return remaining, nil
+}
+
 func (a *App) handleInit(args []string) error {
-	opts := commands.InitOptions{}
+	// The global --profiles-dir, already parsed, doubles as init's own flag
+	opts := commands.InitOptions{ProfilesDir: a.profilesDirFlag}
 
 	// Parse arguments
 	for i := 0; i < len(args); i++ {
@@ -96,26 +213,25 @@ func (a *App) handleInit(args []string) error {
 			return nil
 		case "-f", "--force":
 			opts.Force = true
-		case "--profiles-dir":
-			if i+1 < len(args) {
-				opts.ProfilesDir = args[i+1]
-				i++
-			}
 		case "--interactive", "-i":
 			opts.Interactive = true
+		case "--git":
+			opts.Git = true
 		}
 	}
 
-	return commands.InitConfig(opts)
+	if opts.Force || opts.Interactive {
+		return commands.InitConfig(opts)
+	}
+	return commands.InitProfilesDir(opts)
 }
 
 func (a *App) handleCreate(args []string) error {
-	opts := commands.CreateOptions{
-		Template: "basic",
-	}
+	opts := commands.CreateOptions{}
 
 	// Track if any non-interactive flags are provided
 	hasNonInteractiveFlags := false
+	noInteractive := false
 
 	// Parse arguments
 	for i := 0; i < len(args); i++ {
@@ -124,6 +240,8 @@ func (a *App) handleCreate(args []string) error {
 		case "-h", "--help":
 			a.showCreateHelp()
 			return nil
+		case "--list-templates-json":
+			return commands.ListTemplatesJSON()
 		case "-f", "--force":
 			opts.Force = true
 			hasNonInteractiveFlags = true
@@ -133,6 +251,104 @@ func (a *App) handleCreate(args []string) error {
 				i++
 				hasNonInteractiveFlags = true
 			}
+		case "--template-dir":
+			if i+1 < len(args) {
+				opts.TemplateDir = args[i+1]
+				i++
+			}
+		case "--refresh":
+			opts.Refresh = true
+		case "--allow":
+			opts.Allow = true
+		case "--encrypt-cache":
+			opts.EncryptCache = true
+		case "--op-account":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--op-account requires a 1Password account")
+			}
+			opts.OpAccount = args[i+1]
+			i++
+		case "--op-field-prefix":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--op-field-prefix requires a label prefix")
+			}
+			opts.OpFieldPrefix = args[i+1]
+			i++
+		case "--include-totp":
+			opts.IncludeTOTP = true
+		case "--op-exclude-field":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--op-exclude-field requires a field label")
+			}
+			opts.OpExcludeFields = append(opts.OpExcludeFields, args[i+1])
+			i++
+		case "--vault":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--vault requires a 1Password vault name")
+			}
+			opts.Vaults = append(opts.Vaults, args[i+1])
+			i++
+		case "--watch":
+			opts.NoWatch = false
+		case "--no-watch":
+			opts.NoWatch = true
+		case "--path-add":
+			if i+1 < len(args) {
+				opts.PathAdd = append(opts.PathAdd, args[i+1])
+				i++
+			}
+		case "--shared-ssh-key":
+			if i+1 < len(args) {
+				opts.SharedSSHKey = args[i+1]
+				i++
+			}
+		case "--git-include":
+			if i+1 < len(args) {
+				opts.GitIncludes = append(opts.GitIncludes, args[i+1])
+				i++
+			}
+		case "--default-branch":
+			if i+1 < len(args) {
+				opts.DefaultBranch = args[i+1]
+				i++
+			}
+		case "--edit":
+			opts.Edit = true
+		case "--with-gitattributes":
+			opts.Gitattributes = true
+		case "--no-cloud":
+			opts.NoCloud = true
+		case "--no-readme":
+			opts.NoReadme = true
+		case "--relative-ssh-paths":
+			opts.RelativeSSHPaths = true
+		case "--from-profile", "--profile-template-from":
+			if i+1 < len(args) {
+				opts.FromProfile = args[i+1]
+				i++
+				hasNonInteractiveFlags = true
+			}
+		case "--extra-dir":
+			if i+1 < len(args) {
+				opts.ExtraDirs = append(opts.ExtraDirs, args[i+1])
+				i++
+			}
+		case "--copy-from-home":
+			if i+1 < len(args) {
+				for _, tool := range strings.Split(args[i+1], ",") {
+					if tool = strings.TrimSpace(tool); tool != "" {
+						opts.CopyFromHome = append(opts.CopyFromHome, tool)
+					}
+				}
+				i++
+			}
+		case "--include-creds":
+			opts.IncludeCreds = true
+		case "--post-create-hook":
+			if i+1 < len(args) {
+				opts.PostCreateHook = args[i+1]
+				i++
+			}
 		case "--git-name":
 			if i+1 < len(args) {
 				opts.GitName = args[i+1]
@@ -149,6 +365,7 @@ func (a *App) handleCreate(args []string) error {
 			opts.Interactive = true
 		case "--no-interactive":
 			opts.Interactive = false
+			noInteractive = true
 			hasNonInteractiveFlags = true
 		case "--dry-run":
 			opts.DryRun = true
@@ -179,11 +396,53 @@ func (a *App) handleCreate(args []string) error {
 		opts.Interactive = true
 	}
 
+	// --from-profile supplies the template unless one is given
+	if opts.Template == "" && opts.FromProfile == "" {
+		opts.Template = "basic"
+	}
+
+	// An editor needs the terminal
+	if opts.Edit && (noInteractive || !ui.IsInteractive()) {
+		opts.Edit = false
+	}
+
+	// Fall back to the template_dir and post_create_hook config keys and
+	// the template's defaults
+	if cfg, err := config.LoadConfig(); err == nil {
+		if defaults, ok := cfg.Templates[opts.Template]; ok {
+			if opts.GitName == "" {
+				opts.GitName = defaults.GitName
+			}
+			if opts.GitEmail == "" {
+				opts.GitEmail = defaults.GitEmail
+			}
+		}
+		if opts.TemplateDir == "" {
+			opts.TemplateDir = cfg.TemplateDir
+		}
+		if opts.PostCreateHook == "" {
+			opts.PostCreateHook = cfg.PostCreateHook
+		}
+		if opts.FromProfile == "" {
+			if opts.OpAccount == "" {
+				opts.OpAccount = cfg.OpAccount
+			}
+			if opts.OpFieldPrefix == "" {
+				opts.OpFieldPrefix = cfg.OpFieldPrefix
+			}
+			if len(opts.OpExcludeFields) == 0 {
+				opts.OpExcludeFields = cfg.OpExcludeFields
+			}
+		}
+	}
+
 	return commands.CreateProfile(a.profilesDir, opts)
 }
 
 func (a *App) handleUpdate(args []string) error {
 	opts := commands.UpdateOptions{}
+	rollbackTo := ""
+	all := false
 
 	// Parse arguments
 	for i := 0; i < len(args); i++ {
@@ -194,10 +453,38 @@ func (a *App) handleUpdate(args []string) error {
 			return nil
 		case "-f", "--force":
 			opts.Force = true
+		case "--overwrite":
+			opts.Overwrite = true
+		case "--skip-backup-confirm":
+			opts.SkipBackupConfirm = true
 		case "--dry-run":
 			opts.DryRun = true
+		case "--explain":
+			opts.Explain = true
 		case "--no-backup":
 			opts.NoBackup = true
+		case "--allow":
+			opts.Allow = true
+		case "--create-vault":
+			opts.CreateVault = true
+		case "--relative-ssh-paths":
+			opts.RelativeSSHPaths = true
+		case "--all", "-a":
+			all = true
+		case "--plan-file":
+			if i+1 < len(args) {
+				opts.PlanFile = args[i+1]
+				i++
+			} else {
+				return fmt.Errorf("--plan-file requires a path")
+			}
+		case "--rollback-to":
+			if i+1 < len(args) {
+				rollbackTo = args[i+1]
+				i++
+			} else {
+				return fmt.Errorf("--rollback-to requires a schema version")
+			}
 		default:
 			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
 				opts.ProfileName = arg
@@ -205,34 +492,146 @@ func (a *App) handleUpdate(args []string) error {
 		}
 	}
 
+	if rollbackTo != "" {
+		if opts.ProfileName == "" {
+			return fmt.Errorf("profile name is required with --rollback-to")
+		}
+		return commands.RollbackMigration(a.profilesDir, opts.ProfileName, rollbackTo)
+	}
+
+	if all {
+		if opts.ProfileName != "" {
+			return fmt.Errorf("--all cannot be combined with a profile name")
+		}
+		return commands.MigrateAll(a.profilesDir, opts)
+	}
+
 	// Profile name is optional - will show interactive selection if not provided
 	return commands.UpdateProfile(a.profilesDir, opts)
 }
 
+func (a *App) handleApply(args []string) error {
+	opts := commands.ApplyOptions{}
+	planFile := ""
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-h", "--help":
+			a.showApplyHelp()
+			return nil
+		case "-f", "--force":
+			opts.Force = true
+		case "--overwrite":
+			opts.Overwrite = true
+		case "--no-backup":
+			opts.NoBackup = true
+		case "--plan-file":
+			if i+1 < len(args) {
+				planFile = args[i+1]
+				i++
+			} else {
+				return fmt.Errorf("--plan-file requires a path")
+			}
+		default:
+			if planFile == "" && !strings.HasPrefix(arg, "-") {
+				planFile = arg
+			}
+		}
+	}
+
+	if planFile == "" {
+		return fmt.Errorf("plan file is required (see 'shell-profiler apply --help')")
+	}
+	return commands.ApplyPlan(a.profilesDir, planFile, opts)
+}
+
 func (a *App) handleList(args []string) error {
 	opts := commands.ListOptions{
 		Interactive: true, // Default to interactive
 	}
+	page := 0
 
 	// Parse arguments
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch arg {
+		case "-t", "--tag":
+			if i+1 < len(args) {
+				opts.Tags = append(opts.Tags, args[i+1])
+				i++
+			} else {
+				return fmt.Errorf("--tag requires a value")
+			}
 		case "-v", "--verbose":
 			opts.Verbose = true
 			opts.Interactive = false // Verbose disables interactive
 		case "-c", "--config":
 			opts.ShowConfig = true
 			opts.Interactive = false // Config disables interactive
+		case "--include-archived":
+			opts.IncludeArchived = true
+		case "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires table, plain or json")
+			}
+			opts.Format = args[i+1]
+			opts.Interactive = false
+			i++
 		case "-i", "--interactive":
 			opts.Interactive = true
 		case "--no-interactive":
 			opts.Interactive = false
+		case "--since", "--modified-before":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires an age, e.g. 90d", arg)
+			}
+			age, err := util.ParseHumanDuration(args[i+1])
+			if err != nil {
+				return err
+			}
+			i++
+			if arg == "--since" {
+				opts.Since = age
+			} else {
+				opts.ModifiedBefore = age
+			}
+		case "--sort":
+			if i+1 >= len(args) || (args[i+1] != commands.ListSortName && args[i+1] != commands.ListSortModified) {
+				return fmt.Errorf("--sort requires %s or %s", commands.ListSortName, commands.ListSortModified)
+			}
+			opts.Sort = args[i+1]
+			i++
+		case "--limit", "--offset", "--page":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a number", arg)
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 || (arg == "--page" && n == 0) {
+				return fmt.Errorf("invalid %s value: %s", arg, args[i+1])
+			}
+			i++
+			switch arg {
+			case "--limit":
+				opts.Limit = n
+			case "--offset":
+				opts.Offset = n
+			case "--page":
+				page = n
+			}
 		case "-h", "--help":
 			a.showListHelp()
 			return nil
 		}
 	}
 
+	if page > 0 {
+		if opts.Limit == 0 {
+			return fmt.Errorf("--page requires --limit")
+		}
+		opts.Offset = (page - 1) * opts.Limit
+	}
+
 	return commands.ListProfiles(a.profilesDir, opts)
 }
 
@@ -250,6 +649,8 @@ func (a *App) handleDelete(args []string) error {
 			opts.Force = true
 		case "--dry-run":
 			opts.DryRun = true
+		case "--preserve-data":
+			opts.PreserveData = true
 		case "--no-interactive":
 			// This is handled in DeleteProfile - if profile name is provided, interactive is skipped
 		default:
@@ -385,92 +786,838 @@ func (a *App) handleSync(args []string) error {
 	}
 }
 
-func (a *App) handleInfo(_args []string) error {
-	// This can be implemented in Go since it reads environment variables
-	pm := profile.NewManager(a.profilesDir)
-	return pm.ShowInfo()
-}
-
-func (a *App) handleSelect(args []string) error {
-	opts := commands.SelectOptions{}
+func (a *App) handleCollection(args []string) error {
+	if len(args) == 0 {
+		a.showCollectionHelp()
+		return nil
+	}
+	subcommand := args[0]
+	args = args[1:]
 
-	// Parse arguments
+	opts := commands.CollectionOptions{}
+	moveOpts := commands.MoveCollectionOptions{}
+	var positional []string
 	for i := 0; i < len(args); i++ {
-		arg := args[i]
-		switch arg {
+		switch args[i] {
 		case "-h", "--help":
-			a.showSelectHelp()
+			a.showCollectionHelp()
 			return nil
-		case "--allow-direnv":
-			opts.AllowDirenv = true
+		case "--dry-run":
+			moveOpts.DryRun = true
+		case "-m", "--message":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a message", args[i])
+			}
+			opts.Message = args[i+1]
+			i++
+		case "--force":
+			opts.Force = true
 		default:
-			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
-				opts.ProfileName = arg
+			if strings.HasPrefix(args[i], "-") {
+				return fmt.Errorf("unknown option for collection %s: %s", subcommand, args[i])
 			}
+			positional = append(positional, args[i])
 		}
 	}
+	if subcommand != "move" && len(positional) > 0 {
+		return fmt.Errorf("unexpected argument for collection %s: %s", subcommand, positional[0])
+	}
 
-	return commands.SelectProfile(a.profilesDir, opts)
+	switch subcommand {
+	case "status":
+		return commands.CollectionStatus(a.profilesDir)
+	case "commit":
+		_, err := commands.CollectionCommit(a.profilesDir, opts)
+		return err
+	case "push":
+		return commands.CollectionPush(a.profilesDir, opts)
+	case "move":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: shell-profiler collection move <new-dir> [--dry-run]")
+		}
+		return commands.MoveCollection(a.profilesDir, positional[0], moveOpts)
+	case "-h", "--help", "help":
+		a.showCollectionHelp()
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown collection command: %s\n\n", subcommand)
+		a.showCollectionHelp()
+		return fmt.Errorf("unknown collection command: %s", subcommand)
+	}
 }
 
-func (a *App) handleStatus(_args []string) error {
-	// Check if direnv is installed and show status
-	return profile.ShowDirenvStatus()
+func (a *App) handleDoctor(args []string) error {
+	opts := commands.DoctorOptions{}
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showDoctorHelp()
+			return nil
+		case "--fix":
+			opts.Fix = true
+		case "--strict":
+			opts.Strict = true
+		case "--dry-run":
+			opts.DryRun = true
+		default:
+			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
+				opts.ProfileName = arg
+			}
+		}
+	}
+	if opts.DryRun && !opts.Fix {
+		return fmt.Errorf("--dry-run is used with --fix, to show the repairs it would make")
+	}
+	return commands.Doctor(a.profilesDir, opts)
 }
 
-func (a *App) handleDotfiles(args []string) error {
-	if len(args) == 0 {
-		a.showDotfilesHelp()
-		return nil
+func (a *App) handleFixPerms(args []string) error {
+	opts := commands.FixPermissionsOptions{}
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showFixPermsHelp()
+			return nil
+		case "--dry-run":
+			opts.DryRun = true
+		default:
+			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
+				opts.ProfileName = arg
+			}
+		}
 	}
 
-	subcommand := args[0]
-	args = args[1:]
-
-	opts := commands.DotfilesOptions{}
+	if opts.ProfileName == "" {
+		active, ok := profile.ActiveProfileIn(a.profilesDir)
+		if !ok {
+			return fmt.Errorf("profile name is required")
+		}
+		opts.ProfileName = active
+	}
+	return commands.FixProfilePermissions(a.profilesDir, opts)
+}
 
-	// Parse common options
+func (a *App) handleSetIdentity(args []string) error {
+	var name, email string
+	opts := commands.GitIdentityOptions{}
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		switch arg {
-		case "--profile", "-p":
-			if i+1 < len(args) {
-				opts.ProfileName = args[i+1]
-				i++
+		case "-h", "--help":
+			a.showSetIdentityHelp()
+			return nil
+		case "--name", "--email", "-t", "--tag":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a value", arg)
 			}
-		case "--file", "-f":
-			if i+1 < len(args) {
-				opts.FileName = args[i+1]
-				i++
+			switch arg {
+			case "--name":
+				name = args[i+1]
+			case "--email":
+				email = args[i+1]
+			default:
+				opts.Tags = append(opts.Tags, args[i+1])
 			}
-		case "--editor", "-e":
+			i++
+		case "--dry-run":
+			opts.DryRun = true
+		case "--no-backup":
+			opts.NoBackup = true
+		}
+	}
+	return commands.SetGitIdentity(a.profilesDir, name, email, opts)
+}
+
+func (a *App) handleReset(args []string) error {
+	opts := commands.ResetOptions{}
+	profileName := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-h", "--help":
+			a.showResetHelp()
+			return nil
+		case "--all":
+			opts.All = true
+		case "--dry-run":
+			opts.DryRun = true
+		case "--tools":
 			if i+1 < len(args) {
-				opts.Editor = args[i+1]
+				for _, tool := range strings.Split(args[i+1], ",") {
+					if tool = strings.TrimSpace(tool); tool != "" {
+						opts.Tools = append(opts.Tools, tool)
+					}
+				}
 				i++
 			}
-		case "-h", "--help":
-			a.showDotfilesHelp()
-			return nil
 		default:
-			// First non-flag argument could be profile name
-			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
-				opts.ProfileName = arg
+			if profileName == "" && !strings.HasPrefix(arg, "-") {
+				profileName = arg
+			}
+		}
+	}
+
+	if profileName == "" {
+		active, ok := profile.ActiveProfileIn(a.profilesDir)
+		if !ok {
+			return fmt.Errorf("profile name is required")
+		}
+		profileName = active
+	}
+	return commands.ResetProfile(a.profilesDir, profileName, opts)
+}
+
+func (a *App) handleConflicts(args []string) error {
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			a.showConflictsHelp()
+			return nil
+		}
+	}
+	return commands.ShowConflicts(a.profilesDir)
+}
+
+func (a *App) handleWhoami(args []string) error {
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			a.showWhoamiHelp()
+			return nil
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return commands.Whoami(a.profilesDir, cwd)
+}
+
+func (a *App) handleEnv(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		a.showEnvHelp()
+		return nil
+	}
+	subcommand := args[0]
+	args = args[1:]
+
+	opts := commands.EnvListOptions{}
+	exampleOpts := commands.EnvExampleOptions{}
+	profileName := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-h" || arg == "--help":
+			a.showEnvHelp()
+			return nil
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires text or json")
+			}
+			opts.Format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			opts.Format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--reveal":
+			opts.Reveal = true
+		case arg == "--secrets":
+			opts.Secrets = true
+		case arg == "--regenerate" && subcommand == "example":
+			exampleOpts.Regenerate = true
+		case arg == "--dry-run" && subcommand == "example":
+			exampleOpts.DryRun = true
+		case !strings.HasPrefix(arg, "-") && profileName == "":
+			profileName = arg
+		default:
+			return fmt.Errorf("unknown option for env %s: %s", subcommand, arg)
+		}
+	}
+
+	switch subcommand {
+	case "list", "ls":
+		return commands.EnvList(a.profilesDir, profileName, opts)
+	case "example":
+		return commands.EnvExample(a.profilesDir, profileName, exampleOpts)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown env command: %s\n\n", subcommand)
+		a.showEnvHelp()
+		return fmt.Errorf("unknown env command: %s", subcommand)
+	}
+}
+
+func (a *App) handleSecrets(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		a.showSecretsHelp()
+		return nil
+	}
+	subcommand := args[0]
+	args = args[1:]
+
+	opts := commands.SecretsDiffOptions{}
+	profileName := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-h" || arg == "--help":
+			a.showSecretsHelp()
+			return nil
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires text or json")
+			}
+			opts.Format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			opts.Format = strings.TrimPrefix(arg, "--format=")
+		case !strings.HasPrefix(arg, "-") && profileName == "":
+			profileName = arg
+		default:
+			return fmt.Errorf("unknown option for secrets %s: %s", subcommand, arg)
+		}
+	}
+
+	switch subcommand {
+	case "diff":
+		return commands.DiffSecrets(a.profilesDir, profileName, opts)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown secrets command: %s\n\n", subcommand)
+		a.showSecretsHelp()
+		return fmt.Errorf("unknown secrets command: %s", subcommand)
+	}
+}
+
+func (a *App) handleRecent(args []string) error {
+	var opts commands.RecentOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-h" || arg == "--help":
+			a.showRecentHelp()
+			return nil
+		case arg == "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires table, plain or json")
+			}
+			opts.Format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			opts.Format = strings.TrimPrefix(arg, "--format=")
+		case !strings.HasPrefix(arg, "-"):
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid count: %s", arg)
+			}
+			opts.Count = n
+		}
+	}
+	return commands.RecentProfiles(a.profilesDir, opts)
+}
+
+func (a *App) handleExists(args []string) error {
+	var opts commands.ExistsOptions
+	for _, arg := range args {
+		switch {
+		case arg == "-h" || arg == "--help":
+			a.showExistsHelp()
+			return nil
+		case arg == "-q" || arg == "--quiet":
+			opts.Quiet = true
+		case !strings.HasPrefix(arg, "-"):
+			opts.ProfileName = arg
+		}
+	}
+	return commands.ProfileExists(a.profilesDir, opts)
+}
+
+func (a *App) handleExportAll(args []string) error {
+	var opts commands.ExportOptions
+	outPath := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-h" || arg == "--help":
+			a.showExportAllHelp()
+			return nil
+		case arg == "-f" || arg == "--force":
+			opts.Force = true
+		case arg == "--exclude" || arg == "-x":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--exclude requires a pattern")
+			}
+			opts.Exclude = append(opts.Exclude, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--exclude="):
+			opts.Exclude = append(opts.Exclude, strings.TrimPrefix(arg, "--exclude="))
+		case !strings.HasPrefix(arg, "-"):
+			outPath = arg
+		}
+	}
+	if outPath == "" {
+		return fmt.Errorf("output file is required")
+	}
+	return commands.ExportAll(a.profilesDir, outPath, opts)
+}
+
+func (a *App) handleImportAll(args []string) error {
+	var opts commands.ImportOptions
+	archivePath := ""
+	for _, arg := range args {
+		switch {
+		case arg == "-h" || arg == "--help":
+			a.showImportAllHelp()
+			return nil
+		case arg == "-f" || arg == "--force":
+			opts.Force = true
+		case !strings.HasPrefix(arg, "-"):
+			archivePath = arg
+		}
+	}
+	if archivePath == "" {
+		return fmt.Errorf("archive file is required")
+	}
+	return commands.ImportAll(archivePath, a.profilesDir, opts)
+}
+
+func (a *App) handleInfo(args []string) error {
+	profileFlag, format := "", ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-h", "--help":
+			a.showInfoHelp()
+			return nil
+		case "-p", "--profile":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--profile requires a profile name")
+			}
+			profileFlag = args[i+1]
+			i++
+		case "--format":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires table, plain or json")
+			}
+			if _, err := ui.ParseFormat(args[i+1]); err != nil {
+				return err
+			}
+			format = args[i+1]
+			i++
+		}
+	}
+
+	pm := profile.NewManager(a.profilesDir)
+
+	// Formatted output describes one profile, active or given
+	if format != "" {
+		if profileFlag == "" {
+			if active, ok := profile.ActiveProfileIn(a.profilesDir); ok {
+				return pm.ShowProfileFormat(active, filepath.Join(a.profilesDir, active), format)
+			}
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		name, dir, err := profile.ResolveProfileIn(a.profilesDir, cwd, profileFlag)
+		if err != nil {
+			return err
+		}
+		return pm.ShowProfileFormat(name, dir, format)
+	}
+
+	// With no --profile, an active direnv environment is the richest source
+	if profileFlag == "" {
+		if _, ok := profile.ActiveProfileIn(a.profilesDir); ok {
+			return pm.ShowInfo()
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	name, dir, err := profile.ResolveProfileIn(a.profilesDir, cwd, profileFlag)
+	if err != nil {
+		if profileFlag != "" {
+			return err
+		}
+		// Not inside a profile: show the "no profile active" overview
+		return pm.ShowInfo()
+	}
+
+	commands.WarnSchemaCompatibility(dir, name)
+	return pm.ShowProfile(name, dir)
+}
+
+func (a *App) handleSelect(args []string) error {
+	opts := commands.SelectOptions{}
+
+	// Parse arguments
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-h", "--help":
+			a.showSelectHelp()
+			return nil
+		case "--allow-direnv":
+			opts.AllowDirenv = true
+		case "--include-archived":
+			opts.IncludeArchived = true
+		default:
+			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
+				opts.ProfileName = arg
+			}
+		}
+	}
+
+	return commands.SelectProfile(a.profilesDir, opts)
+}
+
+func (a *App) handleStatus(_args []string) error {
+	// Check if direnv is installed and show status
+	return profile.ShowDirenvStatus()
+}
+
+func (a *App) handleArchive(args []string, unarchive bool) error {
+	opts := commands.ArchiveOptions{}
+
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showArchiveHelp()
+			return nil
+		case "-z", "--compress":
+			opts.Compress = true
+		default:
+			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
+				opts.ProfileName = arg
+			}
+		}
+	}
+
+	if unarchive {
+		return commands.UnarchiveProfile(a.profilesDir, opts)
+	}
+	return commands.ArchiveProfile(a.profilesDir, opts)
+}
+
+func (a *App) handleRename(args []string) error {
+	opts := commands.RenameOptions{}
+
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showRenameHelp()
+			return nil
+		case "--dry-run":
+			opts.DryRun = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			if opts.ProfileName == "" {
+				opts.ProfileName = arg
+			} else if opts.NewName == "" {
+				opts.NewName = arg
+			}
+		}
+	}
+
+	if opts.NewName == "" {
+		a.showRenameHelp()
+		return fmt.Errorf("old and new profile names are required")
+	}
+	return commands.RenameProfile(a.profilesDir, opts)
+}
+
+func (a *App) handleTag(args []string) error {
+	if len(args) == 0 {
+		a.showTagHelp()
+		return nil
+	}
+
+	subcommand := args[0]
+	args = args[1:]
+
+	opts := commands.TagOptions{}
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showTagHelp()
+			return nil
+		default:
+			if opts.ProfileName == "" {
+				opts.ProfileName = arg
+			} else {
+				opts.Tags = append(opts.Tags, arg)
+			}
+		}
+	}
+
+	switch subcommand {
+	case "add":
+		return commands.AddTags(a.profilesDir, opts)
+	case "remove", "rm":
+		return commands.RemoveTags(a.profilesDir, opts)
+	case "list", "ls":
+		return commands.ListTags(a.profilesDir, opts)
+	case "help", "-h", "--help":
+		a.showTagHelp()
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown tag command: %s\n\n", subcommand)
+		a.showTagHelp()
+		return fmt.Errorf("unknown tag command: %s", subcommand)
+	}
+}
+
+func (a *App) handlePath(args []string) error {
+	if len(args) == 0 {
+		a.showPathHelp()
+		return nil
+	}
+
+	subcommand := args[0]
+	args = args[1:]
+
+	if subcommand == "--print-path" {
+		if len(args) == 0 {
+			return fmt.Errorf("profile name is required")
+		}
+		return commands.PrintProfilePath(a.profilesDir, args[0])
+	}
+
+	opts := commands.PathOptions{}
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showPathHelp()
+			return nil
+		default:
+			if opts.ProfileName == "" {
+				opts.ProfileName = arg
+			} else {
+				opts.Dirs = append(opts.Dirs, arg)
+			}
+		}
+	}
+
+	switch subcommand {
+	case "add":
+		return commands.AddPaths(a.profilesDir, opts)
+	case "remove", "rm":
+		return commands.RemovePaths(a.profilesDir, opts)
+	case "list", "ls":
+		return commands.ListPaths(a.profilesDir, opts)
+	case "help", "-h", "--help":
+		a.showPathHelp()
+		return nil
+	default:
+		if strings.HasPrefix(subcommand, "-") || len(args) > 0 {
+			fmt.Fprintf(os.Stderr, "Unknown path command: %s\n\n", subcommand)
+			a.showPathHelp()
+			return fmt.Errorf("unknown path command: %s", subcommand)
+		}
+		// A bare profile name prints its directory
+		return commands.PrintProfilePath(a.profilesDir, subcommand)
+	}
+}
+
+func (a *App) handleEdit(args []string) error {
+	opts := commands.DotfilesOptions{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-h", "--help":
+			a.showEditHelp()
+			return nil
+		case "--editor", "-e":
+			if i+1 < len(args) {
+				opts.Editor = args[i+1]
+				i++
+			}
+		default:
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			if opts.ProfileName == "" {
+				opts.ProfileName = arg
+			} else if opts.FileName == "" {
+				opts.FileName = arg
+			}
+		}
+	}
+	return commands.EditDotfile(a.profilesDir, opts)
+}
+
+func (a *App) handleReadme(args []string) error {
+	if len(args) == 0 {
+		a.showReadmeHelp()
+		return nil
+	}
+
+	subcommand := args[0]
+	args = args[1:]
+
+	opts := commands.ReadmeOptions{}
+	for _, arg := range args {
+		switch arg {
+		case "-h", "--help":
+			a.showReadmeHelp()
+			return nil
+		case "--dry-run":
+			opts.DryRun = true
+		default:
+			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
+				opts.ProfileName = arg
+			} else {
+				return fmt.Errorf("unknown option: %s", arg)
+			}
+		}
+	}
+
+	switch subcommand {
+	case "regenerate":
+		return commands.RegenerateReadme(a.profilesDir, opts)
+	case "help", "-h", "--help":
+		a.showReadmeHelp()
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown readme command: %s\n\n", subcommand)
+		a.showReadmeHelp()
+		return fmt.Errorf("unknown readme command: %s", subcommand)
+	}
+}
+
+func (a *App) showReadmeHelp() {
+	helpText := `Usage: shell-profiler readme regenerate <profile-name> [options]
+
+Rewrite a profile's README.md from the current template, e.g. after the
+templates changed or for a profile created with --no-readme.
+
+A "## Notes" section you added to README.md is kept, up to the next
+heading of the same or a higher level; everything else is replaced.
+
+Options:
+    -h, --help          Show this help message
+    --dry-run           Show what would change without writing
+
+Examples:
+    shell-profiler readme regenerate my-project
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) handleDotfiles(args []string) error {
+	if len(args) == 0 {
+		a.showDotfilesHelp()
+		return nil
+	}
+
+	subcommand := args[0]
+	args = args[1:]
+
+	opts := commands.DotfilesOptions{}
+
+	// Parse common options
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--profile", "-p":
+			if i+1 < len(args) {
+				opts.ProfileName = args[i+1]
+				i++
+			}
+		case "--file", "-f":
+			if i+1 < len(args) {
+				opts.FileName = args[i+1]
+				i++
+			}
+		case "--editor", "-e":
+			if i+1 < len(args) {
+				opts.Editor = args[i+1]
+				i++
+			}
+		case "-h", "--help":
+			a.showDotfilesHelp()
+			return nil
+		default:
+			// First non-flag argument could be profile name
+			if opts.ProfileName == "" && !strings.HasPrefix(arg, "-") {
+				opts.ProfileName = arg
+			}
+		}
+	}
+
+	switch subcommand {
+	case "list", "ls":
+		return commands.ListDotfiles(a.profilesDir, opts)
+	case "edit", "e":
+		return commands.EditDotfile(a.profilesDir, opts)
+	case "help", "-h", "--help":
+		a.showDotfilesHelp()
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown dotfiles command: %s\n\n", subcommand)
+		a.showDotfilesHelp()
+		return fmt.Errorf("unknown dotfiles command: %s", subcommand)
+	}
+}
+
+func (a *App) handleTemplates(args []string) error {
+	if len(args) == 0 {
+		a.showTemplatesHelp()
+		return nil
+	}
+
+	subcommand := args[0]
+	args = args[1:]
+
+	opts := commands.TemplatesOptions{}
+	previewProfile := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-h", "--help":
+			a.showTemplatesHelp()
+			return nil
+		case "-t", "--template":
+			if i+1 < len(args) {
+				opts.Names = append(opts.Names, args[i+1])
+				i++
+			}
+		case "--profile", "-p":
+			if i+1 < len(args) {
+				previewProfile = args[i+1]
+				i++
+			}
+		case "--template-dir":
+			if i+1 < len(args) {
+				opts.TemplateDir = args[i+1]
+				i++
+			}
+		default:
+			if !strings.HasPrefix(arg, "-") {
+				opts.Names = append(opts.Names, arg)
 			}
 		}
 	}
 
+	if opts.TemplateDir == "" {
+		if cfg, err := config.LoadConfig(); err == nil {
+			opts.TemplateDir = cfg.TemplateDir
+		}
+	}
+
 	switch subcommand {
-	case "list", "ls":
-		return commands.ListDotfiles(a.profilesDir, opts)
-	case "edit", "e":
-		return commands.EditDotfile(a.profilesDir, opts)
+	case "lint":
+		return commands.LintTemplates(opts)
+	case "preview":
+		return commands.ShowTemplatePreview(opts, previewProfile)
 	case "help", "-h", "--help":
-		a.showDotfilesHelp()
+		a.showTemplatesHelp()
 		return nil
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown dotfiles command: %s\n\n", subcommand)
-		a.showDotfilesHelp()
-		return fmt.Errorf("unknown dotfiles command: %s", subcommand)
+		fmt.Fprintf(os.Stderr, "Unknown templates command: %s\n\n", subcommand)
+		a.showTemplatesHelp()
+		return fmt.Errorf("unknown templates command: %s", subcommand)
 	}
 }
 
@@ -479,7 +1626,26 @@ func (a *App) showHelp() {
 
 Manage workspace profiles with direnv for environment-specific configurations.
 
-Usage: shell-profiler <command> [arguments]
+Usage: shell-profiler [--yes] [--no-color] [--ascii] [--json] [--profiles-dir <path>] [--backup-dir <path>] <command> [arguments]
+
+Global options:
+    -y, --yes                  Answer yes to all confirmation prompts. Unlike --force,
+                               this does not skip validations such as existing profiles.
+    --no-color                 Disable colored output. Colors are also disabled when
+                               NO_COLOR is set or output is not a terminal.
+    --ascii, --symbols <set>   Show status as [OK]/[WARN]/[ERR] instead of ✓/⚠/✗
+                               (--symbols ascii), or force the glyphs (--symbols
+                               unicode). ASCII is the default when the locale is
+                               not UTF-8 or TERM is dumb.
+    --profiles-dir <path>      Use this profiles directory instead of profiles_dir
+                               from ~/.profile-manager for this invocation.
+    --backup-dir <path>        Keep backups in <path>/<profile> instead of the
+                               profile's .backups (default: backup_dir).
+    --json                     Write fatal errors to stderr as JSON, e.g.
+                               {"error": "...", "code": "ProfileNotFound"}.
+                               Also enabled by --format json. Codes: ProfileNotFound,
+                               ProfileExists, InvalidName, TemplateNotFound,
+                               DirenvNotFound, ProfilesDirUnusable, Unknown.
 
 Commands:
     init [options]             Initialize the profile manager configuration
@@ -487,33 +1653,70 @@ Commands:
             --profiles-dir <path>    Set profiles directory path
             --interactive            Interactive setup
             --force                  Overwrite existing configuration
+            --git                    Make the profiles directory a git repository
 
     create <name> [options]     Create a new workspace profile
         Options:
             --template <type>       Use template: personal, work, client, basic
+            --template-dir <path>   Search this directory for templates first
             --git-name <name>       Set git user name
             --git-email <email>     Set git user email
             --interactive           Interactive setup (default if no flags provided)
             --no-interactive        Disable interactive mode
             --force                 Overwrite existing profile
+            --allow                 Run 'direnv allow' after creation
+            --encrypt-cache         Encrypt the cached secrets at rest
+            --op-account <account>  Discover secrets in this 1Password account
+            --op-field-prefix <p>   Only export 1Password fields labeled <p>...
+            --op-exclude-field <l>  Never export this 1Password field (repeatable)
+            --include-totp          Export 1Password one-time passwords as <TITLE>_TOTP
+            --vault <name>          Load secrets from this 1Password vault (repeatable)
+            --no-watch              Don't reload direnv when .env changes
+            --path-add <dir>        Also add this directory to PATH (repeatable)
+            --shared-ssh-key <path> Use this existing key for every host
+            --git-include <path>    Include a shared git config (repeatable)
+            --default-branch <name> init.defaultBranch for new repos (default: main)
+            --edit                  Open .gitconfig and .ssh/config in $EDITOR afterwards
+            --with-gitattributes    Add a .gitattributes forcing LF in shell scripts
+            --no-cloud              Leave out AWS, Azure, gcloud, kube and Terraform
+            --no-readme             Don't write README.md
+            --extra-dir <path[:mode]> Also create this directory (repeatable)
+            --from-profile <name>   Reuse another profile's template and settings
+            --relative-ssh-paths    Use ${WORKSPACE_HOME} instead of absolute SSH paths
+            --copy-from-home <list> Copy aws,kube,... configs from $HOME
+            --include-creds         Also copy their credential files
 
     update [name] [options]     Update an existing profile with new features
         Options:
             --dry-run              Preview changes without applying
-            --force                 Overwrite existing files
+            --overwrite            Overwrite existing files
+            --skip-backup-confirm  Continue without asking if the backup fails
+            --force                Both of the above
             --no-backup            Skip creating backup
+            --allow                Run 'direnv allow' after updating
+            --create-vault         Create the profile's 1Password vault if missing
+            --relative-ssh-paths   Rewrite .ssh/config paths as ${WORKSPACE_HOME}
         Note: Interactive selection by default if name is omitted
 
+    apply --plan-file <path>    Apply an update plan saved with update --plan-file
+        Options:
+            --force                 Apply even if the profile changed since the plan
+            --no-backup             Skip creating backup
+
     select [name] [options]     Select and switch to a profile
         Options:
             --allow-direnv          Automatically allow direnv for selected profile
+            --include-archived      Also offer archived profiles
         Note: Interactive selection if name is omitted
 
     list [options]              List all workspace profiles
         Options:
             --verbose               Show detailed information (disables interactive)
             --config                Show git configuration (disables interactive)
+            --tag <tag>              Only list profiles with this tag
+            --include-archived       Also list archived profiles
             --no-interactive         Disable interactive mode
+            --format <format>        table, plain (tab-separated) or json
         Note: Interactive by default unless flags are provided
 
     delete [name] [options]     Delete a workspace profile
@@ -530,8 +1733,48 @@ Commands:
             --file <file>           Restore only a specific file
             --backup-date <date>    Restore from specific dated backup
 
-    info                        Show information about the current profile
+    rename <old> <new>          Rename a profile, updating paths in .envrc, .ssh/config, ...
+    archive <name> [options]    Move a profile to archived/ (hidden from list and select)
+        Options:
+            --compress              Compress large tool data directories
+    unarchive <name>            Restore an archived profile
+
+    tag <command> <name> [tags] Manage profile tags
+        Commands:
+            add <name> <tag>...     Add tags to a profile
+            remove <name> <tag>...  Remove tags from a profile
+            list [name]             List tags
+    path <command> <name> [dirs] Manage extra PATH directories
+        Commands:
+            add <name> <dir>...     Add directories to PATH after bin/
+            remove <name> <dir>...  Remove added directories
+            list <name>             List added directories
+    path <name>                 Print the absolute directory of a profile
+
+    info [--profile <name>]     Show information about the current (or named) profile
+    whoami                      Show the git, AWS, secrets and SSH identity of the active profile
+    env list [name] [--secrets] [--reveal] [--format json]
+                                List the variables a profile sets, secrets masked
+    env example [name] [--regenerate] [--dry-run]
+                                Check .env.example against the tool registry or regenerate it
+    secrets diff [name] [--format json]
+                                Compare the secrets in the vaults with .env.example
+    export-all <file> [--exclude <glob>] [--force]
+                                Archive every profile, without secrets, to one .tar.gz
+    import-all <file> [--force] Restore the profiles of an export-all archive
+    exists <name> [--quiet]     Exit 0 if a profile is valid, 1 if invalid, 2 if absent
+    recent [n] [--format <f>]   Show the n most recently modified profiles (default 5)
+    doctor [name] [--fix [--dry-run]] [--strict]
+                                Check profiles for problems (all profiles if none is active)
+    set-identity [--name <name>] [--email <email>] [--tag <tag>]
+                                Set the git user.name/user.email of every (tagged) profile
+    fix-perms [name] [--dry-run]
+                                Tighten permissions on keys, .env and credential files
+    conflicts                   Find git emails, SSH keys and vaults shared between profiles
+    reset [name] --tools <list>|--all
+                                Clear cached tokens (aws, kubernetes, gcloud, secrets)
     status                      Show direnv status
+    edit [name] [file]          Open a profile dotfile in $EDITOR (unique prefixes complete)
     dotfiles <command> [name]    Manage shell-profiler dotfiles
         Commands:
             list                    List all dotfiles in a profile
@@ -541,6 +1784,10 @@ Commands:
             --file, -f <name>       File name (interactive if omitted)
             --editor, -e <name>     Editor to use (default: $EDITOR or vim)
         Note: Interactive by default if profile/file name is omitted
+    readme regenerate <name> [--dry-run]
+                                Rewrite README.md from the template, keeping ## Notes
+    templates lint [name...]    Check user templates for syntax errors and unknown fields
+    templates preview <name>    Print the files a template produces without writing them
     sync <command> [name]       Sync operations for profiles
         Commands:
             init [--remote <url>]    Initialize repository
@@ -552,6 +1799,13 @@ Commands:
         Options:
             --no-interactive         Disable interactive shell-profiler selection
         Note: Interactive selection by default if name is omitted (except status)
+    collection <command>        Git operations on the whole profiles directory
+    collection move <new-dir>   Relocate the profiles directory and update references to it
+        Commands:
+            status                   Show status of the profiles repository
+            commit [-m <message>]    Commit every profile together
+            push [--force]           Commit, then push to origin
+    schema                      Print the profile layout and config keys as JSON
     help                        Show this help message
 
 Examples:
@@ -625,6 +1879,8 @@ Commands:
     init [--remote <url>]    Initialize repository in profile directory
         Options:
             --remote <url>       Add remote URL during initialization
+            --force              Initialize even inside another git repository
+                                 (e.g. a profiles root under version control)
         Note: If profile-name is omitted, interactive selection will be shown
 
     pull                     Pull changes from remote repository
@@ -699,6 +1955,111 @@ Options:
     --dry-run          Show what would be created without creating it
     --init-git         Initialize git repository after creation
     --git-remote <url> Initialize git repository with remote URL
+    --allow            Run 'direnv allow' after creation
+    --template-dir <path>
+                        Search this directory for templates first
+                        (default: template_dir from ~/.profile-manager)
+    --refresh           Re-fetch a git+ template source even if cached
+    --list-templates-json
+                        Print the built-in templates with their descriptions,
+                        tab colors and git credential cache timeouts as JSON,
+                        then exit (for wizards embedding create)
+    --encrypt-cache     Keep the resolved env cache in $TMPDIR encrypted with
+                        openssl, keyed by ~/.config/profile-manager/cache.key
+                        (generated on first use)
+    --op-account <account>
+                        Discover secrets with op --account <account> and set
+                        it in .config/1Password/agent.toml, for when op is
+                        signed in to several accounts (default: op_account
+                        from ~/.profile-manager, else op's default account)
+    --op-field-prefix <prefix>
+                        Only export 1Password fields whose label starts with
+                        <prefix>, e.g. env: exports the field "env:token" of
+                        item "github" as GITHUB_TOKEN (default: op_field_prefix)
+    --op-exclude-field <label>
+                        Never export 1Password fields with this label, in
+                        any case, e.g. url or notes (repeatable; default:
+                        op_exclude_fields)
+    --include-totp      Also export the current code of each item's one-time
+                        password as <TITLE>_TOTP, fetched with op item get
+                        --otp on every direnv load (never cached). Off by
+                        default: it puts a second factor in the environment
+                        of every process in the profile, next to the
+                        password it protects, which defeats MFA for anything
+                        that can read that environment. Use it only for
+                        automation that must sign in to MFA-protected APIs.
+    --vault <name>      Load secrets from this 1Password vault instead of
+                        workspace-<profile-name>. Repeatable: vaults are read
+                        in order and a later vault's variable replaces an
+                        earlier one of the same name, e.g. --vault personal
+                        --vault team-shared lets the team vault win
+    --watch, --no-watch Reload direnv and rebuild the env cache when .env
+                        changes (watch_file .env in the .envrc; default on)
+    --path-add <dir>    Add this directory to PATH after bin/ when the profile
+                        is active; relative to the profile or absolute.
+                        Repeatable (see 'shell-profiler path --help')
+    --shared-ssh-key <path>
+                        Point .ssh/config's IdentityFile at this existing
+                        private key (e.g. ~/.ssh/id_ed25519) instead of
+                        per-profile keys. The key is not copied.
+    --git-include <path>
+                        Include this shared git config (absolute or ~/...)
+                        from .gitconfig, before the profile's own settings
+                        so they win. Repeatable
+    --default-branch <name>
+                        Branch name for repositories created with git init
+                        in the profile (init.defaultBranch, default: main)
+    --edit              Open .gitconfig, then .ssh/config, in $EDITOR (or
+                        $VISUAL) once created. Skipped with --no-interactive
+                        or when not run from a terminal.
+    --with-gitattributes
+                        Write a .gitattributes marking *.sh, .envrc and bin/*
+                        as text eol=lf, so CRLF checkouts cannot break them.
+                        'update' adds it to existing profiles.
+    --no-cloud          Create a minimal profile without the cloud tools (AWS,
+                        Azure, Google Cloud, Kubernetes, Terraform): no
+                        .aws/.azure/.gcloud/.kube directories, variables or
+                        .gitignore patterns. Recorded in .profile-meta so
+                        'update' does not add them back.
+    --no-readme         Don't write the generated README.md. Recorded in
+                        .profile-meta; 'shell-profiler readme regenerate'
+                        writes it later if wanted.
+    --extra-dir <path[:mode]>
+                        Also create this directory, relative to the profile,
+                        for tools the profile manager does not know about.
+                        An octal mode may follow, e.g. .config/acme:0700
+                        (default 0755). Repeatable; recorded in .profile-meta
+                        so 'update' re-creates missing ones.
+    --from-profile <name>
+                        Base the new profile on an existing one: its template,
+                        git name, email and default branch, --no-cloud,
+                        --extra-dir and --relative-ssh-paths choices apply
+                        unless given here. Files are rendered afresh, not
+                        copied, and the new profile keeps its own name.
+                        Also accepted as --profile-template-from.
+    --relative-ssh-paths
+                        Write the paths of .ssh/config (UserKnownHostsFile,
+                        IdentityFile) as ${WORKSPACE_HOME}/.ssh/... instead of
+                        absolute paths, so the profile survives being moved.
+                        Needs OpenSSH 8.4+ and WORKSPACE_HOME in the
+                        environment: direnv sets it and bin/ssh exports it,
+                        but a plain 'ssh -F .ssh/config' from elsewhere will
+                        not find the keys. Convert an existing profile with
+                        'update --relative-ssh-paths'.
+    --copy-from-home <list>
+                        Copy existing tool configs from $HOME into the profile,
+                        e.g. aws,kube. Tools: aws (~/.aws/config), kubernetes
+                        or kube (~/.kube/config), terraform (~/.terraformrc),
+                        azure (~/.azure/config, clouds.config), gcloud
+                        (~/.config/gcloud configurations). Missing files are
+                        skipped.
+    --include-creds     With --copy-from-home, also copy credentials
+                        (~/.aws/credentials, Azure and gcloud token stores),
+                        written with 0600 permissions
+    --post-create-hook <path>
+                        Run this script after the profile is created, with
+                        WORKSPACE_PROFILE and WORKSPACE_HOME set
+                        (default: post_create_hook from ~/.profile-manager)
 
 Examples:
     # Create a basic profile
@@ -715,6 +2076,12 @@ Examples:
     # Preview what would be created
     shell-profiler create my-project --dry-run
 
+    # Same style as an existing profile, new identity
+    shell-profiler create acme-staging --from-profile acme --git-email ops@acme.com
+
+    # Minimal profile with only git, ssh and the AI tools
+    shell-profiler create scratch --template basic --no-cloud
+
     # Create with git initialization
     shell-profiler create my-project --init-git
     shell-profiler create my-project --git-remote https://github.com/user/my-project.git
@@ -725,6 +2092,22 @@ Templates:
     client      - Client projects with isolated credentials
     basic       - Minimal configuration (default)
 
+Custom templates:
+    Templates are looked up in --template-dir, then
+    ~/.config/profile-manager/templates, then the built-in templates.
+    A template is a directory named after it containing any of envrc.tpl,
+    env.tpl and gitconfig.tpl; missing files come from the built-in template.
+    A custom directory named after a built-in template shadows it.
+
+        shell-profiler create svc --template-dir ./company-templates --template golang
+
+    A template can also come from a git repository, given as
+    git+<url>#<name>. The repository is cached in
+    ~/.cache/profile-manager/templates and fetched again after 24 hours
+    (or with --refresh); if fetching fails the cached copy is used.
+
+        shell-profiler create svc --template git+https://github.com/acme/templates#golang
+
 Secrets Management:
     Profiles include a .env.secrets.tpl file for 1Password integration.
     Store secrets as op:// references (safe to commit), resolved at runtime
@@ -748,6 +2131,7 @@ Arguments:
 Options:
     -h, --help          Show this help message
     --allow-direnv      Automatically allow direnv for the selected profile
+    --include-archived  Also offer archived profiles
 
 Examples:
     # Interactive selection
@@ -777,13 +2161,486 @@ Options:
     -h, --help          Show this help message
     -v, --verbose       Show detailed information (disables interactive)
     -c, --config        Show git configuration (disables interactive)
+    -t, --tag <tag>     Only list profiles with this tag (repeatable; all must match)
+    --include-archived  Also list profiles archived with 'shell-profiler archive'
     --no-interactive    Disable interactive mode
+    --limit <n>         Show at most n profiles
+    --offset <n>        Skip the first n profiles (in name order)
+    --page <n>          Show page n of --limit profiles (same as --offset (n-1)*limit)
+    --since <age>       Only profiles modified within <age>, e.g. 90d, 2w, 12h
+    --modified-before <age>
+                        Only profiles not modified for <age>
+    --sort <order>      name (default) or modified (most recent first).
+                        Modification times ignore .backups and tool caches.
+    --format <format>   One row per profile (disables interactive): table
+                        (aligned columns), plain (tab-separated, no header,
+                        for awk and cut; the total goes to stderr) or
+                        json ({"total": n, "profiles": [...]})
 
 Examples:
     shell-profiler list                # Interactive selection menu (default)
     shell-profiler list --verbose      # Show detailed information for all profiles
     shell-profiler list --config       # Show git configuration for all profiles
     shell-profiler list --no-interactive  # List all profiles without interactive menu
+    shell-profiler list --tag client --no-interactive  # List profiles tagged client
+    shell-profiler list --no-interactive --limit 20 --page 2  # Profiles 21-40
+    shell-profiler list --no-interactive --modified-before 90d --sort modified  # Cleanup candidates
+    shell-profiler list --format plain | cut -f1   # Profile names only
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showArchiveHelp() {
+	helpText := `Usage: shell-profiler archive <profile-name> [options]
+       shell-profiler unarchive <profile-name>
+
+Archive a profile you no longer use instead of deleting it. The profile is
+moved to <profiles-dir>/archived/ and no longer appears in list or select.
+
+Options:
+    -h, --help          Show this help message
+    -z, --compress      Compress tool data directories (.aws, .azure, .gcloud,
+                        .kube, .terraform.d, .cache) into <dir>.tar.gz
+
+Unarchiving moves the profile back and expands any compressed directories.
+Use --include-archived with list or select to see archived profiles.
+
+Examples:
+    shell-profiler archive old-client
+    shell-profiler archive old-client --compress
+    shell-profiler unarchive old-client
+    shell-profiler list --include-archived --no-interactive
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showRenameHelp() {
+	helpText := `Usage: shell-profiler rename <profile-name> <new-name> [options]
+
+Rename a profile. The directory is moved and the generated files that refer
+to the old name or location are updated: .envrc, .env, .gitconfig,
+README.md, the 1Password agent config, the absolute IdentityFile and
+UserKnownHostsFile paths in .ssh/config, and .profile-meta.
+
+Secrets are looked up in the 1Password vault workspace-<name>, so rename the
+vault as well to keep them. Run 'direnv allow' on the renamed profile.
+
+Options:
+    -h, --help          Show this help message
+    --dry-run           Show what would be renamed without changing anything
+
+Examples:
+    shell-profiler rename acme acme-corp
+    shell-profiler mv acme acme-corp --dry-run
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showCollectionHelp() {
+	helpText := `Usage: shell-profiler collection <command> [options]
+
+Operations on the profiles directory as a whole. The git commands are for
+when every profile is versioned in one repository (see 'shell-profiler init
+--git'). These are separate from the per-profile repositories managed by
+'shell-profiler sync'.
+
+Commands:
+    status                  Show the status of the profiles repository
+    commit [-m <message>]   Stage every profile and commit them together
+    push [-m <message>] [--force]
+                            Commit any changes, then push to origin
+    move <new-dir> [--dry-run]
+                            Move the profiles directory, rewrite the absolute
+                            paths in each profile's .envrc, .ssh/config and
+                            other generated files, and point profiles_dir in
+                            the config at the new location
+
+Options:
+    -h, --help              Show this help message
+    -m, --message <text>    Commit message (default: "Update profiles")
+    --force                 Force push (use with caution)
+    --dry-run               Show what move would change without moving
+
+Each profile's .gitignore still applies, so .env and other ignored secrets
+are never committed.
+
+Examples:
+    shell-profiler init --git
+    shell-profiler collection commit -m "Add client profile"
+    shell-profiler collection push
+    shell-profiler collection move ~/code/profiles --dry-run
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showDoctorHelp() {
+	helpText := `Usage: shell-profiler doctor [profile-name] [options]
+
+Check a profile for problems. Without a name, the active profile
+(WORKSPACE_PROFILE) is checked, or every profile if none is active.
+
+Checks:
+    permissions   Sensitive files are no more permissive than the policy
+                  (see 'shell-profiler fix-perms --help')
+    schema        The profile is at the latest schema version
+    shadowing     Executables in bin/ that hide a command on PATH (other
+                  than the ssh wrapper)
+    direnv        The .envrc is allowed by direnv (per 'direnv status')
+    workspace-home
+                  The .envrc derives WORKSPACE_HOME from its own location
+                  rather than $PWD, so symlinked profiles resolve to their
+                  real directory (--fix rewrites it)
+    gitconfig     git can parse .gitconfig (skipped without git)
+    ssh-policy    .ssh/config does not disable host key checking or enable
+                  weak ciphers, key exchanges or MACs, and no IdentityFile
+                  is a DSA key or an RSA key below ssh_min_rsa_bits (3072)
+    ssh-paths     IdentityFile and UserKnownHostsFile paths in .ssh/config
+                  do not point into the profile's old location after a
+                  move (--fix rewrites them to the current one)
+    env-example   .env.example documents the examples of every managed tool
+                  (--fix regenerates it, keeping examples added below the
+                  managed block)
+    conflicts     No git email, SSH IdentityFile or vault is shared with
+                  another profile (see 'shell-profiler conflicts --help')
+
+Options:
+    -h, --help          Show this help message
+    --fix               Repair problems that can be fixed automatically
+    --dry-run           With --fix, show each finding with the repair --fix
+                        would make (the file and the change), without writing
+                        anything. The exit status is that of the unrepaired
+                        findings
+    --strict            Exit 2 when warnings remain, as for errors
+
+Exit status, the worst over every profile checked (fixed problems don't
+count):
+    0   Healthy: no problems found
+    1   Warnings only
+    2   Errors found, warnings with --strict, or doctor could not run (for
+        example, the profile does not exist)
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showFixPermsHelp() {
+	helpText := `Usage: shell-profiler fix-perms [profile-name] [options]
+
+Tighten permissions on sensitive profile files. Files that are already
+stricter than the policy are left alone. Without a name, the active
+profile (WORKSPACE_PROFILE) is used.
+
+Policy:
+    .ssh                            0700
+    .ssh/* (except *.pub)           0600
+    .env, .backups/*/.env           0600
+    .config/1Password/agent.toml    0600
+    .aws/credentials                0600
+    .kube/config                    0600
+
+Options:
+    -h, --help          Show this help message
+    --dry-run           Report the changes without making them
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showResetHelp() {
+	helpText := `Usage: shell-profiler reset [profile-name] (--tools <list> | --all) [options]
+
+Delete cached tokens and other tool state from a profile, keeping its
+configuration. Useful when a tool's authentication gets into a bad state.
+Without a name, the active profile (WORKSPACE_PROFILE) is reset.
+
+Caches:
+    aws           .aws/cli/cache, .aws/sso/cache
+    kubernetes    .kube/cache, .kube/http-cache (also: kube, kubectl)
+    gcloud        .gcloud/logs
+    secrets       The resolved .env cache in $TMPDIR/sp-profiles/<name>
+
+Options:
+    -h, --help          Show this help message
+    --tools <list>      Comma-separated caches to clear, e.g. aws,kube
+    --all               Clear every cache above
+    --dry-run           Show what would be removed
+
+Examples:
+    shell-profiler reset acme --tools aws,kube
+    shell-profiler reset --all
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showConflictsHelp() {
+	helpText := `Usage: shell-profiler conflicts
+
+Find identity values shared by more than one profile, which defeats the
+isolation between them:
+
+    git email       user.email in .gitconfig (case-insensitive)
+    ssh identity    IdentityFile paths in the profile's SSH config
+    vault           The 1Password vault the profile loads secrets from
+
+Exits non-zero if any conflicts are found.
+
+Options:
+    -h, --help          Show this help message
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showEnvHelp() {
+	helpText := `Usage: shell-profiler env list [profile-name] [options]
+       shell-profiler env example [profile-name] [--regenerate] [--dry-run]
+
+List the variables a profile sets from .env, without activating it, with
+$WORKSPACE_HOME and $WORKSPACE_PROFILE expanded to the profile's values.
+Without a name, the active profile (WORKSPACE_PROFILE) is used.
+
+Values of secrets, and of variables whose names look sensitive (TOKEN,
+SECRET, PASSWORD, API_KEY, ...), are masked unless --reveal is given.
+
+Options:
+    -h, --help              Show this help message
+    --format <text|json>    Output format (default: text)
+    --secrets               Also list secrets resolved into the env cache by
+                            the .envrc. Nothing is fetched from 1Password, so
+                            the profile must have been loaded recently; an
+                            encrypted cache (--encrypt-cache) cannot be read
+    --reveal                Show masked values
+
+env example lists the credential examples of managed tools that the
+profile's .env.example lacks, as tools are added after it was created.
+--regenerate rewrites the examples between the profile-manager managed
+fences; examples you add below the block are kept. An .env.example from
+before the fences existed keeps the lines it has beyond the generated ones.
+--dry-run shows whether it would change. 'shell-profiler doctor' runs the
+same check.
+
+Examples:
+    shell-profiler env list my-project
+    shell-profiler env list --secrets --format json
+    shell-profiler env example my-project --regenerate
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showSecretsHelp() {
+	helpText := `Usage: shell-profiler secrets diff [profile-name] [options]
+
+Compare the secrets a profile loads with the ones its .env.example
+documents. The secret backend lists the variables the .envrc would export
+from each of the profile's vaults, with the same field prefix and
+exclusions, and the names are compared with the variables in .env.example
+(the tool registry's examples if there is none). Without a name, the active
+profile (WORKSPACE_PROFILE) is used.
+
+Reported are secrets in a vault that .env.example does not document, and
+documented variables no vault provides. Only names are shown, never values.
+Requires the 1Password CLI (op), signed in.
+
+Options:
+    -h, --help              Show this help message
+    --format <text|json>    Output format (default: text)
+
+Examples:
+    shell-profiler secrets diff my-project
+    shell-profiler secrets diff --format json
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showWhoamiHelp() {
+	helpText := `Usage: shell-profiler whoami
+
+Show the identity of the active profile: git user.name and user.email, the
+AWS config file, the secret vault and the SSH config in use. A quick check
+before committing or deploying that you are acting as the right person.
+
+The active profile is taken from WORKSPACE_PROFILE, or else the profile
+containing the current directory.
+
+Options:
+    -h, --help          Show this help message
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showSetIdentityHelp() {
+	helpText := `Usage: shell-profiler set-identity [--name <name>] [--email <email>] [options]
+
+Set the git user.name and user.email in the .gitconfig of every profile, for
+example after changing your email address. Only the [user] section is
+changed, and each profile is backed up to .backups/identity_<timestamp>/
+before it is.
+
+Options:
+    -h, --help          Show this help message
+    --name <name>       New git user.name (left unchanged if omitted)
+    --email <email>     New git user.email (left unchanged if omitted)
+    -t, --tag <tag>     Only profiles with this tag (repeatable; all must match)
+    --dry-run           Show what would change without changing it
+    --no-backup         Skip the backups
+
+Examples:
+    shell-profiler set-identity --email jane@new.example
+    shell-profiler set-identity --email jane@acme.example --tag acme --dry-run
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showRecentHelp() {
+	helpText := `Usage: shell-profiler recent [n] [options]
+
+Show the n most recently modified profiles (default 5), newest first, with
+when each last changed. Only the files at the top of each profile (.envrc,
+.env, .gitconfig...) are looked at, not tool data.
+
+Options:
+    -h, --help          Show this help message
+    --format <format>   table (aligned columns), plain (tab-separated, no
+                        header) or json
+
+Examples:
+    shell-profiler recent               # What was I working on?
+    shell-profiler recent 10
+    shell-profiler recent 10 --format json
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showExistsHelp() {
+	helpText := `Usage: shell-profiler exists <profile-name> [options]
+
+Check whether a profile exists, for use in scripts. A profile is valid when
+its directory has a .envrc.
+
+Exit status:
+    0    The profile exists and is valid
+    1    The profile directory exists but is not valid
+    2    The profile does not exist
+
+Options:
+    -q, --quiet         Print nothing; only set the exit status
+    -h, --help          Show this help message
+
+Examples:
+    shell-profiler exists acme --quiet || shell-profiler create acme
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showExportAllHelp() {
+	helpText := `Usage: shell-profiler export-all <file.tar.gz> [options]
+
+Write every profile to a single archive, for backup or moving to another
+machine. Files matched by each profile's .gitignore (.env, .envrc.local,
+SSH keys, cloud credentials...) and .git directories are left out, so the
+archive holds no secrets. A manifest.json lists the profiles, their
+metadata and the files excluded from each.
+
+Options:
+    -x, --exclude <glob>    Also leave out paths matching the glob, relative
+                            to each profile (repeatable). A trailing / matches
+                            directories only. Secrets are excluded regardless.
+    -f, --force             Overwrite an existing archive
+    -h, --help              Show this help message
+
+Examples:
+    shell-profiler export-all ~/profiles-backup.tar.gz
+    shell-profiler export-all backup.tar.gz --exclude .terraform.d/ --exclude 'code/*/node_modules/'
+    shell-profiler import-all ~/profiles-backup.tar.gz
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showImportAllHelp() {
+	helpText := `Usage: shell-profiler import-all <file.tar.gz> [options]
+
+Restore the profiles of an archive written by export-all into the profiles
+directory. Profiles that already exist are skipped. Secrets are not in the
+archive: recreate each profile's .env and run 'direnv allow' afterwards.
+Hooks in an imported .profile-meta are removed and listed, so none of the
+archive's scripts run until you review them and add them back.
+
+Options:
+    -f, --force         Replace profiles that already exist
+    -h, --help          Show this help message
+
+Examples:
+    shell-profiler import-all ~/profiles-backup.tar.gz
+    shell-profiler --profiles-dir ~/new-profiles import-all backup.tar.gz
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showInfoHelp() {
+	helpText := `Usage: shell-profiler info [options]
+
+Show information about a workspace profile.
+
+Without --profile, the active profile (WORKSPACE_PROFILE) is shown. If no
+profile is active, the profile containing the current directory is detected
+by walking up to the nearest .envrc that exports WORKSPACE_PROFILE.
+
+Options:
+    -h, --help              Show this help message
+    -p, --profile <name>    Show a profile from the configured profiles directory
+    --format <format>       Show the profile's fields as a table, plain
+                            (tab-separated field and value) or json
+
+Examples:
+    shell-profiler info
+    shell-profiler show --profile my-project
+    shell-profiler show --profile my-project --format json
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showTagHelp() {
+	helpText := `Usage: shell-profiler tag <command> [profile-name] [tags...]
+
+Manage free-form tags stored in a profile's .profile-meta.
+
+Commands:
+    add <name> <tag>...       Add one or more tags to a profile
+    remove <name> <tag>...    Remove one or more tags from a profile
+    list [name]               List a profile's tags, or every tag in use
+
+Tags may contain letters, digits, '_', '.', ':' and '-' (max 64 characters),
+so namespaced tags such as team:platform are allowed.
+
+Examples:
+    shell-profiler tag add acme client team:platform
+    shell-profiler tag remove acme team:platform
+    shell-profiler tag list
+    shell-profiler list --tag client --no-interactive
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showPathHelp() {
+	helpText := `Usage: shell-profiler path <command> <profile-name> [dirs...]
+       shell-profiler path [--print-path] <profile-name>
+
+Manage the directories a profile adds to PATH after bin/. Each is a
+PATH_add line in the profile's .envrc, so direnv must re-allow it.
+Directories are relative to the profile (WORKSPACE_HOME) or absolute.
+
+Given only a profile name, print the profile's absolute directory, with
+symlinks resolved, and nothing else. Use --print-path for a profile named
+like one of the commands.
+
+Commands:
+    add <name> <dir>...       Add directories; ones already added are skipped
+    remove <name> <dir>...    Remove added directories
+    list <name>               List added directories
+
+Examples:
+    cd "$(shell-profiler path acme)"
+    shell-profiler path add acme code/app/node_modules/.bin
+    shell-profiler path add acme /opt/acme/bin
+    shell-profiler path remove acme /opt/acme/bin
+    shell-profiler create acme --path-add code/app/node_modules/.bin
 `
 	fmt.Print(helpText)
 }
@@ -802,6 +2659,9 @@ Options:
     -h, --help          Show this help message
     -f, --force         Skip confirmation prompt (disables interactive)
     --dry-run          Show what would be deleted without deleting (disables interactive)
+    --preserve-data     Save the tools' credential and config directories (.aws,
+                        .kube, .ssh, ...) to archived/<name>-data-<time>.tar.gz
+                        first, then delete the rest
     --no-interactive    Disable interactive mode
 
 Examples:
@@ -817,10 +2677,77 @@ Examples:
     # Preview what would be deleted
     shell-profiler delete old-project --dry-run
 
+    # Delete the profile but keep its cloud credentials
+    shell-profiler delete old-project --preserve-data
+
 Safety:
     - You will be prompted for confirmation unless --force is used
     - The profile directory and all its contents will be deleted
     - This operation cannot be undone
+    - direnv's allow record for the profile's .envrc is revoked (direnv deny),
+      so it does not linger; skipped when direnv is not installed
+
+Hooks:
+    A pre_delete_hook in ~/.profile-manager (or "pre-delete" under "hooks" in
+    the profile's .profile-meta) runs first with WORKSPACE_PROFILE and
+    WORKSPACE_HOME set; a non-zero exit cancels the delete.
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showTemplatesHelp() {
+	helpText := `Usage: shell-profiler templates <command> [template-name...] [options]
+
+Check templates for text/template syntax errors and references to fields
+the template is not rendered with, e.g. {{.ProfileNam}}. Only files from
+template directories are checked; built-in files are always valid. User
+templates are also linted before every render.
+
+Commands:
+    lint                  Lint the named templates, or all of them
+    preview <name>        Print the .envrc, .env, .gitconfig and .gitignore
+                          the template produces, without writing any files
+
+Options:
+    -h, --help              Show this help message
+    -t, --template <name>   Template to preview (same as the positional name)
+    -p, --profile <name>    Profile name to render the preview for
+                            (default: example)
+    --template-dir <path>   Search this directory first (default: template_dir
+                            from ~/.profile-manager)
+
+Fields available to each file:
+    envrc.tpl       .ProfileName .Template .CreatedAt .EncryptCache .PathAdd
+                    .Watch .OpAccount .OpFieldPrefix .OpExcludeFields .IncludeTOTP
+                    .Vaults .OpVaults (the vaults as shell words)
+                    .OpFieldFilter .OpFieldLabel (jq for the 1Password fields)
+    env.tpl         .ProfileName .Template
+    gitconfig.tpl   .ProfileName .Template .GitName .GitEmail
+
+Examples:
+    shell-profiler templates lint
+    shell-profiler templates lint team --template-dir ~/templates
+    shell-profiler templates preview personal --profile my-project
+`
+	fmt.Print(helpText)
+}
+
+func (a *App) showEditHelp() {
+	helpText := `Usage: shell-profiler edit [profile-name] [file] [options]
+
+Open a profile's dotfile in $EDITOR (then $VISUAL, vim, nano or vi).
+The file is a path relative to the profile; a unique prefix is completed,
+with or without the leading dot. Without a profile or file, you are
+prompted to choose. Same as 'shell-profiler dotfiles edit'.
+
+Options:
+    -h, --help            Show this help message
+    -e, --editor <name>   Editor to use, may include arguments ("code --wait")
+
+Examples:
+    shell-profiler edit acme .gitconfig
+    shell-profiler edit acme ssh/c        # .ssh/config
+    shell-profiler edit acme aws/config --editor "code --wait"
 `
 	fmt.Print(helpText)
 }
@@ -883,6 +2810,34 @@ Note: Interactive mode is enabled by default if profile or file name is omitted.
 	fmt.Print(helpText)
 }
 
+func (a *App) showApplyHelp() {
+	helpText := `Usage: shell-profiler apply --plan-file <path> [options]
+
+Apply an update plan saved with 'shell-profiler update <name> --plan-file <path>'.
+Exactly the migrations recorded in the plan are run, so a plan can be
+reviewed and approved before anything changes.
+
+The plan records a hash of each profile file it was made against. If any of
+them changed since, the plan is stale and apply refuses unless --force is
+given. A plan made against a different schema version is always refused.
+
+Options:
+    -h, --help          Show this help message
+    --plan-file <path>  Plan to apply (may also be given as an argument)
+    -f, --force         Apply even if profile files changed since the plan
+    --overwrite         Let migrations overwrite existing files, including
+                        your edits to the managed block of .env (otherwise
+                        you are asked first, and they are kept without a
+                        terminal)
+    --no-backup         Skip creating backup before applying
+
+Examples:
+    shell-profiler update my-project --plan-file my-project.plan.json
+    shell-profiler apply --plan-file my-project.plan.json
+`
+	fmt.Print(helpText)
+}
+
 func (a *App) showUpdateHelp() {
 	helpText := `Usage: shell-profiler update [profile-name] [options]
 
@@ -897,9 +2852,30 @@ Arguments:
 
 Options:
     -h, --help          Show this help message
-    -f, --force         Overwrite existing files without prompting
+    -a, --all           Update every profile. Profiles at the latest schema
+                       version whose files are unchanged since their last
+                       update are skipped quickly
+    --overwrite        Let migrations overwrite existing files, including
+                       your edits to the managed block of .env (otherwise
+                       you are asked first, and they are kept without a
+                       terminal)
+    --skip-backup-confirm
+                       Continue without asking if the backup cannot be created
+    -f, --force         Same as --overwrite --skip-backup-confirm
     --dry-run          Preview changes without applying them
+    --explain          Preview changes with the migration behind each one
+                       and why it is needed; nothing is applied
     --no-backup        Skip creating backup before updating
+    --allow            Run 'direnv allow' after updating (prompted when
+                       .envrc changes in an interactive terminal)
+    --create-vault     When migrating to vault discovery, create the
+                       profile's vault (op vault create) if it does not exist
+    --relative-ssh-paths
+                       Rewrite the absolute profile paths in .ssh/config as
+                       ${WORKSPACE_HOME} (see 'shell-profiler create --help')
+    --rollback-to <n>  Revert the profile to an earlier schema version
+    --plan-file <path> Write the migrations the update would run to <path>
+                       as JSON instead of applying them
 
 Examples:
     # Interactive selection
@@ -908,21 +2884,52 @@ Examples:
     # Update specific profile
     shell-profiler update my-project
 
+    # Update every profile
+    shell-profiler update --all
+
     # Preview changes without applying
     shell-profiler update my-project --dry-run
 
+    # Show why each change is needed
+    shell-profiler update my-project --explain
+
     # Update without creating backup
     shell-profiler update my-project --no-backup
 
+    # Save the update plan for review
+    shell-profiler update my-project --plan-file my-project.plan.json
+
+    # Undo the most recent migrations back to schema version 3
+    shell-profiler update my-project --rollback-to 3
+
 What gets updated:
     - Missing directories (.azure, .gcloud, etc.)
     - Missing environment variables in .envrc
     - Missing patterns in .gitignore
     - SSH directory permissions
 
+Overrides:
+    Managed variables in .env are reset to the template's values when the managed
+    block is regenerated. To keep a different value, map the variable to it under
+    "overrides" in the profile's .profile-meta, e.g.
+        "overrides": {"KUBECONFIG": "$HOME/.kube/shared-config"}
+    Values may refer to $WORKSPACE_HOME, $WORKSPACE_PROFILE and $HOME.
+
 Backup:
-    By default, a backup is created in .backups/update_<timestamp>/ before making changes.
+    By default, a backup is created in .backups/update_<timestamp>/ before making changes,
+    or in <backup_dir>/<profile>/ when backup_dir or --backup-dir is set.
     Use --no-backup to skip this.
+
+Rollback:
+    Migrations with a defined inverse are reverted in place. Otherwise the newest
+    update backup taken at the target schema version is restored; if there is
+    none, the rollback is refused.
+
+Hooks:
+    pre_update_hook and post_update_hook in ~/.profile-manager (or "pre-update"
+    and "post-update" under "hooks" in the profile's .profile-meta) run around
+    the update with WORKSPACE_PROFILE and WORKSPACE_HOME set. A failing
+    pre-update hook cancels the update. Hooks are skipped with --dry-run.
 `
 	fmt.Print(helpText)
 }
@@ -932,15 +2939,19 @@ func (a *App) showInitHelp() {
 
 Initialize the profile manager configuration.
 
-This command creates a ~/.profile-manager configuration file that stores
-the path to your profiles directory. If not initialized, the tool will use
-the default path: ~/workspaces/profiles
+This command creates the profiles directory and, if it does not exist, a
+~/.profile-manager configuration file that stores its path. If not
+initialized, the tool will use the default path: ~/workspaces/profiles
+
+Anything that already exists is left alone, so init is safe to re-run.
 
 Options:
     -h, --help              Show this help message
     -f, --force             Overwrite existing configuration
     -i, --interactive       Interactive setup (prompt for paths)
     --profiles-dir <path>   Set profiles directory path
+    --git                   Make the profiles directory a git repository so
+                            every profile is versioned together
 
 Examples:
     # Initialize with default path
@@ -952,6 +2963,9 @@ Examples:
     # Initialize with custom path
     shell-profiler init --profiles-dir ~/my-profiles
 
+    # Version the whole collection in one repository
+    shell-profiler init --git
+
     # Overwrite existing configuration
     shell-profiler init --force
 
@@ -962,6 +2976,15 @@ Configuration:
     
     You can edit this file manually if needed. Paths can use ~ for home directory
     and environment variables will be expanded.
+
+    If ~/.profile-manager.yaml exists it is used instead, with the same keys
+    plus per-template defaults:
+
+        profiles_dir: ~/workspaces/profiles
+        templates:
+          work:
+            git_name: Jane Doe
+            git_email: jane@acme.com
 `
 	fmt.Print(helpText)
 }
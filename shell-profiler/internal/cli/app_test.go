@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+)
+
+// newConfiguredApp writes a ~/.profile-manager pointing at a profiles
+// directory and returns an App built from it the way main does
+func newConfiguredApp(t *testing.T) (*App, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("WORKSPACE_PROFILE", "")
+
+	// Commands other than init require direnv on PATH
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "direnv"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	configDir := filepath.Join(home, "config-profiles")
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte("profiles_dir="+configDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	return NewApp(cfg.ProfilesDir), configDir
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestProfilesDirFlag_OverridesConfigForCreate(t *testing.T) {
+	app, configDir := newConfiguredApp(t)
+	flagDir := filepath.Join(t.TempDir(), "flag-profiles")
+
+	captureStdout(t, func() {
+		if err := app.Run([]string{"--profiles-dir", flagDir, "create", "svc", "--no-interactive"}); err != nil {
+			t.Fatalf("create error: %v", err)
+		}
+	})
+
+	if _, err := os.Stat(filepath.Join(flagDir, "svc", ".envrc")); err != nil {
+		t.Errorf("profile not created under --profiles-dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "svc")); !os.IsNotExist(err) {
+		t.Errorf("profile should not be created in the configured profiles_dir (err=%v)", err)
+	}
+}
+
+func TestProfilesDirFlag_OverridesConfigForList(t *testing.T) {
+	app, configDir := newConfiguredApp(t)
+	flagDir := t.TempDir()
+	for dir, name := range map[string]string{configDir: "from-config", flagDir: "from-flag"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, ".envrc"), []byte("export WORKSPACE_PROFILE=\""+name+"\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Global flags are accepted after the command too
+	out := captureStdout(t, func() {
+		if err := app.Run([]string{"list", "--no-interactive", "--profiles-dir", flagDir}); err != nil {
+			t.Fatalf("list error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "from-flag") {
+		t.Errorf("list should show profiles from --profiles-dir:\n%s", out)
+	}
+	if strings.Contains(out, "from-config") {
+		t.Errorf("list should not show profiles from the configured profiles_dir:\n%s", out)
+	}
+}
+
+func TestProfilesDirFlag_SetsInitDirInEitherPosition(t *testing.T) {
+	for _, position := range []string{"before", "after"} {
+		app, _ := newConfiguredApp(t)
+		flagDir := filepath.Join(t.TempDir(), "init-profiles")
+		args := []string{"--profiles-dir", flagDir, "init"}
+		if position == "after" {
+			args = []string{"init", "--profiles-dir", flagDir}
+		}
+		captureStdout(t, func() {
+			if err := app.Run(args); err != nil {
+				t.Fatalf("init error: %v", err)
+			}
+		})
+		if info, err := os.Stat(flagDir); err != nil || !info.IsDir() {
+			t.Errorf("--profiles-dir %s init: directory not created: %v", position, err)
+		}
+	}
+}
+
+func TestProfilesDirFlag_ExpandsHome(t *testing.T) {
+	app, _ := newConfiguredApp(t)
+	home := os.Getenv("HOME")
+
+	if _, err := app.parseGlobalFlags([]string{"--profiles-dir", "~/alt", "list"}); err != nil {
+		t.Fatalf("parseGlobalFlags() error: %v", err)
+	}
+	if want := filepath.Join(home, "alt"); app.profilesDir != want {
+		t.Errorf("profilesDir = %q, want %q", app.profilesDir, want)
+	}
+
+	if _, err := app.parseGlobalFlags([]string{"list", "--profiles-dir"}); err == nil {
+		t.Error("expected an error when --profiles-dir has no value")
+	}
+}
+
+func TestPath_PrintsOnlyProfileDir(t *testing.T) {
+	app, configDir := newConfiguredApp(t)
+	if err := os.MkdirAll(filepath.Join(configDir, "work"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "work", ".envrc"), []byte("export WORKSPACE_PROFILE=\"work\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(filepath.Join(configDir, "work"))
+
+	for _, args := range [][]string{{"path", "work"}, {"path", "--print-path", "work"}} {
+		out := captureStdout(t, func() {
+			if err := app.Run(args); err != nil {
+				t.Fatalf("%v error: %v", args, err)
+			}
+		})
+		if out != want+"\n" {
+			t.Errorf("%v printed %q, want %q", args, out, want+"\n")
+		}
+	}
+
+	var runErr error
+	out := captureStdout(t, func() { runErr = app.Run([]string{"path", "missing"}) })
+	if runErr == nil {
+		t.Fatal("path succeeded for a missing profile")
+	}
+	if out != "" {
+		t.Errorf("missing profile wrote to stdout: %q", out)
+	}
+	var stderr strings.Builder
+	app.PrintError(&stderr, runErr)
+	if !strings.Contains(stderr.String(), "profile 'missing' does not exist") {
+		t.Errorf("unexpected error output: %q", stderr.String())
+	}
+}
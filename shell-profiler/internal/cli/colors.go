@@ -4,15 +4,8 @@ import (
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
-// Re-export color constants and functions for convenience
-const (
-	ColorReset  = ui.ColorReset
-	ColorRed    = ui.ColorRed
-	ColorGreen  = ui.ColorGreen
-	ColorYellow = ui.ColorYellow
-	ColorBlue   = ui.ColorBlue
-	ColorCyan   = ui.ColorCyan
-)
+// Re-export print functions for convenience. Color codes are read from the
+// ui package directly since they change when colors are disabled.
 
 func PrintError(msg string) {
 	ui.PrintError(msg)
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+// jsonError is the shape of a fatal error in --json mode
+type jsonError struct {
+	Error string    `json:"error"`
+	Code  errs.Code `json:"code"`
+}
+
+// PrintError writes a fatal error to w, as a JSON object when the command
+// line asked for JSON output. Errors with no message only set the exit status.
+func (a *App) PrintError(w io.Writer, err error) {
+	if err.Error() == "" {
+		return
+	}
+	if !a.jsonErrors {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	data, _ := json.Marshal(jsonError{Error: err.Error(), Code: errs.CodeOf(err)})
+	fmt.Fprintf(w, "%s\n", data)
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+// runJSON runs the app and decodes the error it would print in JSON mode
+func runJSON(t *testing.T, app *App, args ...string) jsonError {
+	t.Helper()
+	var runErr error
+	captureStdout(t, func() { runErr = app.Run(args) })
+	if runErr == nil {
+		t.Fatalf("Run(%v) succeeded, want an error", args)
+	}
+
+	var buf bytes.Buffer
+	app.PrintError(&buf, runErr)
+	var got jsonError
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("error output is not JSON: %v\n%s", err, buf.String())
+	}
+	if got.Error != runErr.Error() {
+		t.Errorf("error = %q, want %q", got.Error, runErr.Error())
+	}
+	return got
+}
+
+func TestPrintError_JSONCodes(t *testing.T) {
+	app, configDir := newConfiguredApp(t)
+	if err := os.MkdirAll(filepath.Join(configDir, "acme"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want errs.Code
+	}{
+		{"existing profile", []string{"--json", "create", "acme", "--no-interactive"}, errs.ProfileExists},
+		{"invalid name", []string{"--json", "create", "bad name!", "--no-interactive"}, errs.InvalidName},
+		{"unknown template", []string{"create", "svc", "--template", "nope", "--json"}, errs.TemplateNotFound},
+		{"missing profile", []string{"--json", "info", "--profile", "ghost"}, errs.ProfileNotFound},
+		{"uncoded error", []string{"--json", "no-such-command"}, errs.Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runJSON(t, app, tt.args...)
+			if got.Code != tt.want {
+				t.Errorf("code = %q, want %q (error: %s)", got.Code, tt.want, got.Error)
+			}
+		})
+	}
+}
+
+func TestPrintError_FormatJSONEnablesJSON(t *testing.T) {
+	app := NewApp(t.TempDir())
+	if _, err := app.parseGlobalFlags([]string{"list", "--format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	app.PrintError(&buf, errs.Wrapf(errs.ErrProfileNotFound, "profile 'x' does not exist"))
+	if want := `{"error":"profile 'x' does not exist","code":"ProfileNotFound"}` + "\n"; buf.String() != want {
+		t.Errorf("PrintError() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPrintError_PlainByDefault(t *testing.T) {
+	app := NewApp(t.TempDir())
+
+	var buf bytes.Buffer
+	app.PrintError(&buf, errs.Wrapf(errs.ErrProfileNotFound, "profile 'x' does not exist"))
+	if got := buf.String(); got != "Error: profile 'x' does not exist\n" || strings.Contains(got, "{") {
+		t.Errorf("PrintError() = %q", got)
+	}
+}
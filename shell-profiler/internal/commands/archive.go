@@ -0,0 +1,334 @@
+package commands
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// archivedDirName is the subdirectory of the profiles directory that holds
+// archived profiles. It has no .envrc, so profile listings skip it.
+const archivedDirName = "archived"
+
+// profileNameTaken reports whether name is in use by an active or archived
+// profile, and where, so a later restore cannot collide with it
+func profileNameTaken(profilesDir, name string) (bool, string) {
+	for _, dir := range []string{
+		filepath.Join(profilesDir, name),
+		filepath.Join(profilesDir, archivedDirName, name),
+	} {
+		if _, err := os.Lstat(dir); err == nil {
+			return true, dir
+		}
+	}
+	return false, ""
+}
+
+// nameTakenError describes a profile name conflict found by profileNameTaken,
+// with how to free the name up
+func nameTakenError(profilesDir, name, location string) error {
+	if filepath.Dir(location) == filepath.Join(profilesDir, archivedDirName) {
+		return errs.Wrapf(errs.ErrProfileExists, "an archived profile named '%s' exists at: %s (restore it with: shell-profiler unarchive %s, or delete it first)", name, location, name)
+	}
+	return errs.Wrapf(errs.ErrProfileExists, "profile '%s' already exists at: %s", name, location)
+}
+
+// archiveDataDirs are the tool-data directories that can grow large and are
+// compressed when archiving with --compress
+var archiveDataDirs = []string{
+	".aws",
+	".azure",
+	".gcloud",
+	".kube",
+	".terraform.d",
+	".cache",
+}
+
+type ArchiveOptions struct {
+	ProfileName string
+	Compress    bool
+}
+
+// ArchiveProfile moves a profile into the archived/ subdirectory so it no
+// longer shows up in list or select, optionally compressing tool data
+func ArchiveProfile(profilesDir string, opts ArchiveOptions) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+	}
+
+	archiveDir := filepath.Join(profilesDir, archivedDirName)
+	archivedPath := filepath.Join(archiveDir, opts.ProfileName)
+	if _, err := os.Stat(archivedPath); err == nil {
+		return nameTakenError(profilesDir, opts.ProfileName, archivedPath)
+	}
+
+	if os.Getenv("WORKSPACE_PROFILE") == opts.ProfileName {
+		ui.PrintWarning("You are currently in this profile!")
+	}
+
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock file moves with the profile
+
+	if opts.Compress {
+		for _, dir := range archiveDataDirs {
+			compressed, err := compressDataDir(profileDir, dir)
+			if err != nil {
+				return err
+			}
+			if compressed {
+				fmt.Printf("  Compressed %s -> %s.tar.gz\n", dir, dir)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.Rename(profileDir, archivedPath); err != nil {
+		return fmt.Errorf("failed to archive profile: %w", err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Profile archived: %s", opts.ProfileName))
+	fmt.Printf("  Location: %s\n", archivedPath)
+	fmt.Printf("  Restore with: shell-profiler unarchive %s\n", opts.ProfileName)
+	return nil
+}
+
+// UnarchiveProfile moves an archived profile back into the profiles directory
+// and expands any tool data compressed when it was archived
+func UnarchiveProfile(profilesDir string, opts ArchiveOptions) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	archivedPath := filepath.Join(profilesDir, archivedDirName, opts.ProfileName)
+	if _, err := os.Stat(filepath.Join(archivedPath, ".envrc")); os.IsNotExist(err) {
+		return fmt.Errorf("no archived profile named '%s' at: %s", opts.ProfileName, archivedPath)
+	}
+
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	if _, err := os.Stat(profileDir); err == nil {
+		return nameTakenError(profilesDir, opts.ProfileName, profileDir)
+	}
+
+	lock, err := profile.AcquireLock(archivedPath, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock file moves with the profile
+
+	for _, dir := range archiveDataDirs {
+		expanded, err := expandDataDir(archivedPath, dir)
+		if err != nil {
+			return err
+		}
+		if expanded {
+			fmt.Printf("  Expanded %s.tar.gz -> %s\n", dir, dir)
+		}
+	}
+
+	if err := os.Rename(archivedPath, profileDir); err != nil {
+		return fmt.Errorf("failed to restore profile: %w", err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Profile restored: %s", opts.ProfileName))
+	fmt.Printf("  Location: %s\n", profileDir)
+	return nil
+}
+
+// archivedProfiles returns the names of archived profiles
+func archivedProfiles(profilesDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(profilesDir, archivedDirName))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		envrcPath := filepath.Join(profilesDir, archivedDirName, entry.Name(), ".envrc")
+		if _, err := os.Stat(envrcPath); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// compressDataDir replaces <dir> with <dir>.tar.gz. Missing or empty
+// directories are left alone.
+func compressDataDir(profileDir, dir string) (bool, error) {
+	srcDir := filepath.Join(profileDir, dir)
+	entries, err := os.ReadDir(srcDir)
+	if err != nil || len(entries) == 0 {
+		return false, nil
+	}
+
+	archivePath := srcDir + ".tar.gz"
+	file, err := os.OpenFile(archivePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return false, fmt.Errorf("failed to create %s.tar.gz: %w", dir, err)
+	}
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	walkErr := tarDir(tw, srcDir, "")
+
+	// Close in order so the archive is complete before the source is removed
+	if err := tw.Close(); err != nil && walkErr == nil {
+		walkErr = err
+	}
+	if err := gz.Close(); err != nil && walkErr == nil {
+		walkErr = err
+	}
+	if err := file.Close(); err != nil && walkErr == nil {
+		walkErr = err
+	}
+	if walkErr != nil {
+		os.Remove(archivePath)
+		return false, fmt.Errorf("failed to compress %s: %w", dir, walkErr)
+	}
+
+	if err := os.RemoveAll(srcDir); err != nil {
+		return false, fmt.Errorf("failed to remove %s after compressing: %w", dir, err)
+	}
+	return true, nil
+}
+
+// tarDir writes the contents of srcDir to tw, naming each entry by its path
+// relative to srcDir under prefix
+func tarDir(tw *tar.Writer, srcDir, prefix string) error {
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil || (rel == "." && prefix == "") {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+}
+
+// expandDataDir restores <dir> from <dir>.tar.gz and removes the archive
+func expandDataDir(profileDir, dir string) (bool, error) {
+	archivePath := filepath.Join(profileDir, dir) + ".tar.gz"
+	file, err := os.Open(archivePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s.tar.gz: %w", dir, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s.tar.gz: %w", dir, err)
+	}
+	defer gz.Close()
+
+	destDir := filepath.Join(profileDir, dir)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s.tar.gz: %w", dir, err)
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, destDir+string(os.PathSeparator)) {
+			return false, fmt.Errorf("refusing to extract %s outside of %s", header.Name, dir)
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return false, fmt.Errorf("failed to create %s: %w", target, err)
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return false, fmt.Errorf("failed to create symlink %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return false, fmt.Errorf("failed to create %s: %w", target, err)
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return false, fmt.Errorf("failed to extract %s: %w", target, err)
+			}
+			if err := out.Close(); err != nil {
+				return false, fmt.Errorf("failed to extract %s: %w", target, err)
+			}
+		}
+	}
+
+	if err := os.Remove(archivePath); err != nil {
+		return false, fmt.Errorf("failed to remove %s.tar.gz: %w", dir, err)
+	}
+	return true, nil
+}
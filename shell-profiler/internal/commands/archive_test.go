@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+func TestArchiveProfile_RelocatesAndRestores(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := newTaggableProfile(t, tmpDir, "old")
+	newTaggableProfile(t, tmpDir, "current")
+
+	if err := ArchiveProfile(tmpDir, ArchiveOptions{ProfileName: "old"}); err != nil {
+		t.Fatalf("ArchiveProfile() error: %v", err)
+	}
+
+	if _, err := os.Stat(profileDir); !os.IsNotExist(err) {
+		t.Error("archived profile should be moved out of the profiles directory")
+	}
+	archivedPath := filepath.Join(tmpDir, "archived", "old")
+	if _, err := os.Stat(filepath.Join(archivedPath, ".envrc")); err != nil {
+		t.Fatalf("archived profile should be under archived/: %v", err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{})
+	})
+	if err != nil {
+		t.Fatalf("ListProfiles() error: %v", err)
+	}
+	if strings.Contains(out, "old") || !strings.Contains(out, "Total profiles: 1") {
+		t.Errorf("archived profile should be excluded from the default listing:\n%s", out)
+	}
+
+	out, _ = captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{IncludeArchived: true})
+	})
+	if !strings.Contains(out, "archived/old") {
+		t.Errorf("--include-archived should list the archived profile:\n%s", out)
+	}
+
+	if err := SelectProfile(tmpDir, SelectOptions{ProfileName: "old"}); err == nil {
+		t.Error("select should not find an archived profile by default")
+	}
+
+	if err := UnarchiveProfile(tmpDir, ArchiveOptions{ProfileName: "old"}); err != nil {
+		t.Fatalf("UnarchiveProfile() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		t.Errorf("unarchived profile should be back in the profiles directory: %v", err)
+	}
+	if _, err := os.Stat(archivedPath); !os.IsNotExist(err) {
+		t.Error("unarchived profile should be removed from archived/")
+	}
+}
+
+func TestArchiveProfile_CompressRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := newTaggableProfile(t, tmpDir, "big")
+
+	credsPath := filepath.Join(profileDir, ".aws", "sso", "cache", "token.json")
+	if err := os.MkdirAll(filepath.Dir(credsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credsPath, []byte(`{"token":"x"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Empty data directories are left as-is
+	if err := os.MkdirAll(filepath.Join(profileDir, ".kube"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ArchiveProfile(tmpDir, ArchiveOptions{ProfileName: "big", Compress: true}); err != nil {
+		t.Fatalf("ArchiveProfile() error: %v", err)
+	}
+
+	archivedPath := filepath.Join(tmpDir, "archived", "big")
+	if _, err := os.Stat(filepath.Join(archivedPath, ".aws.tar.gz")); err != nil {
+		t.Errorf(".aws should be compressed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archivedPath, ".aws")); !os.IsNotExist(err) {
+		t.Error(".aws should be removed after compressing")
+	}
+	if _, err := os.Stat(filepath.Join(archivedPath, ".kube.tar.gz")); !os.IsNotExist(err) {
+		t.Error("empty .kube should not be compressed")
+	}
+
+	if err := UnarchiveProfile(tmpDir, ArchiveOptions{ProfileName: "big"}); err != nil {
+		t.Fatalf("UnarchiveProfile() error: %v", err)
+	}
+
+	data, err := os.ReadFile(credsPath)
+	if err != nil || string(data) != `{"token":"x"}` {
+		t.Errorf("compressed file not restored: %q, %v", data, err)
+	}
+	if info, err := os.Stat(credsPath); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("restored file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".aws.tar.gz")); !os.IsNotExist(err) {
+		t.Error(".aws.tar.gz should be removed after expanding")
+	}
+}
+
+func TestArchiveProfile_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := ArchiveProfile(tmpDir, ArchiveOptions{ProfileName: "missing"}); err == nil {
+		t.Error("archiving a missing profile should fail")
+	}
+	if err := UnarchiveProfile(tmpDir, ArchiveOptions{ProfileName: "missing"}); err == nil {
+		t.Error("unarchiving a missing archived profile should fail")
+	}
+
+	// Unarchive refuses to overwrite an active profile with the same name
+	newTaggableProfile(t, tmpDir, "dup")
+	if err := ArchiveProfile(tmpDir, ArchiveOptions{ProfileName: "dup"}); err != nil {
+		t.Fatal(err)
+	}
+	newTaggableProfile(t, tmpDir, "dup")
+	if err := UnarchiveProfile(tmpDir, ArchiveOptions{ProfileName: "dup"}); !errors.Is(err, errs.ErrProfileExists) || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected already exists error, got: %v", err)
+	}
+
+	// Nor does archive overwrite an archived profile with the same name
+	err := ArchiveProfile(tmpDir, ArchiveOptions{ProfileName: "dup"})
+	if !errors.Is(err, errs.ErrProfileExists) || !strings.Contains(err.Error(), "unarchive dup") {
+		t.Errorf("expected ErrProfileExists naming the archived profile, got: %v", err)
+	}
+}
+
+func TestCreateProfile_ReservesArchivedName(t *testing.T) {
+	err := CreateProfile(t.TempDir(), CreateOptions{ProfileName: "archived", Template: "basic"})
+	if err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("expected reserved name error, got: %v", err)
+	}
+}
+
+func TestProfileNameTaken(t *testing.T) {
+	tmpDir := t.TempDir()
+	newTaggableProfile(t, tmpDir, "active")
+	if err := os.MkdirAll(filepath.Join(tmpDir, archivedDirName, "old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		taken    bool
+		location string
+	}{
+		{"active", true, filepath.Join(tmpDir, "active")},
+		{"old", true, filepath.Join(tmpDir, archivedDirName, "old")},
+		{"free", false, ""},
+	}
+	for _, tt := range tests {
+		taken, location := profileNameTaken(tmpDir, tt.name)
+		if taken != tt.taken || location != tt.location {
+			t.Errorf("profileNameTaken(%q) = %v, %q, want %v, %q", tt.name, taken, location, tt.taken, tt.location)
+		}
+	}
+}
+
+func TestCreateProfile_ArchivedNameTaken(t *testing.T) {
+	tmpDir := t.TempDir()
+	newTaggableProfile(t, tmpDir, "old")
+	if err := ArchiveProfile(tmpDir, ArchiveOptions{ProfileName: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// --force overwrites active profiles only
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "old", Template: "basic", Force: true})
+	if !errors.Is(err, errs.ErrProfileExists) {
+		t.Fatalf("err = %v, want ErrProfileExists", err)
+	}
+	archivedPath := filepath.Join(tmpDir, archivedDirName, "old")
+	if !strings.Contains(err.Error(), archivedPath) || !strings.Contains(err.Error(), "unarchive old") {
+		t.Errorf("error should name the archived location and how to restore it: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "old")); !os.IsNotExist(err) {
+		t.Error("no profile should have been created")
+	}
+
+	newTaggableProfile(t, tmpDir, "other")
+	err = RenameProfile(tmpDir, RenameOptions{ProfileName: "other", NewName: "old"})
+	if !errors.Is(err, errs.ErrProfileExists) || !strings.Contains(err.Error(), archivedPath) {
+		t.Errorf("rename onto an archived name: err = %v", err)
+	}
+}
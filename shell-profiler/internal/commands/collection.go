@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// DefaultCollectionMessage is the commit message used by collection commit
+// when none is given
+const DefaultCollectionMessage = "Update profiles"
+
+// CollectionOptions configure git operations on the profiles directory
+// itself, as opposed to the per-profile repositories managed by sync
+type CollectionOptions struct {
+	Message string // Commit message
+	Force   bool   // Force push
+}
+
+// requireCollectionRepo fails unless the profiles directory is the root of a
+// git repository
+func requireCollectionRepo(profilesDir string) error {
+	if _, err := os.Stat(filepath.Join(profilesDir, ".git")); os.IsNotExist(err) {
+		return fmt.Errorf("profiles directory %s is not a git repository (run 'shell-profiler init --git' first)", profilesDir)
+	}
+	return nil
+}
+
+// collectionGit runs git in the profiles directory and returns its output
+func collectionGit(profilesDir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = profilesDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// CollectionStatus shows the git status of the profiles directory
+func CollectionStatus(profilesDir string) error {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
+	if err := requireCollectionRepo(profilesDir); err != nil {
+		return err
+	}
+	output, err := collectionGit(profilesDir, "status", "--short", "--branch")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s=== Git Status for Profiles: %s ===%s\n", ui.ColorBlue, profilesDir, ui.ColorReset)
+	fmt.Print(output)
+	return nil
+}
+
+// CollectionCommit stages every profile and commits them together. Each
+// profile's .gitignore still applies, so ignored secrets are not committed.
+// It reports whether there was anything to commit.
+func CollectionCommit(profilesDir string, opts CollectionOptions) (bool, error) {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return false, err
+	}
+	if err := requireCollectionRepo(profilesDir); err != nil {
+		return false, err
+	}
+	if _, err := collectionGit(profilesDir, "add", "--all"); err != nil {
+		return false, fmt.Errorf("failed to stage profiles: %w", err)
+	}
+	staged, err := collectionGit(profilesDir, "diff", "--cached", "--name-only")
+	if err != nil {
+		return false, fmt.Errorf("failed to check staged changes: %w", err)
+	}
+	if strings.TrimSpace(staged) == "" {
+		ui.PrintInfo("No profile changes to commit")
+		return false, nil
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = DefaultCollectionMessage
+	}
+	if _, err := collectionGit(profilesDir, "commit", "--quiet", "-m", message); err != nil {
+		return false, fmt.Errorf("failed to commit profiles: %w", err)
+	}
+	files := strings.Split(strings.TrimSpace(staged), "\n")
+	ui.PrintSuccess(fmt.Sprintf("Committed %d file(s): %s", len(files), message))
+	return true, nil
+}
+
+// CollectionPush commits any profile changes and pushes the profiles
+// directory to its origin remote
+func CollectionPush(profilesDir string, opts CollectionOptions) error {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+	if err := requireCollectionRepo(profilesDir); err != nil {
+		return err
+	}
+	if _, err := collectionGit(profilesDir, "remote", "get-url", "origin"); err != nil {
+		return fmt.Errorf("no remote 'origin' configured for %s (add one with 'git -C %s remote add origin <url>')", profilesDir, profilesDir)
+	}
+	if _, err := CollectionCommit(profilesDir, opts); err != nil {
+		return err
+	}
+
+	branch, err := collectionGit(profilesDir, "branch", "--show-current")
+	if err != nil || strings.TrimSpace(branch) == "" {
+		branch = "main"
+	}
+	pushArgs := []string{"push", "origin", strings.TrimSpace(branch)}
+	if opts.Force {
+		pushArgs = append(pushArgs, "--force")
+	}
+	cmd := exec.Command("git", pushArgs...)
+	cmd.Dir = profilesDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to push profiles: %w", err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Pushed profiles to origin/%s", strings.TrimSpace(branch)))
+	return nil
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newCollection creates profiles in a profiles directory that is itself a
+// git repository
+func newCollection(t *testing.T, names ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "test")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "test@example.com")
+	}
+
+	profilesDir := t.TempDir()
+	for _, name := range names {
+		if err := CreateProfile(profilesDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+	}
+	if _, err := initCollectionRepo(profilesDir); err != nil {
+		t.Fatal(err)
+	}
+	return profilesDir
+}
+
+func TestCollectionCommit_CapturesProfilesWithoutSecrets(t *testing.T) {
+	profilesDir := newCollection(t, "alpha", "beta")
+	for _, name := range []string{"alpha", "beta"} {
+		if err := os.WriteFile(filepath.Join(profilesDir, name, ".envrc.local"), []byte("export TOKEN=secret\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	committed, err := CollectionCommit(profilesDir, CollectionOptions{Message: "Save profiles"})
+	if err != nil {
+		t.Fatalf("CollectionCommit() error: %v", err)
+	}
+	if !committed {
+		t.Fatal("CollectionCommit() committed nothing")
+	}
+
+	tree, err := collectionGit(profilesDir, "ls-tree", "-r", "--name-only", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := strings.Split(strings.TrimSpace(tree), "\n")
+	for _, want := range []string{"alpha/.envrc", "beta/.envrc", "alpha/.gitconfig", "beta/.gitignore"} {
+		if !slices.Contains(files, want) {
+			t.Errorf("commit missing %s:\n%s", want, tree)
+		}
+	}
+	for _, secret := range []string{"alpha/.env", "beta/.env", "alpha/.envrc.local", "beta/.envrc.local"} {
+		if slices.Contains(files, secret) {
+			t.Errorf("commit includes ignored %s", secret)
+		}
+	}
+	subject, _ := collectionGit(profilesDir, "log", "-1", "--format=%s")
+	if strings.TrimSpace(subject) != "Save profiles" {
+		t.Errorf("commit subject = %q, want %q", strings.TrimSpace(subject), "Save profiles")
+	}
+
+	// Nothing changed since
+	committed, err = CollectionCommit(profilesDir, CollectionOptions{})
+	if err != nil {
+		t.Fatalf("second CollectionCommit() error: %v", err)
+	}
+	if committed {
+		t.Error("second CollectionCommit() committed with no changes")
+	}
+}
+
+func TestCollection_RequiresRepo(t *testing.T) {
+	profilesDir := t.TempDir()
+	for name, run := range map[string]func() error{
+		"status": func() error { return CollectionStatus(profilesDir) },
+		"commit": func() error { _, err := CollectionCommit(profilesDir, CollectionOptions{}); return err },
+		"push":   func() error { return CollectionPush(profilesDir, CollectionOptions{}) },
+	} {
+		err := run()
+		if err == nil || !strings.Contains(err.Error(), "init --git") {
+			t.Errorf("%s error = %v, want a pointer to init --git", name, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+type MoveCollectionOptions struct {
+	DryRun bool
+}
+
+// MoveCollection moves the whole profiles directory to newDir, rewrites the
+// absolute paths each profile's generated files hold to the old location,
+// and points profiles_dir in the config at the new one when it named the old
+func MoveCollection(oldDir, newDir string, opts MoveCollectionOptions) error {
+	if newDir == "" {
+		return fmt.Errorf("new profiles directory is required")
+	}
+	if err := checkProfilesDir(oldDir, !opts.DryRun); err != nil {
+		return err
+	}
+	oldDir, err := filepath.Abs(oldDir)
+	if err != nil {
+		return err
+	}
+	newDir, err = filepath.Abs(config.ExpandPath(newDir))
+	if err != nil {
+		return err
+	}
+	if newDir == oldDir {
+		return fmt.Errorf("profiles directory is already at %s", oldDir)
+	}
+	if strings.HasPrefix(newDir, oldDir+string(filepath.Separator)) {
+		return fmt.Errorf("cannot move the profiles directory into itself: %s", newDir)
+	}
+	if entries, err := os.ReadDir(newDir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", newDir)
+	} else if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot use %s: %w", newDir, err)
+	}
+
+	names, err := profileNames(oldDir)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	configured, _ := filepath.Abs(config.ExpandPath(cfg.ProfilesDir))
+	updateConfig := configured == oldDir
+
+	if opts.DryRun {
+		ui.PrintInfo("DRY RUN - Nothing will be moved")
+		fmt.Printf("  Would move: %s -> %s\n", oldDir, newDir)
+		replacer := collectionReplacer(oldDir, newDir)
+		for _, name := range names {
+			var files []string
+			for _, file := range renamedFiles {
+				content, err := os.ReadFile(filepath.Join(oldDir, name, file))
+				if err == nil && replacer.Replace(string(content)) != string(content) {
+					files = append(files, file)
+				}
+			}
+			if len(files) > 0 {
+				fmt.Printf("  Would update %s: %s\n", name, strings.Join(files, ", "))
+			}
+		}
+		if updateConfig {
+			fmt.Printf("  Would set profiles_dir to %s\n", newDir)
+		}
+		return nil
+	}
+
+	if active := os.Getenv("WORKSPACE_PROFILE"); active != "" && isProfileDir(filepath.Join(oldDir, active)) {
+		ui.PrintWarning("You are currently in a profile in this collection!")
+	}
+
+	// Keep other commands out of every profile until the paths are rewritten
+	for _, name := range names {
+		lock, err := profile.AcquireLock(filepath.Join(oldDir, name), profile.DefaultLockTimeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+	}
+
+	if err := moveDir(oldDir, newDir); err != nil {
+		return fmt.Errorf("failed to move profiles directory: %w", err)
+	}
+
+	replacer := collectionReplacer(oldDir, newDir)
+	for _, name := range names {
+		profileDir := filepath.Join(newDir, name)
+		if err := rewriteProfileFiles(profileDir, replacer); err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+		if _, err := fixSSHPaths(profileDir, false); err != nil {
+			return err
+		}
+	}
+
+	if updateConfig {
+		cfg.ProfilesDir = newDir
+		if err := config.SaveConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Profiles moved: %s -> %s", oldDir, newDir))
+	fmt.Printf("  Profiles updated: %d\n", len(names))
+	if updateConfig {
+		fmt.Printf("  profiles_dir now points to %s\n", newDir)
+	} else {
+		fmt.Printf("  profiles_dir is %s; pass --profiles-dir %s or update it to use the moved profiles\n", cfg.ProfilesDir, newDir)
+	}
+	fmt.Println("  Allow each updated .envrc with: direnv allow <profile-dir>")
+	return nil
+}
+
+// collectionReplacer rewrites the absolute references generated files hold
+// to the profiles directory
+func collectionReplacer(oldDir, newDir string) *strings.Replacer {
+	return strings.NewReplacer(
+		oldDir+"/", newDir+"/",
+		`"`+oldDir+`"`, `"`+newDir+`"`,
+	)
+}
+
+// moveDir renames src to dst, copying and removing it when they are on
+// different filesystems
+func moveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	// An empty destination is replaced
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst) //nolint:errcheck // The copy already failed
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies a directory, keeping modes and symlinks
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+)
+
+// newMoveFixture configures a collection of two profiles whose SSH configs
+// refer to their keys by absolute path
+func newMoveFixture(t *testing.T) (home, oldDir string) {
+	t.Helper()
+	t.Setenv("WORKSPACE_PROFILE", "")
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	oldDir = filepath.Join(home, "profiles")
+	if err := os.MkdirAll(oldDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(&config.Config{ProfilesDir: oldDir}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"acme", "globex"} {
+		if err := CreateProfile(oldDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+		profileDir := filepath.Join(oldDir, name)
+		sshConfig := "Host github.com\n    IdentityFile " + profileDir + "/.ssh/id_github\n" +
+			"    UserKnownHostsFile \"" + profileDir + "/.ssh/known_hosts\"\n"
+		if err := os.WriteFile(filepath.Join(profileDir, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home, oldDir
+}
+
+func TestMoveCollection(t *testing.T) {
+	home, oldDir := newMoveFixture(t)
+	newDir := filepath.Join(home, "code", "profiles")
+
+	if _, err := captureStdout(t, func() error {
+		return MoveCollection(oldDir, newDir, MoveCollectionOptions{})
+	}); err != nil {
+		t.Fatalf("MoveCollection() error: %v", err)
+	}
+
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("%s should no longer exist", oldDir)
+	}
+	for _, name := range []string{"acme", "globex"} {
+		data, err := os.ReadFile(filepath.Join(newDir, name, ".ssh", "config"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(data)
+		if strings.Contains(content, oldDir+"/") {
+			t.Errorf("%s .ssh/config still refers to the old location:\n%s", name, content)
+		}
+		for _, want := range []string{
+			"IdentityFile " + filepath.Join(newDir, name) + "/.ssh/id_github",
+			"UserKnownHostsFile \"" + filepath.Join(newDir, name) + "/.ssh/known_hosts\"",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("expected %q in %s .ssh/config:\n%s", want, name, content)
+			}
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProfilesDir != newDir {
+		t.Errorf("profiles_dir = %s, want %s", cfg.ProfilesDir, newDir)
+	}
+}
+
+func TestMoveCollection_DryRun(t *testing.T) {
+	home, oldDir := newMoveFixture(t)
+	newDir := filepath.Join(home, "code", "profiles")
+	configPath, _ := config.GetConfigPath()
+	configBefore, _ := os.ReadFile(configPath)
+	before := snapshotTree(t, oldDir)
+
+	out, err := captureStdout(t, func() error {
+		return MoveCollection(oldDir, newDir, MoveCollectionOptions{DryRun: true})
+	})
+	if err != nil {
+		t.Fatalf("MoveCollection() error: %v", err)
+	}
+	for _, want := range []string{"Would move: " + oldDir + " -> " + newDir, "Would update acme: ", ".ssh/config", "Would set profiles_dir to " + newDir} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	if _, err := os.Stat(newDir); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s", newDir)
+	}
+	after := snapshotTree(t, oldDir)
+	for path, state := range before {
+		if after[path] != state {
+			t.Errorf("dry run changed %s", path)
+		}
+	}
+	if configAfter, _ := os.ReadFile(configPath); string(configAfter) != string(configBefore) {
+		t.Errorf("dry run changed the config:\n%s", configAfter)
+	}
+}
+
+func TestMoveCollection_RejectsDestination(t *testing.T) {
+	home, oldDir := newMoveFixture(t)
+	occupied := filepath.Join(home, "occupied")
+	if err := os.MkdirAll(occupied, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(occupied, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, newDir := range []string{occupied, filepath.Join(oldDir, "nested"), oldDir} {
+		if err := MoveCollection(oldDir, newDir, MoveCollectionOptions{}); err == nil {
+			t.Errorf("MoveCollection(%s) should fail", newDir)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(oldDir, "acme", ".envrc")); err != nil {
+		t.Errorf("a rejected move should leave the collection in place: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// newerSchemaMessage describes a profile written by a newer shell-profiler,
+// or returns "" if this binary knows its schema version
+func newerSchemaMessage(meta *profile.Meta, profileName string) string {
+	latest := profileMigrations.Latest()
+	if meta.SchemaVersion <= latest {
+		return ""
+	}
+	return fmt.Sprintf("profile '%s' has schema version %d, newer than this shell-profiler supports (%d)", profileName, meta.SchemaVersion, latest)
+}
+
+// checkSchemaCompatible refuses to modify a profile created by a newer
+// binary, whose layout the migrations here would mangle
+func checkSchemaCompatible(meta *profile.Meta, profileName string) error {
+	if msg := newerSchemaMessage(meta, profileName); msg != "" {
+		return fmt.Errorf("%s; upgrade shell-profiler to modify it", msg)
+	}
+	return nil
+}
+
+// WarnSchemaCompatibility prints a warning when a profile was created by a
+// newer binary. Read-only commands call it and carry on.
+func WarnSchemaCompatibility(profileDir, profileName string) {
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		return
+	}
+	if msg := newerSchemaMessage(meta, profileName); msg != "" {
+		ui.PrintWarning(msg + "; some details may be missing or wrong")
+	}
+}
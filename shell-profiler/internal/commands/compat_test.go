@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+// newFutureProfile creates a profile whose metadata declares a schema
+// version newer than this binary knows about
+func newFutureProfile(t *testing.T, profilesDir, name string) string {
+	t.Helper()
+	profileDir := newV1Profile(t, profilesDir, name)
+	meta := &profile.Meta{SchemaVersion: profileMigrations.Latest() + 1, Name: name, Template: "basic"}
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+	return profileDir
+}
+
+func TestMutatingCommands_RefuseNewerSchema(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	profileDir := newFutureProfile(t, tmpDir, "acme")
+	before, _ := os.ReadFile(profile.MetaPath(profileDir))
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"update", func() error { return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", Force: true}) }},
+		{"tag", func() error { return AddTags(tmpDir, TagOptions{ProfileName: "acme", Tags: []string{"client"}}) }},
+		{"rollback", func() error { return RollbackMigration(tmpDir, "acme", "1") }},
+		{"fix-perms", func() error { return FixProfilePermissions(tmpDir, FixPermissionsOptions{ProfileName: "acme"}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := captureStdout(t, tt.run)
+			if err == nil || !strings.Contains(err.Error(), "newer than this shell-profiler supports") {
+				t.Fatalf("expected a newer-schema refusal, got: %v", err)
+			}
+		})
+	}
+
+	after, _ := os.ReadFile(profile.MetaPath(profileDir))
+	if string(after) != string(before) {
+		t.Errorf("%s was modified:\n%s", profile.MetaFileName, after)
+	}
+}
+
+func TestReadOnlyCommands_WarnOnNewerSchema(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	profileDir := newFutureProfile(t, tmpDir, "acme")
+
+	out, err := captureStdout(t, func() error { return Whoami(tmpDir, profileDir) })
+	if err != nil {
+		t.Fatalf("Whoami() error: %v", err)
+	}
+	if !strings.Contains(out, "WARNING:") || !strings.Contains(out, "newer than this shell-profiler supports") {
+		t.Errorf("expected a newer-schema warning:\n%s", out)
+	}
+	if !strings.Contains(out, "Active profile: acme") {
+		t.Errorf("whoami should still print the profile:\n%s", out)
+	}
+
+	findings, err := DiagnoseProfile(profileDir, "acme", false)
+	if err != nil {
+		t.Fatalf("DiagnoseProfile() error: %v", err)
+	}
+	found := false
+	for _, f := range findings {
+		if f.Check == "schema" && f.Severity == SeverityError {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("doctor should report the newer schema as an error: %+v", findings)
+	}
+}
+
+func TestWarnSchemaCompatibility_CurrentSchemaIsQuiet(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+
+	out, _ := captureStdout(t, func() error {
+		WarnSchemaCompatibility(profileDir, "acme")
+		return nil
+	})
+	if out != "" {
+		t.Errorf("unexpected output for a supported schema: %q", out)
+	}
+}
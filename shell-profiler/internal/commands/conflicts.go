@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// Kinds of identity value checked for conflicts
+const (
+	ConflictGitEmail    = "git email"
+	ConflictSSHIdentity = "ssh identity"
+	ConflictVault       = "vault"
+)
+
+// Conflict is an identity value shared by more than one profile, which
+// defeats the isolation profiles are meant to provide
+type Conflict struct {
+	Kind     string
+	Value    string
+	Profiles []string // Sorted
+}
+
+// conflictPlaceholders are values profiles share without choosing to: the
+// template's placeholder email and OpenSSH's "IdentityFile none"
+var conflictPlaceholders = map[string][]string{
+	ConflictGitEmail:    {templates.DefaultGitEmail},
+	ConflictSSHIdentity: {"none"},
+}
+
+// DetectConflicts scans every profile for git emails, SSH IdentityFiles and
+// secret vaults used by more than one of them. Emails and vault names are
+// compared case-insensitively; empty and placeholder values are ignored.
+func DetectConflicts(profilesDir string) ([]Conflict, error) {
+	names, err := profileNames(profilesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// kind -> normalized value -> profiles using it
+	seen := map[string]map[string][]string{}
+	display := map[string]string{}
+	record := func(kind, value, name string) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return
+		}
+		key := value
+		if kind != ConflictSSHIdentity {
+			key = strings.ToLower(value)
+		}
+		for _, placeholder := range conflictPlaceholders[kind] {
+			if key == strings.ToLower(placeholder) {
+				return
+			}
+		}
+		if seen[kind] == nil {
+			seen[kind] = map[string][]string{}
+		}
+		for _, existing := range seen[kind][key] {
+			if existing == name {
+				return
+			}
+		}
+		seen[kind][key] = append(seen[kind][key], name)
+		if _, ok := display[kind+"\x00"+key]; !ok {
+			display[kind+"\x00"+key] = value
+		}
+	}
+
+	for _, name := range names {
+		id := profile.LoadIdentity(name, filepath.Join(profilesDir, name))
+		record(ConflictGitEmail, id.GitEmail, name)
+		for _, file := range id.SSHIdentityFiles {
+			record(ConflictSSHIdentity, file, name)
+		}
+		record(ConflictVault, id.SecretVault, name)
+	}
+
+	var conflicts []Conflict
+	for _, kind := range []string{ConflictGitEmail, ConflictSSHIdentity, ConflictVault} {
+		keys := make([]string, 0, len(seen[kind]))
+		for key := range seen[kind] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			profiles := seen[kind][key]
+			if len(profiles) < 2 {
+				continue
+			}
+			sort.Strings(profiles)
+			conflicts = append(conflicts, Conflict{Kind: kind, Value: display[kind+"\x00"+key], Profiles: profiles})
+		}
+	}
+	return conflicts, nil
+}
+
+// conflictFindings reports the conflicts involving one profile as doctor findings
+func conflictFindings(conflicts []Conflict, profileName string) []Finding {
+	var findings []Finding
+	for _, c := range conflicts {
+		var others []string
+		involved := false
+		for _, name := range c.Profiles {
+			if name == profileName {
+				involved = true
+			} else {
+				others = append(others, name)
+			}
+		}
+		if involved {
+			findings = append(findings, Finding{
+				Check:    "conflicts",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s %s is also used by: %s", c.Kind, c.Value, strings.Join(others, ", ")),
+			})
+		}
+	}
+	return findings
+}
+
+// ShowConflicts prints identity values shared between profiles and fails if
+// there are any
+func ShowConflicts(profilesDir string) error {
+	conflicts, err := DetectConflicts(profilesDir)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) == 0 {
+		ui.PrintSuccess("No identity conflicts between profiles")
+		return nil
+	}
+
+	for _, c := range conflicts {
+		fmt.Printf("  %s%s %s%s %s: %s\n", ui.ColorRed, ui.SymbolErr, c.Kind, ui.ColorReset, c.Value, strings.Join(c.Profiles, ", "))
+	}
+	return fmt.Errorf("found %d identity conflict(s) between profiles", len(conflicts))
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setIdentityFile points a profile's SSH config at a key
+func setIdentityFile(t *testing.T, profileDir, key string) {
+	t.Helper()
+	config := "Host github.com\n    IdentityFile " + key + "\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".ssh", "config"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectConflicts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	for name, email := range map[string]string{
+		"acme":     "jane@work.example",
+		"globex":   "Jane@Work.example",
+		"personal": "jane@home.example",
+	} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "work", GitName: "Jane", GitEmail: email}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+	}
+	sharedKey := filepath.Join(tmpDir, "shared", "id_ed25519")
+	setIdentityFile(t, filepath.Join(tmpDir, "acme"), sharedKey)
+	setIdentityFile(t, filepath.Join(tmpDir, "personal"), sharedKey)
+	setIdentityFile(t, filepath.Join(tmpDir, "globex"), "$WORKSPACE_HOME/.ssh/id_ed25519")
+
+	conflicts, err := DetectConflicts(tmpDir)
+	if err != nil {
+		t.Fatalf("DetectConflicts() error: %v", err)
+	}
+
+	want := []Conflict{
+		{Kind: ConflictGitEmail, Value: "jane@work.example", Profiles: []string{"acme", "globex"}},
+		{Kind: ConflictSSHIdentity, Value: sharedKey, Profiles: []string{"acme", "personal"}},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("DetectConflicts() = %+v, want %+v", conflicts, want)
+	}
+
+	// Doctor reports the conflicts on each profile involved
+	out, _ := captureStdout(t, func() error {
+		return Doctor(tmpDir, DoctorOptions{ProfileName: "globex"})
+	})
+	if !strings.Contains(out, "git email jane@work.example is also used by: acme") {
+		t.Errorf("doctor should report the shared email:\n%s", out)
+	}
+}
+
+func TestShowConflicts_NoneFound(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	for _, name := range []string{"acme", "globex"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "work", GitEmail: name + "@example.com"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+	}
+
+	out, err := captureStdout(t, func() error { return ShowConflicts(tmpDir) })
+	if err != nil {
+		t.Fatalf("ShowConflicts() error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "No identity conflicts") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestDetectConflicts_IgnoresTemplateDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	for _, name := range []string{"acme", "globex"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+		setIdentityFile(t, filepath.Join(tmpDir, name), "none")
+	}
+
+	conflicts, err := DetectConflicts(tmpDir)
+	if err != nil {
+		t.Fatalf("DetectConflicts() error: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("profiles with the template defaults conflict: %+v", conflicts)
+	}
+}
@@ -5,10 +5,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"time"
+	"strings"
 
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
 	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
+	"github.com/neverprepared/shell-profile-manager/internal/util"
 )
 
 type CreateOptions struct {
@@ -21,35 +28,249 @@ type CreateOptions struct {
 	DryRun      bool
 	InitGit     bool
 	GitRemote   string
+	TemplateDir string // Searched for templates before the user and embedded templates
+	Refresh     bool   // Re-fetch a git+ template source even if the cache is fresh
+
+	PostCreateHook   string   // Script run after the profile is created
+	Allow            bool     // Run `direnv allow` once the profile is created
+	EncryptCache     bool     // Keep the resolved secrets cache encrypted at rest
+	NoWatch          bool     // Leave watch_file .env out of the .envrc
+	PathAdd          []string // Extra PATH directories, absolute or relative to the profile
+	SharedSSHKey     string   // Existing private key referenced by .ssh/config instead of a per-profile key
+	Edit             bool     // Open the generated .gitconfig and .ssh/config in $EDITOR afterwards
+	Gitattributes    bool     // Write a .gitattributes keeping shell scripts LF-terminated
+	CopyFromHome     []string // Tools whose config files are copied from $HOME
+	IncludeCreds     bool     // Also copy those tools' credential files
+	GitIncludes      []string // Shared git configs included by .gitconfig
+	DefaultBranch    string   // init.defaultBranch; templates.DefaultBranchName if empty
+	NoCloud          bool     // Leave out the cloud tools' directories, variables and ignores
+	NoReadme         bool     // Don't write README.md (see RegenerateReadme)
+	OpAccount        string   // 1Password account the .envrc and agent.toml use; op's default if empty
+	OpFieldPrefix    string   // Only export 1Password fields whose label has this prefix
+	OpExcludeFields  []string // 1Password field labels never exported
+	IncludeTOTP      bool     // Export items' current one-time passwords as <TITLE>_TOTP
+	Vaults           []string // 1Password vaults secrets come from, later ones winning; workspace-<name> if empty
+	ExtraDirs        []string // More directories to create, as PATH[:MODE]
+	RelativeSSHPaths bool     // Write .ssh/config paths as ${WORKSPACE_HOME} instead of absolute
+	FromProfile      string   // Existing profile whose template and settings fill in unset options
+
+	Clock clock.Clock // Time source for creation dates; the system clock if nil
+
+	// templateSpec is the original git+ template spec and remoteDir its
+	// cached checkout, set when Template names a remote template
+	templateSpec string
+	remoteDir    string
+
+	// overrides are the managed .env values inherited from FromProfile
+	overrides map[string]string
 }
 
-func CreateProfile(profilesDir string, opts CreateOptions) error {
-	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+// templateSource returns the template search path for this profile
+func (o CreateOptions) templateSource() *templates.Source {
+	if o.remoteDir != "" {
+		return templates.NewSource(o.remoteDir, o.TemplateDir, templates.UserTemplateDir()).WithClock(o.Clock)
+	}
+	return templates.DefaultSource(o.TemplateDir).WithClock(o.Clock)
+}
 
-	// Validate profile name
-	if opts.ProfileName == "" {
-		return fmt.Errorf("profile name is required")
+// inheritProfileSettings fills the options left unset from the profile
+// named by opts.FromProfile: its template, the tools it was created with
+// and its git identity. Nothing is copied; the files are rendered afresh.
+func inheritProfileSettings(profilesDir string, opts *CreateOptions) error {
+	if opts.FromProfile == opts.ProfileName {
+		return fmt.Errorf("cannot create profile '%s' from itself", opts.ProfileName)
+	}
+	sourceDir := filepath.Join(profilesDir, opts.FromProfile)
+	if !isProfileDir(sourceDir) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.FromProfile, sourceDir)
+	}
+	meta, err := profile.LoadMeta(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to read settings of profile '%s': %w", opts.FromProfile, err)
+	}
+
+	if opts.Template == "" {
+		opts.Template = meta.Template
+		if meta.TemplateURL != "" {
+			opts.Template = meta.TemplateURL
+		}
+	}
+	opts.NoCloud = opts.NoCloud || meta.NoCloud
+	opts.RelativeSSHPaths = opts.RelativeSSHPaths || meta.RelativeSSHPaths
+	opts.IncludeTOTP = opts.IncludeTOTP || meta.IncludeTOTP
+	opts.NoReadme = opts.NoReadme || meta.NoReadme
+	opts.overrides = meta.Overrides
+	if len(opts.ExtraDirs) == 0 {
+		opts.ExtraDirs = meta.ExtraDirs
 	}
+	if opts.OpAccount == "" {
+		opts.OpAccount = meta.OpAccount
+	}
+	if opts.OpFieldPrefix == "" {
+		opts.OpFieldPrefix = meta.OpFieldPrefix
+	}
+	if len(opts.OpExcludeFields) == 0 {
+		opts.OpExcludeFields = meta.OpExcludeFields
+	}
+	if len(opts.Vaults) == 0 {
+		opts.Vaults = meta.Vaults
+	}
+
+	gitConfig := filepath.Join(sourceDir, ".gitconfig")
+	name, email := profile.GitIdentity(gitConfig)
+	if opts.GitName == "" {
+		opts.GitName = name
+	}
+	if opts.GitEmail == "" {
+		opts.GitEmail = email
+	}
+	if opts.DefaultBranch == "" {
+		opts.DefaultBranch = profile.GitConfigValue(gitConfig, "init.defaultBranch")
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Using the settings of profile '%s' (template: %s)", opts.FromProfile, opts.Template))
+	return nil
+}
 
-	matched, err := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, opts.ProfileName)
+// resolveRemoteTemplate fetches a git+<url>#<name> template into the cache
+// and points opts at the named template inside it
+func resolveRemoteTemplate(opts *CreateOptions) error {
+	url, name, err := templates.ParseRemote(opts.Template)
+	if err != nil {
+		return err
+	}
+
+	spinner := ui.NewSpinner()
+	spinner.Start(fmt.Sprintf("Fetching templates from %s", url))
+	fetched, err := templates.FetchRemote(url, templates.RemoteOptions{Refresh: opts.Refresh})
+	spinner.Stop()
+	if err != nil {
+		return err
+	}
+	if fetched.FetchErr != nil {
+		ui.PrintWarning(fmt.Sprintf("Using cached templates: %v", fetched.FetchErr))
+	}
+
+	opts.templateSpec = opts.Template
+	opts.Template = name
+	opts.remoteDir = fetched.Dir
+	return nil
+}
+
+// validateProfileName checks the name of a new profile
+func validateProfileName(name string) error {
+	if name == "" {
+		return errs.Wrapf(errs.ErrInvalidName, "profile name is required")
+	}
+
+	matched, err := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, name)
 	if err != nil {
 		return fmt.Errorf("failed to validate profile name: %w", err)
 	}
 	if !matched {
-		return fmt.Errorf("profile name can only contain letters, numbers, hyphens, and underscores")
+		return errs.Wrapf(errs.ErrInvalidName, "profile name can only contain letters, numbers, hyphens, and underscores")
+	}
+	if name == archivedDirName {
+		return errs.Wrapf(errs.ErrInvalidName, "profile name '%s' is reserved for archived profiles", archivedDirName)
+	}
+	return nil
+}
+
+func CreateProfile(profilesDir string, opts CreateOptions) error {
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+
+	// Validate profile name
+	if err := validateProfileName(opts.ProfileName); err != nil {
+		return err
+	}
+
+	if opts.FromProfile != "" {
+		if err := inheritProfileSettings(profilesDir, &opts); err != nil {
+			return err
+		}
 	}
 
 	// Validate template
-	validTemplates := map[string]bool{
-		"basic": true, "personal": true, "work": true, "client": true,
+	if templates.IsRemote(opts.Template) {
+		if err := resolveRemoteTemplate(&opts); err != nil {
+			return err
+		}
+	}
+	if opts.TemplateDir != "" {
+		if info, err := os.Stat(opts.TemplateDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("template directory does not exist: %s", opts.TemplateDir)
+		}
+	}
+	source := opts.templateSource()
+	if !source.Exists(opts.Template) {
+		return errs.Wrapf(errs.ErrInvalidTemplate, "invalid template: %s (must be one of: %s)", opts.Template, strings.Join(source.Names(), ", "))
+	}
+
+	for i, dir := range opts.PathAdd {
+		if err := validatePathDir(dir); err != nil {
+			return err
+		}
+		opts.PathAdd[i] = filepath.Clean(dir)
+	}
+
+	homeFiles, err := homeFilesFor(opts.CopyFromHome, opts.IncludeCreds)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range opts.GitIncludes {
+		if err := validateGitInclude(path); err != nil {
+			return err
+		}
+	}
+	if opts.DefaultBranch != "" {
+		if err := validateBranchName(opts.DefaultBranch); err != nil {
+			return err
+		}
+	}
+
+	for _, spec := range opts.ExtraDirs {
+		if _, err := parseExtraDir(spec); err != nil {
+			return err
+		}
+	}
+	if opts.OpAccount != "" && !opAccountPattern.MatchString(opts.OpAccount) {
+		return fmt.Errorf("invalid 1Password account %q: use its sign-in address, email or ID", opts.OpAccount)
 	}
-	if !validTemplates[opts.Template] {
-		return fmt.Errorf("invalid template: %s (must be: basic, personal, work, or client)", opts.Template)
+	for _, vault := range opts.Vaults {
+		if strings.TrimSpace(vault) == "" || strings.ContainsAny(vault, "\n\r") {
+			return fmt.Errorf("invalid 1Password vault name %q", vault)
+		}
 	}
 
-	// Check if profile exists
-	if _, err := os.Stat(profileDir); err == nil && !opts.Force {
-		return fmt.Errorf("profile '%s' already exists at: %s (use --force to overwrite)", opts.ProfileName, profileDir)
+	if opts.SharedSSHKey != "" {
+		key, err := resolveSharedSSHKey(opts.SharedSSHKey)
+		if err != nil {
+			return err
+		}
+		opts.SharedSSHKey = key
+	}
+
+	// A dry run creates nothing, not even the profiles directory
+	if opts.DryRun {
+		if _, err := os.Lstat(profilesDir); !os.IsNotExist(err) {
+			if err := checkProfilesDir(profilesDir, false); err != nil {
+				return err
+			}
+		}
+	} else if err := createProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	// Check if profile exists. --force only overwrites an active profile, an
+	// archived one would collide when restored.
+	if taken, location := profileNameTaken(profilesDir, opts.ProfileName); taken {
+		if location != profileDir {
+			return nameTakenError(profilesDir, opts.ProfileName, location)
+		}
+		if !opts.Force {
+			return errs.Wrapf(errs.ErrProfileExists, "profile '%s' already exists at: %s (use --force to overwrite)", opts.ProfileName, profileDir)
+		}
 	}
 
 	// Interactive mode
@@ -73,32 +294,80 @@ func CreateProfile(profilesDir string, opts CreateOptions) error {
 		if opts.GitEmail != "" {
 			fmt.Printf("  Git user.email: %s\n", opts.GitEmail)
 		}
+		if opts.SharedSSHKey != "" {
+			fmt.Printf("  SSH IdentityFile (shared): %s\n", opts.SharedSSHKey)
+		}
+		for _, path := range opts.GitIncludes {
+			fmt.Printf("  Git include: %s\n", path)
+		}
+		for _, spec := range opts.ExtraDirs {
+			fmt.Printf("  Extra directory: %s\n", spec)
+		}
+		if opts.PostCreateHook != "" {
+			fmt.Printf("  Would run post-create hook: %s\n", opts.PostCreateHook)
+		}
+		if opts.NoCloud {
+			fmt.Println("  Without cloud tools (AWS, Azure, Google Cloud, Kubernetes, Terraform)")
+		}
+		if opts.OpAccount != "" {
+			fmt.Printf("  1Password account: %s\n", opts.OpAccount)
+		}
+		if opts.OpFieldPrefix != "" {
+			fmt.Printf("  Only 1Password fields labeled %s...\n", opts.OpFieldPrefix)
+		}
+		if len(opts.OpExcludeFields) > 0 {
+			fmt.Printf("  Excluded 1Password fields: %s\n", strings.Join(opts.OpExcludeFields, ", "))
+		}
+		if len(opts.Vaults) > 0 {
+			fmt.Printf("  1Password vaults: %s\n", strings.Join(opts.Vaults, ", "))
+		}
+		if opts.IncludeTOTP {
+			fmt.Println("  Export 1Password one-time passwords as <TITLE>_TOTP")
+		}
+		if opts.NoReadme {
+			fmt.Println("  Without README.md")
+		}
+		if opts.Allow {
+			fmt.Println("  Would run: direnv allow")
+		}
+		if len(homeFiles) > 0 {
+			fmt.Printf("  Would copy from $HOME (if present):")
+			for _, file := range homeFiles {
+				fmt.Printf(" %s", file.Home)
+			}
+			fmt.Println()
+		}
+		if opts.Edit {
+			fmt.Printf("  Would open in editor: %s\n", strings.Join(editedFiles, ", "))
+		}
 		return nil
 	}
 
 	// Create profile
 	ui.PrintInfo(fmt.Sprintf("Creating profile: %s (template: %s)", opts.ProfileName, opts.Template))
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", profileDir, err)
+	}
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
 
 	// Create directories
-	dirs := []string{
-		".config/1Password",
-		".config/claude",
-		".config/gemini",
-		".ssh",
-		".aws",
-		".azure",
-		".gcloud",
-		".kube",
-		"bin",
-		"code",
+	dirs := tools.Dirs()
+	if opts.NoCloud {
+		dirs = tools.LocalDirs()
 	}
-
 	for _, dir := range dirs {
 		fullPath := filepath.Join(profileDir, dir)
 		if err := os.MkdirAll(fullPath, 0755); err != nil {
 			return fmt.Errorf("failed to create directory %s: %w", fullPath, err)
 		}
 	}
+	if _, err := createExtraDirs(profileDir, opts.ExtraDirs, false); err != nil {
+		return err
+	}
 
 	// Set SSH directory permissions
 	sshDir := filepath.Join(profileDir, ".ssh")
@@ -120,6 +389,9 @@ func CreateProfile(profilesDir string, opts CreateOptions) error {
 	if err := createGitconfig(profileDir, opts); err != nil {
 		return fmt.Errorf("failed to create .gitconfig: %w", err)
 	}
+	if _, err := validateGitconfig(filepath.Join(profileDir, ".gitconfig")); err != nil {
+		ui.PrintWarning(fmt.Sprintf("git cannot read the generated .gitconfig: %v", err))
+	}
 
 	// Create SSH config (only if it doesn't exist)
 	if err := createSSHConfig(profileDir, opts); err != nil {
@@ -145,20 +417,41 @@ func CreateProfile(profilesDir string, opts CreateOptions) error {
 	}
 
 	// Create .gitignore
-	if err := createGitignore(profileDir); err != nil {
+	if err := createGitignore(profileDir, opts.NoCloud); err != nil {
 		return fmt.Errorf("failed to create .gitignore: %w", err)
 	}
 
+	if opts.Gitattributes {
+		if err := createGitattributes(profileDir); err != nil {
+			return fmt.Errorf("failed to create .gitattributes: %w", err)
+		}
+	}
+
+	if len(homeFiles) > 0 {
+		copied, err := copyFromHome(profileDir, homeFiles)
+		if err != nil {
+			return fmt.Errorf("failed to copy configs from $HOME: %w", err)
+		}
+		printCopied(copied)
+	}
+
 	// Create README
-	if err := createREADME(profileDir, opts); err != nil {
-		return fmt.Errorf("failed to create README: %w", err)
+	if !opts.NoReadme {
+		if err := createREADME(profileDir, opts); err != nil {
+			return fmt.Errorf("failed to create README: %w", err)
+		}
 	}
 
 	// Create .env.example
-	if err := createEnvExample(profileDir); err != nil {
+	if err := createEnvExample(profileDir, opts.NoCloud); err != nil {
 		return fmt.Errorf("failed to create .env.example: %w", err)
 	}
 
+	// Create .profile-meta
+	if err := createMeta(profileDir, opts); err != nil {
+		return fmt.Errorf("failed to create %s: %w", profile.MetaFileName, err)
+	}
+
 	// Initialize git if requested
 	if opts.InitGit {
 		gitOpts := GitOptions{
@@ -170,19 +463,47 @@ func CreateProfile(profilesDir string, opts CreateOptions) error {
 		}
 	}
 
+	// Run the post-create hook once everything is in place
+	if opts.PostCreateHook != "" {
+		if err := runHook(opts.PostCreateHook, hooks.PostCreate, opts.ProfileName, profileDir); err != nil {
+			return fmt.Errorf("profile '%s' was created but the post-create hook failed: %w", opts.ProfileName, err)
+		}
+	}
+
+	if opts.Edit {
+		if err := EditFiles(profileDir, editedFiles, ""); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Failed to open editor: %v", err))
+		}
+	}
+
+	allowed := false
+	if opts.Allow {
+		allowed = allowProfile(profileDir)
+	}
+
 	ui.PrintSuccess(fmt.Sprintf("Profile created successfully: %s", opts.ProfileName))
 	fmt.Println()
 	ui.PrintInfo("Next steps:")
-	fmt.Printf("  1. cd %s\n", profileDir)
-	fmt.Println("  2. direnv allow")
-	fmt.Println("  3. Edit .gitconfig as needed")
-	fmt.Printf("  4. echo $WORKSPACE_PROFILE to verify\n")
+	steps := []string{"cd " + profileDir}
+	if !allowed {
+		steps = append(steps, "direnv allow")
+	}
+	if !opts.Edit {
+		steps = append(steps, "Edit .gitconfig as needed")
+	}
+	steps = append(steps, "echo $WORKSPACE_PROFILE to verify")
+	for i, step := range steps {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
 	fmt.Println()
 	ui.PrintInfo(fmt.Sprintf("Profile location: %s", profileDir))
 
 	return nil
 }
 
+// editedFiles are opened by create --edit, in order
+var editedFiles = []string{".gitconfig", ".ssh/config"}
+
 func interactiveSetup(opts *CreateOptions) error {
 	// Template selection
 	template, err := ui.SelectTemplate()
@@ -225,13 +546,31 @@ func interactiveSetup(opts *CreateOptions) error {
 		}
 	}
 
+	if !opts.Allow {
+		allow, err := ui.Confirm("Run 'direnv allow' after creation?", true)
+		if err != nil {
+			return fmt.Errorf("failed to get direnv preference: %w", err)
+		}
+		opts.Allow = allow
+	}
+
 	return nil
 }
 
 func createEnvrc(profileDir string, opts CreateOptions) error {
 	ui.PrintInfo("Creating .envrc...")
 
-	envrcContent, err := templates.RenderEnvrc(opts.ProfileName, opts.Template)
+	envrcContent, err := opts.templateSource().RenderEnvrcWith(opts.ProfileName, opts.Template, templates.EnvrcOptions{
+		EncryptCache:    opts.EncryptCache,
+		PathAdd:         opts.PathAdd,
+		Watch:           !opts.NoWatch,
+		LocatedHome:     true,
+		OpAccount:       opts.OpAccount,
+		OpFieldPrefix:   opts.OpFieldPrefix,
+		OpExcludeFields: opts.OpExcludeFields,
+		IncludeTOTP:     opts.IncludeTOTP,
+		Vaults:          opts.Vaults,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to render .envrc template: %w", err)
 	}
@@ -243,19 +582,39 @@ func createEnvrc(profileDir string, opts CreateOptions) error {
 func createEnvFile(profileDir string, opts CreateOptions) error {
 	ui.PrintInfo("Creating .env...")
 
-	envContent, err := templates.RenderEnv(opts.ProfileName, opts.Template)
+	envContent, err := opts.templateSource().RenderEnv(opts.ProfileName, opts.Template)
 	if err != nil {
 		return fmt.Errorf("failed to render .env template: %w", err)
 	}
+	if opts.NoCloud {
+		envContent = stripCloudEnv(envContent)
+	}
+	envContent = applyEnvOverrides(envContent, opts.overrides)
 
 	envPath := filepath.Join(profileDir, ".env")
-	return os.WriteFile(envPath, []byte(envContent), 0644)
+	return writeEnvFile(envPath, []byte(envContent))
+}
+
+// envFileMode is the mode of .env. The .envrc appends resolved secrets to a
+// copy of it, so it is kept private like the secrets themselves.
+const envFileMode os.FileMode = 0600
+
+// writeEnvFile writes .env with envFileMode, tightening an existing file
+// (WriteFile keeps the mode of a file it overwrites)
+func writeEnvFile(path string, content []byte) error {
+	if err := os.WriteFile(path, content, envFileMode); err != nil {
+		return err
+	}
+	return os.Chmod(path, envFileMode)
 }
 
 func createGitconfig(profileDir string, opts CreateOptions) error {
 	ui.PrintInfo("Creating .gitconfig...")
 
-	gitconfigContent, err := templates.RenderGitconfig(opts.ProfileName, opts.Template, opts.GitName, opts.GitEmail)
+	gitconfigContent, err := opts.templateSource().RenderGitconfigWith(opts.ProfileName, opts.Template, opts.GitName, opts.GitEmail, templates.GitconfigOptions{
+		Includes:      opts.GitIncludes,
+		DefaultBranch: opts.DefaultBranch,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to render .gitconfig template: %w", err)
 	}
@@ -264,6 +623,36 @@ func createGitconfig(profileDir string, opts CreateOptions) error {
 	return os.WriteFile(gitconfigPath, []byte(gitconfigContent), 0644)
 }
 
+// validateGitInclude checks that a --git-include path is absolute or starts
+// with ~/ (which git expands), since a relative include path would be
+// resolved against the profile's .gitconfig
+func validateGitInclude(path string) error {
+	if strings.ContainsAny(path, "\"\n") {
+		return fmt.Errorf("invalid git include path %q: quotes and newlines are not allowed", path)
+	}
+	if !filepath.IsAbs(path) && !strings.HasPrefix(path, "~/") {
+		return fmt.Errorf("git include path must be absolute or start with ~/: %s", path)
+	}
+	return nil
+}
+
+// resolveSharedSSHKey expands a --shared-ssh-key path to an absolute path
+// and checks that it is an existing file
+func resolveSharedSSHKey(path string) (string, error) {
+	key, err := filepath.Abs(config.ExpandPath(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	info, err := os.Stat(key)
+	if err != nil {
+		return "", fmt.Errorf("shared SSH key not found: %s", key)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("shared SSH key is not a file: %s", key)
+	}
+	return key, nil
+}
+
 func createSSHConfig(profileDir string, opts CreateOptions) error {
 	sshConfigPath := filepath.Join(profileDir, ".ssh/config")
 
@@ -279,21 +668,33 @@ func createSSHConfig(profileDir string, opts CreateOptions) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
+	pathsNote, sshHome := sshAbsolutePathsNote, profileAbsPath
+	if opts.RelativeSSHPaths {
+		pathsNote, sshHome = sshRelativePathsNote, sshWorkspaceHome
+	}
+
+	// Quoted, since the profile directory may contain spaces
+	sshPath := func(rel string) string { return util.SSHQuote(sshHome + "/" + rel) }
+
+	// A shared key is referenced where it is, never copied into the profile
+	identity := ""
+	if opts.SharedSSHKey != "" {
+		identity = fmt.Sprintf("\n    # Shared key used by every host (--shared-ssh-key)\n    IdentityFile %s\n", util.SSHQuote(opts.SharedSSHKey))
+	}
+
 	sshConfigContent := fmt.Sprintf(`# SSH configuration for workspace profile: %s
 # This config is used instead of ~/.ssh/config when this profile is active
 #
-# Note: SSH config files don't support environment variable expansion.
-# All paths are absolute paths to ensure they work regardless of current directory.
-
+%s
 # Default settings for all hosts
 Host *
     # Use workspace-specific known_hosts file
-    UserKnownHostsFile %s/.ssh/known_hosts
+    UserKnownHostsFile %s
 
     # Security settings
     AddKeysToAgent yes
     IdentitiesOnly yes
-
+%s
     # 1Password SSH Agent (commented out by default)
     # IdentityAgent "~/Library/Group Containers/2BUA8C4S2C.com.1password/t/agent.sock"
 
@@ -308,14 +709,14 @@ Host *
 # Host github.com
 #     HostName github.com
 #     User git
-#     IdentityFile %s/.ssh/id_ed25519_github
+#     IdentityFile %s
 #     IdentitiesOnly yes
 
 # Example: GitLab with profile-specific key
 # Host gitlab.com
 #     HostName gitlab.com
 #     User git
-#     IdentityFile %s/.ssh/id_ed25519_gitlab
+#     IdentityFile %s
 #     IdentitiesOnly yes
 
 # Example: Personal server
@@ -323,20 +724,22 @@ Host *
 #     HostName example.com
 #     User myuser
 #     Port 22
-#     IdentityFile %s/.ssh/id_ed25519_server
+#     IdentityFile %s
 
 # Example: Jump host (bastion)
 # Host bastion
 #     HostName bastion.example.com
 #     User admin
-#     IdentityFile %s/.ssh/id_ed25519_bastion
+#     IdentityFile %s
 #
 # Host internal-server
 #     HostName internal.example.com
 #     User admin
 #     ProxyJump bastion
-#     IdentityFile %s/.ssh/id_ed25519_internal
-`, opts.ProfileName, profileAbsPath, profileAbsPath, profileAbsPath, profileAbsPath, profileAbsPath, profileAbsPath)
+#     IdentityFile %s
+`, opts.ProfileName, pathsNote, sshPath(".ssh/known_hosts"), identity,
+		sshPath(".ssh/id_ed25519_github"), sshPath(".ssh/id_ed25519_gitlab"), sshPath(".ssh/id_ed25519_server"),
+		sshPath(".ssh/id_ed25519_bastion"), sshPath(".ssh/id_ed25519_internal"))
 
 	if err := os.WriteFile(sshConfigPath, []byte(sshConfigContent), 0600); err != nil {
 		return err
@@ -346,9 +749,23 @@ Host *
 	return nil
 }
 
+// opAccountPattern matches the forms op --account accepts: a sign-in
+// address, an email or an account ID
+var opAccountPattern = regexp.MustCompile(`^[A-Za-z0-9._@+-]+$`)
+
 func create1PasswordConfig(profileDir string, opts CreateOptions) error {
 	ui.PrintInfo("Creating 1Password agent configuration...")
 
+	account := "my.1password.com"
+	cliSection := `# [cli]
+# Uncomment to configure CLI authentication
+# account = "my.1password.com"`
+	if opts.OpAccount != "" {
+		account = opts.OpAccount
+		cliSection = fmt.Sprintf(`[cli]
+account = %q`, opts.OpAccount)
+	}
+
 	configContent := fmt.Sprintf(`# 1Password SSH Agent configuration for workspace profile: %s
 # This config is used when this profile is active
 
@@ -357,7 +774,7 @@ func create1PasswordConfig(profileDir string, opts CreateOptions) error {
 # Example: Add your SSH keys from 1Password
 # vault = "Private"
 # item = "GitHub SSH Key"
-# account = "my.1password.com"
+# account = "%s"
 
 # Multiple keys can be configured
 # [[ssh-keys]]
@@ -365,16 +782,14 @@ func create1PasswordConfig(profileDir string, opts CreateOptions) error {
 # item = "Work GitHub Key"
 
 # CLI configuration
-# [cli]
-# Uncomment to configure CLI authentication
-# account = "my.1password.com"
+%s
 
 # Notes:
 # - SSH keys stored in 1Password can be used for Git operations
 # - The SSH agent will automatically load keys when profile is active
 # - Use 'op item list' to find vault and item names
 # - See: https://developer.1password.com/docs/ssh/agent/
-`, opts.ProfileName)
+`, opts.ProfileName, account, cliSection)
 
 	configPath := filepath.Join(profileDir, ".config/1Password/agent.toml")
 	return os.WriteFile(configPath, []byte(configContent), 0600)
@@ -389,7 +804,8 @@ func createSSHWrapper(profileDir string) error {
 
 # Get the directory where this script is located
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
-WORKSPACE_HOME="$(dirname "$SCRIPT_DIR")"
+# Exported for the ${WORKSPACE_HOME} paths of .ssh/config
+export WORKSPACE_HOME="$(dirname "$SCRIPT_DIR")"
 
 # Use workspace-specific SSH config
 exec /usr/bin/ssh -F "$WORKSPACE_HOME/.ssh/config" "$@"
@@ -403,15 +819,16 @@ exec /usr/bin/ssh -F "$WORKSPACE_HOME/.ssh/config" "$@"
 	return nil
 }
 
-func createGitignore(profileDir string) error {
-	ui.PrintInfo("Creating .gitignore...")
-
-	gitignoreContent := `# Workspace profile gitignore
+// profileGitignore is the .gitignore written into new profiles
+const profileGitignore = `# Workspace profile gitignore
 
 # Environment files with secrets
 .env
 .envrc.local
 
+# Profile manager lock file
+.lock
+
 # SSH keys and sensitive files
 .ssh/id_*
 .ssh/*.pem
@@ -479,14 +896,27 @@ build/
 *.log
 `
 
+func createGitignore(profileDir string, noCloud bool) error {
+	ui.PrintInfo("Creating .gitignore...")
+
+	content := profileGitignore
+	if noCloud {
+		content = stripCloudGitignore(content)
+	}
 	gitignorePath := filepath.Join(profileDir, ".gitignore")
-	return os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644)
+	return os.WriteFile(gitignorePath, []byte(content), 0644)
 }
 
 func createREADME(profileDir string, opts CreateOptions) error {
 	ui.PrintInfo("Creating README.md...")
 
-	created := time.Now().UTC().Format("2006-01-02 15:04:05 UTC")
+	created := clock.Or(opts.Clock).Now().UTC().Format(templates.CreatedAtLayout)
+	readmePath := filepath.Join(profileDir, "README.md")
+	return os.WriteFile(readmePath, []byte(renderREADME(profileDir, opts.ProfileName, opts.Template, created)), 0644)
+}
+
+// renderREADME returns the generated README.md of a profile
+func renderREADME(profileDir, profileName, template, created string) string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		homeDir = "" // Fall back to not abbreviating path
@@ -496,8 +926,8 @@ func createREADME(profileDir string, opts CreateOptions) error {
 		displayPath = "~" + profileDir[len(homeDir):]
 	}
 
-	readmeContent := "# Workspace Profile: " + opts.ProfileName + "\n\n" +
-		"Template: " + opts.Template + "\n" +
+	return "# Workspace Profile: " + profileName + "\n\n" +
+		"Template: " + template + "\n" +
 		"Created: " + created + "\n\n" +
 		"## Setup\n\n" +
 		"1. Navigate to this directory:\n" +
@@ -522,7 +952,7 @@ func createREADME(profileDir string, opts CreateOptions) error {
 		"- Add SSH keys to .ssh/ directory\n\n" +
 		"## Environment Variables\n\n" +
 		"### Workspace\n" +
-		"- WORKSPACE_PROFILE: " + opts.ProfileName + "\n" +
+		"- WORKSPACE_PROFILE: " + profileName + "\n" +
 		"- WORKSPACE_HOME: Path to this directory\n" +
 		"- XDG_CONFIG_HOME: Path to profile-specific XDG config directory (.config)\n\n" +
 		"### Git\n" +
@@ -559,7 +989,7 @@ func createREADME(profileDir string, opts CreateOptions) error {
 		"   - Set up jump hosts if needed\n\n" +
 		"3. Add SSH keys (optional):\n" +
 		"   ```bash\n" +
-		"   ssh-keygen -t ed25519 -f .ssh/id_ed25519_" + opts.ProfileName + " -C \"email@example.com\"\n" +
+		"   ssh-keygen -t ed25519 -f .ssh/id_ed25519_" + profileName + " -C \"email@example.com\"\n" +
 		"   ```\n\n" +
 		"4. Configure 1Password SSH Agent in .config/1Password/agent.toml:\n" +
 		"   - Uncomment and configure SSH keys from your 1Password vaults\n" +
@@ -598,52 +1028,41 @@ func createREADME(profileDir string, opts CreateOptions) error {
 		"12. Add project-specific environment variables to .envrc\n\n" +
 		"13. Create .env for secrets (AWS keys, API tokens, Azure credentials, GCP credentials, Claude API keys, Gemini API keys, etc.)\n\n" +
 		"14. Add custom scripts to bin/ directory\n"
+}
 
-	readmePath := filepath.Join(profileDir, "README.md")
-	return os.WriteFile(readmePath, []byte(readmeContent), 0644)
+func createMeta(profileDir string, opts CreateOptions) error {
+	meta := &profile.Meta{
+		SchemaVersion:    profileMigrations.Latest(),
+		Name:             opts.ProfileName,
+		Template:         opts.Template,
+		TemplateURL:      opts.templateSpec,
+		Created:          clock.Or(opts.Clock).Now().UTC().Format(templates.CreatedAtLayout),
+		SecretBackend:    profile.DefaultSecretBackend,
+		NoCloud:          opts.NoCloud,
+		NoReadme:         opts.NoReadme,
+		ExtraDirs:        opts.ExtraDirs,
+		RelativeSSHPaths: opts.RelativeSSHPaths,
+		OpAccount:        opts.OpAccount,
+		OpFieldPrefix:    opts.OpFieldPrefix,
+		OpExcludeFields:  opts.OpExcludeFields,
+		IncludeTOTP:      opts.IncludeTOTP,
+		Vaults:           opts.Vaults,
+		Overrides:        opts.overrides,
+		ManagedHashes:    managedBlockHashes(profileDir),
+	}
+	// Recording the hash lets update --all skip the profile until it changes
+	hash, err := managedContentHash(profileDir)
+	if err != nil {
+		return err
+	}
+	meta.ContentHash = hash
+	return profile.WriteMeta(profileDir, meta)
 }
 
-func createEnvExample(profileDir string) error {
+func createEnvExample(profileDir string, noCloud bool) error {
 	ui.PrintInfo("Creating .env.example...")
 
-	envExampleContent := `# Example environment variables
-# Copy this to .env and fill in your non-secret config
-# Secrets are loaded automatically from 1Password vault (workspace-<profile>)
-
-# AWS credentials
-# AWS_ACCESS_KEY_ID=your-access-key
-# AWS_SECRET_ACCESS_KEY=your-secret-key
-# AWS_DEFAULT_REGION=us-east-1
-
-# Azure credentials (optional - can also use 'az login')
-# AZURE_CLIENT_ID=your-client-id
-# AZURE_CLIENT_SECRET=your-client-secret
-# AZURE_TENANT_ID=your-tenant-id
-# AZURE_SUBSCRIPTION_ID=your-subscription-id
-
-# Google Cloud credentials (optional - can also use 'gcloud auth login')
-# GOOGLE_APPLICATION_CREDENTIALS=/path/to/service-account-key.json
-# GCP_PROJECT=your-project-id
-# GCP_REGION=us-central1
-# GCP_ZONE=us-central1-a
-
-# Claude Code / Anthropic API credentials
-# ANTHROPIC_API_KEY=your-anthropic-api-key
-
-# Gemini CLI / Google AI API credentials
-# GEMINI_API_KEY=your-gemini-api-key
-# GOOGLE_AI_API_KEY=your-google-ai-api-key
-
-# API keys
-# API_KEY=your-api-key
-# API_SECRET=your-api-secret
-
-# Database
-# DATABASE_URL=postgresql://localhost:5432/mydb
-# REDIS_URL=redis://localhost:6379
-`
-
+	envExampleContent := renderEnvExample(noCloud)
 	envExamplePath := filepath.Join(profileDir, ".env.example")
 	return os.WriteFile(envExamplePath, []byte(envExampleContent), 0644)
 }
-
@@ -1,10 +1,19 @@
 package commands
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
 func TestCreateProfile_EmptyName(t *testing.T) {
@@ -16,7 +25,7 @@ func TestCreateProfile_EmptyName(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for empty name")
 	}
-	if !strings.Contains(err.Error(), "profile name is required") {
+	if !errors.Is(err, errs.ErrInvalidName) || !strings.Contains(err.Error(), "profile name is required") {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
@@ -33,7 +42,7 @@ func TestCreateProfile_InvalidChars(t *testing.T) {
 		if err == nil {
 			t.Errorf("expected error for name %q", name)
 		}
-		if err != nil && !strings.Contains(err.Error(), "can only contain") {
+		if err != nil && !errors.Is(err, errs.ErrInvalidName) {
 			t.Errorf("name %q: unexpected error: %v", name, err)
 		}
 	}
@@ -62,7 +71,7 @@ func TestCreateProfile_InvalidTemplate(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for invalid template")
 	}
-	if !strings.Contains(err.Error(), "invalid template") {
+	if !errors.Is(err, errs.ErrInvalidTemplate) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
@@ -96,11 +105,26 @@ func TestCreateProfile_ExistingWithoutForce(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for existing profile without force")
 	}
-	if !strings.Contains(err.Error(), "already exists") {
+	if !errors.Is(err, errs.ErrProfileExists) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
 
+func TestCreateProfile_AssumeYesDoesNotBypassForce(t *testing.T) {
+	ui.SetAssumeYes(true)
+	defer ui.SetAssumeYes(false)
+
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "existing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "existing", Template: "basic"})
+	if !errors.Is(err, errs.ErrProfileExists) {
+		t.Errorf("--yes must not skip the existing-profile check, got: %v", err)
+	}
+}
+
 func TestCreateProfile_ExistingWithForce(t *testing.T) {
 	tmpDir := t.TempDir()
 	profileDir := filepath.Join(tmpDir, "existing")
@@ -172,6 +196,222 @@ func TestCreateProfile_DirectoryStructure(t *testing.T) {
 	}
 }
 
+func TestCreateProfile_NoCloud(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{
+		ProfileName: "minimal",
+		Template:    "basic",
+		NoCloud:     true,
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "minimal")
+
+	for _, dir := range []string{".aws", ".azure", ".gcloud", ".kube"} {
+		if _, err := os.Stat(filepath.Join(profileDir, dir)); err == nil {
+			t.Errorf("directory %q should not exist", dir)
+		}
+	}
+	for _, dir := range []string{".ssh", ".config/claude", "bin", "code"} {
+		if _, err := os.Stat(filepath.Join(profileDir, dir)); err != nil {
+			t.Errorf("directory %q should exist: %v", dir, err)
+		}
+	}
+
+	env, _ := os.ReadFile(filepath.Join(profileDir, ".env"))
+	for _, name := range []string{"AWS_CONFIG_FILE", "KUBECONFIG", "TF_CLI_CONFIG_FILE", "AZURE_CONFIG_DIR", "CLOUDSDK_CONFIG"} {
+		if strings.Contains(string(env), name) {
+			t.Errorf(".env should not set %s:\n%s", name, env)
+		}
+	}
+	for _, want := range []string{"GIT_CONFIG_GLOBAL=", "CLAUDE_CONFIG_DIR=", "# >>> profile-manager managed", "# <<< profile-manager managed"} {
+		if !strings.Contains(string(env), want) {
+			t.Errorf(".env missing %q:\n%s", want, env)
+		}
+	}
+
+	gitignore, _ := os.ReadFile(filepath.Join(profileDir, ".gitignore"))
+	for _, pattern := range []string{".aws/credentials", ".azure/config", ".gcloud/credentials", ".kube/cache", "*.tfstate"} {
+		if strings.Contains(string(gitignore), pattern) {
+			t.Errorf(".gitignore should not contain %s", pattern)
+		}
+	}
+	if !strings.Contains(string(gitignore), ".ssh/id_*") {
+		t.Error(".gitignore should still ignore SSH keys")
+	}
+
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil || !meta.NoCloud {
+		t.Fatalf("meta should record noCloud: %+v, %v", meta, err)
+	}
+
+	// Update must not add the cloud pieces back
+	created, err := updateDirectories(profileDir, false, nil)
+	if err != nil || len(created) > 0 {
+		t.Errorf("updateDirectories() = %v, %v; want nothing created", created, err)
+	}
+	if _, err := updateGitignore(profileDir, false, false); err != nil {
+		t.Fatalf("updateGitignore() error: %v", err)
+	}
+	gitignore, _ = os.ReadFile(filepath.Join(profileDir, ".gitignore"))
+	if strings.Contains(string(gitignore), ".azure/config") || strings.Contains(string(gitignore), ".gcloud") {
+		t.Errorf("update added cloud patterns:\n%s", gitignore)
+	}
+}
+
+func TestCreateProfile_FromProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{
+		ProfileName:   "acme",
+		Template:      "work",
+		GitName:       "Ada Acme",
+		GitEmail:      "ada@acme.example",
+		DefaultBranch: "trunk",
+		NoCloud:       true,
+		ExtraDirs:     []string{".config/acme:0700"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile(acme) error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "acme", "code", "notes.txt"), []byte("acme data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = CreateProfile(tmpDir, CreateOptions{
+		ProfileName: "acme-staging",
+		FromProfile: "acme",
+		GitEmail:    "ops@acme.example",
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile(--from-profile) error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme-staging")
+
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "acme-staging" || meta.Template != "work" || !meta.NoCloud {
+		t.Errorf("meta = %+v, want name acme-staging, template work, noCloud", meta)
+	}
+	if len(meta.ExtraDirs) != 1 || meta.ExtraDirs[0] != ".config/acme:0700" {
+		t.Errorf("extra dirs = %v", meta.ExtraDirs)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".aws")); err == nil {
+		t.Error("--no-cloud of the source profile should carry over")
+	}
+	if info, err := os.Stat(filepath.Join(profileDir, ".config/acme")); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("extra directory not created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, "code", "notes.txt")); err == nil {
+		t.Error("data of the source profile should not be copied")
+	}
+
+	envrc, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if !strings.Contains(string(envrc), `export WORKSPACE_PROFILE="acme-staging"`) {
+		t.Errorf(".envrc should set its own WORKSPACE_PROFILE:\n%s", envrc)
+	}
+	gitconfig := filepath.Join(profileDir, ".gitconfig")
+	if name, email := profile.GitIdentity(gitconfig); name != "Ada Acme" || email != "ops@acme.example" {
+		t.Errorf("git identity = %q <%q>, want the inherited name and the given email", name, email)
+	}
+	if branch := profile.GitConfigValue(gitconfig, "init.defaultBranch"); branch != "trunk" {
+		t.Errorf("init.defaultBranch = %q, want trunk", branch)
+	}
+}
+
+func TestCreateProfile_FromMissingProfile(t *testing.T) {
+	err := CreateProfile(t.TempDir(), CreateOptions{ProfileName: "new", FromProfile: "ghost"})
+	if !errors.Is(err, errs.ErrProfileNotFound) {
+		t.Errorf("err = %v, want ErrProfileNotFound", err)
+	}
+}
+
+func TestCreateProfile_OpAccount(t *testing.T) {
+	tmpDir := t.TempDir()
+	opts := CreateOptions{ProfileName: "acme", Template: "basic", OpAccount: "acme.1password.com"}
+	if err := CreateProfile(tmpDir, opts); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+
+	envrc, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if !strings.Contains(string(envrc), `op item list --account "$_op_account"`) {
+		t.Errorf(".envrc should pass --account to op:\n%s", envrc)
+	}
+	agent, _ := os.ReadFile(filepath.Join(profileDir, ".config/1Password/agent.toml"))
+	if !strings.Contains(string(agent), "[cli]\naccount = \"acme.1password.com\"\n") {
+		t.Errorf("agent.toml should set the CLI account:\n%s", agent)
+	}
+	if meta, _ := profile.ReadMeta(profileDir); meta.OpAccount != "acme.1password.com" {
+		t.Errorf("meta OpAccount = %q", meta.OpAccount)
+	}
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "other", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	envrc, _ = os.ReadFile(filepath.Join(tmpDir, "other", ".envrc"))
+	if strings.Contains(string(envrc), "--account") {
+		t.Errorf(".envrc should not pick an account by default:\n%s", envrc)
+	}
+
+	opts = CreateOptions{ProfileName: "bad", Template: "basic", OpAccount: `x" ; rm -rf ~`}
+	if err := CreateProfile(tmpDir, opts); err == nil {
+		t.Error("expected an error for an invalid account")
+	}
+}
+
+func TestCreateProfile_IncludeTOTP(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "ci", Template: "basic", IncludeTOTP: true}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	envrc, _ := os.ReadFile(filepath.Join(tmpDir, "ci", ".envrc"))
+	if !strings.Contains(string(envrc), `op item get "$_op_id" --otp`) {
+		t.Errorf(".envrc should fetch one-time passwords:\n%s", envrc)
+	}
+	if meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "ci")); !meta.IncludeTOTP {
+		t.Error("meta should record IncludeTOTP")
+	}
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "ci2", FromProfile: "ci"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "ci2")); !meta.IncludeTOTP {
+		t.Error("--from-profile should inherit IncludeTOTP")
+	}
+}
+
+func TestCreateProfile_Vaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	vaults := []string{"personal", "team-shared"}
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic", Vaults: vaults}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	envrc, _ := os.ReadFile(filepath.Join(tmpDir, "acme", ".envrc"))
+	if !strings.Contains(string(envrc), "for _op_vault in personal team-shared; do") {
+		t.Errorf(".envrc should loop over the vaults in order:\n%s", envrc)
+	}
+	if meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "acme")); strings.Join(meta.Vaults, ",") != "personal,team-shared" {
+		t.Errorf("meta Vaults = %v, want %v", meta.Vaults, vaults)
+	}
+	if id := profile.LoadIdentity("acme", filepath.Join(tmpDir, "acme")); strings.Join(id.SecretVaults, ",") != "personal,team-shared" {
+		t.Errorf("identity SecretVaults = %v, want %v", id.SecretVaults, vaults)
+	}
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme2", FromProfile: "acme"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "acme2")); len(meta.Vaults) != 2 {
+		t.Errorf("--from-profile should inherit Vaults, got %v", meta.Vaults)
+	}
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "bad", Template: "basic", Vaults: []string{" "}}); err == nil {
+		t.Error("a blank vault name should be rejected")
+	}
+}
+
 func TestCreateProfile_SSHPermissions(t *testing.T) {
 	tmpDir := t.TempDir()
 	err := CreateProfile(tmpDir, CreateOptions{
@@ -214,8 +454,8 @@ func TestCreateProfile_EnvrcContent(t *testing.T) {
 	if !strings.Contains(content, `WORKSPACE_PROFILE="myprof"`) {
 		t.Error(".envrc should contain WORKSPACE_PROFILE")
 	}
-	if !strings.Contains(content, `WORKSPACE_HOME="$PWD"`) {
-		t.Error(".envrc should contain WORKSPACE_HOME")
+	if !strings.Contains(content, templates.WorkspaceHomeLine) {
+		t.Error(".envrc should derive WORKSPACE_HOME from its location")
 	}
 }
 
@@ -342,6 +582,52 @@ func TestCreateProfile_SSHConfigContainsAbsPath(t *testing.T) {
 	}
 }
 
+func TestCreateProfile_SSHConfigQuotesPathsWithSpaces(t *testing.T) {
+	tmpDir := filepath.Join(t.TempDir(), "My Profiles")
+	key := filepath.Join(t.TempDir(), "shared keys", "id_ed25519")
+	if err := os.MkdirAll(filepath.Dir(key), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(key, []byte("PRIVATE KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "test", Template: "basic", SharedSSHKey: key}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "test", ".ssh", "config"))
+	if err != nil {
+		t.Fatalf("read .ssh/config: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "test")
+	for _, want := range []string{
+		`UserKnownHostsFile "` + profileDir + `/.ssh/known_hosts"`,
+		`IdentityFile "` + key + `"`,
+		`#     IdentityFile "` + profileDir + `/.ssh/id_ed25519_github"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf(".ssh/config missing %s:\n%s", want, data)
+		}
+	}
+
+	// A moved profile's quoted paths are found and rewritten whole
+	movedDir := filepath.Join(tmpDir, "test moved")
+	if err := os.Rename(profileDir, movedDir); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := fixSSHPaths(movedDir, false)
+	if err != nil {
+		t.Fatalf("fixSSHPaths() error: %v", err)
+	}
+	if len(stale) == 0 || stale[0].OldDir != profileDir {
+		t.Errorf("fixSSHPaths() = %+v, want paths under %s", stale, profileDir)
+	}
+	data, _ = os.ReadFile(filepath.Join(movedDir, ".ssh", "config"))
+	if want := `UserKnownHostsFile "` + movedDir + `/.ssh/known_hosts"`; !strings.Contains(string(data), want) {
+		t.Errorf(".ssh/config missing %s after the fix:\n%s", want, data)
+	}
+}
+
 func TestCreateProfile_SSHWrapperExecutable(t *testing.T) {
 	tmpDir := t.TempDir()
 	err := CreateProfile(tmpDir, CreateOptions{
@@ -395,3 +681,273 @@ func TestCreateProfile_EnvExampleExists(t *testing.T) {
 		t.Errorf(".env.example should exist: %v", err)
 	}
 }
+
+func TestCreateProfile_WritesMeta(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{
+		ProfileName: "metatest",
+		Template:    "work",
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	meta, err := profile.ReadMeta(filepath.Join(tmpDir, "metatest"))
+	if err != nil {
+		t.Fatalf("ReadMeta() error: %v", err)
+	}
+	if meta.Name != "metatest" {
+		t.Errorf("meta name = %q, want metatest", meta.Name)
+	}
+	if meta.Template != "work" {
+		t.Errorf("meta template = %q, want work", meta.Template)
+	}
+	if meta.Created == "" {
+		t.Error("meta created should be set")
+	}
+	if meta.SecretBackend != profile.DefaultSecretBackend {
+		t.Errorf("meta secretBackend = %q, want %q", meta.SecretBackend, profile.DefaultSecretBackend)
+	}
+}
+
+func TestCreateProfile_CustomTemplateDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	templateDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(templateDir, "golang"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	envTpl := "# {{.Template}} env\nGOPATH=\"$WORKSPACE_HOME/go\"\n"
+	if err := os.WriteFile(filepath.Join(templateDir, "golang", "env.tpl"), []byte(envTpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "svc", Template: "golang", TemplateDir: templateDir})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	env, _ := os.ReadFile(filepath.Join(tmpDir, "svc", ".env"))
+	if !strings.Contains(string(env), "# golang env") {
+		t.Errorf(".env not rendered from custom template:\n%s", env)
+	}
+	meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "svc"))
+	if meta.Template != "golang" {
+		t.Errorf("meta template = %q, want golang", meta.Template)
+	}
+
+	// Unknown templates are still rejected, listing the custom ones
+	err = CreateProfile(tmpDir, CreateOptions{ProfileName: "x", Template: "rust", TemplateDir: templateDir})
+	if err == nil || !strings.Contains(err.Error(), "golang") {
+		t.Errorf("expected invalid template error listing golang, got: %v", err)
+	}
+}
+
+func TestCreateProfile_MissingTemplateDir(t *testing.T) {
+	err := CreateProfile(t.TempDir(), CreateOptions{ProfileName: "x", Template: "basic", TemplateDir: "/nonexistent/templates"})
+	if err == nil || !strings.Contains(err.Error(), "template directory does not exist") {
+		t.Errorf("expected missing template dir error, got: %v", err)
+	}
+}
+
+func TestCreateProfile_RemoteTemplate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoDir, "golang"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "golang", "env.tpl"), []byte("# remote {{.Template}} env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "templates"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	tmpDir := t.TempDir()
+	spec := "git+file://" + repoDir + "#golang"
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "svc", Template: spec}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	env, _ := os.ReadFile(filepath.Join(tmpDir, "svc", ".env"))
+	if !strings.Contains(string(env), "# remote golang env") {
+		t.Errorf(".env not rendered from remote template:\n%s", env)
+	}
+	meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "svc"))
+	if meta.Template != "golang" || meta.TemplateURL != spec {
+		t.Errorf("meta template = %q (%q), want golang (%q)", meta.Template, meta.TemplateURL, spec)
+	}
+}
+
+func writeHookScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCreateProfile_PostCreateHook(t *testing.T) {
+	tmpDir := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "hook-env")
+	hook := writeHookScript(t, `echo "$WORKSPACE_PROFILE $WORKSPACE_HOME" > `+marker+"\n"+`test -f "$WORKSPACE_HOME/.profile-meta"`+"\n")
+
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "hooked", Template: "basic", PostCreateHook: hook})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	got, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	if want := "hooked " + filepath.Join(tmpDir, "hooked") + "\n"; string(got) != want {
+		t.Errorf("hook env = %q, want %q", got, want)
+	}
+}
+
+func TestCreateProfile_PostCreateHookFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	hook := writeHookScript(t, "exit 7\n")
+
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "hooked", Template: "basic", PostCreateHook: hook})
+	if err == nil || !strings.Contains(err.Error(), "exited with code 7") {
+		t.Fatalf("expected hook failure to be reported, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "hooked", ".envrc")); err != nil {
+		t.Error("profile should still exist after a failed post-create hook")
+	}
+}
+
+func TestCreateProfile_PostCreateHookSkippedInDryRun(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	hook := writeHookScript(t, "touch "+marker+"\n")
+
+	err := CreateProfile(t.TempDir(), CreateOptions{ProfileName: "hooked", Template: "basic", DryRun: true, PostCreateHook: hook})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("post-create hook should not run in dry-run mode")
+	}
+}
+
+func TestCreateProfile_SharedSSHKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(key, []byte("PRIVATE KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "test", Template: "basic", SharedSSHKey: key}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "test", ".ssh", "config"))
+	if err != nil {
+		t.Fatalf("read .ssh/config: %v", err)
+	}
+	if !strings.Contains(string(data), "    IdentityFile "+key+"\n") {
+		t.Errorf(".ssh/config should reference the shared key %s:\n%s", key, data)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(tmpDir, "test", ".ssh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "id_") {
+			t.Errorf("key %s should not be copied into the profile", entry.Name())
+		}
+	}
+}
+
+func TestCreateProfile_SharedSSHKeyMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "test", Template: "basic", SharedSSHKey: filepath.Join(tmpDir, "nope")})
+	if err == nil || !strings.Contains(err.Error(), "shared SSH key not found") {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "test")); !os.IsNotExist(err) {
+		t.Error("profile should not be created when the shared key is missing")
+	}
+}
+
+func TestCreateProfile_ClockDatesFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	fake := clock.NewFake(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "dated", Template: "basic", Clock: fake}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	const want = "2024-05-06 07:08:09 UTC"
+	for _, file := range []string{".envrc", "README.md"} {
+		data, err := os.ReadFile(filepath.Join(tmpDir, "dated", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s should be dated %s:\n%s", file, want, data)
+		}
+	}
+	meta, err := profile.LoadMeta(filepath.Join(tmpDir, "dated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Created != want {
+		t.Errorf("meta.Created = %q, want %q", meta.Created, want)
+	}
+}
+
+func TestCreateProfile_GitInclude(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmpDir := t.TempDir()
+	shared := filepath.Join(t.TempDir(), "team.gitconfig")
+	if err := os.WriteFile(shared, []byte("[user]\n\temail = team@example.com\n[alias]\n\tteam = log --oneline\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "work", GitEmail: "jane@acme.example", GitIncludes: []string{shared}})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	gitconfig := filepath.Join(tmpDir, "acme", ".gitconfig")
+	get := func(key string) string {
+		out, err := exec.Command("git", "config", "--file", gitconfig, "--includes", key).Output()
+		if err != nil {
+			t.Fatalf("git config %s: %v", key, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if got := get("alias.team"); got != "log --oneline" {
+		t.Errorf("alias.team = %q, want the shared alias", got)
+	}
+	if got := get("user.email"); got != "jane@acme.example" {
+		t.Errorf("user.email = %q, the profile's own setting should win", got)
+	}
+
+	for _, path := range []string{"team.gitconfig", "../team.gitconfig", "~team/x"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "bad", Template: "basic", GitIncludes: []string{path}}); err == nil {
+			t.Errorf("expected an error for include path %q", path)
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package commands
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
@@ -12,9 +21,70 @@ type DeleteOptions struct {
 	ProfileName string
 	Force       bool
 	DryRun      bool
+
+	// PreserveData saves the tools' credential and config directories to
+	// archived/<name>-data-<timestamp>.tar.gz before deleting the profile
+	PreserveData bool
+	Clock        clock.Clock // Time source for the data archive name; the system clock if nil
+}
+
+// ProfileUsage is what a profile directory holds
+type ProfileUsage struct {
+	Files int
+	Dirs  int
+	Bytes int64 // Total size of the files
+}
+
+// ProfileSize walks a profile directory and totals its files. Unreadable
+// entries are skipped.
+func ProfileSize(profileDir string) ProfileUsage {
+	var usage ProfileUsage
+	filepath.Walk(profileDir, func(_ string, info os.FileInfo, err error) error { //nolint:errcheck // Counting files, errors are not critical
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			usage.Dirs++
+		} else {
+			usage.Files++
+			usage.Bytes += info.Size()
+		}
+		return nil
+	})
+	return usage
+}
+
+// notableContents lists what deleting a profile loses that is worth a
+// second look: its dotfiles, described where known, and SSH private keys.
+// Paths are relative to the profile and sorted.
+func notableContents(profileDir string) []DotfileInfo {
+	var notable []DotfileInfo
+	for _, dotfile := range findDotfiles(profileDir) {
+		if rel, err := filepath.Rel(profileDir, dotfile.Path); err == nil {
+			dotfile.Path = rel
+		}
+		notable = append(notable, dotfile)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(profileDir, ".ssh"))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, ".pub") {
+			continue
+		}
+		if strings.HasPrefix(name, "id_") || strings.HasSuffix(name, ".pem") || strings.HasSuffix(name, ".key") {
+			notable = append(notable, DotfileInfo{Path: filepath.Join(".ssh", name), Description: "SSH private key"})
+		}
+	}
+
+	sort.Slice(notable, func(i, j int) bool { return notable[i].Path < notable[j].Path })
+	return notable
 }
 
 func DeleteProfile(profilesDir string, opts DeleteOptions) error {
+	if err := checkProfilesDir(profilesDir, !opts.DryRun); err != nil {
+		return err
+	}
 	profileDir := filepath.Join(profilesDir, opts.ProfileName)
 
 	// If no profile name provided and not forced/dry-run, show interactive selection
@@ -49,7 +119,7 @@ func DeleteProfile(profilesDir string, opts DeleteOptions) error {
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Check if currently in this profile
@@ -63,28 +133,15 @@ func DeleteProfile(profilesDir string, opts DeleteOptions) error {
 	ui.PrintInfo(fmt.Sprintf("Profile to delete: %s", opts.ProfileName))
 	fmt.Printf("  Location: %s\n", profileDir)
 
-	// Count files
-	fileCount := 0
-	dirCount := 0
-	filepath.Walk(profileDir, func(_ string, info os.FileInfo, err error) error { //nolint:errcheck // Counting files, errors are not critical
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			dirCount++
-		} else {
-			fileCount++
-		}
-		return nil
-	})
-
-	fmt.Printf("  Files: %d\n", fileCount)
-	fmt.Printf("  Directories: %d\n", dirCount)
+	usage := ProfileSize(profileDir)
+	fmt.Printf("  Files: %d\n", usage.Files)
+	fmt.Printf("  Directories: %d\n", usage.Dirs)
+	fmt.Printf("  Size: %s\n", formatFileSize(usage.Bytes))
 
 	// List important files
 	envFile := filepath.Join(profileDir, ".env")
 	if _, err := os.Stat(envFile); err == nil {
-		fmt.Printf("  %s⚠ Contains .env file (may have secrets)%s\n", ui.ColorYellow, ui.ColorReset)
+		fmt.Printf("  %s%s Contains .env file (may have secrets)%s\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset)
 	}
 
 	binDir := filepath.Join(profileDir, "bin")
@@ -102,7 +159,7 @@ func DeleteProfile(profilesDir string, opts DeleteOptions) error {
 			}
 		}
 		if scriptCount > 0 {
-			fmt.Printf("  %s⚠ Contains %d executable script(s)%s\n", ui.ColorYellow, scriptCount, ui.ColorReset)
+			fmt.Printf("  %s%s Contains %d executable script(s)%s\n", ui.ColorYellow, ui.SymbolWarn, scriptCount, ui.ColorReset)
 		}
 	}
 
@@ -110,20 +167,24 @@ func DeleteProfile(profilesDir string, opts DeleteOptions) error {
 	if opts.DryRun {
 		ui.PrintInfo("DRY RUN - Nothing will be deleted")
 		fmt.Println()
-		fmt.Println("Would delete:")
-		count := 0
-		filepath.Walk(profileDir, func(path string, info os.FileInfo, err error) error { //nolint:errcheck // Listing files for preview, errors are not critical
-			if err != nil {
-				return nil
+		fmt.Printf("Would delete %s: %d file(s) in %d directories, %s\n",
+			profileDir, usage.Files, usage.Dirs, formatFileSize(usage.Bytes))
+		if notable := notableContents(profileDir); len(notable) > 0 {
+			fmt.Println("Including:")
+			table := &ui.Table{Indent: "  - "}
+			for _, item := range notable {
+				table.AddRow(item.Path, item.Description)
 			}
-			if !info.IsDir() && count < 20 {
-				fmt.Printf("  - %s\n", path)
-				count++
+			if err := table.Render(os.Stdout, ui.FormatTable); err != nil {
+				return err
+			}
+		}
+		if opts.PreserveData {
+			if dirs := preservedDataDirs(profileDir); len(dirs) > 0 {
+				fmt.Printf("Would first save %s to %s\n", strings.Join(dirs, ", "), dataArchivePath(profilesDir, opts))
+			} else {
+				fmt.Println("No tool data to preserve")
 			}
-			return nil
-		})
-		if fileCount > 20 {
-			fmt.Printf("  ... and %d more files\n", fileCount-20)
 		}
 		return nil
 	}
@@ -144,9 +205,31 @@ func DeleteProfile(profilesDir string, opts DeleteOptions) error {
 		}
 	}
 
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock file is removed along with the profile
+
+	// A failing pre-delete hook (e.g. credentials could not be revoked)
+	// keeps the profile
+	if err := runProfileHook(profileDir, hooks.PreDelete, opts.ProfileName); err != nil {
+		return fmt.Errorf("delete cancelled: %w", err)
+	}
+
+	// A failure to save the data keeps the profile
+	if opts.PreserveData {
+		if err := preserveProfileData(profileDir, dataArchivePath(profilesDir, opts)); err != nil {
+			return fmt.Errorf("delete cancelled: %w", err)
+		}
+	}
+
 	// Delete profile
 	ui.PrintInfo(fmt.Sprintf("Deleting profile: %s", opts.ProfileName))
 
+	// direnv deny needs the .envrc, so revoke the allow before removing it
+	denyProfile(profileDir)
+
 	if err := os.RemoveAll(profileDir); err != nil {
 		return fmt.Errorf("failed to delete profile: %w", err)
 	}
@@ -169,3 +252,71 @@ func DeleteProfile(profilesDir string, opts DeleteOptions) error {
 
 	return nil
 }
+
+// preservedDataDirs lists the tool directories in a profile that hold
+// anything, the credentials and config delete --preserve-data keeps
+func preservedDataDirs(profileDir string) []string {
+	var dirs []string
+	for _, tool := range tools.All() {
+		for _, dir := range tool.Dirs {
+			if entries, err := os.ReadDir(filepath.Join(profileDir, dir)); err == nil && len(entries) > 0 {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// dataArchivePath returns where delete --preserve-data saves a profile's
+// tool data: beside archived profiles, named after the profile and the time
+func dataArchivePath(profilesDir string, opts DeleteOptions) string {
+	timestamp := clock.Or(opts.Clock).Now().Format(backupTimeLayout)
+	return filepath.Join(profilesDir, archivedDirName, opts.ProfileName+"-data-"+timestamp+".tar.gz")
+}
+
+// preserveProfileData writes a profile's tool directories to a gzipped tar
+// at archivePath, keeping their paths relative to the profile so they can be
+// extracted into a new one. A profile without tool data gets no archive.
+func preserveProfileData(profileDir, archivePath string) error {
+	dirs := preservedDataDirs(profileDir)
+	if len(dirs) == 0 {
+		ui.PrintInfo("No tool data to preserve")
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(archivePath), err)
+	}
+	// Credentials, so only the owner may read it
+	file, err := os.OpenFile(archivePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create data archive: %w", err)
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	var writeErr error
+	for _, dir := range dirs {
+		if writeErr = tarDir(tw, filepath.Join(profileDir, dir), dir); writeErr != nil {
+			break
+		}
+	}
+	// Close in order so the archive is complete before the profile is removed
+	if err := tw.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if err := gz.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if err := file.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("failed to preserve profile data: %w", writeErr)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Saved %s to %s", strings.Join(dirs, ", "), archivePath))
+	fmt.Printf("  Restore into a profile with: tar -xzf %s -C <profile-dir>\n", archivePath)
+	return nil
+}
@@ -1,10 +1,19 @@
 package commands
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+	"strings"
 )
 
 func TestDeleteProfile_MissingProfile(t *testing.T) {
@@ -17,7 +26,7 @@ func TestDeleteProfile_MissingProfile(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for missing profile")
 	}
-	if !strings.Contains(err.Error(), "does not exist") {
+	if !errors.Is(err, errs.ErrProfileNotFound) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
@@ -75,6 +84,53 @@ func TestDeleteProfile_DryRunDoesNotDelete(t *testing.T) {
 	}
 }
 
+func TestDeleteProfile_DryRunSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "drytest")
+	files := map[string]string{
+		".envrc":              "export WORKSPACE_PROFILE=drytest\n",
+		".env":                "API_TOKEN=secret\n",
+		".ssh/id_ed25519":     strings.Repeat("k", 400),
+		".ssh/id_ed25519.pub": "ssh-ed25519 AAAA\n",
+		"code/main.go":        strings.Repeat("x", 1600),
+	}
+	var total int
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(profileDir, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		total += len(content)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "drytest", DryRun: true})
+	})
+	if err != nil {
+		t.Fatalf("dry run should succeed: %v", err)
+	}
+	for _, want := range []string{
+		"5 file(s)",
+		formatFileSize(int64(total)),
+		".env ",
+		"Environment variables (secrets)",
+		".ssh/id_ed25519 ",
+		"SSH private key",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "id_ed25519.pub") {
+		t.Errorf("public keys are not notable:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".ssh", "id_ed25519")); err != nil {
+		t.Error("dry run should not delete anything")
+	}
+}
+
 func TestDeleteProfile_EmptyNameWithForceNonexistentDir(t *testing.T) {
 	// When profilesDir doesn't exist, empty name + Force should fail
 	err := DeleteProfile("/nonexistent/profiles/dir", DeleteOptions{
@@ -84,7 +140,162 @@ func TestDeleteProfile_EmptyNameWithForceNonexistentDir(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for nonexistent profile")
 	}
-	if !strings.Contains(err.Error(), "does not exist") {
+	if !errors.Is(err, errs.ErrProfileNotFound) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestDeleteProfile_AssumeYesConfirms(t *testing.T) {
+	ui.SetAssumeYes(true)
+	defer ui.SetAssumeYes(false)
+
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "yes")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteProfile(tmpDir, DeleteOptions{ProfileName: "yes"}); err != nil {
+		t.Fatalf("delete under --yes should succeed without prompting: %v", err)
+	}
+	if _, err := os.Stat(profileDir); !os.IsNotExist(err) {
+		t.Error("profile directory should be removed after confirming with --yes")
+	}
+}
+
+// readTarGz returns the contents of the regular files in a gzipped tar
+func readTarGz(t *testing.T, path string) map[string]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			data, _ := io.ReadAll(tr)
+			files[header.Name] = string(data)
+		}
+	}
+}
+
+func TestDeleteProfile_PreserveData(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	for rel, content := range map[string]string{
+		".aws/credentials": "[default]\naws_access_key_id = AKIA\n",
+		".kube/config":     "apiVersion: v1\n",
+		"code/main.go":     "package main\n",
+	} {
+		path := filepath.Join(profileDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clk := clock.NewFake(time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local))
+	if _, err := captureStdout(t, func() error {
+		return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", Force: true, PreserveData: true, Clock: clk})
+	}); err != nil {
+		t.Fatalf("DeleteProfile() error: %v", err)
+	}
+
+	if _, err := os.Stat(profileDir); !os.IsNotExist(err) {
+		t.Error("the profile scaffolding should be removed")
+	}
+	archivePath := filepath.Join(tmpDir, archivedDirName, "acme-data-2026-03-01_09-30-00.tar.gz")
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		t.Fatalf("data archive not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("data archive mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	files := readTarGz(t, archivePath)
+	if files[".aws/credentials"] != "[default]\naws_access_key_id = AKIA\n" {
+		t.Errorf(".aws/credentials not preserved, archive holds %v", files)
+	}
+	if files[".kube/config"] != "apiVersion: v1\n" {
+		t.Errorf(".kube/config not preserved, archive holds %v", files)
+	}
+	if _, ok := files["code/main.go"]; ok {
+		t.Error("workspace files are not tool data and should not be archived")
+	}
+	if _, ok := files[".envrc"]; ok {
+		t.Error(".envrc is scaffolding and should not be archived")
+	}
+	if names := archivedProfiles(tmpDir); len(names) != 0 {
+		t.Errorf("the data archive should not list as an archived profile, got %v", names)
+	}
+}
+
+func TestDeleteProfile_PreserveDataDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "acme", ".aws", "credentials"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", DryRun: true, PreserveData: true})
+	})
+	if err != nil {
+		t.Fatalf("DeleteProfile() error: %v", err)
+	}
+	if !strings.Contains(out, "Would first save") || !strings.Contains(out, ".aws") {
+		t.Errorf("dry run should list the preserved directories:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, archivedDirName)); !os.IsNotExist(err) {
+		t.Error("dry run should not write a data archive")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "acme")); err != nil {
+		t.Error("dry run should not delete the profile")
+	}
+}
+
+func TestDeleteProfile_PreserveDataFailureKeepsProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	// A file where the archived directory should be
+	if err := os.WriteFile(filepath.Join(tmpDir, archivedDirName), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := captureStdout(t, func() error {
+		return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", Force: true, PreserveData: true})
+	})
+	if err == nil {
+		t.Fatal("DeleteProfile() should fail when the data cannot be saved")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "acme", ".envrc")); err != nil {
+		t.Error("the profile should be kept when its data cannot be saved")
+	}
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// errDirenvNotFound is returned by direnvAllow and direnvDeny when direnv is
+// not installed
+var errDirenvNotFound = errors.New("direnv not found in PATH")
+
+// direnvAllow runs `direnv allow` on a profile's .envrc
+func direnvAllow(profileDir string) error {
+	return runDirenv("allow", profileDir)
+}
+
+// direnvDeny runs `direnv deny` on a profile's .envrc, revoking its allow
+// record. The .envrc must still exist.
+func direnvDeny(profileDir string) error {
+	return runDirenv("deny", profileDir)
+}
+
+func runDirenv(command, profileDir string) error {
+	direnv, err := exec.LookPath("direnv")
+	if err != nil {
+		return errDirenvNotFound
+	}
+
+	cmd := exec.Command(direnv, command, filepath.Join(profileDir, ".envrc"))
+	cmd.Dir = profileDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("direnv %s failed: %w: %s", command, err, msg)
+		}
+		return fmt.Errorf("direnv %s failed: %w", command, err)
+	}
+	return nil
+}
+
+// allowProfile runs direnv allow for a profile, warning rather than failing
+// when direnv is missing or refuses
+func allowProfile(profileDir string) bool {
+	if err := direnvAllow(profileDir); err != nil {
+		if errors.Is(err, errDirenvNotFound) {
+			ui.PrintWarning("direnv is not installed; skipping 'direnv allow'")
+		} else {
+			ui.PrintWarning(fmt.Sprintf("Failed to allow direnv: %v", err))
+		}
+		fmt.Printf("  Run 'direnv allow' in %s manually\n", profileDir)
+		return false
+	}
+	ui.PrintSuccess("direnv allowed")
+	return true
+}
+
+// denyProfile revokes direnv's allow record for a profile about to be
+// removed, so it does not outlive the .envrc. A missing direnv is skipped
+// silently and a failure only warns.
+func denyProfile(profileDir string) {
+	if err := direnvDeny(profileDir); err != nil && !errors.Is(err, errDirenvNotFound) {
+		ui.PrintWarning(fmt.Sprintf("Failed to revoke the direnv allow: %v", err))
+	}
+}
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+// stubDirenv puts a fake direnv on PATH that records its arguments and
+// returns the log path
+func stubDirenv(t *testing.T, exitCode string) string {
+	t.Helper()
+	binDir := t.TempDir()
+	logPath := filepath.Join(t.TempDir(), "direnv.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\nexit " + exitCode + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "direnv"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func TestDirenvAllow_PassesEnvrcPath(t *testing.T) {
+	logPath := stubDirenv(t, "0")
+	profileDir := t.TempDir()
+
+	if err := direnvAllow(profileDir); err != nil {
+		t.Fatalf("direnvAllow() error: %v", err)
+	}
+	got, _ := os.ReadFile(logPath)
+	if want := "allow " + filepath.Join(profileDir, ".envrc") + "\n"; string(got) != want {
+		t.Errorf("direnv invoked with %q, want %q", got, want)
+	}
+}
+
+func TestDirenvAllow_Missing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if err := direnvAllow(t.TempDir()); !errors.Is(err, errDirenvNotFound) {
+		t.Errorf("direnvAllow() error = %v, want errDirenvNotFound", err)
+	}
+}
+
+func TestDirenvAllow_Failure(t *testing.T) {
+	stubDirenv(t, "1")
+
+	if err := direnvAllow(t.TempDir()); err == nil || !strings.Contains(err.Error(), "direnv allow failed") {
+		t.Errorf("expected direnv allow failure, got: %v", err)
+	}
+}
+
+func TestCreateProfile_Allow(t *testing.T) {
+	logPath := stubDirenv(t, "0")
+	tmpDir := t.TempDir()
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic", Allow: true}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	got, _ := os.ReadFile(logPath)
+	if want := "allow " + filepath.Join(tmpDir, "acme", ".envrc") + "\n"; string(got) != want {
+		t.Errorf("direnv invoked with %q, want %q", got, want)
+	}
+}
+
+func TestCreateProfile_AllowWithoutDirenv(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if err := CreateProfile(t.TempDir(), CreateOptions{ProfileName: "acme", Template: "basic", Allow: true}); err != nil {
+		t.Errorf("missing direnv should only warn, got: %v", err)
+	}
+}
+
+func TestUpdateProfile_Allow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	meta, _ := profile.ReadMeta(profileDir)
+	meta.SchemaVersion = profileMigrations.Latest() - 1
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	logPath := stubDirenv(t, "0")
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true, Allow: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	got, _ := os.ReadFile(logPath)
+	if want := "allow " + filepath.Join(profileDir, ".envrc") + "\n"; string(got) != want {
+		t.Errorf("direnv invoked with %q, want %q", got, want)
+	}
+}
+
+func TestDeleteProfile_DeniesDirenv(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+
+	// Record whether the .envrc still existed when direnv ran
+	binDir := t.TempDir()
+	logPath := filepath.Join(t.TempDir(), "direnv.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n[ -f \"$2\" ] || echo missing >> " + logPath + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "direnv"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, err := captureStdout(t, func() error {
+		return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", Force: true})
+	}); err != nil {
+		t.Fatalf("DeleteProfile() error: %v", err)
+	}
+	got, _ := os.ReadFile(logPath)
+	if want := "deny " + filepath.Join(profileDir, ".envrc") + "\n"; string(got) != want {
+		t.Errorf("direnv invoked with %q, want %q", got, want)
+	}
+	if _, err := os.Stat(profileDir); !os.IsNotExist(err) {
+		t.Error("profile should be deleted")
+	}
+}
+
+func TestDeleteProfile_DirenvDenyOptional(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		setup func(t *testing.T)
+	}{
+		{"direnv missing", func(t *testing.T) { t.Setenv("PATH", t.TempDir()) }},
+		{"direnv fails", func(t *testing.T) { stubDirenv(t, "1") }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+				t.Fatalf("CreateProfile() error: %v", err)
+			}
+			tt.setup(t)
+
+			if _, err := captureStdout(t, func() error {
+				return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", Force: true})
+			}); err != nil {
+				t.Fatalf("DeleteProfile() error: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(tmpDir, "acme")); !os.IsNotExist(err) {
+				t.Error("profile should be deleted even without a direnv deny")
+			}
+		})
+	}
+}
+
+func TestDeleteProfile_DryRunKeepsDirenvAllow(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	logPath := stubDirenv(t, "0")
+
+	if _, err := captureStdout(t, func() error {
+		return DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", DryRun: true})
+	}); err != nil {
+		t.Fatalf("DeleteProfile() error: %v", err)
+	}
+	if got, err := os.ReadFile(logPath); err == nil {
+		t.Errorf("dry run should not call direnv, got %q", got)
+	}
+}
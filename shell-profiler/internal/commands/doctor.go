@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// Severity ranks doctor findings
+type Severity int
+
+const (
+	SeverityWarning Severity = iota + 1
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Finding is a problem doctor found in a profile
+type Finding struct {
+	Check    string
+	Severity Severity
+	Message  string
+	Repair   string // What --fix does about it; empty if it must be fixed by hand
+	Fixed    bool   // Repaired by --fix
+}
+
+// doctorCheck inspects a profile, repairing what it can when fix is set
+type doctorCheck struct {
+	Name string
+	Run  func(profileDir, profileName string, fix bool) ([]Finding, error)
+}
+
+// doctorChecks run in order for every profile
+var doctorChecks = []doctorCheck{
+	{Name: "permissions", Run: checkPermissions},
+	{Name: "schema", Run: checkSchema},
+	{Name: "shadowing", Run: checkShadowing},
+	{Name: "direnv", Run: checkDirenvAllowed},
+	{Name: "workspace-home", Run: checkWorkspaceHome},
+	{Name: "gitconfig", Run: checkGitconfig},
+	{Name: "ssh-policy", Run: checkSSHPolicy},
+	{Name: "ssh-paths", Run: checkSSHPaths},
+	{Name: "env-example", Run: checkEnvExample},
+}
+
+// Doctor exit statuses, the worst over every profile checked
+const (
+	DoctorHealthy  = 0 // No unfixed findings
+	DoctorWarnings = 1 // Only warnings remain
+	DoctorErrors   = 2 // Errors remain, or warnings with Strict, or doctor could not run
+)
+
+type DoctorOptions struct {
+	ProfileName string // Check only this profile
+	Fix         bool   // Repair what can be repaired
+	DryRun      bool   // With Fix, show the repairs instead of making them
+	Strict      bool   // Treat remaining warnings as errors
+}
+
+// DiagnoseProfile runs every doctor check against a profile
+func DiagnoseProfile(profileDir, profileName string, fix bool) ([]Finding, error) {
+	var findings []Finding
+	for _, check := range doctorChecks {
+		found, err := check.Run(profileDir, profileName, fix)
+		if err != nil {
+			return findings, fmt.Errorf("%s check failed: %w", check.Name, err)
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+// Doctor checks the named profile, or else the active profile, or else every
+// profile, and reports what it finds. Unless every profile is healthy it
+// returns an errs.ExitError with DoctorWarnings or DoctorErrors as status.
+func Doctor(profilesDir string, opts DoctorOptions) error {
+	status, err := doctor(profilesDir, opts)
+	if err != nil {
+		return &errs.ExitError{Status: DoctorErrors, Err: err}
+	}
+	if status == nil {
+		return nil
+	}
+	return status
+}
+
+// doctor runs Doctor, returning the status it ends with, or an error if it
+// could not check every profile
+func doctor(profilesDir string, opts DoctorOptions) (*errs.ExitError, error) {
+	names := []string{opts.ProfileName}
+	if opts.ProfileName == "" {
+		if active, ok := profile.ActiveProfileIn(profilesDir); ok {
+			names = []string{active}
+		} else {
+			var err error
+			if names, err = profileNames(profilesDir); err != nil {
+				return nil, err
+			}
+			if len(names) == 0 {
+				return nil, fmt.Errorf("no profiles found")
+			}
+		}
+	}
+
+	// Conflicts span profiles, so they are detected once for all of them
+	conflicts, err := DetectConflicts(profilesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Fix && opts.DryRun {
+		ui.PrintInfo("DRY RUN - Showing the repairs --fix would make; nothing is changed")
+	}
+	problems, warnings := 0, 0
+	for i, name := range names {
+		profileDir := filepath.Join(profilesDir, name)
+		if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+			return nil, errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", name, profileDir)
+		}
+
+		fix := opts.Fix && !opts.DryRun
+		var lock *profile.Lock
+		if fix {
+			if lock, err = profile.AcquireLock(profileDir, profile.DefaultLockTimeout); err != nil {
+				return nil, err
+			}
+		}
+		findings, err := DiagnoseProfile(profileDir, name, fix)
+		lock.Release() //nolint:errcheck // Nothing to recover; the repairs are done
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s': %w", name, err)
+		}
+		findings = append(findings, conflictFindings(conflicts, name)...)
+
+		if i > 0 {
+			fmt.Println()
+		}
+		printFindings(name, findings, opts.Fix && opts.DryRun)
+		for _, f := range findings {
+			switch {
+			case f.Fixed:
+			case f.Severity == SeverityError:
+				problems++
+			default:
+				warnings++
+			}
+		}
+	}
+
+	switch {
+	case problems > 0:
+		return &errs.ExitError{Status: DoctorErrors, Err: fmt.Errorf("doctor found %d problem(s)", problems)}, nil
+	case warnings > 0 && opts.Strict:
+		return &errs.ExitError{Status: DoctorErrors, Err: fmt.Errorf("doctor found %d warning(s) (--strict)", warnings)}, nil
+	case warnings > 0:
+		// The warnings are already printed; the status alone tells CI
+		return &errs.ExitError{Status: DoctorWarnings}, nil
+	}
+	return nil, nil
+}
+
+// printFindings lists a profile's findings; with repairs set, each is
+// followed by what --fix would do about it
+func printFindings(name string, findings []Finding, repairs bool) {
+	fmt.Printf("=== %s ===\n", name)
+	if len(findings) == 0 {
+		fmt.Printf("  %s%s No problems found%s\n", ui.ColorGreen, ui.SymbolOK, ui.ColorReset)
+		return
+	}
+	for _, f := range findings {
+		switch {
+		case f.Fixed:
+			fmt.Printf("  %s%s fixed%s %s: %s\n", ui.ColorGreen, ui.SymbolOK, ui.ColorReset, f.Check, f.Message)
+		case f.Severity == SeverityError:
+			fmt.Printf("  %s%s %s%s: %s\n", ui.ColorRed, ui.SymbolErr, f.Check, ui.ColorReset, f.Message)
+		default:
+			fmt.Printf("  %s%s %s%s: %s\n", ui.ColorYellow, ui.SymbolWarn, f.Check, ui.ColorReset, f.Message)
+		}
+		switch {
+		case !repairs || f.Fixed:
+		case f.Repair != "":
+			fmt.Printf("      would fix: %s\n", f.Repair)
+		default:
+			fmt.Println("      not fixed automatically")
+		}
+	}
+}
+
+// profileNames lists the profiles directly under profilesDir
+func profileNames(profilesDir string) ([]string, error) {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != ".git" {
+			if _, err := os.Stat(filepath.Join(profilesDir, entry.Name(), ".envrc")); err == nil {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func checkPermissions(profileDir, _ string, fix bool) ([]Finding, error) {
+	changes, err := FixPermissions(profileDir, !fix)
+	var findings []Finding
+	for _, change := range changes {
+		findings = append(findings, Finding{
+			Check:    "permissions",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s: mode %04o is more permissive than %04o", change.Path, change.was, change.mode),
+			Repair:   fmt.Sprintf("chmod %04o %s", change.mode, change.Path),
+			Fixed:    fix,
+		})
+	}
+	return findings, err
+}
+
+func checkSchema(profileDir, profileName string, _ bool) ([]Finding, error) {
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return nil, err
+	}
+	if msg := newerSchemaMessage(meta, profileName); msg != "" {
+		return []Finding{{
+			Check:    "schema",
+			Severity: SeverityError,
+			Message:  msg + " (upgrade shell-profiler)",
+		}}, nil
+	}
+	if latest := profileMigrations.Latest(); meta.Legacy || meta.SchemaVersion < latest {
+		return []Finding{{
+			Check:    "schema",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("schema version %d is behind %d (run: shell-profiler update %s)", meta.SchemaVersion, latest, profileName),
+		}}, nil
+	}
+	return nil, nil
+}
+
+func checkDirenvAllowed(profileDir, profileName string, _ bool) ([]Finding, error) {
+	if profile.DirenvAllowState(profileDir) != profile.AllowBlocked {
+		return nil, nil
+	}
+	return []Finding{{
+		Check:    "direnv",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf(".envrc is not allowed, so direnv ignores it (run: direnv allow %s)", profileDir),
+	}}, nil
+}
@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+)
+
+// newDoctorFixture creates a profile that doctor finds healthy, with only
+// warnings, or with errors
+func newDoctorFixture(t *testing.T, profilesDir, name, health string) {
+	t.Helper()
+	// A distinct email, so profiles don't conflict with each other
+	opts := CreateOptions{ProfileName: name, Template: "basic", GitEmail: name + "@example.com"}
+	if err := CreateProfile(profilesDir, opts); err != nil {
+		t.Fatalf("CreateProfile(%s) error: %v", name, err)
+	}
+	profileDir := filepath.Join(profilesDir, name)
+
+	switch health {
+	case "warning":
+		// An .env.example from before a tool was added
+		path := filepath.Join(profileDir, ".env.example")
+		data, _ := os.ReadFile(path)
+		stale := strings.Replace(string(data), "# GEMINI_API_KEY=your-gemini-api-key\n", "", 1)
+		if err := os.WriteFile(path, []byte(stale), 0644); err != nil {
+			t.Fatal(err)
+		}
+	case "error":
+		// Written by a newer shell-profiler
+		meta, err := profile.ReadMeta(profileDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta.SchemaVersion = profileMigrations.Latest() + 1
+		if err := profile.WriteMeta(profileDir, meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDoctor_ExitStatus(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tests := []struct {
+		name     string
+		profiles []string // Health of each profile
+		strict   bool
+		want     int
+	}{
+		{"healthy", []string{"healthy"}, false, DoctorHealthy},
+		{"warnings only", []string{"warning"}, false, DoctorWarnings},
+		{"warnings strict", []string{"warning"}, true, DoctorErrors},
+		{"errors", []string{"error"}, false, DoctorErrors},
+		{"all profiles, worst is warning", []string{"healthy", "warning"}, false, DoctorWarnings},
+		{"all profiles, worst is error", []string{"warning", "healthy", "error"}, false, DoctorErrors},
+		{"all profiles healthy, strict", []string{"healthy", "healthy"}, true, DoctorHealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for i, health := range tt.profiles {
+				newDoctorFixture(t, tmpDir, string(rune('a'+i))+"-"+health, health)
+			}
+
+			var err error
+			_, _ = captureStdout(t, func() error {
+				err = Doctor(tmpDir, DoctorOptions{Strict: tt.strict})
+				return nil
+			})
+			if got := errs.ExitStatus(err); got != tt.want {
+				t.Errorf("exit status = %d (%v), want %d", got, err, tt.want)
+			}
+
+			// A single profile gets the same status as in all-profiles mode
+			if len(tt.profiles) == 1 {
+				_, _ = captureStdout(t, func() error {
+					err = Doctor(tmpDir, DoctorOptions{ProfileName: "a-" + tt.profiles[0], Strict: tt.strict})
+					return nil
+				})
+				if got := errs.ExitStatus(err); got != tt.want {
+					t.Errorf("single-profile exit status = %d (%v), want %d", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDoctor_FailureExitsWithErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := Doctor(tmpDir, DoctorOptions{ProfileName: "missing"})
+	if got := errs.ExitStatus(err); got != DoctorErrors {
+		t.Errorf("exit status = %d, want %d", got, DoctorErrors)
+	}
+	if !errors.Is(err, errs.ErrProfileNotFound) {
+		t.Errorf("error = %v, want ErrProfileNotFound", err)
+	}
+}
+
+func TestDoctor_FixedWarningsAreHealthy(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	newDoctorFixture(t, tmpDir, "acme", "warning")
+
+	var err error
+	_, _ = captureStdout(t, func() error {
+		err = Doctor(tmpDir, DoctorOptions{Fix: true, Strict: true})
+		return nil
+	})
+	if got := errs.ExitStatus(err); got != DoctorHealthy {
+		t.Errorf("exit status after --fix = %d (%v), want %d", got, err, DoctorHealthy)
+	}
+}
+
+// snapshotTree records the mode and content of every file under root
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content := ""
+		if d.Type().IsRegular() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			content = string(data)
+		}
+		files[path] = fmt.Sprintf("%v %s", info.Mode(), content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestDoctor_FixDryRun(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	newDoctorFixture(t, tmpDir, "acme", "warning") // Stale .env.example
+	profileDir := filepath.Join(tmpDir, "acme")
+
+	writeWithMode(t, profileDir, ".ssh/id_rsa", 0644)
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	envrc, _ := os.ReadFile(envrcPath)
+	pwdEnvrc := strings.Replace(string(envrc), templates.WorkspaceHomeLine, `export WORKSPACE_HOME="$PWD"`, 1)
+	if err := os.WriteFile(envrcPath, []byte(pwdEnvrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldDir := filepath.Join(tmpDir, "old-acme")
+	sshConfig := "Host github.com\n    IdentityFile " + oldDir + "/.ssh/id_github\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	before := snapshotTree(t, tmpDir)
+
+	var err error
+	out, _ := captureStdout(t, func() error {
+		err = Doctor(tmpDir, DoctorOptions{Fix: true, DryRun: true})
+		return nil
+	})
+	if got := errs.ExitStatus(err); got != DoctorErrors {
+		t.Errorf("exit status = %d (%v), want %d for the unrepaired findings", got, err, DoctorErrors)
+	}
+	for _, want := range []string{
+		"permissions: .ssh/id_rsa: mode 0644 is more permissive than 0600\n      would fix: chmod 0600 .ssh/id_rsa",
+		"would fix: replace \"export WORKSPACE_HOME=\\\"$PWD\\\"\" in .envrc with",
+		"would fix: rewrite it to " + profileDir + "/.ssh/id_github",
+		"would fix: regenerate the managed block of .env.example",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "fixed") {
+		t.Errorf("a dry run should not report anything as fixed:\n%s", out)
+	}
+
+	after := snapshotTree(t, tmpDir)
+	for path, state := range before {
+		if after[path] != state {
+			t.Errorf("dry run changed %s", path)
+		}
+	}
+	if len(after) != len(before) {
+		t.Errorf("dry run created or removed files: %d before, %d after", len(before), len(after))
+	}
+}
+
+func TestRepairCommands_RespectProfileLock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profilesDir := t.TempDir()
+	newDoctorFixture(t, profilesDir, "alpha", "warning")
+	profileDir := filepath.Join(profilesDir, "alpha")
+
+	orig := profile.DefaultLockTimeout
+	profile.DefaultLockTimeout = 0
+	t.Cleanup(func() { profile.DefaultLockTimeout = orig })
+	lock, err := profile.AcquireLock(profileDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release() //nolint:errcheck // Test cleanup
+
+	commands := map[string]func() error{
+		"doctor --fix": func() error { return Doctor(profilesDir, DoctorOptions{ProfileName: "alpha", Fix: true}) },
+		"fix-perms":    func() error { return FixProfilePermissions(profilesDir, FixPermissionsOptions{ProfileName: "alpha"}) },
+		"readme":       func() error { return RegenerateReadme(profilesDir, ReadmeOptions{ProfileName: "alpha"}) },
+		"env-example": func() error {
+			return EnvExample(profilesDir, "alpha", EnvExampleOptions{Regenerate: true})
+		},
+	}
+	for name, run := range commands {
+		if _, err := captureStdout(t, run); !errors.Is(err, profile.ErrLocked) {
+			t.Errorf("%s error = %v, want ErrLocked", name, err)
+		}
+	}
+	// Diagnosing without repairs does not need the lock
+	if _, err := captureStdout(t, func() error { return Doctor(profilesDir, DoctorOptions{ProfileName: "alpha"}) }); errors.Is(err, profile.ErrLocked) {
+		t.Errorf("doctor without --fix waited for the lock: %v", err)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
@@ -18,6 +21,9 @@ type DotfilesOptions struct {
 
 // ListDotfiles lists all dotfiles in a profile
 func ListDotfiles(profilesDir string, opts DotfilesOptions) error {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
 	// If no profile name provided, show interactive selection
 	if opts.ProfileName == "" {
 		entries, err := os.ReadDir(profilesDir)
@@ -51,7 +57,7 @@ func ListDotfiles(profilesDir string, opts DotfilesOptions) error {
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Find all dotfiles
@@ -101,6 +107,9 @@ func ListDotfiles(profilesDir string, opts DotfilesOptions) error {
 
 // EditDotfile opens a dotfile for editing
 func EditDotfile(profilesDir string, opts DotfilesOptions) error {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
 	// If no profile name provided, show interactive selection
 	if opts.ProfileName == "" {
 		entries, err := os.ReadDir(profilesDir)
@@ -134,7 +143,7 @@ func EditDotfile(profilesDir string, opts DotfilesOptions) error {
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Find all dotfiles
@@ -169,50 +178,90 @@ func EditDotfile(profilesDir string, opts DotfilesOptions) error {
 		opts.FileName = parts[0]
 	}
 
-	// Find the full path
-	var targetPath string
-	for _, dotfile := range dotfiles {
-		relPath, relErr := filepath.Rel(profileDir, dotfile.Path)
-		if relErr != nil {
-			relPath = dotfile.Path
-		}
-		if relPath == opts.FileName || dotfile.Path == opts.FileName {
-			targetPath = dotfile.Path
-			break
-		}
+	targetPath, err := matchDotfile(profileDir, dotfiles, opts.FileName)
+	if err != nil {
+		return fmt.Errorf("%w in profile '%s'", err, opts.ProfileName)
 	}
+	opts.FileName, _ = filepath.Rel(profileDir, targetPath)
 
-	if targetPath == "" {
-		return fmt.Errorf("dotfile '%s' not found in profile '%s'", opts.FileName, opts.ProfileName)
+	editor, err := resolveEditor(opts.Editor)
+	if err != nil {
+		return err
 	}
 
-	// Determine editor
-	editor := opts.Editor
-	if editor == "" {
-		editor = os.Getenv("EDITOR")
-		if editor == "" {
-			editor = os.Getenv("VISUAL")
-			if editor == "" {
-				// Default to common editors
-				if _, err := exec.LookPath("vim"); err == nil {
-					editor = "vim"
-				} else if _, err := exec.LookPath("nano"); err == nil {
-					editor = "nano"
-				} else if _, err := exec.LookPath("vi"); err == nil {
-					editor = "vi"
-				} else {
-					return fmt.Errorf("no editor found. Set EDITOR or VISUAL environment variable")
-				}
-			}
-		}
+	// Hold the lock while the editor is open so an update cannot rewrite the
+	// file underneath it
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
 	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
 
 	// Open editor
 	ui.PrintInfo(fmt.Sprintf("Opening %s with %s...", opts.FileName, editor))
 	fmt.Printf("  Path: %s\n", targetPath)
 	fmt.Println()
 
-	cmd := exec.Command(editor, targetPath)
+	if err := openInEditor(editor, targetPath); err != nil {
+		return err
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Finished editing %s", opts.FileName))
+
+	return nil
+}
+
+// matchDotfile finds the dotfile named by a path relative to the profile.
+// A unique prefix is completed, with or without the leading dot, so "git"
+// finds .gitconfig when nothing else starts with .git.
+func matchDotfile(profileDir string, dotfiles []DotfileInfo, name string) (string, error) {
+	var matches []string
+	rels := make(map[string]string)
+	for _, dotfile := range dotfiles {
+		relPath, err := filepath.Rel(profileDir, dotfile.Path)
+		if err != nil {
+			relPath = dotfile.Path
+		}
+		if relPath == name || dotfile.Path == name {
+			return dotfile.Path, nil
+		}
+		rels[relPath] = dotfile.Path
+		if strings.HasPrefix(relPath, name) || strings.HasPrefix(relPath, "."+name) {
+			matches = append(matches, relPath)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("dotfile '%s' not found", name)
+	case 1:
+		return rels[matches[0]], nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("dotfile '%s' is ambiguous (matches: %s)", name, strings.Join(matches, ", "))
+}
+
+// resolveEditor returns the editor to use: the given one, else $EDITOR,
+// else $VISUAL, else the first of vim, nano and vi on PATH
+func resolveEditor(editor string) (string, error) {
+	for _, candidate := range []string{editor, os.Getenv("EDITOR"), os.Getenv("VISUAL")} {
+		if candidate != "" {
+			return candidate, nil
+		}
+	}
+	for _, fallback := range []string{"vim", "nano", "vi"} {
+		if _, err := exec.LookPath(fallback); err == nil {
+			return fallback, nil
+		}
+	}
+	return "", fmt.Errorf("no editor found. Set EDITOR or VISUAL environment variable")
+}
+
+// openInEditor runs the editor on path attached to the terminal. The editor
+// may include arguments, e.g. "code --wait".
+func openInEditor(editor, path string) error {
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -220,9 +269,26 @@ func EditDotfile(profilesDir string, opts DotfilesOptions) error {
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to open editor: %w", err)
 	}
+	return nil
+}
 
-	ui.PrintSuccess(fmt.Sprintf("Finished editing %s", opts.FileName))
-
+// EditFiles opens files in a profile one after another in the editor,
+// skipping any that do not exist
+func EditFiles(profileDir string, files []string, editor string) error {
+	editor, err := resolveEditor(editor)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		path := filepath.Join(profileDir, file)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		ui.PrintInfo(fmt.Sprintf("Opening %s with %s...", file, editor))
+		if err := openInEditor(editor, path); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@ package commands
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -114,3 +115,81 @@ func TestFindDotfiles_EmptyDir(t *testing.T) {
 		t.Errorf("findDotfiles on empty dir should return 0 items, got %d", len(dotfiles))
 	}
 }
+
+// fakeEditor sets $EDITOR to a script appending each file it is given to a
+// log, and returns the log path
+func fakeEditor(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "editor.log")
+	script := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "editor"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", filepath.Join(dir, "editor"))
+	t.Setenv("VISUAL", "")
+	return logPath
+}
+
+func TestCreateProfile_EditOpensFilesInOrder(t *testing.T) {
+	logPath := fakeEditor(t)
+	tmpDir := t.TempDir()
+
+	if _, err := captureStdout(t, func() error {
+		return CreateProfile(tmpDir, CreateOptions{ProfileName: "test", Template: "basic", Edit: true})
+	}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("editor was not run: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "test")
+	want := filepath.Join(profileDir, ".gitconfig") + "\n" + filepath.Join(profileDir, ".ssh", "config") + "\n"
+	if string(log) != want {
+		t.Errorf("editor calls = %q, want %q", log, want)
+	}
+}
+
+func TestCreateProfile_EditSkippedOnDryRun(t *testing.T) {
+	logPath := fakeEditor(t)
+
+	if _, err := captureStdout(t, func() error {
+		return CreateProfile(t.TempDir(), CreateOptions{ProfileName: "test", Template: "basic", Edit: true, DryRun: true})
+	}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Error("editor should not run on a dry run")
+	}
+}
+
+func TestEditDotfile_CompletesPrefix(t *testing.T) {
+	logPath := fakeEditor(t)
+	profilesDir := t.TempDir()
+	profileDir := filepath.Join(profilesDir, "acme")
+	for _, file := range []string{".envrc", ".gitconfig", ".gitignore", ".ssh/config"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(profileDir, file)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(profileDir, file), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return EditDotfile(profilesDir, DotfilesOptions{ProfileName: "acme", FileName: "ssh/c"})
+	}); err != nil {
+		t.Fatalf("EditDotfile() error: %v", err)
+	}
+	log, _ := os.ReadFile(logPath)
+	if want := filepath.Join(profileDir, ".ssh", "config") + "\n"; string(log) != want {
+		t.Errorf("editor calls = %q, want %q", log, want)
+	}
+
+	err := EditDotfile(profilesDir, DotfilesOptions{ProfileName: "acme", FileName: "git"})
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguous match error, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// Sources of a listed environment variable
+const (
+	EnvSourceFile   = "env"    // The profile's .env
+	EnvSourceSecret = "secret" // Resolved from the secret backend into the cache
+)
+
+// maskedValue replaces values that are not revealed
+const maskedValue = "********"
+
+// sensitiveNamePattern matches variable names whose values are masked even
+// when they come from .env
+var sensitiveNamePattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIAL|API_?KEY|PRIVATE_KEY)`)
+
+type EnvListOptions struct {
+	Format  string // "text" (default) or "json"
+	Reveal  bool   // Show secret values instead of masking them
+	Secrets bool   // Include secrets from the resolved env cache
+}
+
+// EnvVar is a variable a profile sets
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Masked bool   `json:"masked,omitempty"`
+}
+
+// EnvList prints the variables the named profile, or else the active
+// profile, sets from .env, with $WORKSPACE_HOME and $WORKSPACE_PROFILE
+// expanded. Secret values are masked unless opts.Reveal is set.
+func EnvList(profilesDir, profileName string, opts EnvListOptions) error {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
+	if opts.Format != "" && opts.Format != "text" && opts.Format != "json" {
+		return fmt.Errorf("unknown format: %s (use text or json)", opts.Format)
+	}
+	if profileName == "" {
+		active, ok := profile.ActiveProfileIn(profilesDir)
+		if !ok {
+			return fmt.Errorf("no profile specified and WORKSPACE_PROFILE is not set")
+		}
+		profileName = active
+	}
+	profileDir := filepath.Join(profilesDir, profileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", profileName, profileDir)
+	}
+
+	vars, err := ResolveEnv(profileDir, profileName, opts.Secrets)
+	if err != nil {
+		return err
+	}
+	if opts.Secrets && !envCacheExists(profileName) {
+		// Kept off stdout so JSON output stays parseable
+		ui.PrintWarningStderr("No resolved secrets cached yet; load the profile with direnv first")
+	}
+	if !opts.Reveal {
+		for i := range vars {
+			if vars[i].Source == EnvSourceSecret || sensitiveNamePattern.MatchString(vars[i].Name) {
+				vars[i].Value = maskedValue
+				vars[i].Masked = true
+			}
+		}
+	}
+
+	if opts.Format == "json" {
+		if vars == nil {
+			vars = []EnvVar{}
+		}
+		content, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode environment: %w", err)
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Environment for profile: %s", profileName))
+	for _, v := range vars {
+		if v.Source == EnvSourceSecret {
+			fmt.Printf("  %s=%s  %s(secret)%s\n", v.Name, v.Value, ui.ColorYellow, ui.ColorReset)
+			continue
+		}
+		fmt.Printf("  %s=%s\n", v.Name, v.Value)
+	}
+	return nil
+}
+
+// ResolveEnv returns the variables a profile sets from .env in file order,
+// expanded for the profile. With secrets, variables only found in the
+// resolved env cache follow as EnvSourceSecret; nothing is fetched from the
+// secret backend, so there are none until the profile has been loaded.
+func ResolveEnv(profileDir, profileName string, secrets bool) ([]EnvVar, error) {
+	absDir, err := filepath.Abs(profileDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	expand := strings.NewReplacer(
+		"${WORKSPACE_HOME}", absDir,
+		"$WORKSPACE_HOME", absDir,
+		"${WORKSPACE_PROFILE}", profileName,
+		"$WORKSPACE_PROFILE", profileName,
+	)
+
+	fileVars, err := readDotenv(filepath.Join(profileDir, ".env"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+	var vars []EnvVar
+	seen := make(map[string]bool)
+	for _, v := range fileVars {
+		v.Value = expand.Replace(v.Value)
+		v.Source = EnvSourceFile
+		vars = append(vars, v)
+		seen[v.Name] = true
+	}
+	if !secrets {
+		return vars, nil
+	}
+
+	cached, err := readEnvCache(profileName)
+	if os.IsNotExist(err) {
+		return vars, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range cached {
+		if seen[v.Name] {
+			continue
+		}
+		v.Source = EnvSourceSecret
+		vars = append(vars, v)
+		seen[v.Name] = true
+	}
+	return vars, nil
+}
+
+// envCachePath is the plaintext resolved env cache the generated .envrc
+// writes, per envrc.tpl
+func envCachePath(profileName string) string {
+	return filepath.Join(SecretCacheDir(profileName), ".env")
+}
+
+// encryptedEnvCachePath is the resolved env cache of profiles created with
+// --encrypt-cache, and envCacheKeyPath the key it is encrypted with
+func encryptedEnvCachePath(profileName string) string {
+	return filepath.Join(SecretCacheDir(profileName), ".env.enc")
+}
+
+func envCacheKeyPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "profile-manager", "cache.key")
+}
+
+// envCacheExists reports whether the profile has a resolved env cache,
+// plaintext or encrypted
+func envCacheExists(profileName string) bool {
+	for _, path := range []string{envCachePath(profileName), encryptedEnvCachePath(profileName)} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readEnvCache returns the variables of the profile's resolved env cache,
+// decrypting it with openssl the way the .envrc does when it is encrypted.
+// The error satisfies os.IsNotExist when there is no cache.
+func readEnvCache(profileName string) ([]EnvVar, error) {
+	vars, err := readDotenv(envCachePath(profileName))
+	if !os.IsNotExist(err) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the env cache: %w", err)
+		}
+		return vars, nil
+	}
+
+	encPath := encryptedEnvCachePath(profileName)
+	if _, err := os.Stat(encPath); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("openssl"); err != nil {
+		return nil, fmt.Errorf("the env cache %s is encrypted and openssl is not installed to decrypt it", encPath)
+	}
+	out, err := exec.Command("openssl", "enc", "-d", "-aes-256-cbc", "-pbkdf2",
+		"-pass", "file:"+envCacheKeyPath(), "-in", encPath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the env cache %s with %s: %w", encPath, envCacheKeyPath(), err)
+	}
+	return parseDotenv(bytes.NewReader(out))
+}
+
+// readDotenv parses the KEY=VALUE lines of a dotenv file in order
+func readDotenv(path string) ([]EnvVar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseDotenv(file)
+}
+
+// parseDotenv parses dotenv content. Values lose their surrounding quotes;
+// single-quoted values, as written for secrets by the .envrc, are unescaped
+// the way the shell would.
+func parseDotenv(r io.Reader) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = strings.ReplaceAll(value[1:len(value)-1], `'\''`, "'")
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = value[1 : len(value)-1]
+		}
+		vars = append(vars, EnvVar{Name: strings.TrimSpace(name), Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
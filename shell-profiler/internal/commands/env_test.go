@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newEnvProfile creates a profile whose .env has a user secret appended and
+// whose resolved env cache holds a secret from the vault
+func newEnvProfile(t *testing.T) (profilesDir string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	profilesDir = t.TempDir()
+	if err := CreateProfile(profilesDir, CreateOptions{ProfileName: "svc", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	envPath := filepath.Join(profilesDir, "svc", ".env")
+	env, _ := os.ReadFile(envPath)
+	env = append(env, "\nAPI_TOKEN=\"plain-token\"\nLOG_DIR=$WORKSPACE_HOME/logs/$WORKSPACE_PROFILE\n"...)
+	if err := os.WriteFile(envPath, env, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := envCachePath("svc")
+	if err := os.MkdirAll(filepath.Dir(cache), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache, append(env, "\nGITHUB_TOKEN='s3cr'\\''et'\n"...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return profilesDir
+}
+
+func listEnv(t *testing.T, profilesDir string, opts EnvListOptions) map[string]EnvVar {
+	t.Helper()
+	opts.Format = "json"
+	out, err := captureStdout(t, func() error { return EnvList(profilesDir, "svc", opts) })
+	if err != nil {
+		t.Fatalf("EnvList() error: %v", err)
+	}
+	var vars []EnvVar
+	if err := json.Unmarshal([]byte(out), &vars); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	byName := make(map[string]EnvVar)
+	for _, v := range vars {
+		byName[v.Name] = v
+	}
+	return byName
+}
+
+func TestEnvList_ExpandsWorkspaceVars(t *testing.T) {
+	profilesDir := newEnvProfile(t)
+	profileDir := filepath.Join(profilesDir, "svc")
+
+	vars := listEnv(t, profilesDir, EnvListOptions{})
+	if got := vars["GIT_CONFIG_GLOBAL"].Value; got != filepath.Join(profileDir, ".gitconfig") {
+		t.Errorf("GIT_CONFIG_GLOBAL = %q, want the profile's .gitconfig", got)
+	}
+	if got, want := vars["LOG_DIR"].Value, profileDir+"/logs/svc"; got != want {
+		t.Errorf("LOG_DIR = %q, want %q", got, want)
+	}
+	for name, v := range vars {
+		if strings.Contains(v.Value, "$WORKSPACE_") {
+			t.Errorf("%s not expanded: %q", name, v.Value)
+		}
+	}
+	if _, ok := vars["GITHUB_TOKEN"]; ok {
+		t.Error("secrets listed without --secrets")
+	}
+}
+
+func TestEnvList_MasksSecrets(t *testing.T) {
+	profilesDir := newEnvProfile(t)
+
+	vars := listEnv(t, profilesDir, EnvListOptions{Secrets: true})
+	secret := vars["GITHUB_TOKEN"]
+	if secret.Source != EnvSourceSecret || !secret.Masked || secret.Value != maskedValue {
+		t.Errorf("GITHUB_TOKEN = %+v, want a masked secret", secret)
+	}
+	if token := vars["API_TOKEN"]; !token.Masked || token.Value != maskedValue {
+		t.Errorf("API_TOKEN = %+v, want it masked by name", token)
+	}
+	if v := vars["LOG_DIR"]; v.Masked || v.Source != EnvSourceFile {
+		t.Errorf("LOG_DIR = %+v, want an unmasked .env value", v)
+	}
+
+	vars = listEnv(t, profilesDir, EnvListOptions{Secrets: true, Reveal: true})
+	if got := vars["GITHUB_TOKEN"].Value; got != "s3cr'et" {
+		t.Errorf("revealed GITHUB_TOKEN = %q, want %q", got, "s3cr'et")
+	}
+	if got := vars["API_TOKEN"].Value; got != "plain-token" {
+		t.Errorf("revealed API_TOKEN = %q, want %q", got, "plain-token")
+	}
+}
+
+func TestEnvList_TextOutput(t *testing.T) {
+	profilesDir := newEnvProfile(t)
+
+	out, err := captureStdout(t, func() error {
+		return EnvList(profilesDir, "svc", EnvListOptions{Secrets: true})
+	})
+	if err != nil {
+		t.Fatalf("EnvList() error: %v", err)
+	}
+	if !strings.Contains(out, "GITHUB_TOKEN="+maskedValue) || strings.Contains(out, "s3cr") {
+		t.Errorf("secret not masked in text output:\n%s", out)
+	}
+
+	if err := EnvList(profilesDir, "missing", EnvListOptions{}); err == nil {
+		t.Error("EnvList() succeeded for a missing profile")
+	}
+}
+
+func TestEnvList_EncryptedCache(t *testing.T) {
+	profilesDir := newEnvProfile(t)
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not installed")
+	}
+	// Encrypt the cache the way the .envrc of --encrypt-cache does
+	plain := envCachePath("svc")
+	key := envCacheKeyPath()
+	if err := os.MkdirAll(filepath.Dir(key), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(key, []byte("0123456789abcdef\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("openssl", "enc", "-aes-256-cbc", "-pbkdf2", "-salt", "-pass", "file:"+key, "-in", plain, "-out", encryptedEnvCachePath("svc"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("openssl enc: %v\n%s", err, out)
+	}
+	if err := os.Remove(plain); err != nil {
+		t.Fatal(err)
+	}
+
+	vars := listEnv(t, profilesDir, EnvListOptions{Secrets: true, Reveal: true})
+	if got := vars["GITHUB_TOKEN"]; got.Source != EnvSourceSecret || got.Value != "s3cr'et" {
+		t.Errorf("GITHUB_TOKEN = %+v, want the secret from the encrypted cache", got)
+	}
+
+	// A cache that cannot be decrypted is reported, not taken as missing
+	if err := os.WriteFile(key, []byte("wrong key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := captureStdout(t, func() error { return EnvList(profilesDir, "svc", EnvListOptions{Secrets: true}) })
+	if err == nil || !strings.Contains(err.Error(), "decrypt") {
+		t.Errorf("EnvList() error = %v, want a decryption failure", err)
+	}
+}
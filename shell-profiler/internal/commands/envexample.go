@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+const envExampleHeader = `# Example environment variables
+# Copy this to .env and fill in your non-secret config
+# Secrets are loaded automatically from 1Password vault (workspace-<profile>)
+`
+
+// envExampleUserMarker follows the managed block; examples users add below
+// it survive regeneration
+const envExampleUserMarker = "# Add your own examples below; they are kept when .env.example is regenerated\n"
+
+// envExampleGeneric documents variables no managed tool owns
+const envExampleGeneric = `# API keys
+# API_KEY=your-api-key
+# API_SECRET=your-api-secret
+
+# Database
+# DATABASE_URL=postgresql://localhost:5432/mydb
+# REDIS_URL=redis://localhost:6379
+`
+
+type EnvExampleOptions struct {
+	Regenerate bool // Rewrite .env.example instead of only reporting drift
+	DryRun     bool
+}
+
+// renderEnvExample returns a fresh .env.example: the examples of every tool
+// in the registry inside the managed block, without the cloud tools for a
+// --no-cloud profile
+func renderEnvExample(noCloud bool) string {
+	var block strings.Builder
+	for _, tool := range tools.All() {
+		if len(tool.Examples) == 0 || (noCloud && tool.Cloud) {
+			continue
+		}
+		fmt.Fprintf(&block, "# %s\n", tool.ExampleTitle)
+		for _, v := range tool.Examples {
+			fmt.Fprintf(&block, "# %s=%s\n", v.Name, v.Value)
+		}
+		block.WriteString("\n")
+	}
+	block.WriteString(envExampleGeneric)
+
+	return envExampleHeader + "\n" + managed.Fence(block.String()) + "\n" + envExampleUserMarker
+}
+
+// missingEnvExamples lists the registry's example variables that content
+// does not mention
+func missingEnvExamples(content string, noCloud bool) []string {
+	present := envExampleNames(content)
+
+	var missing []string
+	for _, tool := range tools.All() {
+		if noCloud && tool.Cloud {
+			continue
+		}
+		for _, v := range tool.Examples {
+			if !present[v.Name] {
+				missing = append(missing, v.Name)
+			}
+		}
+	}
+	return missing
+}
+
+// envExampleNames returns the variables an .env.example documents, set or
+// commented out
+func envExampleNames(content string) map[string]bool {
+	names := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		if match := envAssignmentPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			names[match[1]] = true
+		}
+	}
+	return names
+}
+
+// regenerateEnvExample rewrites a profile's .env.example from the registry,
+// reporting whether it changed. Lines after the managed block are kept; in a
+// file from before the block existed, every line that is not part of the
+// generated content is kept after it.
+func regenerateEnvExample(profileDir string, noCloud, dryRun bool) (bool, error) {
+	path := filepath.Join(profileDir, ".env.example")
+	generated := renderEnvExample(noCloud)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read .env.example: %w", err)
+	}
+	existing := string(data)
+
+	content := generated
+	if err == nil {
+		content, err = managed.Regenerate(existing, generated)
+		if errors.Is(err, managed.ErrNoBlock) {
+			content, err = generated+userEnvExampleLines(existing, generated), nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to regenerate .env.example: %w", err)
+		}
+	}
+
+	if content == existing {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write .env.example: %w", err)
+	}
+	return true, nil
+}
+
+// userEnvExampleLines returns the non-blank lines of an unfenced
+// .env.example that the generated content does not contain
+func userEnvExampleLines(existing, generated string) string {
+	known := map[string]bool{}
+	for _, line := range strings.Split(generated, "\n") {
+		known[strings.TrimSpace(line)] = true
+	}
+
+	var kept strings.Builder
+	for _, line := range strings.Split(existing, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !known[trimmed] {
+			kept.WriteString(line + "\n")
+		}
+	}
+	return kept.String()
+}
+
+// checkEnvExample reports a .env.example that lacks examples now in the
+// registry or predates the managed block, regenerating it when fix is set
+func checkEnvExample(profileDir, _ string, fix bool) ([]Finding, error) {
+	data, err := os.ReadFile(filepath.Join(profileDir, ".env.example"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .env.example: %w", err)
+	}
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+
+	content := string(data)
+	var problems []string
+	if missing := missingEnvExamples(content, meta.NoCloud); len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing examples for %s", strings.Join(missing, ", ")))
+	}
+	repair := "regenerate the managed block of .env.example from the tool registry, keeping the lines added below it"
+	if _, err := managed.Split(content); errors.Is(err, managed.ErrNoBlock) {
+		problems = append(problems, "no managed block")
+		repair = "rewrite .env.example from the tool registry inside a managed block, keeping the lines that are not generated"
+	} else if err != nil {
+		return []Finding{{
+			Check:    "env-example",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf(".env.example has broken managed fences: %v", err),
+		}}, nil
+	}
+	if len(problems) == 0 {
+		return nil, nil
+	}
+
+	finding := Finding{
+		Check:    "env-example",
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf(".env.example is out of date (%s); run 'shell-profiler env example --regenerate'", strings.Join(problems, "; ")),
+		Repair:   repair,
+	}
+	if fix {
+		if _, err := regenerateEnvExample(profileDir, meta.NoCloud, false); err != nil {
+			return nil, err
+		}
+		finding.Fixed = true
+	}
+	return []Finding{finding}, nil
+}
+
+// EnvExample reports how the .env.example of the named profile, or else the
+// active profile, differs from the tool registry, and with opts.Regenerate
+// rewrites it, keeping the examples added below the managed block
+func EnvExample(profilesDir, profileName string, opts EnvExampleOptions) error {
+	if profileName == "" {
+		active, ok := profile.ActiveProfileIn(profilesDir)
+		if !ok {
+			return fmt.Errorf("no profile specified and WORKSPACE_PROFILE is not set")
+		}
+		profileName = active
+	}
+	if err := checkProfilesDir(profilesDir, opts.Regenerate && !opts.DryRun); err != nil {
+		return err
+	}
+	profileDir := filepath.Join(profilesDir, profileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", profileName, profileDir)
+	}
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+
+	path := filepath.Join(profileDir, ".env.example")
+	if !opts.Regenerate {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			ui.PrintWarning(fmt.Sprintf("%s does not exist; create it with --regenerate", path))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read .env.example: %w", err)
+		}
+		missing := missingEnvExamples(string(data), meta.NoCloud)
+		if len(missing) == 0 {
+			ui.PrintSuccess(".env.example documents every tool's examples")
+			return nil
+		}
+		ui.PrintWarning(".env.example is missing examples for:")
+		for _, name := range missing {
+			fmt.Printf("  %s\n", name)
+		}
+		fmt.Println("  Run with --regenerate to add them")
+		return nil
+	}
+
+	if !opts.DryRun {
+		lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+	}
+	changed, err := regenerateEnvExample(profileDir, meta.NoCloud, opts.DryRun)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !changed:
+		ui.PrintInfo(".env.example is already up to date")
+	case opts.DryRun:
+		ui.PrintInfo(fmt.Sprintf("DRY RUN - Would regenerate %s", path))
+	default:
+		ui.PrintSuccess(fmt.Sprintf("Regenerated %s", path))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
+)
+
+func TestRenderEnvExample(t *testing.T) {
+	content := renderEnvExample(false)
+	if _, err := managed.Split(content); err != nil {
+		t.Fatalf("rendered .env.example has no managed block: %v", err)
+	}
+	for _, want := range []string{"# AWS_ACCESS_KEY_ID=your-access-key\n", "# ANTHROPIC_API_KEY=", "# GEMINI_API_KEY=", "# DATABASE_URL="} {
+		if !strings.Contains(content, want) {
+			t.Errorf(".env.example missing %q", want)
+		}
+	}
+	if missing := missingEnvExamples(content, false); len(missing) != 0 {
+		t.Errorf("missingEnvExamples() of a fresh render = %v", missing)
+	}
+
+	local := renderEnvExample(true)
+	if strings.Contains(local, "AWS_") || strings.Contains(local, "AZURE_") || strings.Contains(local, "GCP_") {
+		t.Errorf("--no-cloud .env.example documents cloud credentials:\n%s", local)
+	}
+	if !strings.Contains(local, "# GEMINI_API_KEY=") {
+		t.Error("--no-cloud .env.example should keep the non-cloud tools")
+	}
+}
+
+func TestRegenerateEnvExample_AddsMissingKeepsCustom(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	path := filepath.Join(profileDir, ".env.example")
+	original, _ := os.ReadFile(path)
+
+	// As if created before gemini was registered, with an example of the user's own
+	stale := strings.Replace(string(original), "# GEMINI_API_KEY=your-gemini-api-key\n", "", 1)
+	stale += "# STRIPE_KEY=sk_test_example\n"
+	if err := os.WriteFile(path, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := checkEnvExample(profileDir, "acme", false)
+	if err != nil {
+		t.Fatalf("checkEnvExample() error: %v", err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "GEMINI_API_KEY") {
+		t.Fatalf("checkEnvExample() = %+v, want a finding naming GEMINI_API_KEY", findings)
+	}
+
+	if err := EnvExample(tmpDir, "acme", EnvExampleOptions{Regenerate: true, DryRun: true}); err != nil {
+		t.Fatalf("EnvExample(dry run) error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != stale {
+		t.Error("dry run should not change .env.example")
+	}
+
+	if err := EnvExample(tmpDir, "acme", EnvExampleOptions{Regenerate: true}); err != nil {
+		t.Fatalf("EnvExample() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := string(original) + "# STRIPE_KEY=sk_test_example\n"; string(data) != want {
+		t.Errorf("regenerated .env.example =\n%s\nwant:\n%s", data, want)
+	}
+	if findings, _ := checkEnvExample(profileDir, "acme", false); len(findings) != 0 {
+		t.Errorf("checkEnvExample() after regenerating = %+v", findings)
+	}
+}
+
+func TestCheckEnvExample_FixesUnfencedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	path := filepath.Join(profileDir, ".env.example")
+
+	legacy := envExampleHeader + "\n# AWS credentials\n# AWS_ACCESS_KEY_ID=your-access-key\n\n# Internal\n# VAULT_ADDR=https://vault.example\n"
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := checkEnvExample(profileDir, "acme", true)
+	if err != nil {
+		t.Fatalf("checkEnvExample() error: %v", err)
+	}
+	if len(findings) != 1 || !findings[0].Fixed {
+		t.Fatalf("checkEnvExample(fix) = %+v, want one fixed finding", findings)
+	}
+	data, _ := os.ReadFile(path)
+	want := renderEnvExample(false) + "# Internal\n# VAULT_ADDR=https://vault.example\n"
+	if string(data) != want {
+		t.Errorf("fixed .env.example =\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+// Exit statuses of exists
+const (
+	ExistsValid   = 0 // The profile exists and has a .envrc
+	ExistsInvalid = 1 // The profile directory exists without a .envrc
+	ExistsAbsent  = 2 // There is no profile directory
+)
+
+type ExistsOptions struct {
+	ProfileName string
+	Quiet       bool // Print nothing; only the exit status reports the state
+}
+
+// isProfileDir reports whether dir is a valid profile, which is a directory
+// with a .envrc
+func isProfileDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".envrc"))
+	return err == nil
+}
+
+// ProfileState returns ExistsValid, ExistsInvalid or ExistsAbsent for the
+// named profile
+func ProfileState(profilesDir, profileName string) int {
+	profileDir := filepath.Join(profilesDir, profileName)
+	info, err := os.Stat(profileDir)
+	switch {
+	case err != nil:
+		return ExistsAbsent
+	case !info.IsDir() || !isProfileDir(profileDir):
+		return ExistsInvalid
+	}
+	return ExistsValid
+}
+
+// ProfileExists reports the state of the named profile. A profile that is
+// not valid yields an errs.ExitError with ExistsInvalid or ExistsAbsent
+// as its status, and no message.
+func ProfileExists(profilesDir string, opts ExistsOptions) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if filepath.Base(opts.ProfileName) != opts.ProfileName {
+		return errs.Wrapf(errs.ErrInvalidName, "invalid profile name: %s", opts.ProfileName)
+	}
+
+	// A missing profiles directory holds no profiles, which is the absent
+	// state; one that is unusable is an error
+	if err := checkProfilesDir(profilesDir, false); err != nil && !errors.Is(err, errs.ErrProfileNotFound) {
+		return err
+	}
+
+	state := ProfileState(profilesDir, opts.ProfileName)
+	if !opts.Quiet {
+		switch state {
+		case ExistsValid:
+			fmt.Printf("Profile '%s' exists\n", opts.ProfileName)
+		case ExistsInvalid:
+			fmt.Printf("Profile '%s' exists but is not valid (no .envrc)\n", opts.ProfileName)
+		default:
+			fmt.Printf("Profile '%s' does not exist\n", opts.ProfileName)
+		}
+	}
+	if state != ExistsValid {
+		return &errs.ExitError{Status: state}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+func TestProfileExists_ExitStatus(t *testing.T) {
+	profilesDir := t.TempDir()
+	newPathProfile(t, profilesDir, "valid")
+	if err := os.MkdirAll(filepath.Join(profilesDir, "broken"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"valid", ExistsValid},
+		{"broken", ExistsInvalid},
+		{"missing", ExistsAbsent},
+	}
+	for _, tt := range tests {
+		out, err := captureStdout(t, func() error {
+			return ProfileExists(profilesDir, ExistsOptions{ProfileName: tt.name, Quiet: true})
+		})
+		if got := errs.ExitStatus(err); got != tt.want {
+			t.Errorf("exists %s: exit status %d, want %d (err=%v)", tt.name, got, tt.want, err)
+		}
+		if out != "" {
+			t.Errorf("exists %s --quiet printed %q", tt.name, out)
+		}
+		if err != nil && err.Error() != "" {
+			t.Errorf("exists %s: error has a message: %v", tt.name, err)
+		}
+	}
+
+	out, _ := captureStdout(t, func() error {
+		return ProfileExists(profilesDir, ExistsOptions{ProfileName: "broken"})
+	})
+	if out != "Profile 'broken' exists but is not valid (no .envrc)\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
@@ -0,0 +1,510 @@
+package commands
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// exportManifestName is the file at the top of an export archive listing the
+// profiles it holds
+const exportManifestName = "manifest.json"
+
+// exportFormatVersion is bumped when the archive layout changes
+const exportFormatVersion = 1
+
+type ExportOptions struct {
+	Force   bool        // Overwrite an existing archive
+	Exclude []string    // Extra globs, relative to the profile, to leave out
+	Clock   clock.Clock // Time source for the manifest; the system clock if nil
+}
+
+type ImportOptions struct {
+	Force bool // Replace profiles that already exist
+}
+
+// ExportManifest describes the profiles in an export archive
+type ExportManifest struct {
+	Version  int                   `json:"version"`
+	Exported string                `json:"exported"`
+	Profiles []ExportedProfileInfo `json:"profiles"`
+}
+
+// ExportedProfileInfo is a manifest entry. Excluded lists the files left out
+// of the archive because the profile's .gitignore or an --exclude pattern
+// matches them.
+type ExportedProfileInfo struct {
+	Name     string        `json:"name"`
+	Meta     *profile.Meta `json:"meta,omitempty"`
+	Excluded []string      `json:"excluded,omitempty"`
+}
+
+// ExportAll writes every profile to a single .tar.gz at outPath, with a
+// manifest. Files the profile's .gitignore matches, such as .env and keys,
+// are left out, as are .git directories, so the archive holds no secrets.
+func ExportAll(profilesDir, outPath string, opts ExportOptions) error {
+	if _, err := os.Stat(outPath); err == nil && !opts.Force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", outPath)
+	}
+	exclude, err := parseExcludePatterns(opts.Exclude)
+	if err != nil {
+		return err
+	}
+	names, err := profileNames(profilesDir)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no profiles to export in %s", profilesDir)
+	}
+
+	file, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outPath, err)
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	now := clock.Or(opts.Clock).Now()
+	manifest := ExportManifest{
+		Version:  exportFormatVersion,
+		Exported: now.UTC().Format(time.RFC3339),
+	}
+	writeErr := func() error {
+		for _, name := range names {
+			info, err := exportProfile(tw, filepath.Join(profilesDir, name), name, exclude)
+			if err != nil {
+				return fmt.Errorf("failed to export profile '%s': %w", name, err)
+			}
+			manifest.Profiles = append(manifest.Profiles, info)
+		}
+		content, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		header := &tar.Header{Name: exportManifestName, Mode: 0644, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	}()
+
+	// Close in order so the archive is complete
+	if err := tw.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if err := gz.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if err := file.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		os.Remove(outPath)
+		return writeErr
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Exported %d profile(s) to %s", len(names), outPath))
+	for _, p := range manifest.Profiles {
+		fmt.Printf("  %s (%d file(s) excluded)\n", p.Name, len(p.Excluded))
+	}
+	return nil
+}
+
+// exportProfile adds one profile to the archive under <name>/ and returns
+// its manifest entry. Paths matching exclude are left out as well as those
+// the profile's .gitignore matches; exclude cannot re-include them.
+func exportProfile(tw *tar.Writer, profileDir, name string, exclude exportIgnore) (ExportedProfileInfo, error) {
+	info := ExportedProfileInfo{Name: name}
+	if meta, err := profile.LoadMeta(profileDir); err == nil {
+		info.Meta = meta
+	}
+	ignore, err := loadExportIgnore(profileDir)
+	if err != nil {
+		return info, err
+	}
+
+	err = filepath.Walk(profileDir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(profileDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if fi.IsDir() && fi.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if ignore.matches(rel, fi.IsDir()) || exclude.matches(rel, fi.IsDir()) {
+			info.Excluded = append(info.Excluded, rel)
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		} else if !fi.Mode().IsRegular() && !fi.IsDir() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name + "/" + rel
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	return info, err
+}
+
+// ImportAll restores the profiles of an archive written by ExportAll into
+// profilesDir. Profiles that already exist are skipped unless opts.Force is
+// set, in which case they are replaced. Excluded secrets are not restored;
+// the profiles need their .env recreated. Hooks in the profiles'
+// .profile-meta are removed, so nothing from the archive runs unreviewed.
+func ImportAll(archivePath, profilesDir string, opts ImportOptions) error {
+	manifest, err := readExportManifest(archivePath)
+	if err != nil {
+		return err
+	}
+	if manifest.Version > exportFormatVersion {
+		return fmt.Errorf("%s was exported by a newer version (format %d, supported %d)", archivePath, manifest.Version, exportFormatVersion)
+	}
+	if err := createProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	restore := make(map[string]bool)
+	for _, p := range manifest.Profiles {
+		if filepath.Base(p.Name) != p.Name || p.Name == "." || p.Name == ".." {
+			return fmt.Errorf("invalid profile name in manifest: %q", p.Name)
+		}
+		if _, err := os.Stat(filepath.Join(profilesDir, p.Name)); err == nil && !opts.Force {
+			ui.PrintWarning(fmt.Sprintf("Skipping %s: profile already exists (use --force to replace it)", p.Name))
+			continue
+		}
+		restore[p.Name] = true
+	}
+
+	// Extract into a staging directory so a bad archive leaves the existing
+	// profiles untouched; it lives in profilesDir so the swap is a rename
+	staging, err := os.MkdirTemp(profilesDir, ".import-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	if err := extractExport(archivePath, staging, restore); err != nil {
+		return err
+	}
+	strippedHooks := make(map[string]map[string]string)
+	for name := range restore {
+		if _, err := os.Stat(filepath.Join(staging, name)); err != nil {
+			return fmt.Errorf("%s holds no files for profile '%s'", archivePath, name)
+		}
+		stripped, err := stripProfileHooks(filepath.Join(staging, name))
+		if err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+		if len(stripped) > 0 {
+			strippedHooks[name] = stripped
+		}
+	}
+	for name := range restore {
+		if err := swapInProfile(filepath.Join(staging, name), filepath.Join(profilesDir, name)); err != nil {
+			return fmt.Errorf("failed to restore profile '%s': %w", name, err)
+		}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Imported %d profile(s) into %s", len(restore), profilesDir))
+	for _, p := range manifest.Profiles {
+		if restore[p.Name] {
+			fmt.Printf("  %s\n", p.Name)
+		}
+	}
+	for _, p := range manifest.Profiles {
+		stripped := strippedHooks[p.Name]
+		if len(stripped) == 0 {
+			continue
+		}
+		ui.PrintWarning(fmt.Sprintf("Removed the hooks of %s from its %s; review them and add them back to run them:", p.Name, profile.MetaFileName))
+		hookNames := make([]string, 0, len(stripped))
+		for hook := range stripped {
+			hookNames = append(hookNames, hook)
+		}
+		sort.Strings(hookNames)
+		for _, hook := range hookNames {
+			fmt.Printf("  %s: %s\n", hook, stripped[hook])
+		}
+	}
+	if len(restore) > 0 {
+		ui.PrintInfo("Secrets were not exported: recreate each profile's .env and run 'direnv allow' in it")
+	}
+	return nil
+}
+
+// openExport calls fn for each entry of an export archive
+func openExport(archivePath string, fn func(header *tar.Header, r io.Reader) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", archivePath, err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", archivePath, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", archivePath, err)
+		}
+		if err := fn(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readExportManifest returns the manifest of an export archive
+func readExportManifest(archivePath string) (*ExportManifest, error) {
+	var manifest *ExportManifest
+	err := openExport(archivePath, func(header *tar.Header, r io.Reader) error {
+		if header.Name != exportManifestName {
+			return nil
+		}
+		manifest = &ExportManifest{}
+		if err := json.NewDecoder(r).Decode(manifest); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", exportManifestName, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a profile export: no %s", archivePath, exportManifestName)
+	}
+	return manifest, nil
+}
+
+// swapInProfile moves the extracted profile at src to dest, replacing any
+// existing profile only once the new one is in place
+func swapInProfile(src, dest string) error {
+	old := ""
+	if _, err := os.Lstat(dest); err == nil {
+		old = src + ".old"
+		if err := os.Rename(dest, old); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(src, dest); err != nil {
+		if old != "" {
+			os.Rename(old, dest)
+		}
+		return err
+	}
+	if old != "" {
+		return os.RemoveAll(old)
+	}
+	return nil
+}
+
+// extractExport extracts the files of the named profiles into destDir.
+// Entries may not leave their profile, whether by path, by a symlink
+// pointing outside it, or by being written through a symlink.
+func extractExport(archivePath, destDir string, restore map[string]bool) error {
+	return openExport(archivePath, func(header *tar.Header, r io.Reader) error {
+		name, _, _ := strings.Cut(header.Name, "/")
+		if !restore[name] {
+			return nil
+		}
+		root := filepath.Join(destDir, name)
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		if !withinDir(root, target) {
+			return fmt.Errorf("refusing to extract %s outside of profile '%s'", header.Name, name)
+		}
+		if err := checkNoSymlinks(root, target); err != nil {
+			return fmt.Errorf("refusing to extract %s: %w", header.Name, err)
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+		case tar.TypeSymlink:
+			if filepath.IsAbs(header.Linkname) || !withinDir(root, filepath.Join(filepath.Dir(target), header.Linkname)) {
+				return fmt.Errorf("refusing to extract symlink %s pointing outside of profile '%s'", header.Name, name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return fmt.Errorf("failed to create symlink %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+			if _, err := io.Copy(out, r); err != nil {
+				out.Close()
+				return fmt.Errorf("failed to extract %s: %w", target, err)
+			}
+			if err := out.Close(); err != nil {
+				return fmt.Errorf("failed to extract %s: %w", target, err)
+			}
+		}
+		return nil
+	})
+}
+
+// withinDir reports whether path is dir or lies under it, by path text alone
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// checkNoSymlinks fails if target or any directory between root and it is an
+// existing symlink, so nothing is written through one
+func checkNoSymlinks(root, target string) error {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return err
+	}
+	current := root
+	for _, part := range append([]string{"."}, strings.Split(rel, string(os.PathSeparator))...) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", current)
+		}
+	}
+	return nil
+}
+
+// exportIgnore holds gitignore patterns, applied in order so that a later
+// negated pattern can re-include a path
+type exportIgnore []ignorePattern
+
+type ignorePattern struct {
+	glob    string
+	negate  bool
+	dirOnly bool
+	rooted  bool // Matched against the whole relative path, not just the name
+}
+
+// loadExportIgnore reads the profile's .gitignore, falling back to the one
+// profiles are created with
+func loadExportIgnore(profileDir string) (exportIgnore, error) {
+	content, err := os.ReadFile(filepath.Join(profileDir, ".gitignore"))
+	if os.IsNotExist(err) {
+		content = []byte(profileGitignore)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	var patterns exportIgnore
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p ignorePattern
+		if p.negate = strings.HasPrefix(line, "!"); p.negate {
+			line = line[1:]
+		}
+		if p.dirOnly = strings.HasSuffix(line, "/"); p.dirOnly {
+			line = strings.TrimSuffix(line, "/")
+		}
+		p.rooted = strings.Contains(line, "/")
+		p.glob = strings.TrimPrefix(line, "/")
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// parseExcludePatterns turns --exclude globs into patterns matched against
+// the whole path relative to the profile. A trailing / matches directories
+// only.
+func parseExcludePatterns(globs []string) (exportIgnore, error) {
+	var patterns exportIgnore
+	for _, glob := range globs {
+		p := ignorePattern{rooted: true}
+		if p.dirOnly = strings.HasSuffix(glob, "/"); p.dirOnly {
+			glob = strings.TrimSuffix(glob, "/")
+		}
+		p.glob = strings.TrimPrefix(filepath.ToSlash(glob), "/")
+		if _, err := path.Match(p.glob, ""); p.glob == "" || err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %q", glob)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// matches reports whether the slash-separated path rel, relative to the
+// profile, is ignored
+func (ig exportIgnore) matches(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range ig {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		subject := rel
+		if !p.rooted {
+			subject = path.Base(rel)
+		}
+		if ok, _ := path.Match(p.glob, subject); ok {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestExportAll_ImportAllRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profilesDir := t.TempDir()
+	for _, name := range []string{"alpha", "beta"} {
+		if err := CreateProfile(profilesDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+	}
+	secrets := []string{".envrc.local", ".ssh/id_ed25519", ".aws/credentials"}
+	for _, rel := range append(secrets, "notes.txt", ".git/HEAD") {
+		path := filepath.Join(profilesDir, "alpha", rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "profiles.tar.gz")
+	if _, err := captureStdout(t, func() error { return ExportAll(profilesDir, archive, ExportOptions{}) }); err != nil {
+		t.Fatalf("ExportAll() error: %v", err)
+	}
+	if err := ExportAll(profilesDir, archive, ExportOptions{}); err == nil {
+		t.Error("ExportAll() overwrote an existing archive without --force")
+	}
+
+	manifest, err := readExportManifest(archive)
+	if err != nil {
+		t.Fatalf("readExportManifest() error: %v", err)
+	}
+	if len(manifest.Profiles) != 2 || manifest.Profiles[0].Name != "alpha" || manifest.Profiles[1].Name != "beta" {
+		t.Fatalf("manifest profiles = %+v", manifest.Profiles)
+	}
+	if meta := manifest.Profiles[0].Meta; meta == nil || meta.Template != "basic" {
+		t.Errorf("manifest meta = %+v, want the profile's metadata", meta)
+	}
+	for _, rel := range append(secrets, ".env") {
+		if !slices.Contains(manifest.Profiles[0].Excluded, rel) {
+			t.Errorf("manifest does not list %s as excluded: %v", rel, manifest.Profiles[0].Excluded)
+		}
+	}
+
+	targetDir := filepath.Join(t.TempDir(), "restored")
+	if _, err := captureStdout(t, func() error { return ImportAll(archive, targetDir, ImportOptions{}) }); err != nil {
+		t.Fatalf("ImportAll() error: %v", err)
+	}
+	for _, rel := range []string{"alpha/.envrc", "alpha/.gitconfig", "alpha/.profile-meta", "alpha/notes.txt", "beta/.envrc"} {
+		want, _ := os.ReadFile(filepath.Join(profilesDir, rel))
+		got, err := os.ReadFile(filepath.Join(targetDir, rel))
+		if err != nil || string(got) != string(want) {
+			t.Errorf("%s not restored intact (err=%v)", rel, err)
+		}
+	}
+	for _, rel := range append(secrets, ".env", ".git") {
+		if _, err := os.Stat(filepath.Join(targetDir, "alpha", rel)); !os.IsNotExist(err) {
+			t.Errorf("excluded %s was restored", rel)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(targetDir, "alpha", "notes.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("notes.txt mode not preserved: %v %v", info, err)
+	}
+}
+
+func TestImportAll_SkipsExistingProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profilesDir := t.TempDir()
+	if err := CreateProfile(profilesDir, CreateOptions{ProfileName: "alpha", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "profiles.tar.gz")
+	if _, err := captureStdout(t, func() error { return ExportAll(profilesDir, archive, ExportOptions{}) }); err != nil {
+		t.Fatal(err)
+	}
+
+	// The local .env survives an import over the same profile
+	envPath := filepath.Join(profilesDir, "alpha", ".env")
+	if _, err := captureStdout(t, func() error { return ImportAll(archive, profilesDir, ImportOptions{}) }); err != nil {
+		t.Fatalf("ImportAll() error: %v", err)
+	}
+	if _, err := os.Stat(envPath); err != nil {
+		t.Errorf("existing profile was replaced without --force: %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error { return ImportAll(archive, profilesDir, ImportOptions{Force: true}) }); err != nil {
+		t.Fatalf("ImportAll(--force) error: %v", err)
+	}
+	if _, err := os.Stat(envPath); !os.IsNotExist(err) {
+		t.Error("--force did not replace the existing profile")
+	}
+}
+
+func TestExportAll_ExcludePatterns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profilesDir := t.TempDir()
+	if err := CreateProfile(profilesDir, CreateOptions{ProfileName: "alpha", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{".terraform.d/plugins/aws", "code/app/node_modules/x.js", "code/app/main.go", "big.log", "logs/keep.txt"} {
+		path := filepath.Join(profilesDir, "alpha", rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "profiles.tar.gz")
+	opts := ExportOptions{Exclude: []string{".terraform.d/", "code/*/node_modules", "*.log", "!.env"}}
+	if _, err := captureStdout(t, func() error { return ExportAll(profilesDir, archive, opts) }); err != nil {
+		t.Fatalf("ExportAll() error: %v", err)
+	}
+	targetDir := t.TempDir()
+	if _, err := captureStdout(t, func() error { return ImportAll(archive, targetDir, ImportOptions{}) }); err != nil {
+		t.Fatal(err)
+	}
+
+	for rel, want := range map[string]bool{
+		".terraform.d":               false,
+		"code/app/node_modules/x.js": false,
+		"big.log":                    false,
+		".env":                       false, // Built-in exclusions still apply
+		"code/app/main.go":           true,
+		"logs/keep.txt":              true,
+		".envrc":                     true,
+	} {
+		_, err := os.Stat(filepath.Join(targetDir, "alpha", rel))
+		if got := err == nil; got != want {
+			t.Errorf("%s restored = %v, want %v", rel, got, want)
+		}
+	}
+
+	if err := ExportAll(profilesDir, archive, ExportOptions{Force: true, Exclude: []string{"[bad"}}); err == nil {
+		t.Error("ExportAll() accepted a malformed pattern")
+	}
+}
+
+// writeTestExport writes an export archive holding a manifest for the named
+// profiles followed by the given entries
+func writeTestExport(t *testing.T, names []string, entries []*tar.Header) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "profiles.tar.gz")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	manifest := ExportManifest{Version: exportFormatVersion}
+	for _, name := range names {
+		manifest.Profiles = append(manifest.Profiles, ExportedProfileInfo{Name: name})
+	}
+	content, _ := json.Marshal(manifest)
+	entries = append([]*tar.Header{{Name: exportManifestName, Mode: 0644, Size: int64(len(content))}}, entries...)
+	for i, header := range entries {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			tw.Write(content)
+		} else if header.Typeflag == tar.TypeReg {
+			tw.Write(make([]byte, header.Size))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	file.Close()
+	return archive
+}
+
+func TestImportAll_RejectsSymlinkEscape(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	outside := t.TempDir()
+	tests := []struct {
+		name     string
+		linkname string
+	}{
+		{"absolute link", outside},
+		{"relative link", "../../" + filepath.Base(outside)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profilesDir := t.TempDir()
+			if err := CreateProfile(profilesDir, CreateOptions{ProfileName: "alpha", Template: "basic"}); err != nil {
+				t.Fatal(err)
+			}
+			envPath := filepath.Join(profilesDir, "alpha", ".env")
+			archive := writeTestExport(t, []string{"alpha"}, []*tar.Header{
+				{Name: "alpha/.envrc", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+				{Name: "alpha/escape", Typeflag: tar.TypeSymlink, Linkname: tt.linkname, Mode: 0777},
+				{Name: "alpha/escape/pwned", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+			})
+
+			_, err := captureStdout(t, func() error { return ImportAll(archive, profilesDir, ImportOptions{Force: true}) })
+			if err == nil {
+				t.Fatal("ImportAll() accepted a symlink pointing outside the profile")
+			}
+			if _, err := os.Stat(filepath.Join(outside, "pwned")); !os.IsNotExist(err) {
+				t.Error("ImportAll() wrote a file outside the profiles directory")
+			}
+			if _, err := os.Stat(envPath); err != nil {
+				t.Errorf("a failed --force import destroyed the existing profile: %v", err)
+			}
+		})
+	}
+}
+
+func TestImportAll_RefusesToWriteThroughSymlink(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profilesDir := t.TempDir()
+	archive := writeTestExport(t, []string{"alpha"}, []*tar.Header{
+		{Name: "alpha/.envrc", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+		{Name: "alpha/data", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "alpha/link", Typeflag: tar.TypeSymlink, Linkname: "data", Mode: 0777},
+		{Name: "alpha/link/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+	})
+	if _, err := captureStdout(t, func() error { return ImportAll(archive, profilesDir, ImportOptions{}) }); err == nil {
+		t.Error("ImportAll() wrote through a symlink")
+	}
+	if _, err := os.Stat(filepath.Join(profilesDir, "alpha")); !os.IsNotExist(err) {
+		t.Error("a failed import left a partial profile behind")
+	}
+}
+
+func TestImportAll_StripsHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profilesDir := t.TempDir()
+	if err := CreateProfile(profilesDir, CreateOptions{ProfileName: "alpha", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	profileDir := filepath.Join(profilesDir, "alpha")
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Hooks = map[string]string{hooks.PreUpdate: "hooks/pre-update.sh"}
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "profiles.tar.gz")
+	if _, err := captureStdout(t, func() error { return ExportAll(profilesDir, archive, ExportOptions{}) }); err != nil {
+		t.Fatal(err)
+	}
+
+	targetDir := t.TempDir()
+	out, err := captureStdout(t, func() error { return ImportAll(archive, targetDir, ImportOptions{}) })
+	if err != nil {
+		t.Fatalf("ImportAll() error: %v", err)
+	}
+	if !strings.Contains(out, "hooks/pre-update.sh") {
+		t.Errorf("import did not report the removed hook:\n%s", out)
+	}
+	if script := profileHook(filepath.Join(targetDir, "alpha"), hooks.PreUpdate); script != "" {
+		t.Errorf("imported profile still runs hook %s", script)
+	}
+}
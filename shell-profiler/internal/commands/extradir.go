@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+// extraDirMode is the mode of extra directories given without one
+const extraDirMode os.FileMode = 0755
+
+// extraDir is a user directory created alongside the registry's, parsed
+// from a PATH[:MODE] spec such as ".config/acme:0700"
+type extraDir struct {
+	Path string
+	Mode os.FileMode
+}
+
+// parseExtraDir parses and validates an --extra-dir spec. The path must be
+// relative and stay inside the profile; the mode is octal.
+func parseExtraDir(spec string) (extraDir, error) {
+	path, mode := spec, extraDirMode
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		value, err := strconv.ParseUint(spec[i+1:], 8, 32)
+		if err != nil || value > 0777 {
+			return extraDir{}, fmt.Errorf("invalid extra directory %q: mode must be octal, like 0700", spec)
+		}
+		path, mode = spec[:i], os.FileMode(value)
+	}
+
+	if strings.TrimSpace(path) == "" {
+		return extraDir{}, fmt.Errorf("extra directory cannot be empty")
+	}
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return extraDir{}, fmt.Errorf("invalid extra directory %q: must be relative to the profile", spec)
+	}
+	if mode&0700 != 0700 {
+		return extraDir{}, fmt.Errorf("invalid extra directory %q: the owner needs rwx", spec)
+	}
+	return extraDir{Path: clean, Mode: mode}, nil
+}
+
+// createExtraDirs creates the extra directories of specs that are missing
+// and returns their paths. Existing directories keep their mode.
+func createExtraDirs(profileDir string, specs []string, dryRun bool) ([]string, error) {
+	var created []string
+	for _, spec := range specs {
+		dir, err := parseExtraDir(spec)
+		if err != nil {
+			return nil, err
+		}
+		fullPath := filepath.Join(profileDir, dir.Path)
+		if _, err := os.Stat(fullPath); err == nil {
+			continue
+		}
+		if !dryRun {
+			if err := os.MkdirAll(fullPath, dir.Mode); err != nil {
+				return nil, fmt.Errorf("failed to create directory %s: %w", dir.Path, err)
+			}
+			// MkdirAll is subject to the umask
+			if err := os.Chmod(fullPath, dir.Mode); err != nil {
+				return nil, fmt.Errorf("failed to set permissions on %s: %w", dir.Path, err)
+			}
+		}
+		created = append(created, dir.Path)
+	}
+	return created, nil
+}
+
+// profileExtraDirs returns the extra directories recorded for a profile
+func profileExtraDirs(profileDir string) []string {
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		return nil
+	}
+	return meta.ExtraDirs
+}
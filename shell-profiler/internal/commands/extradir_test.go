@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseExtraDir(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    extraDir
+		wantErr bool
+	}{
+		{spec: ".config/acme", want: extraDir{Path: ".config/acme", Mode: 0755}},
+		{spec: ".config/acme:0700", want: extraDir{Path: ".config/acme", Mode: 0700}},
+		{spec: "data/./cache/:750", want: extraDir{Path: "data/cache", Mode: 0750}},
+		{spec: "", wantErr: true},
+		{spec: ":0700", wantErr: true},
+		{spec: "/etc/acme", wantErr: true},
+		{spec: "../outside", wantErr: true},
+		{spec: ".config/acme:rwx", wantErr: true},
+		{spec: ".config/acme:0999", wantErr: true},
+		{spec: ".config/acme:1777", wantErr: true},
+		{spec: ".config/acme:0500", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseExtraDir(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseExtraDir(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseExtraDir(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCreateProfile_ExtraDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{
+		ProfileName: "extra",
+		Template:    "basic",
+		ExtraDirs:   []string{".config/acme:0700", "data/cache"},
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "extra")
+
+	for dir, mode := range map[string]os.FileMode{".config/acme": 0700, "data/cache": 0755} {
+		info, err := os.Stat(filepath.Join(profileDir, dir))
+		if err != nil {
+			t.Fatalf("extra directory %s not created: %v", dir, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %o, want %o", dir, info.Mode().Perm(), mode)
+		}
+	}
+
+	// Update re-creates a missing extra directory with its mode, even on a
+	// profile with no migrations pending
+	if err := os.Remove(filepath.Join(profileDir, ".config/acme")); err != nil {
+		t.Fatal(err)
+	}
+	out, err := captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "extra", NoBackup: true})
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if !strings.Contains(out, ".config/acme") {
+		t.Errorf("update did not report the re-created directory:\n%s", out)
+	}
+	info, err := os.Stat(filepath.Join(profileDir, ".config/acme"))
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf(".config/acme not re-created with 0700: %v", err)
+	}
+}
+
+func TestCreateProfile_InvalidExtraDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{
+		ProfileName: "extra",
+		Template:    "basic",
+		ExtraDirs:   []string{"../escape"},
+	})
+	if err == nil {
+		t.Fatal("CreateProfile() should reject an extra directory outside the profile")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "extra")); err == nil {
+		t.Error("profile should not be created")
+	}
+}
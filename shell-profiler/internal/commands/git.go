@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
@@ -18,11 +19,14 @@ type GitOptions struct {
 
 // InitGit initializes a git repository in the profile directory
 func InitGit(profilesDir string, opts GitOptions) error {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
 	profileDir := filepath.Join(profilesDir, opts.ProfileName)
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Check if already a git repo
@@ -32,6 +36,15 @@ func InitGit(profilesDir string, opts GitOptions) error {
 		return nil
 	}
 
+	// A repository inside another one is easily committed by mistake as an
+	// embedded repository, or half-tracked by the outer one
+	if outer := enclosingGitRepo(profileDir); outer != "" {
+		if !opts.Force {
+			return fmt.Errorf("profile '%s' is inside the git repository at %s; initializing would nest a repository in it (use --force to do it anyway)", opts.ProfileName, outer)
+		}
+		ui.PrintWarning(fmt.Sprintf("Creating a nested git repository inside %s", outer))
+	}
+
 	ui.PrintInfo(fmt.Sprintf("Initializing git repository for profile: %s", opts.ProfileName))
 
 	// Initialize git repository
@@ -73,13 +86,31 @@ func InitGit(profilesDir string, opts GitOptions) error {
 	return nil
 }
 
+// enclosingGitRepo returns the working tree above dir that has a .git
+// directory, or a .git file as worktrees and submodules do, or "" if none
+func enclosingGitRepo(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for parent := filepath.Dir(dir); parent != dir; dir, parent = parent, filepath.Dir(parent) {
+		if _, err := os.Stat(filepath.Join(parent, ".git")); err == nil {
+			return parent
+		}
+	}
+	return ""
+}
+
 // PullGit pulls changes from the remote repository
 func PullGit(profilesDir string, opts GitOptions) error {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
 	profileDir := filepath.Join(profilesDir, opts.ProfileName)
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Check if it's a git repo
@@ -120,11 +151,14 @@ func PullGit(profilesDir string, opts GitOptions) error {
 
 // PushGit pushes local changes to the remote repository
 func PushGit(profilesDir string, opts GitOptions) error {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
 	profileDir := filepath.Join(profilesDir, opts.ProfileName)
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Check if it's a git repo
@@ -227,11 +261,14 @@ func SyncGit(profilesDir string, opts GitOptions) error {
 
 // SetRemote sets or updates the git remote for a profile
 func SetRemote(profilesDir string, opts GitOptions) error {
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
 	profileDir := filepath.Join(profilesDir, opts.ProfileName)
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Check if it's a git repo
@@ -272,6 +309,9 @@ func SetRemote(profilesDir string, opts GitOptions) error {
 
 // GetGitStatus shows the git status of a profile (or all profiles if no name provided)
 func GetGitStatus(profilesDir string, opts GitOptions) error {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
 	// If no profile name, show status for all profiles
 	if opts.ProfileName == "" {
 		entries, err := os.ReadDir(profilesDir)
@@ -332,7 +372,7 @@ func GetGitStatus(profilesDir string, opts GitOptions) error {
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	// Check if it's a git repo
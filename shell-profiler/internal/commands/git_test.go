@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newNestedProfile makes a profile under a profiles root that is itself a
+// git working tree, marked by a .git directory, or a .git file as for a
+// worktree
+func newNestedProfile(t *testing.T, gitFile bool) (profilesDir, root string) {
+	t.Helper()
+	root = t.TempDir()
+	if gitFile {
+		if err := os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: /elsewhere/.git/worktrees/profiles\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	} else if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	profilesDir = filepath.Join(root, "profiles")
+	if err := os.MkdirAll(filepath.Join(profilesDir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return profilesDir, root
+}
+
+func TestEnclosingGitRepo(t *testing.T) {
+	for _, gitFile := range []bool{false, true} {
+		profilesDir, root := newNestedProfile(t, gitFile)
+		if got := enclosingGitRepo(filepath.Join(profilesDir, "nested")); got != root {
+			t.Errorf("enclosingGitRepo() = %q, want %q (.git file: %v)", got, root, gitFile)
+		}
+	}
+}
+
+func TestInitGit_RefusesNestedRepo(t *testing.T) {
+	profilesDir, root := newNestedProfile(t, false)
+
+	err := InitGit(profilesDir, GitOptions{ProfileName: "nested"})
+	if err == nil {
+		t.Fatal("InitGit() should refuse to nest a repository without --force")
+	}
+	if !strings.Contains(err.Error(), root) || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("error should name the enclosing repository and --force: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profilesDir, "nested", ".git")); err == nil {
+		t.Error("no repository should have been created")
+	}
+}
+
+func TestInitGit_ForceNestsRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	profilesDir, _ := newNestedProfile(t, true)
+
+	if err := InitGit(profilesDir, GitOptions{ProfileName: "nested", Force: true}); err != nil {
+		t.Fatalf("InitGit(force) error: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(profilesDir, "nested", ".git")); err != nil || !info.IsDir() {
+		t.Errorf("expected a repository in the profile: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// gitattributesRules keep shell scripts LF-terminated when a profile is
+// committed from Windows or with core.autocrlf set; a CRLF .envrc or bin/
+// script fails on its shebang line
+var gitattributesRules = []string{
+	"*.sh text eol=lf",
+	".envrc text eol=lf",
+	".envrc.local text eol=lf",
+	"bin/* text eol=lf",
+}
+
+const gitattributesHeader = "# Keep shell scripts LF-terminated on every platform\n"
+
+func createGitattributes(profileDir string) error {
+	ui.PrintInfo("Creating .gitattributes...")
+	content := gitattributesHeader + strings.Join(gitattributesRules, "\n") + "\n"
+	return writeFileMode(filepath.Join(profileDir, ".gitattributes"), []byte(content), 0644)
+}
+
+// updateGitattributes adds the LF rules missing from a profile's
+// .gitattributes, creating it if needed
+func updateGitattributes(profileDir string, dryRun bool) (bool, error) {
+	path := filepath.Join(profileDir, ".gitattributes")
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		present[strings.Join(strings.Fields(line), " ")] = true
+	}
+	var missing []string
+	for _, rule := range gitattributesRules {
+		if !present[rule] {
+			missing = append(missing, rule)
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	updated := string(content)
+	if updated != "" && !strings.HasSuffix(updated, "\n") {
+		updated += "\n"
+	}
+	if updated == "" {
+		updated = gitattributesHeader
+	}
+	updated += strings.Join(missing, "\n") + "\n"
+	return true, writeFileMode(path, []byte(updated), 0644)
+}
+
+// revertGitattributes removes a .gitattributes that still has exactly the
+// generated content
+func revertGitattributes(profileDir string, dryRun bool) (bool, error) {
+	path := filepath.Join(profileDir, ".gitattributes")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if string(content) != gitattributesHeader+strings.Join(gitattributesRules, "\n")+"\n" {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, os.Remove(path)
+}
+
+// writeFileMode writes a file and sets its mode even if it already existed
+func writeFileMode(path string, data []byte, mode os.FileMode) error {
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return os.Chmod(path, mode)
+}
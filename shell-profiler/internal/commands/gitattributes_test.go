@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateProfile_WithGitattributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	if _, err := captureStdout(t, func() error {
+		return CreateProfile(tmpDir, CreateOptions{ProfileName: "test", Template: "basic", Gitattributes: true})
+	}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	path := filepath.Join(tmpDir, "test", ".gitattributes")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf(".gitattributes not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Errorf(".gitattributes mode = %o, want 0644", perm)
+	}
+	data, _ := os.ReadFile(path)
+	for _, rule := range []string{"*.sh text eol=lf", ".envrc text eol=lf", "bin/* text eol=lf"} {
+		if !strings.Contains(string(data), rule+"\n") {
+			t.Errorf(".gitattributes missing %q:\n%s", rule, data)
+		}
+	}
+
+	// Without the flag, create leaves it out
+	if _, err := captureStdout(t, func() error {
+		return CreateProfile(tmpDir, CreateOptions{ProfileName: "plain", Template: "basic"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "plain", ".gitattributes")); !os.IsNotExist(err) {
+		t.Error(".gitattributes should only be created with --with-gitattributes")
+	}
+}
+
+func TestUpdateGitattributes_KeepsExistingRules(t *testing.T) {
+	profileDir := t.TempDir()
+	path := filepath.Join(profileDir, ".gitattributes")
+	if err := os.WriteFile(path, []byte("*.png binary\n*.sh   text  eol=lf"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := updateGitattributes(profileDir, false)
+	if err != nil || !updated {
+		t.Fatalf("updateGitattributes() = %v, %v", updated, err)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.HasPrefix(content, "*.png binary\n*.sh   text  eol=lf\n") {
+		t.Errorf("existing rules should be kept as they are:\n%s", content)
+	}
+	if strings.Count(content, "eol=lf") != len(gitattributesRules) {
+		t.Errorf("each rule should appear once:\n%s", content)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf(".gitattributes mode = %o, want 0644", info.Mode().Perm())
+	}
+
+	if updated, _ := updateGitattributes(profileDir, false); updated {
+		t.Error("a second run should change nothing")
+	}
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+)
+
+// branchNamePattern is a conservative subset of the names git accepts
+var branchNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// validateBranchName checks a --default-branch value
+func validateBranchName(name string) error {
+	if !branchNamePattern.MatchString(name) || strings.Contains(name, "..") ||
+		strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".") {
+		return fmt.Errorf("invalid branch name: %q", name)
+	}
+	return nil
+}
+
+// badConfigLine finds the line number in git's parse errors
+var badConfigLine = regexp.MustCompile(`bad config line (\d+)`)
+
+// validateGitconfig checks that git can parse a config file, quoting the
+// offending line when git names one. checked is false when git is not
+// installed and nothing was checked.
+func validateGitconfig(path string) (checked bool, err error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false, nil
+	}
+
+	cmd := exec.Command("git", "config", "--file", path, "--list")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if runErr := cmd.Run(); runErr != nil {
+		msg := strings.TrimPrefix(strings.TrimSpace(stderr.String()), "fatal: ")
+		if msg == "" {
+			msg = runErr.Error()
+		}
+		if m := badConfigLine.FindStringSubmatch(msg); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			if data, readErr := os.ReadFile(path); readErr == nil {
+				if lines := strings.Split(string(data), "\n"); n >= 1 && n <= len(lines) {
+					msg += fmt.Sprintf(": %q", lines[n-1])
+				}
+			}
+		}
+		return true, fmt.Errorf("%s", msg)
+	}
+	return true, nil
+}
+
+func checkGitconfig(profileDir, _ string, _ bool) ([]Finding, error) {
+	path := filepath.Join(profileDir, ".gitconfig")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	if _, err := validateGitconfig(path); err != nil {
+		return []Finding{{
+			Check:    "gitconfig",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("git cannot read .gitconfig: %v", err),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// gitconfigHasKey reports whether a git config sets section.key. Section
+// and key names are compared case-insensitively, as git does.
+func gitconfigHasKey(content, section, key string) bool {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			if end := strings.Index(line, "]"); end > 0 {
+				current = strings.ToLower(strings.TrimSpace(line[1:end]))
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(line, "=")
+		if current == section && strings.EqualFold(strings.TrimSpace(name), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDefaultBranch adds init.defaultBranch to a .gitconfig that does
+// not set it
+func updateDefaultBranch(profileDir string, dryRun bool) (bool, error) {
+	path := filepath.Join(profileDir, ".gitconfig")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read .gitconfig: %w", err)
+	}
+	content := string(data)
+	if gitconfigHasKey(content, "init", "defaultBranch") {
+		return false, nil
+	}
+
+	if !dryRun {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += fmt.Sprintf("\n[init]\n    defaultBranch = %s\n", templates.DefaultBranchName)
+		if err := writeFileMode(path, []byte(content), 0644); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateProfile_DefaultBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "plain", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "trunk", Template: "basic", DefaultBranch: "trunk"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	for name, want := range map[string]string{"plain": "main", "trunk": "trunk"} {
+		data, err := os.ReadFile(filepath.Join(tmpDir, name, ".gitconfig"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "[init]\n    defaultBranch = "+want+"\n") {
+			t.Errorf("%s: expected defaultBranch = %s:\n%s", name, want, data)
+		}
+	}
+
+	for _, bad := range []string{"-main", "a..b", "with space", "main.lock", "main/"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "bad", Template: "basic", DefaultBranch: bad}); err == nil {
+			t.Errorf("expected an error for branch %q", bad)
+		}
+	}
+}
+
+func TestUpdateDefaultBranch(t *testing.T) {
+	profileDir := t.TempDir()
+	path := filepath.Join(profileDir, ".gitconfig")
+	if err := os.WriteFile(path, []byte("[user]\n    name = Jane\n[init]\n    templateDir = ~/.git-template"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := updateDefaultBranch(profileDir, false)
+	if err != nil || !updated {
+		t.Fatalf("updateDefaultBranch() = %v, %v; want an update", updated, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), "templateDir = ~/.git-template\n\n[init]\n    defaultBranch = main\n") {
+		t.Errorf("unexpected .gitconfig:\n%s", data)
+	}
+
+	// Already set, in any case
+	if err := os.WriteFile(path, []byte("[Init]\n\tdefaultbranch = trunk\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := updateDefaultBranch(profileDir, false); err != nil || updated {
+		t.Errorf("updateDefaultBranch() = %v, %v; want no change when already set", updated, err)
+	}
+
+	// No .gitconfig
+	if updated, err := updateDefaultBranch(t.TempDir(), false); err != nil || updated {
+		t.Errorf("updateDefaultBranch() = %v, %v; want no change without .gitconfig", updated, err)
+	}
+}
+
+func TestValidateGitconfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid")
+	if err := os.WriteFile(valid, []byte("[user]\n    name = Jane\n[init]\n    defaultBranch = main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if checked, err := validateGitconfig(valid); !checked || err != nil {
+		t.Errorf("validateGitconfig(valid) = %v, %v", checked, err)
+	}
+
+	malformed := filepath.Join(dir, "malformed")
+	if err := os.WriteFile(malformed, []byte("[user]\n    name = Jane\n[core\n    editor = vim\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := validateGitconfig(malformed)
+	if err == nil || !strings.Contains(err.Error(), "line 3") || !strings.Contains(err.Error(), `"[core"`) {
+		t.Errorf("validateGitconfig(malformed) error = %v, want line 3 quoted", err)
+	}
+
+	// The doctor check reports it as an error
+	profileDir := t.TempDir()
+	data, _ := os.ReadFile(malformed)
+	if err := os.WriteFile(filepath.Join(profileDir, ".gitconfig"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	findings, err := checkGitconfig(profileDir, "acme", false)
+	if err != nil || len(findings) != 1 || findings[0].Severity != SeverityError {
+		t.Errorf("checkGitconfig() = %+v, %v; want one error", findings, err)
+	}
+}
+
+func TestValidateGitconfig_WithoutGit(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	checked, err := validateGitconfig(filepath.Join(t.TempDir(), "missing"))
+	if checked || err != nil {
+		t.Errorf("validateGitconfig() without git = %v, %v; want unchecked", checked, err)
+	}
+}
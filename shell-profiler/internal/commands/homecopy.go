@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// homeFilesFor returns the $HOME files to copy for the named tools
+func homeFilesFor(names []string, includeCreds bool) ([]tools.HomeFile, error) {
+	var files []tools.HomeFile
+	for _, name := range names {
+		tool, ok := tools.Lookup(name)
+		if !ok || len(tool.HomeFiles) == 0 {
+			return nil, fmt.Errorf("cannot copy %s from $HOME (available: %s)", name, strings.Join(copyableTools(), ", "))
+		}
+		for _, file := range tool.HomeFiles {
+			if file.Credential && !includeCreds {
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// copyableTools lists the tools accepted by --copy-from-home
+func copyableTools() []string {
+	var names []string
+	for _, tool := range tools.All() {
+		if len(tool.HomeFiles) > 0 {
+			names = append(names, tool.Name)
+		}
+	}
+	return names
+}
+
+// copyFromHome copies tool files from $HOME into a new profile, skipping
+// any the user does not have. Credentials are written 0600.
+func copyFromHome(profileDir string, files []tools.HomeFile) ([]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	var copied []string
+	for _, file := range files {
+		src := filepath.Join(homeDir, file.Home)
+		info, err := os.Stat(src)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		mode := info.Mode().Perm()
+		if file.Credential {
+			mode = 0600
+		}
+		dst := filepath.Join(profileDir, file.Profile)
+		if err := copyFile(src, dst, mode); err != nil {
+			return copied, err
+		}
+		copied = append(copied, file.Profile)
+	}
+	return copied, nil
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dst, err)
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// OpenFile only applies the mode to new files
+	return os.Chmod(dst, mode)
+}
+
+// printCopied reports the files copied from $HOME
+func printCopied(copied []string) {
+	if len(copied) == 0 {
+		ui.PrintWarning("No tool configs found in $HOME to copy")
+		return
+	}
+	ui.PrintSuccess(fmt.Sprintf("Copied from $HOME: %s", strings.Join(copied, ", ")))
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateProfile_CopyFromHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeFiles(t, home, ".aws/config", ".aws/credentials", ".kube/config")
+	if err := os.Chmod(filepath.Join(home, ".aws/credentials"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	if _, err := captureStdout(t, func() error {
+		return CreateProfile(tmpDir, CreateOptions{ProfileName: "plain", Template: "basic", CopyFromHome: []string{"aws", "kube", "terraform"}})
+	}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "plain")
+	for _, file := range []string{".aws/config", ".kube/config"} {
+		if !exists(filepath.Join(profileDir, file)) {
+			t.Errorf("%s should be copied from $HOME", file)
+		}
+	}
+	if exists(filepath.Join(profileDir, ".aws/credentials")) {
+		t.Error("credentials should not be copied without IncludeCreds")
+	}
+	if exists(filepath.Join(profileDir, ".terraformrc")) {
+		t.Error(".terraformrc does not exist in $HOME and should be skipped")
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return CreateProfile(tmpDir, CreateOptions{ProfileName: "creds", Template: "basic", CopyFromHome: []string{"aws"}, IncludeCreds: true})
+	}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(tmpDir, "creds", ".aws/credentials"))
+	if err != nil {
+		t.Fatalf("credentials should be copied with IncludeCreds: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("copied credentials mode = %o, want 0600", perm)
+	}
+}
+
+func TestCreateProfile_CopyFromHomeUnknownTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "x", Template: "basic", CopyFromHome: []string{"git"}}); err == nil {
+		t.Error("expected an error for a tool with no $HOME configs")
+	}
+	if exists(filepath.Join(tmpDir, "x")) {
+		t.Error("profile should not be created when --copy-from-home is invalid")
+	}
+}
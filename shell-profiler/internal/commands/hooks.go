@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// profileHook returns the script configured for a lifecycle hook, preferring
+// the profile's .profile-meta over ~/.profile-manager
+func profileHook(profileDir, hook string) string {
+	if meta, err := profile.ReadMeta(profileDir); err == nil {
+		if script := meta.Hooks[hook]; script != "" {
+			if !filepath.IsAbs(script) {
+				script = filepath.Join(profileDir, script)
+			}
+			return script
+		}
+	}
+	if cfg, err := config.LoadConfig(); err == nil {
+		return cfg.Hook(hook)
+	}
+	return ""
+}
+
+// runHook runs a hook script for a profile and shows its output
+func runHook(script, hook, profileName, profileDir string) error {
+	ui.PrintInfo(fmt.Sprintf("Running %s hook: %s", hook, script))
+	result, err := hooks.Run(script, hook, profileName, profileDir)
+	if result != nil && result.Output != "" {
+		for _, line := range strings.Split(result.Output, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	return err
+}
+
+// stripProfileHooks removes the hooks declared in a profile's .profile-meta
+// and returns them. Profiles imported from an archive get no say in what
+// runs on this machine until the user adds the hooks back.
+func stripProfileHooks(profileDir string) (map[string]string, error) {
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil || len(meta.Hooks) == 0 {
+		return nil, nil
+	}
+	stripped := meta.Hooks
+	meta.Hooks = nil
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", profile.MetaFileName, err)
+	}
+	return stripped, nil
+}
+
+// runProfileHook runs the configured lifecycle hook for a profile, if any
+func runProfileHook(profileDir, hook, profileName string) error {
+	script := profileHook(profileDir, hook)
+	if script == "" {
+		return nil
+	}
+	return runHook(script, hook, profileName, profileDir)
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+// setProfileHooks records per-profile hooks in .profile-meta
+func setProfileHooks(t *testing.T, profileDir string, hooks map[string]string) {
+	t.Helper()
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Hooks = hooks
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfileHook_MetaOverridesConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	content := "pre_delete_hook=/usr/local/bin/global-hook\npost_update_hook=/usr/local/bin/post-update\n"
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	setProfileHooks(t, profileDir, map[string]string{hooks.PreDelete: "bin/revoke.sh"})
+
+	if got, want := profileHook(profileDir, hooks.PreDelete), filepath.Join(profileDir, "bin", "revoke.sh"); got != want {
+		t.Errorf("profileHook(pre-delete) = %q, want %q", got, want)
+	}
+	if got := profileHook(profileDir, hooks.PostUpdate); got != "/usr/local/bin/post-update" {
+		t.Errorf("profileHook(post-update) = %q, want the configured hook", got)
+	}
+	if got := profileHook(profileDir, hooks.PreUpdate); got != "" {
+		t.Errorf("profileHook(pre-update) = %q, want empty", got)
+	}
+}
+
+func TestDeleteProfile_PreDeleteHookCancels(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	setProfileHooks(t, profileDir, map[string]string{hooks.PreDelete: writeHookScript(t, "echo cannot revoke >&2\nexit 1\n")})
+
+	err := DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", Force: true})
+	if err == nil || !strings.Contains(err.Error(), "delete cancelled") {
+		t.Fatalf("expected delete to be cancelled, got: %v", err)
+	}
+	if _, err := os.Stat(profileDir); err != nil {
+		t.Error("profile should remain after a failing pre-delete hook")
+	}
+}
+
+func TestDeleteProfile_PreDeleteHookRunsBeforeRemoval(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	marker := filepath.Join(t.TempDir(), "seen")
+	hook := writeHookScript(t, `test -f "$WORKSPACE_HOME/.envrc" && echo "$WORKSPACE_PROFILE" > `+marker+"\n")
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte("pre_delete_hook="+hook+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if err := DeleteProfile(tmpDir, DeleteOptions{ProfileName: "acme", Force: true}); err != nil {
+		t.Fatalf("DeleteProfile() error: %v", err)
+	}
+
+	got, err := os.ReadFile(marker)
+	if err != nil || string(got) != "acme\n" {
+		t.Errorf("pre-delete hook did not see the profile: %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "acme")); !os.IsNotExist(err) {
+		t.Error("profile should be deleted after a successful pre-delete hook")
+	}
+}
+
+func TestUpdateProfile_RunsUpdateHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := func(name string) string {
+		return writeHookScript(t, `echo "`+name+` $(grep -o '"schemaVersion": [0-9]*' "$WORKSPACE_HOME/.profile-meta")" >> `+log+"\n")
+	}
+	setProfileHooks(t, profileDir, map[string]string{
+		hooks.PreUpdate:  record("pre"),
+		hooks.PostUpdate: record("post"),
+	})
+	meta, _ := profile.ReadMeta(profileDir)
+	meta.SchemaVersion = profileMigrations.Latest() - 1
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	got, _ := os.ReadFile(log)
+	latest := profileMigrations.Latest()
+	want := fmt.Sprintf("pre \"schemaVersion\": %d\npost \"schemaVersion\": %d\n", latest-1, latest)
+	if string(got) != want {
+		t.Errorf("hook log = %q, want %q", got, want)
+	}
+}
+
+func TestUpdateProfile_PreUpdateHookCancels(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	setProfileHooks(t, profileDir, map[string]string{hooks.PreUpdate: writeHookScript(t, "exit 2\n")})
+	meta, _ := profile.ReadMeta(profileDir)
+	meta.SchemaVersion = 0
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true})
+	if err == nil || !strings.Contains(err.Error(), "update cancelled") {
+		t.Fatalf("expected update to be cancelled, got: %v", err)
+	}
+	meta, _ = profile.ReadMeta(profileDir)
+	if meta.SchemaVersion != 0 {
+		t.Errorf("schema version = %d, want 0 after a cancelled update", meta.SchemaVersion)
+	}
+}
+
+func TestUpdateProfile_HooksCoverChangesWithoutMigrations(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic", ExtraDirs: []string{"data"}}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	sshConfig := filepath.Join(profileDir, ".ssh", "config")
+	before, _ := os.ReadFile(sshConfig)
+	if err := os.Remove(filepath.Join(profileDir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	opts := UpdateOptions{ProfileName: "acme", NoBackup: true, RelativeSSHPaths: true}
+
+	// A failing pre-update hook cancels before anything is written
+	setProfileHooks(t, profileDir, map[string]string{hooks.PreUpdate: writeHookScript(t, "exit 2\n")})
+	err := UpdateProfile(tmpDir, opts)
+	if err == nil || !strings.Contains(err.Error(), "update cancelled") {
+		t.Fatalf("expected update to be cancelled, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, "data")); !os.IsNotExist(err) {
+		t.Error("extra directory created despite the cancelled update")
+	}
+	if after, _ := os.ReadFile(sshConfig); string(after) != string(before) {
+		t.Errorf(".ssh/config rewritten despite the cancelled update:\n%s", after)
+	}
+
+	// Otherwise both hooks run around the changes
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := func(name string) string {
+		return writeHookScript(t, `echo "`+name+` $(test -d "$WORKSPACE_HOME/data" && echo data)" >> `+log+"\n")
+	}
+	setProfileHooks(t, profileDir, map[string]string{
+		hooks.PreUpdate:  record("pre"),
+		hooks.PostUpdate: record("post"),
+	})
+	if err := UpdateProfile(tmpDir, opts); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if got, _ := os.ReadFile(log); string(got) != "pre \npost data\n" {
+		t.Errorf("hook log = %q, want the pre-update hook before the change and the post-update hook after", got)
+	}
+	if after, _ := os.ReadFile(sshConfig); string(after) == string(before) {
+		t.Error(".ssh/config paths not converted")
+	}
+}
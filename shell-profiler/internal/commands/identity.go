@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+type GitIdentityOptions struct {
+	Tags     []string // Only update profiles carrying all of these tags
+	DryRun   bool
+	NoBackup bool
+}
+
+// setGitConfig sets key in a git config file. git only touches the key's
+// section, leaving the rest of the file as it was.
+func setGitConfig(configFile, key, value string) error {
+	output, err := exec.Command("git", "config", "--file", configFile, key, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git config %s: %s", key, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// SetGitIdentity sets user.name and user.email in the .gitconfig of every
+// profile, or those carrying opts.Tags. An empty name or email is left
+// unchanged. Each profile is backed up before it is changed.
+func SetGitIdentity(profilesDir, name, email string, opts GitIdentityOptions) error {
+	if name == "" && email == "" {
+		return fmt.Errorf("specify a git user name, email or both")
+	}
+	if email != "" && !strings.Contains(email, "@") {
+		return fmt.Errorf("invalid email address: %s", email)
+	}
+	if err := checkProfilesDir(profilesDir, !opts.DryRun); err != nil {
+		return err
+	}
+
+	names, err := profileNames(profilesDir)
+	if err != nil {
+		return err
+	}
+
+	changes := map[string]string{"user.name": name, "user.email": email}
+	updated, failed := 0, 0
+	for _, profileName := range names {
+		profileDir := filepath.Join(profilesDir, profileName)
+		if !profileHasTags(profileDir, opts.Tags) {
+			continue
+		}
+		gitconfig := filepath.Join(profileDir, ".gitconfig")
+		if _, err := os.Stat(gitconfig); os.IsNotExist(err) {
+			ui.PrintWarning(fmt.Sprintf("%s has no .gitconfig, skipping", profileName))
+			continue
+		}
+
+		var diffs []string
+		for _, key := range []string{"user.name", "user.email"} {
+			if value := changes[key]; value != "" {
+				if current := profile.GitConfigValue(gitconfig, key); current != value {
+					diffs = append(diffs, fmt.Sprintf("%s: %q -> %q", key, current, value))
+				}
+			}
+		}
+		if len(diffs) == 0 {
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := setProfileIdentity(profileDir, gitconfig, changes, opts.NoBackup); err != nil {
+				failed++
+				fmt.Printf("  %s%s %s%s: %v\n", ui.ColorRed, ui.SymbolErr, profileName, ui.ColorReset, err)
+				continue
+			}
+		}
+		updated++
+		fmt.Printf("  %s%s%s %s: %s\n", ui.ColorGreen, ui.SymbolOK, ui.ColorReset, profileName, strings.Join(diffs, ", "))
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("failed to update %d profile(s)", failed)
+	case updated == 0:
+		ui.PrintInfo("No profiles needed changes")
+	case opts.DryRun:
+		ui.PrintInfo(fmt.Sprintf("DRY RUN - Would update %d profile(s)", updated))
+	default:
+		ui.PrintSuccess(fmt.Sprintf("Updated git identity in %d profile(s)", updated))
+	}
+	return nil
+}
+
+// setProfileIdentity applies the non-empty changes to one profile under its lock
+func setProfileIdentity(profileDir, gitconfig string, changes map[string]string, noBackup bool) error {
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+
+	if !noBackup {
+		if _, err := createBackup(profileDir, "identity", nil); err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+	for _, key := range []string{"user.name", "user.email"} {
+		if value := changes[key]; value != "" {
+			if err := setGitConfig(gitconfig, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
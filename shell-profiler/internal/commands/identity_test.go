@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestSetGitIdentity(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"acme", "globex", "home"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "work", GitName: "Jane", GitEmail: "jane@old.example"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+		if name != "home" {
+			profileDir := filepath.Join(tmpDir, name)
+			meta, err := profile.LoadMeta(profileDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta.Tags = []string{"client"}
+			if err := profile.WriteMeta(profileDir, meta); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(tmpDir, name, ".gitconfig"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	before := read("acme")
+
+	// Dry run changes nothing
+	if _, err := captureStdout(t, func() error {
+		return SetGitIdentity(tmpDir, "", "jane@new.example", GitIdentityOptions{Tags: []string{"client"}, DryRun: true})
+	}); err != nil {
+		t.Fatalf("SetGitIdentity() dry run error: %v", err)
+	}
+	if read("acme") != before {
+		t.Error("dry run should not change .gitconfig")
+	}
+
+	out, err := captureStdout(t, func() error {
+		return SetGitIdentity(tmpDir, "", "jane@new.example", GitIdentityOptions{Tags: []string{"client"}})
+	})
+	if err != nil {
+		t.Fatalf("SetGitIdentity() error: %v", err)
+	}
+
+	for _, name := range []string{"acme", "globex"} {
+		gitconfig := filepath.Join(tmpDir, name, ".gitconfig")
+		if got := profile.GitConfigValue(gitconfig, "user.email"); got != "jane@new.example" {
+			t.Errorf("%s user.email = %q, want jane@new.example", name, got)
+		}
+		if got := profile.GitConfigValue(gitconfig, "user.name"); got != "Jane" {
+			t.Errorf("%s user.name = %q, want it unchanged", name, got)
+		}
+		backups, _ := filepath.Glob(filepath.Join(tmpDir, name, ".backups", "identity_*", ".gitconfig"))
+		if len(backups) != 1 {
+			t.Errorf("%s: expected one identity backup, got %v", name, backups)
+		}
+	}
+	if got := profile.GitConfigValue(filepath.Join(tmpDir, "home", ".gitconfig"), "user.email"); got != "jane@old.example" {
+		t.Errorf("untagged profile should be left alone, user.email = %q", got)
+	}
+	if !strings.Contains(out, `user.email: "jane@old.example" -> "jane@new.example"`) {
+		t.Errorf("expected the change to be reported:\n%s", out)
+	}
+
+	// Nothing outside [user] changed
+	if got, want := withoutUserSection(read("acme")), withoutUserSection(before); got != want {
+		t.Errorf("other sections should be untouched:\n%s\nwant\n%s", got, want)
+	}
+}
+
+// withoutUserSection drops the [user] section from a git config
+func withoutUserSection(gitconfig string) string {
+	var kept []string
+	inUser := false
+	for _, line := range strings.Split(gitconfig, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") {
+			inUser = trimmed == "[user]"
+		}
+		if !inUser {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func TestSetGitIdentity_Validation(t *testing.T) {
+	if err := SetGitIdentity(t.TempDir(), "", "", GitIdentityOptions{}); err == nil {
+		t.Error("expected an error without name or email")
+	}
+	if err := SetGitIdentity(t.TempDir(), "", "not-an-email", GitIdentityOptions{}); err == nil {
+		t.Error("expected an error for an invalid email")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -15,6 +16,7 @@ type InitOptions struct {
 	ProfilesDir string
 	Force       bool
 	Interactive bool
+	Git         bool // git init the profiles directory
 }
 
 // InitConfig initializes the profile manager configuration
@@ -31,12 +33,17 @@ func InitConfig(opts InitOptions) error {
 		fmt.Println()
 		fmt.Print("Overwrite existing configuration? [y/N]: ")
 
-		reader := bufio.NewReader(os.Stdin)
-		confirmation, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("failed to read input: %w", err)
+		confirmation := "yes"
+		if ui.AssumeYes() {
+			fmt.Println("yes (--yes)")
+		} else {
+			reader := bufio.NewReader(os.Stdin)
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			confirmation = strings.TrimSpace(strings.ToLower(input))
 		}
-		confirmation = strings.TrimSpace(strings.ToLower(confirmation))
 
 		if confirmation != "y" && confirmation != "yes" {
 			ui.PrintInfo("Initialization cancelled")
@@ -77,6 +84,12 @@ func InitConfig(opts InitOptions) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
+	if opts.Git {
+		if _, err := initCollectionRepo(opts.ProfilesDir); err != nil {
+			return err
+		}
+	}
+
 	ui.PrintSuccess("Profile manager initialized successfully")
 	fmt.Println()
 	fmt.Printf("  Profiles directory: %s\n", opts.ProfilesDir)
@@ -90,6 +103,86 @@ func InitConfig(opts InitOptions) error {
 	return nil
 }
 
+// InitProfilesDir creates the configured profiles directory and, if there is
+// no config file, a default one. With opts.Git the directory is also made a
+// git repository so every profile is versioned together. Anything already
+// in place is left alone, so re-running it is a no-op.
+func InitProfilesDir(opts InitOptions) error {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	_, statErr := os.Stat(configPath)
+	configExists := statErr == nil
+
+	profilesDir := cfg.ProfilesDir
+	if opts.ProfilesDir != "" {
+		profilesDir = expandPath(opts.ProfilesDir)
+		if configExists && profilesDir != cfg.ProfilesDir {
+			ui.PrintWarning(fmt.Sprintf("%s sets profiles_dir to %s (use --force to overwrite it)", configPath, cfg.ProfilesDir))
+		}
+	}
+
+	var created []string
+	if _, err := os.Stat(profilesDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(profilesDir, 0755); err != nil {
+			return fmt.Errorf("failed to create profiles directory: %w", err)
+		}
+		created = append(created, fmt.Sprintf("Profiles directory: %s", profilesDir))
+	}
+
+	if !configExists {
+		if err := config.SaveConfig(&config.Config{ProfilesDir: profilesDir}); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		// SaveConfig writes the flat format when no config exists
+		configPath, err = config.GetConfigPath()
+		if err != nil {
+			return err
+		}
+		created = append(created, fmt.Sprintf("Config file: %s", configPath))
+	}
+
+	if opts.Git {
+		initialized, err := initCollectionRepo(profilesDir)
+		if err != nil {
+			return err
+		}
+		if initialized {
+			created = append(created, fmt.Sprintf("Git repository: %s", filepath.Join(profilesDir, ".git")))
+		}
+	}
+
+	if len(created) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Profiles directory already initialized: %s", profilesDir))
+		return nil
+	}
+	ui.PrintSuccess("Profile manager initialized successfully")
+	fmt.Println()
+	for _, line := range created {
+		fmt.Printf("  Created %s\n", line)
+	}
+	return nil
+}
+
+// initCollectionRepo runs git init in the profiles directory unless it is
+// already a repository, reporting whether it created one
+func initCollectionRepo(profilesDir string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(profilesDir, ".git")); err == nil {
+		return false, nil
+	}
+	cmd := exec.Command("git", "init", "--quiet")
+	cmd.Dir = profilesDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return false, fmt.Errorf("failed to initialize git repository: %s", strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
+
 func interactiveInit(opts *InitOptions) error {
 	fmt.Println("Profile Manager Initialization")
 	fmt.Println()
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitProfilesDir_CreatesDirAndConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	profilesDir := filepath.Join(home, "profiles")
+
+	out, err := captureStdout(t, func() error {
+		return InitProfilesDir(InitOptions{ProfilesDir: profilesDir})
+	})
+	if err != nil {
+		t.Fatalf("InitProfilesDir() error: %v", err)
+	}
+	if info, err := os.Stat(profilesDir); err != nil || !info.IsDir() {
+		t.Fatalf("profiles directory not created: %v", err)
+	}
+	config, err := os.ReadFile(filepath.Join(home, ".profile-manager"))
+	if err != nil {
+		t.Fatalf("config not created: %v", err)
+	}
+	if !strings.Contains(string(config), "profiles_dir=~/profiles\n") {
+		t.Errorf("config does not point at the profiles directory:\n%s", config)
+	}
+	for _, want := range []string{"Created Profiles directory: " + profilesDir, "Created Config file: "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Re-running changes nothing
+	out, err = captureStdout(t, func() error {
+		return InitProfilesDir(InitOptions{})
+	})
+	if err != nil {
+		t.Fatalf("second InitProfilesDir() error: %v", err)
+	}
+	if !strings.Contains(out, "already initialized") || strings.Contains(out, "Created") {
+		t.Errorf("second run was not a no-op:\n%s", out)
+	}
+	again, _ := os.ReadFile(filepath.Join(home, ".profile-manager"))
+	if string(again) != string(config) {
+		t.Errorf("config rewritten:\n%s", again)
+	}
+}
+
+func TestInitProfilesDir_KeepsExistingConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configured := filepath.Join(home, "configured")
+	content := "profiles_dir=" + configured + "\n"
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error { return InitProfilesDir(InitOptions{}) }); err != nil {
+		t.Fatalf("InitProfilesDir() error: %v", err)
+	}
+	if _, err := os.Stat(configured); err != nil {
+		t.Errorf("configured profiles directory not created: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(home, ".profile-manager")); string(got) != content {
+		t.Errorf("existing config changed:\n%s", got)
+	}
+}
+
+func TestInitProfilesDir_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	profilesDir := filepath.Join(home, "profiles")
+
+	out, err := captureStdout(t, func() error {
+		return InitProfilesDir(InitOptions{ProfilesDir: profilesDir, Git: true})
+	})
+	if err != nil {
+		t.Fatalf("InitProfilesDir() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profilesDir, ".git")); err != nil {
+		t.Fatalf("git repository not created: %v", err)
+	}
+	if !strings.Contains(out, "Created Git repository") {
+		t.Errorf("output missing git repository:\n%s", out)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return InitProfilesDir(InitOptions{Git: true})
+	})
+	if err != nil {
+		t.Fatalf("second InitProfilesDir() error: %v", err)
+	}
+	if strings.Contains(out, "Created") {
+		t.Errorf("second run was not a no-op:\n%s", out)
+	}
+}
@@ -1,22 +1,131 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
 type ListOptions struct {
-	Verbose     bool
-	ShowConfig  bool
-	Interactive bool
+	Verbose         bool
+	ShowConfig      bool
+	Interactive     bool
+	Tags            []string // Only list profiles carrying all of these tags
+	IncludeArchived bool     // Also list profiles under archived/
+	Offset          int      // Skip this many profiles
+	Limit           int      // Show at most this many profiles (0 = all)
+
+	Since          time.Duration // Only profiles modified within this long (0 = any)
+	ModifiedBefore time.Duration // Only profiles not modified for this long (0 = any)
+	Sort           string        // ListSortName (default) or ListSortModified
+	Format         string        // ui.FormatTable, FormatPlain or FormatJSON; empty for the detailed listing
+	Clock          clock.Clock   // Time source for Since and ModifiedBefore; the system clock if nil
+}
+
+// List sort orders
+const (
+	ListSortName     = "name"
+	ListSortModified = "modified" // Most recently modified first
+)
+
+// profileModTime returns the most recent modification time of the files in
+// a profile, ignoring backups, tool caches and the lock file
+func profileModTime(profileDir string) (time.Time, error) {
+	skip := map[string]bool{".backups": true, profile.LockFileName: true}
+	for _, tool := range tools.All() {
+		for _, cache := range tool.Caches {
+			skip[cache] = true
+		}
+	}
+
+	var latest time.Time
+	err := filepath.WalkDir(profileDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(profileDir, path)
+		if err != nil {
+			return err
+		}
+		if skip[filepath.ToSlash(rel)] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// filterByModTime applies the Since and ModifiedBefore filters and the
+// modified sort order
+func filterByModTime(profilesDir string, profiles []string, opts ListOptions) ([]string, error) {
+	now := clock.Or(opts.Clock).Now()
+	modified := make(map[string]time.Time)
+	var kept []string
+	for _, name := range profiles {
+		modTime, err := profileModTime(filepath.Join(profilesDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read modification time of %s: %w", name, err)
+		}
+		if opts.Since > 0 && modTime.Before(now.Add(-opts.Since)) {
+			continue
+		}
+		if opts.ModifiedBefore > 0 && !modTime.Before(now.Add(-opts.ModifiedBefore)) {
+			continue
+		}
+		modified[name] = modTime
+		kept = append(kept, name)
+	}
+
+	if opts.Sort == ListSortModified {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return modified[kept[i]].After(modified[kept[j]])
+		})
+	}
+	return kept, nil
+}
+
+// paginate returns the profiles selected by offset and limit (0 = no limit)
+func paginate(profiles []string, offset, limit int) []string {
+	if offset >= len(profiles) {
+		return nil
+	}
+	page := profiles[offset:]
+	if limit > 0 && limit < len(page) {
+		page = page[:limit]
+	}
+	return page
 }
 
 func ListProfiles(profilesDir string, opts ListOptions) error {
+	if opts.Format != "" {
+		if _, err := ui.ParseFormat(opts.Format); err != nil {
+			return err
+		}
+	}
 
 	// Check if profiles directory exists
 	if _, err := os.Stat(profilesDir); os.IsNotExist(err) {
@@ -25,6 +134,9 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 		fmt.Println("  profile create my-profile")
 		return nil
 	}
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
 
 	// Get all profile directories
 	entries, err := os.ReadDir(profilesDir)
@@ -36,13 +148,41 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 	for _, entry := range entries {
 		if entry.IsDir() && entry.Name() != ".git" {
 			profilePath := filepath.Join(profilesDir, entry.Name())
-			envrcPath := filepath.Join(profilePath, ".envrc")
-			if _, err := os.Stat(envrcPath); err == nil {
+			if isProfileDir(profilePath) && profileHasTags(profilePath, opts.Tags) {
 				profiles = append(profiles, entry.Name())
 			}
 		}
 	}
 
+	if opts.IncludeArchived {
+		for _, name := range archivedProfiles(profilesDir) {
+			key := filepath.Join(archivedDirName, name)
+			if profileHasTags(filepath.Join(profilesDir, key), opts.Tags) {
+				profiles = append(profiles, key)
+			}
+		}
+	}
+
+	if opts.Since > 0 || opts.ModifiedBefore > 0 || opts.Sort == ListSortModified {
+		unfiltered := len(profiles)
+		if profiles, err = filterByModTime(profilesDir, profiles, opts); err != nil {
+			return err
+		}
+		if len(profiles) == 0 && unfiltered > 0 && opts.Format == "" {
+			fmt.Printf("%sNo profiles modified in the requested period%s\n", ui.ColorYellow, ui.ColorReset)
+			return nil
+		}
+	}
+
+	if opts.Format != "" {
+		return renderProfileList(profilesDir, profiles, opts)
+	}
+
+	if len(profiles) == 0 && len(opts.Tags) > 0 {
+		fmt.Printf("%sNo profiles tagged: %s%s\n", ui.ColorYellow, strings.Join(opts.Tags, ", "), ui.ColorReset)
+		return nil
+	}
+
 	if len(profiles) == 0 {
 		fmt.Printf("%sNo profiles found%s\n", ui.ColorYellow, ui.ColorReset)
 		fmt.Println("Create your first profile with:")
@@ -50,6 +190,13 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 		return nil
 	}
 
+	total := len(profiles)
+	profiles = paginate(profiles, opts.Offset, opts.Limit)
+	if len(profiles) == 0 {
+		fmt.Printf("%sNo profiles at offset %d (total profiles: %d)%s\n", ui.ColorYellow, opts.Offset, total, ui.ColorReset)
+		return nil
+	}
+
 	// Interactive mode - show selection menu
 	if opts.Interactive {
 		selected, err := ui.SelectProfile(profiles, "Select a profile:")
@@ -78,7 +225,6 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 		profileDir := filepath.Join(profilesDir, profileName)
 		envrcFile := filepath.Join(profileDir, ".envrc")
 		gitconfigFile := filepath.Join(profileDir, ".gitconfig")
-		readmeFile := filepath.Join(profileDir, "README.md")
 
 		// Profile header
 		if currentProfile == profileName {
@@ -99,20 +245,20 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 				output, statusErr := statusCmd.Output()
 				if statusErr == nil {
 					if strings.Contains(string(output), "Found RC allowed true") {
-						fmt.Printf("  %s✓ direnv allowed%s\n", ui.ColorGreen, ui.ColorReset)
+						fmt.Printf("  %s%s direnv allowed%s\n", ui.ColorGreen, ui.SymbolOK, ui.ColorReset)
 					} else {
-						fmt.Printf("  %s⚠ direnv not allowed%s (run: cd %s && direnv allow)\n", ui.ColorYellow, ui.ColorReset, profileDir)
+						fmt.Printf("  %s%s direnv not allowed%s (run: cd %s && direnv allow)\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset, profileDir)
 					}
 				}
 			}
 		} else {
-			fmt.Printf("  %s⚠ Missing .envrc%s\n", ui.ColorYellow, ui.ColorReset)
+			fmt.Printf("  %s%s Missing .envrc%s\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset)
 		}
 
 		// Show git configuration
 		if _, err := os.Stat(gitconfigFile); err == nil {
-			gitName := getGitConfig(gitconfigFile, "user.name")
-			gitEmail := getGitConfig(gitconfigFile, "user.email")
+			gitName := profile.GitConfigValue(gitconfigFile, "user.name")
+			gitEmail := profile.GitConfigValue(gitconfigFile, "user.email")
 			if gitName == "" {
 				gitName = "Not set"
 			}
@@ -125,29 +271,13 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 				fmt.Printf("    %sConfig:%s %s\n", ui.ColorBlue, ui.ColorReset, gitconfigFile)
 			}
 		} else {
-			fmt.Printf("  %s⚠ Missing .gitconfig%s\n", ui.ColorYellow, ui.ColorReset)
+			fmt.Printf("  %s%s Missing .gitconfig%s\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset)
 		}
 
 		// Verbose mode
 		if opts.Verbose {
-			// Check for README
-			if _, err := os.Stat(readmeFile); err == nil {
-				readmeContent, readErr := os.ReadFile(readmeFile)
-				if readErr != nil {
-					continue
-				}
-				lines := strings.Split(string(readmeContent), "\n")
-				for _, line := range lines {
-					if strings.HasPrefix(line, "Template:") {
-						template := strings.TrimSpace(strings.TrimPrefix(line, "Template:"))
-						fmt.Printf("  %sTemplate:%s %s\n", ui.ColorBlue, ui.ColorReset, template)
-					}
-					if strings.HasPrefix(line, "Created:") {
-						created := strings.TrimSpace(strings.TrimPrefix(line, "Created:"))
-						fmt.Printf("  %sCreated:%s %s\n", ui.ColorBlue, ui.ColorReset, created)
-					}
-				}
-			}
+			// Template and creation time from .profile-meta (or legacy headers)
+			printProfileMeta(profileDir)
 
 			// Check for .env file
 			envFile := filepath.Join(profileDir, ".env")
@@ -191,7 +321,11 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 	}
 
 	// Summary
-	fmt.Printf("%sTotal profiles: %d%s\n", ui.ColorBlue, len(profiles), ui.ColorReset)
+	if len(profiles) < total {
+		fmt.Printf("%sShowing profiles %d-%d of %d%s\n", ui.ColorBlue, opts.Offset+1, opts.Offset+len(profiles), total, ui.ColorReset)
+	} else {
+		fmt.Printf("%sTotal profiles: %d%s\n", ui.ColorBlue, total, ui.ColorReset)
+	}
 
 	if !opts.Verbose {
 		fmt.Println()
@@ -202,13 +336,76 @@ func ListProfiles(profilesDir string, opts ListOptions) error {
 	return nil
 }
 
-func getGitConfig(configFile, key string) string {
-	cmd := exec.Command("git", "config", "--file", configFile, key)
-	output, err := cmd.Output()
+// renderProfileList prints a page of profiles for --format along with the
+// total. JSON output is an object holding the total and the page; plain
+// output keeps stdout to one line per profile and reports the total on
+// stderr.
+func renderProfileList(profilesDir string, profiles []string, opts ListOptions) error {
+	total := len(profiles)
+	table := profileTable(profilesDir, paginate(profiles, opts.Offset, opts.Limit))
+	if opts.Format == ui.FormatJSON {
+		content, err := json.MarshalIndent(struct {
+			Total    int                 `json:"total"`
+			Profiles []map[string]string `json:"profiles"`
+		}{total, table.Records()}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	if err := table.Render(os.Stdout, opts.Format); err != nil {
+		return err
+	}
+	summary := os.Stdout
+	if opts.Format == ui.FormatPlain {
+		summary = os.Stderr
+	}
+	if shown := len(table.Rows); shown > 0 && shown < total {
+		fmt.Fprintf(summary, "Showing profiles %d-%d of %d\n", opts.Offset+1, opts.Offset+shown, total)
+	} else {
+		fmt.Fprintf(summary, "Total profiles: %d\n", total)
+	}
+	return nil
+}
+
+// profileTable lists profiles one per row for --format
+func profileTable(profilesDir string, profiles []string) *ui.Table {
+	currentProfile := os.Getenv("WORKSPACE_PROFILE")
+	table := ui.NewTable("NAME", "STATUS", "TEMPLATE", "GIT NAME", "GIT EMAIL", "PATH")
+	for _, name := range profiles {
+		profileDir := filepath.Join(profilesDir, name)
+		status := ""
+		if name == currentProfile {
+			status = "active"
+		}
+		template := ""
+		if meta, err := profile.LoadMeta(profileDir); err == nil {
+			template = meta.Template
+		}
+		gitconfigFile := filepath.Join(profileDir, ".gitconfig")
+		table.AddRow(name, status, template,
+			profile.GitConfigValue(gitconfigFile, "user.name"),
+			profile.GitConfigValue(gitconfigFile, "user.email"),
+			profileDir)
+	}
+	return table
+}
+
+// printProfileMeta prints the template, creation time and tags recorded for a profile
+func printProfileMeta(profileDir string) {
+	meta, err := profile.LoadMeta(profileDir)
 	if err != nil {
-		return ""
+		return
+	}
+	fmt.Printf("  %sTemplate:%s %s\n", ui.ColorBlue, ui.ColorReset, meta.Template)
+	if meta.Created != "" {
+		fmt.Printf("  %sCreated:%s %s\n", ui.ColorBlue, ui.ColorReset, meta.Created)
+	}
+	if len(meta.Tags) > 0 {
+		fmt.Printf("  %sTags:%s %s\n", ui.ColorBlue, ui.ColorReset, strings.Join(meta.Tags, ", "))
 	}
-	return strings.TrimSpace(string(output))
 }
 
 // showProfileDetails shows detailed information for a single profile
@@ -218,7 +415,6 @@ func showProfileDetails(profileDir, profileName string, opts ListOptions) error
 
 	envrcFile := filepath.Join(profileDir, ".envrc")
 	gitconfigFile := filepath.Join(profileDir, ".gitconfig")
-	readmeFile := filepath.Join(profileDir, "README.md")
 
 	// Show path
 	fmt.Printf("  %sPath:%s %s\n", ui.ColorBlue, ui.ColorReset, profileDir)
@@ -232,20 +428,20 @@ func showProfileDetails(profileDir, profileName string, opts ListOptions) error
 			output, statusErr := statusCmd.Output()
 			if statusErr == nil {
 				if strings.Contains(string(output), "Found RC allowed true") {
-					fmt.Printf("  %s✓ direnv allowed%s\n", ui.ColorGreen, ui.ColorReset)
+					fmt.Printf("  %s%s direnv allowed%s\n", ui.ColorGreen, ui.SymbolOK, ui.ColorReset)
 				} else {
-					fmt.Printf("  %s⚠ direnv not allowed%s (run: cd %s && direnv allow)\n", ui.ColorYellow, ui.ColorReset, profileDir)
+					fmt.Printf("  %s%s direnv not allowed%s (run: cd %s && direnv allow)\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset, profileDir)
 				}
 			}
 		}
 	} else {
-		fmt.Printf("  %s⚠ Missing .envrc%s\n", ui.ColorYellow, ui.ColorReset)
+		fmt.Printf("  %s%s Missing .envrc%s\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset)
 	}
 
 	// Show git configuration
 	if _, err := os.Stat(gitconfigFile); err == nil {
-		gitName := getGitConfig(gitconfigFile, "user.name")
-		gitEmail := getGitConfig(gitconfigFile, "user.email")
+		gitName := profile.GitConfigValue(gitconfigFile, "user.name")
+		gitEmail := profile.GitConfigValue(gitconfigFile, "user.email")
 		if gitName == "" {
 			gitName = "Not set"
 		}
@@ -258,28 +454,13 @@ func showProfileDetails(profileDir, profileName string, opts ListOptions) error
 			fmt.Printf("    %sConfig:%s %s\n", ui.ColorBlue, ui.ColorReset, gitconfigFile)
 		}
 	} else {
-		fmt.Printf("  %s⚠ Missing .gitconfig%s\n", ui.ColorYellow, ui.ColorReset)
+		fmt.Printf("  %s%s Missing .gitconfig%s\n", ui.ColorYellow, ui.SymbolWarn, ui.ColorReset)
 	}
 
 	// Always show verbose info in interactive mode
 	if opts.Verbose || opts.Interactive {
-		// Check for README
-		if _, err := os.Stat(readmeFile); err == nil {
-			readmeContent, readErr := os.ReadFile(readmeFile)
-			if readErr == nil {
-				lines := strings.Split(string(readmeContent), "\n")
-				for _, line := range lines {
-					if strings.HasPrefix(line, "Template:") {
-						template := strings.TrimSpace(strings.TrimPrefix(line, "Template:"))
-						fmt.Printf("  %sTemplate:%s %s\n", ui.ColorBlue, ui.ColorReset, template)
-					}
-					if strings.HasPrefix(line, "Created:") {
-						created := strings.TrimSpace(strings.TrimPrefix(line, "Created:"))
-						fmt.Printf("  %sCreated:%s %s\n", ui.ColorBlue, ui.ColorReset, created)
-					}
-				}
-			}
-		}
+		// Template and creation time from .profile-meta (or legacy headers)
+		printProfileMeta(profileDir)
 
 		// Check for .env file
 		envFile := filepath.Join(profileDir, ".env")
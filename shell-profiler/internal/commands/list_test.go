@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+func TestPaginate(t *testing.T) {
+	profiles := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          []string
+	}{
+		{"all", 0, 0, []string{"a", "b", "c", "d", "e"}},
+		{"first page", 0, 2, []string{"a", "b"}},
+		{"middle page", 2, 2, []string{"c", "d"}},
+		{"last partial page", 4, 2, []string{"e"}},
+		{"offset only", 3, 0, []string{"d", "e"}},
+		{"past the end", 5, 2, nil},
+		{"limit beyond total", 1, 10, []string{"b", "c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paginate(profiles, tt.offset, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paginate(%d, %d) = %v, want %v", tt.offset, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListProfiles_Pagination(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	for _, name := range []string{"alpha", "bravo", "charlie", "delta", "echo"} {
+		newTaggableProfile(t, tmpDir, name)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Offset: 1, Limit: 2})
+	})
+	if err != nil {
+		t.Fatalf("ListProfiles() error: %v", err)
+	}
+	for _, name := range []string{"bravo", "charlie"} {
+		if !strings.Contains(out, name) {
+			t.Errorf("expected %s on the page:\n%s", name, out)
+		}
+	}
+	for _, name := range []string{"alpha", "delta", "echo"} {
+		if strings.Contains(out, name) {
+			t.Errorf("%s should not be on the page:\n%s", name, out)
+		}
+	}
+	if !strings.Contains(out, "Showing profiles 2-3 of 5") {
+		t.Errorf("expected the full total in the summary:\n%s", out)
+	}
+
+	out, _ = captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Offset: 10, Limit: 2})
+	})
+	if !strings.Contains(out, "No profiles at offset 10 (total profiles: 5)") {
+		t.Errorf("expected an empty page message with the total:\n%s", out)
+	}
+}
+
+func TestListProfiles_FormatPlain(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "bravo")
+	tmpDir := t.TempDir()
+	for _, name := range []string{"alpha", "bravo", "charlie"} {
+		newTaggableProfile(t, tmpDir, name)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Format: ui.FormatPlain, Limit: 2})
+	})
+	if err != nil {
+		t.Fatalf("ListProfiles() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per profile:\n%s", out)
+	}
+	for i, name := range []string{"alpha", "bravo"} {
+		fields := strings.Split(lines[i], "\t")
+		if len(fields) != 6 || fields[0] != name || fields[5] != filepath.Join(tmpDir, name) {
+			t.Errorf("line %d = %q", i, lines[i])
+		}
+	}
+	if fields := strings.Split(lines[1], "\t"); fields[1] != "active" {
+		t.Errorf("bravo should be marked active: %q", lines[1])
+	}
+
+	if err := ListProfiles(tmpDir, ListOptions{Format: "csv"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestListProfiles_FormatJSONIncludesTotal(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	for _, name := range []string{"alpha", "bravo", "charlie"} {
+		newTaggableProfile(t, tmpDir, name)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Format: ui.FormatJSON, Offset: 1, Limit: 1})
+	})
+	if err != nil {
+		t.Fatalf("ListProfiles() error: %v", err)
+	}
+	var listing struct {
+		Total    int                 `json:"total"`
+		Profiles []map[string]string `json:"profiles"`
+	}
+	if err := json.Unmarshal([]byte(out), &listing); err != nil {
+		t.Fatalf("output is not a JSON object: %v\n%s", err, out)
+	}
+	if listing.Total != 3 {
+		t.Errorf("total = %d, want 3", listing.Total)
+	}
+	if len(listing.Profiles) != 1 || listing.Profiles[0]["name"] != "bravo" {
+		t.Errorf("profiles = %v, want only bravo", listing.Profiles)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Format: ui.FormatTable, Limit: 2})
+	})
+	if err != nil {
+		t.Fatalf("ListProfiles() error: %v", err)
+	}
+	if !strings.Contains(out, "Showing profiles 1-2 of 3") {
+		t.Errorf("expected the total after the table:\n%s", out)
+	}
+}
+
+func TestListProfiles_ModifiedFilters(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	ages := map[string]time.Duration{"fresh": 24 * time.Hour, "stale": 30 * 24 * time.Hour, "ancient": 200 * 24 * time.Hour}
+	for name, age := range ages {
+		profileDir := newTaggableProfile(t, tmpDir, name)
+		err := filepath.WalkDir(profileDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			return os.Chtimes(path, now.Add(-age), now.Add(-age))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Backups and caches do not count as activity
+	writeFiles(t, filepath.Join(tmpDir, "ancient"), ".backups/update_x/.envrc", ".kube/cache/discovery.json")
+
+	list := func(opts ListOptions) []string {
+		t.Helper()
+		opts.Clock = clock.NewFake(now)
+		out, err := captureStdout(t, func() error { return ListProfiles(tmpDir, opts) })
+		if err != nil {
+			t.Fatalf("ListProfiles() error: %v", err)
+		}
+		var names []string
+		for _, line := range strings.Split(out, "\n") {
+			if name, ok := strings.CutPrefix(line, "○ "); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	if got := list(ListOptions{Since: 7 * 24 * time.Hour}); !reflect.DeepEqual(got, []string{"fresh"}) {
+		t.Errorf("--since 7d = %v, want [fresh]", got)
+	}
+	if got := list(ListOptions{ModifiedBefore: 7 * 24 * time.Hour}); !reflect.DeepEqual(got, []string{"ancient", "stale"}) {
+		t.Errorf("--modified-before 7d = %v, want [ancient stale]", got)
+	}
+	if got := list(ListOptions{Since: 90 * 24 * time.Hour, ModifiedBefore: 7 * 24 * time.Hour}); !reflect.DeepEqual(got, []string{"stale"}) {
+		t.Errorf("--since 90d --modified-before 7d = %v, want [stale]", got)
+	}
+	if got := list(ListOptions{Sort: ListSortModified}); !reflect.DeepEqual(got, []string{"fresh", "stale", "ancient"}) {
+		t.Errorf("--sort modified = %v, want [fresh stale ancient]", got)
+	}
+}
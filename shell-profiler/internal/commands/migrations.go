@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/migrations"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+)
+
+// profileMigrations is the ordered chain of layout changes applied by
+// UpdateProfile. A profile's .profile-meta records the schema version it has
+// reached; profiles without metadata start at version 0. Append new steps to
+// the end of this list - never reorder or renumber existing ones. Steps
+// without a Revert can only be rolled back by restoring an update backup.
+// Each step's Rationale explains its changes in update --explain.
+var profileMigrations = migrations.MustRegistry(
+	migrations.Migration{
+		From:        0,
+		To:          1,
+		Name:        "tool-directories",
+		Description: "Create tool config directories and tighten .ssh permissions",
+		Rationale:   "tools fail or write outside the profile when their config directory is missing, and SSH refuses keys in a group-readable .ssh",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			created, err := updateDirectories(ctx.ProfileDir, ctx.DryRun, ctx.Pause)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update directories: %w", err)
+			}
+			if len(created) == 0 {
+				return nil, nil
+			}
+			return []string{fmt.Sprintf("Created directories: %s", strings.Join(created, ", "))}, nil
+		},
+		Revert: func(ctx migrations.Context) ([]string, error) {
+			removed, err := revertDirectories(ctx.ProfileDir, ctx.DryRun)
+			if err != nil {
+				return nil, err
+			}
+			if len(removed) == 0 {
+				return nil, nil
+			}
+			return []string{fmt.Sprintf("Removed empty directories: %s", strings.Join(removed, ", "))}, nil
+		},
+	},
+	migrations.Migration{
+		From:        1,
+		To:          2,
+		Name:        "envrc-tool-vars",
+		Description: "Move tool-specific exports out of .envrc",
+		Rationale:   "tool variables belong in .env so that .envrc only sets the workspace identity",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateEnvrc(ctx.ProfileDir, ctx.ProfileName, ctx.DryRun, ctx.Force))(
+				"Updated .envrc (moved tool-specific vars to .env)", "failed to update .envrc")
+		},
+	},
+	migrations.Migration{
+		From:        2,
+		To:          3,
+		Name:        "env-file",
+		Description: "Add tool-specific environment variables to .env",
+		Rationale:   "the tools' config paths are only scoped to the profile once .env sets them",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateEnvFile(ctx.ProfileDir, ctx.ProfileName, ctx.DryRun, ctx.Force, ctx.Pause))(
+				"Updated .env with tool-specific environment variables", "failed to update .env")
+		},
+	},
+	migrations.Migration{
+		From:        3,
+		To:          4,
+		Name:        "gitignore-patterns",
+		Description: "Add new tool patterns to .gitignore",
+		Rationale:   "newly supported tools keep credentials and caches in the profile that must not be committed",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateGitignore(ctx.ProfileDir, ctx.DryRun, ctx.Force))(
+				"Updated .gitignore with new patterns", "failed to update .gitignore")
+		},
+	},
+	migrations.Migration{
+		From:        4,
+		To:          5,
+		Name:        "remove-secrets-template",
+		Description: "Remove .env.secrets.tpl in favour of vault discovery",
+		Rationale:   "secrets are now discovered from the profile's vault, so the op inject template is unused",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(removeSecretsTemplate(ctx.ProfileDir, ctx.DryRun))(
+				"Removed .env.secrets.tpl (secrets now auto-discovered from vault)", "failed to remove .env.secrets.tpl")
+		},
+	},
+	migrations.Migration{
+		From:        5,
+		To:          6,
+		Name:        "vault-discovery",
+		Description: "Replace op inject with 1Password vault discovery in .envrc",
+		Rationale:   "op inject needed every secret listed by hand; discovery exports everything in the vault",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateEnvrcVaultDiscovery(ctx.ProfileDir, ctx.ProfileName, ctx.DryRun))(
+				"Replaced op inject with vault discovery in .envrc", "failed to update .envrc with vault discovery")
+		},
+	},
+	migrations.Migration{
+		From:        6,
+		To:          7,
+		Name:        "env-permissions",
+		Description: "Restrict .env to owner read/write (0600)",
+		Rationale:   ".env may hold tokens and should not be readable by other users",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(tightenEnvFile(ctx.ProfileDir, ctx.DryRun))(
+				"Restricted .env permissions to 0600", "failed to set .env permissions")
+		},
+	},
+	migrations.Migration{
+		From:        7,
+		To:          8,
+		Name:        "gitattributes",
+		Description: "Add .gitattributes keeping shell scripts LF-terminated",
+		Rationale:   "CRLF line endings break shell scripts checked out on Windows",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateGitattributes(ctx.ProfileDir, ctx.DryRun))(
+				"Added LF line-ending rules to .gitattributes", "failed to update .gitattributes")
+		},
+		Revert: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(revertGitattributes(ctx.ProfileDir, ctx.DryRun))(
+				"Removed generated .gitattributes", "failed to remove .gitattributes")
+		},
+	},
+	migrations.Migration{
+		From:        8,
+		To:          9,
+		Name:        "env-managed-block",
+		Description: "Fence managed variables in .env and regenerate them from the template",
+		Rationale:   "fencing the generated variables lets update refresh them without touching yours",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateEnvFile(ctx.ProfileDir, ctx.ProfileName, ctx.DryRun, ctx.Force, ctx.Pause))(
+				"Regenerated managed variables in .env", "failed to update .env")
+		},
+	},
+	// Deprecating more variables later needs another step like this one, so
+	// that profiles already past it are cleaned up too
+	migrations.Migration{
+		From:        9,
+		To:          10,
+		Name:        "deprecated-env-vars",
+		Description: "Remove deprecated managed variables from .env",
+		Rationale:   "these variables are no longer read by the tools and only clutter the environment",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			removed, err := removeEnvVars(ctx.ProfileDir, tools.DeprecatedEnvVarNames(), ctx.DryRun)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update .env: %w", err)
+			}
+			if len(removed) == 0 {
+				return nil, nil
+			}
+			return []string{fmt.Sprintf("Removed deprecated variables from .env: %s", strings.Join(removed, ", "))}, nil
+		},
+	},
+	migrations.Migration{
+		From:        10,
+		To:          11,
+		Name:        "git-default-branch",
+		Description: "Set init.defaultBranch in .gitconfig if it is not set",
+		Rationale:   "new repositories otherwise start on git's built-in default branch",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateDefaultBranch(ctx.ProfileDir, ctx.DryRun))(
+				"Set init.defaultBranch in .gitconfig", "failed to update .gitconfig")
+		},
+	},
+	migrations.Migration{
+		From:        11,
+		To:          12,
+		Name:        "envrc-watch-file",
+		Description: "Reload direnv and rebuild the env cache when .env changes",
+		Rationale:   "without watching .env, edits to it are ignored until the cache expires",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateEnvrcWatch(ctx.ProfileDir, ctx.DryRun))(
+				"Added watch_file .env to .envrc", "failed to update .envrc")
+		},
+	},
+	migrations.Migration{
+		From:        12,
+		To:          13,
+		Name:        "envrc-located-home",
+		Description: "Derive WORKSPACE_HOME from the .envrc's location instead of $PWD",
+		Rationale:   "$PWD only matches the profile when direnv evaluates the .envrc; sourced some other way, or through a symlinked or moved directory, it points elsewhere",
+		Apply: func(ctx migrations.Context) ([]string, error) {
+			return changeIf(updateEnvrcWorkspaceHome(ctx.ProfileDir, ctx.DryRun))(
+				"Set WORKSPACE_HOME from the .envrc's location", "failed to update .envrc")
+		},
+	},
+)
+
+// changeIf adapts the (updated bool, err error) result of an update step to
+// the change list returned by a migration
+func changeIf(updated bool, err error) func(change, errContext string) ([]string, error) {
+	return func(change, errContext string) ([]string, error) {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", errContext, err)
+		}
+		if !updated {
+			return nil, nil
+		}
+		return []string{change}, nil
+	}
+}
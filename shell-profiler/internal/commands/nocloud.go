@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+)
+
+// envAssignmentPattern matches a variable assignment in a .env file,
+// including the commented-out examples of optional variables
+var envAssignmentPattern = regexp.MustCompile(`^#?\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// profileNoCloud reports whether a profile was created with --no-cloud
+func profileNoCloud(profileDir string) bool {
+	meta, err := profile.ReadMeta(profileDir)
+	return err == nil && meta.NoCloud
+}
+
+// stripCloudEnv removes the paragraphs of a .env whose assignments all
+// belong to cloud tools. The managed block fences are always kept.
+func stripCloudEnv(content string) string {
+	return filterParagraphs(content, func(line string) (bool, bool) {
+		if line == managed.Begin || line == managed.End {
+			return false, false
+		}
+		match := envAssignmentPattern.FindStringSubmatch(line)
+		if match == nil {
+			return false, true
+		}
+		return true, tools.IsCloudEnvVar(match[1])
+	})
+}
+
+// stripCloudGitignore removes the paragraphs of a .gitignore whose patterns
+// all belong to cloud tools
+func stripCloudGitignore(content string) string {
+	return filterParagraphs(content, func(line string) (bool, bool) {
+		if strings.HasPrefix(line, "#") {
+			return false, true
+		}
+		return true, tools.IsCloudPath(line)
+	})
+}
+
+// filterParagraphs drops the blank-line separated paragraphs of content in
+// which at least one line is an entry and every line is droppable, as
+// classify reports them. Lines that are neither keep their paragraph.
+func filterParagraphs(content string, classify func(line string) (entry, drop bool)) string {
+	paragraphs := strings.Split(strings.TrimRight(content, "\n"), "\n\n")
+	kept := paragraphs[:0]
+	for _, paragraph := range paragraphs {
+		entries, droppable := 0, true
+		for _, line := range strings.Split(paragraph, "\n") {
+			entry, drop := classify(strings.TrimSpace(line))
+			if entry {
+				entries++
+			}
+			droppable = droppable && drop
+		}
+		if entries == 0 || !droppable {
+			kept = append(kept, paragraph)
+		}
+	}
+	return strings.Join(kept, "\n\n") + "\n"
+}
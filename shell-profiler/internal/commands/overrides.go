@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/util"
+)
+
+// applyEnvOverrides sets the managed variables named in overrides, the
+// Overrides of .profile-meta, to their custom values in generated .env
+// content. A commented-out optional variable is enabled; names the template
+// does not generate are ignored, since variables outside the managed block
+// are never touched anyway. Values may refer to $WORKSPACE_HOME,
+// $WORKSPACE_PROFILE and $HOME.
+func applyEnvOverrides(content string, overrides map[string]string) string {
+	if len(overrides) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		match := envAssignmentPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		if value, ok := overrides[match[1]]; ok {
+			lines[i] = match[1] + "=" + util.ShellQuoteExpand(value, templates.PathVars...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	content := "KUBECONFIG=\"$WORKSPACE_HOME/.kube/config\"\n# TF_PLUGIN_CACHE_DIR=\"$WORKSPACE_HOME/.terraform.d/plugin-cache\"\nAWS_PROFILE=\"default\"\n"
+	got := applyEnvOverrides(content, map[string]string{
+		"KUBECONFIG":          "$HOME/.kube/shared",
+		"TF_PLUGIN_CACHE_DIR": "/var/cache/tf",
+		"AWS_PROFILE":         `it's "$x"`,
+		"NOT_GENERATED":       "ignored",
+	})
+	want := "KUBECONFIG=\"$HOME/.kube/shared\"\nTF_PLUGIN_CACHE_DIR=\"/var/cache/tf\"\nAWS_PROFILE=\"it's \\\"\\$x\\\"\"\n"
+	if got != want {
+		t.Errorf("applyEnvOverrides() =\n%s\nwant:\n%s", got, want)
+	}
+	if applyEnvOverrides(content, nil) != content {
+		t.Error("no overrides should leave the content unchanged")
+	}
+}
+
+func TestUpdateProfile_KeepsOverriddenKubeconfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	for _, name := range []string{"shared", "plain"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatal(err)
+		}
+		profileDir := filepath.Join(tmpDir, name)
+		// A profile at schema version 8 whose managed block is regenerated
+		// by the env-managed-block migration
+		writeStaleEnv(t, profileDir, "KUBECONFIG=\"$HOME/.kube/shared\"\n", "")
+		meta, _ := profile.ReadMeta(profileDir)
+		meta.SchemaVersion = 8
+		if name == "shared" {
+			meta.Overrides = map[string]string{"KUBECONFIG": "$HOME/.kube/shared"}
+		}
+		if err := profile.WriteMeta(profileDir, meta); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := captureStdout(t, func() error {
+			return UpdateProfile(tmpDir, UpdateOptions{ProfileName: name, NoBackup: true})
+		}); err != nil {
+			t.Fatalf("UpdateProfile(%s) error: %v", name, err)
+		}
+		data, _ := os.ReadFile(filepath.Join(profileDir, ".env"))
+		content := string(data)
+		if !strings.Contains(content, `GIT_CONFIG_GLOBAL="$WORKSPACE_HOME/.gitconfig"`) {
+			t.Fatalf("%s: managed block was not regenerated:\n%s", name, content)
+		}
+		overridden := strings.Contains(content, `KUBECONFIG="$HOME/.kube/shared"`)
+		reset := strings.Contains(content, `KUBECONFIG="$WORKSPACE_HOME/.kube/config"`)
+		if name == "shared" && (!overridden || reset) {
+			t.Errorf("overridden KUBECONFIG did not survive the update:\n%s", content)
+		}
+		if name == "plain" && (overridden || !reset) {
+			t.Errorf("KUBECONFIG without an override should be reset:\n%s", content)
+		}
+	}
+}
+
+func TestCreateProfile_FromProfileInheritsOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "base", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "base"))
+	meta.Overrides = map[string]string{"KUBECONFIG": "$HOME/.kube/shared"}
+	if err := profile.WriteMeta(filepath.Join(tmpDir, "base"), meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "copy", FromProfile: "base"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "copy", ".env"))
+	if !strings.Contains(string(data), `KUBECONFIG="$HOME/.kube/shared"`) {
+		t.Errorf("inherited override not applied to .env:\n%s", data)
+	}
+	if meta, _ := profile.ReadMeta(filepath.Join(tmpDir, "copy")); meta.Overrides["KUBECONFIG"] != "$HOME/.kube/shared" {
+		t.Errorf("meta overrides = %v", meta.Overrides)
+	}
+}
@@ -0,0 +1,239 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+	"github.com/neverprepared/shell-profile-manager/internal/util"
+)
+
+type PathOptions struct {
+	ProfileName string
+	Dirs        []string
+}
+
+// validatePathDir rejects directories that cannot be written on a single
+// PATH_add line. Anything else is quoted by templates.QuotePath.
+func validatePathDir(dir string) error {
+	if strings.TrimSpace(dir) == "" {
+		return fmt.Errorf("PATH directory cannot be empty")
+	}
+	if strings.ContainsAny(dir, "\n\r\x00") {
+		return fmt.Errorf("invalid PATH directory %q: line breaks are not allowed", dir)
+	}
+	return nil
+}
+
+// pathAddLine is the .envrc line adding dir to PATH, as rendered by envrc.tpl
+func pathAddLine(dir string) string {
+	return "PATH_add " + templates.QuotePath(filepath.Clean(dir))
+}
+
+// parsePathAdd returns the directory of a PATH_add line
+func parsePathAdd(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "PATH_add ")
+	if !ok {
+		return "", false
+	}
+	return filepath.Clean(util.ShellUnquote(strings.TrimSpace(rest))), true
+}
+
+// profilePaths returns the lines of a profile's .envrc, the index just past
+// the PATH_add lines following PATH_add bin, and the line of each directory
+// they add
+func profilePaths(envrcPath string) (lines []string, end int, dirs map[string]int, err error) {
+	data, err := os.ReadFile(envrcPath)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read .envrc: %w", err)
+	}
+	lines = strings.Split(string(data), "\n")
+
+	anchor := -1
+	for i, line := range lines {
+		if dir, ok := parsePathAdd(line); ok && dir == "bin" {
+			anchor = i
+			break
+		}
+	}
+	if anchor < 0 {
+		return nil, 0, nil, fmt.Errorf("%s has no 'PATH_add bin' line to add directories after", envrcPath)
+	}
+
+	dirs = make(map[string]int)
+	end = anchor + 1
+	for ; end < len(lines); end++ {
+		dir, ok := parsePathAdd(lines[end])
+		if !ok {
+			break
+		}
+		dirs[dir] = end
+	}
+	return lines, end, dirs, nil
+}
+
+// AddPaths adds directories to PATH in a profile's .envrc, after bin/.
+// Directories already present are left alone.
+func AddPaths(profilesDir string, opts PathOptions) error {
+	return modifyPaths(profilesDir, opts, func(lines []string, end int, dirs map[string]int) ([]string, []string) {
+		var added, insert []string
+		for _, dir := range opts.Dirs {
+			clean := filepath.Clean(dir)
+			if _, ok := dirs[clean]; ok {
+				continue
+			}
+			dirs[clean] = -1
+			added = append(added, clean)
+			insert = append(insert, pathAddLine(clean))
+		}
+		result := append(append(append([]string(nil), lines[:end]...), insert...), lines[end:]...)
+		return result, added
+	}, "Added")
+}
+
+// RemovePaths removes directories added with AddPaths or --path-add
+func RemovePaths(profilesDir string, opts PathOptions) error {
+	return modifyPaths(profilesDir, opts, func(lines []string, _ int, dirs map[string]int) ([]string, []string) {
+		drop := make(map[int]bool)
+		var removed []string
+		for _, dir := range opts.Dirs {
+			clean := filepath.Clean(dir)
+			if i, ok := dirs[clean]; ok && !drop[i] {
+				drop[i] = true
+				removed = append(removed, clean)
+			}
+		}
+		var result []string
+		for i, line := range lines {
+			if !drop[i] {
+				result = append(result, line)
+			}
+		}
+		return result, removed
+	}, "Removed")
+}
+
+func modifyPaths(profilesDir string, opts PathOptions, apply func(lines []string, end int, dirs map[string]int) ([]string, []string), verb string) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if len(opts.Dirs) == 0 {
+		return fmt.Errorf("at least one directory is required")
+	}
+	for _, dir := range opts.Dirs {
+		if err := validatePathDir(dir); err != nil {
+			return err
+		}
+	}
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	if _, err := os.Stat(envrcPath); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+	}
+
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	if err := checkSchemaCompatible(meta, opts.ProfileName); err != nil {
+		return err
+	}
+
+	lines, end, dirs, err := profilePaths(envrcPath)
+	if err != nil {
+		return err
+	}
+	lines, changed := apply(lines, end, dirs)
+	if len(changed) == 0 {
+		ui.PrintInfo(fmt.Sprintf("No PATH directories changed on profile: %s", opts.ProfileName))
+		return nil
+	}
+
+	if err := os.WriteFile(envrcPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write .envrc: %w", err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("%s PATH directories on %s: %s", verb, opts.ProfileName, strings.Join(changed, ", ")))
+	ui.PrintInfo(fmt.Sprintf("Run 'direnv allow %s' to apply the change", profileDir))
+	return nil
+}
+
+// ListPaths prints the directories a profile adds to PATH after bin/
+func ListPaths(profilesDir string, opts PathOptions) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	if _, err := os.Stat(envrcPath); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+	}
+
+	lines, end, dirs, err := profilePaths(envrcPath)
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Profile %s adds no directories to PATH besides bin/", opts.ProfileName))
+		return nil
+	}
+	start := end
+	for start > 0 {
+		if dir, _ := parsePathAdd(lines[start-1]); dir == "bin" {
+			break
+		}
+		start--
+	}
+	for _, line := range lines[start:end] {
+		dir, _ := parsePathAdd(line)
+		fmt.Println(dir)
+	}
+	return nil
+}
+
+// ProfilePath returns the absolute directory of a profile in profilesDir,
+// with symlinks resolved
+func ProfilePath(profilesDir, profileName string) (string, error) {
+	if profileName == "" {
+		return "", fmt.Errorf("profile name is required")
+	}
+	profileDir := filepath.Join(profilesDir, profileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		return "", errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", profileName, profileDir)
+	}
+	absDir, err := filepath.Abs(profileDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", absDir, err)
+	}
+	return resolved, nil
+}
+
+// PrintProfilePath prints only the profile's directory, for use in
+// command substitution
+func PrintProfilePath(profilesDir, profileName string) error {
+	dir, err := ProfilePath(profilesDir, profileName)
+	if err != nil {
+		return err
+	}
+	fmt.Println(dir)
+	return nil
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+func newPathProfile(t *testing.T, profilesDir, name string) string {
+	t.Helper()
+	profileDir := newTaggableProfile(t, profilesDir, name)
+	envrc := "export WORKSPACE_PROFILE=\"" + name + "\"\nPATH_add bin\n\ndotenv_if_exists .envrc.local\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(profileDir, ".envrc")
+}
+
+func TestAddPaths_Idempotent(t *testing.T) {
+	profilesDir := t.TempDir()
+	envrcPath := newPathProfile(t, profilesDir, "acme")
+
+	opts := PathOptions{ProfileName: "acme", Dirs: []string{"code/app/node_modules/.bin", "/opt/acme/bin/"}}
+	if _, err := captureStdout(t, func() error { return AddPaths(profilesDir, opts) }); err != nil {
+		t.Fatalf("AddPaths() error: %v", err)
+	}
+	first, _ := os.ReadFile(envrcPath)
+	want := "PATH_add bin\nPATH_add \"code/app/node_modules/.bin\"\nPATH_add \"/opt/acme/bin\"\n\ndotenv_if_exists"
+	if !strings.Contains(string(first), want) {
+		t.Fatalf("PATH_add lines not added after bin:\n%s", first)
+	}
+
+	out, err := captureStdout(t, func() error { return AddPaths(profilesDir, opts) })
+	if err != nil {
+		t.Fatalf("second AddPaths() error: %v", err)
+	}
+	second, _ := os.ReadFile(envrcPath)
+	if string(second) != string(first) {
+		t.Errorf("adding the same directories again changed .envrc:\n%s", second)
+	}
+	if !strings.Contains(out, "No PATH directories changed") {
+		t.Errorf("expected a no-op message:\n%s", out)
+	}
+}
+
+func TestRemovePaths(t *testing.T) {
+	profilesDir := t.TempDir()
+	envrcPath := newPathProfile(t, profilesDir, "acme")
+	if _, err := captureStdout(t, func() error {
+		return AddPaths(profilesDir, PathOptions{ProfileName: "acme", Dirs: []string{"tools", "/opt/acme/bin"}})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return RemovePaths(profilesDir, PathOptions{ProfileName: "acme", Dirs: []string{"tools"}})
+	}); err != nil {
+		t.Fatalf("RemovePaths() error: %v", err)
+	}
+	content, _ := os.ReadFile(envrcPath)
+	if strings.Contains(string(content), `"tools"`) || !strings.Contains(string(content), "PATH_add bin\nPATH_add \"/opt/acme/bin\"\n") {
+		t.Errorf("unexpected .envrc after remove:\n%s", content)
+	}
+
+	out, err := captureStdout(t, func() error { return ListPaths(profilesDir, PathOptions{ProfileName: "acme"}) })
+	if err != nil || out != "/opt/acme/bin\n" {
+		t.Errorf("ListPaths() = %q, %v", out, err)
+	}
+}
+
+func TestAddPaths_RejectsUnsafeDirs(t *testing.T) {
+	profilesDir := t.TempDir()
+	newPathProfile(t, profilesDir, "acme")
+
+	for _, dir := range []string{"", "  ", "a\nb"} {
+		if err := AddPaths(profilesDir, PathOptions{ProfileName: "acme", Dirs: []string{dir}}); err == nil {
+			t.Errorf("AddPaths(%q) should fail", dir)
+		}
+	}
+}
+
+func TestAddPaths_QuotesDirs(t *testing.T) {
+	profilesDir := t.TempDir()
+	envrcPath := newPathProfile(t, profilesDir, "acme")
+
+	dirs := []string{`a"b`, "`id`", "$HOME/bin", "$(id)/bin"}
+	if _, err := captureStdout(t, func() error {
+		return AddPaths(profilesDir, PathOptions{ProfileName: "acme", Dirs: dirs})
+	}); err != nil {
+		t.Fatalf("AddPaths() error: %v", err)
+	}
+	content, _ := os.ReadFile(envrcPath)
+	for _, want := range []string{"PATH_add \"a\\\"b\"\n", "PATH_add \"\\`id\\`\"\n", "PATH_add \"$HOME/bin\"\n", "PATH_add \"\\$(id)/bin\"\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf(".envrc missing %q:\n%s", want, content)
+		}
+	}
+
+	out, err := captureStdout(t, func() error { return ListPaths(profilesDir, PathOptions{ProfileName: "acme"}) })
+	if err != nil || out != strings.Join(dirs, "\n")+"\n" {
+		t.Errorf("ListPaths() = %q, %v", out, err)
+	}
+}
+
+func TestProfilePath_ResolvesSymlinks(t *testing.T) {
+	profilesDir := t.TempDir()
+	newPathProfile(t, profilesDir, "acme")
+	link := filepath.Join(t.TempDir(), "profiles")
+	if err := os.Symlink(profilesDir, link); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ProfilePath(link, "acme")
+	if err != nil {
+		t.Fatalf("ProfilePath() error: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(filepath.Join(profilesDir, "acme"))
+	if got != want {
+		t.Errorf("ProfilePath() = %q, want %q", got, want)
+	}
+
+	if _, err := ProfilePath(profilesDir, "missing"); !errors.Is(err, errs.ErrProfileNotFound) {
+		t.Errorf("ProfilePath(missing) error = %v, want ErrProfileNotFound", err)
+	}
+}
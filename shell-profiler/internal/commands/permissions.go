@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// Change is a single repair made to a profile (or, in dry-run, that would be made)
+type Change struct {
+	Path        string `json:"path"` // Relative to the profile directory
+	Description string `json:"description"`
+
+	mode, was os.FileMode // Permissions set and replaced
+}
+
+// permissionRule is the most permissive mode allowed for the paths matching
+// Pattern (relative to the profile directory)
+type permissionRule struct {
+	Pattern string
+	Mode    os.FileMode
+	Dir     bool
+}
+
+// permissionPolicy lists sensitive profile paths and the modes they are
+// corrected to:
+//   - .ssh is 0700 and everything in it except public keys is 0600
+//   - .env and its backups are 0600, since secrets are appended to them
+//   - credential files for 1Password, AWS and Kubernetes are 0600
+var permissionPolicy = []permissionRule{
+	{Pattern: ".ssh", Mode: 0700, Dir: true},
+	{Pattern: ".ssh/*", Mode: 0600},
+	{Pattern: ".env", Mode: 0600},
+	{Pattern: ".backups/*/.env", Mode: 0600},
+	{Pattern: ".config/1Password/agent.toml", Mode: 0600},
+	{Pattern: ".aws/credentials", Mode: 0600},
+	{Pattern: ".kube/config", Mode: 0600},
+}
+
+// FixPermissions tightens sensitive profile paths that are more permissive
+// than permissionPolicy allows. Paths already stricter than the policy are
+// left alone. With dryRun set, the changes are reported but not made.
+func FixPermissions(profileDir string, dryRun bool) ([]Change, error) {
+	var changes []Change
+	for _, rule := range permissionPolicy {
+		matches, err := filepath.Glob(filepath.Join(profileDir, rule.Pattern))
+		if err != nil {
+			return changes, fmt.Errorf("invalid permission pattern %s: %w", rule.Pattern, err)
+		}
+
+		for _, path := range matches {
+			info, err := os.Lstat(path)
+			if err != nil {
+				return changes, fmt.Errorf("failed to stat %s: %w", path, err)
+			}
+			// Symlinks are skipped since chmod would change their target
+			if info.Mode()&os.ModeSymlink != 0 || info.IsDir() != rule.Dir {
+				continue
+			}
+			if !rule.Dir && strings.HasSuffix(path, ".pub") {
+				continue
+			}
+
+			mode := info.Mode().Perm()
+			if mode&^rule.Mode == 0 {
+				continue
+			}
+
+			rel, _ := filepath.Rel(profileDir, path)
+			changes = append(changes, Change{
+				Path:        rel,
+				Description: fmt.Sprintf("chmod %04o (was %04o)", rule.Mode, mode),
+				mode:        rule.Mode,
+				was:         mode,
+			})
+			if !dryRun {
+				if err := os.Chmod(path, rule.Mode); err != nil {
+					return changes, fmt.Errorf("failed to set permissions on %s: %w", rel, err)
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// FixPermissionsOptions selects the profile for FixProfilePermissions
+type FixPermissionsOptions struct {
+	ProfileName string
+	DryRun      bool
+}
+
+// FixProfilePermissions runs FixPermissions on a profile and reports each change
+func FixProfilePermissions(profilesDir string, opts FixPermissionsOptions) error {
+	if err := checkProfilesDir(profilesDir, !opts.DryRun); err != nil {
+		return err
+	}
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+	}
+
+	if meta, err := profile.LoadMeta(profileDir); err == nil {
+		if err := checkSchemaCompatible(meta, opts.ProfileName); err != nil {
+			return err
+		}
+	}
+
+	if !opts.DryRun {
+		lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+	}
+
+	changes, err := FixPermissions(profileDir, opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		ui.PrintSuccess(fmt.Sprintf("Permissions are correct for profile: %s", opts.ProfileName))
+		return nil
+	}
+	if opts.DryRun {
+		ui.PrintInfo("DRY RUN - Would fix permissions:")
+	} else {
+		ui.PrintSuccess(fmt.Sprintf("Fixed permissions for profile: %s", opts.ProfileName))
+	}
+	for _, change := range changes {
+		fmt.Printf("  - %s: %s\n", change.Path, change.Description)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeWithMode creates a file under the profile and forces its mode
+func writeWithMode(t *testing.T, profileDir, rel string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(profileDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func modeOf(t *testing.T, path string) os.FileMode {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode().Perm()
+}
+
+func TestFixPermissions(t *testing.T) {
+	profileDir := t.TempDir()
+	key := writeWithMode(t, profileDir, ".ssh/id_ed25519", 0644)
+	pub := writeWithMode(t, profileDir, ".ssh/id_ed25519.pub", 0644)
+	env := writeWithMode(t, profileDir, ".env", 0644)
+	agent := writeWithMode(t, profileDir, ".config/1Password/agent.toml", 0664)
+	strict := writeWithMode(t, profileDir, ".aws/credentials", 0400)
+	if err := os.Chmod(filepath.Join(profileDir, ".ssh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := FixPermissions(profileDir, false)
+	if err != nil {
+		t.Fatalf("FixPermissions() error: %v", err)
+	}
+
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	want := ".ssh,.ssh/id_ed25519,.env,.config/1Password/agent.toml"
+	if strings.Join(paths, ",") != want {
+		t.Errorf("changed paths = %v, want %s", paths, want)
+	}
+
+	for path, wantMode := range map[string]os.FileMode{
+		filepath.Join(profileDir, ".ssh"): 0700,
+		key:                               0600,
+		pub:                               0644,
+		env:                               0600,
+		agent:                             0600,
+		strict:                            0400,
+	} {
+		if got := modeOf(t, path); got != wantMode {
+			t.Errorf("%s mode = %04o, want %04o", path, got, wantMode)
+		}
+	}
+
+	// A second run has nothing left to do
+	if changes, _ := FixPermissions(profileDir, false); len(changes) != 0 {
+		t.Errorf("second run reported changes: %+v", changes)
+	}
+}
+
+func TestFixPermissions_DryRun(t *testing.T) {
+	profileDir := t.TempDir()
+	env := writeWithMode(t, profileDir, ".env", 0644)
+
+	changes, err := FixPermissions(profileDir, true)
+	if err != nil {
+		t.Fatalf("FixPermissions() error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != ".env" || changes[0].Description != "chmod 0600 (was 0644)" {
+		t.Errorf("changes = %+v", changes)
+	}
+	if got := modeOf(t, env); got != 0644 {
+		t.Errorf("dry run changed .env mode to %04o", got)
+	}
+}
+
+func TestDoctor_FixRepairsPermissions(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	key := writeWithMode(t, filepath.Join(tmpDir, "acme"), ".ssh/id_rsa", 0644)
+
+	findings, err := DiagnoseProfile(filepath.Join(tmpDir, "acme"), "acme", false)
+	if err != nil {
+		t.Fatalf("DiagnoseProfile() error: %v", err)
+	}
+	found := false
+	for _, f := range findings {
+		if f.Check == "permissions" && strings.HasPrefix(f.Message, ".ssh/id_rsa:") && !f.Fixed {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a permissions finding for .ssh/id_rsa, got %+v", findings)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return Doctor(tmpDir, DoctorOptions{Fix: true})
+	})
+	if err != nil {
+		t.Fatalf("Doctor() error: %v", err)
+	}
+	if !strings.Contains(out, "=== acme ===") || !strings.Contains(out, "fixed") {
+		t.Errorf("doctor output:\n%s", out)
+	}
+	if got := modeOf(t, key); got != 0600 {
+		t.Errorf("doctor --fix left id_rsa at %04o", got)
+	}
+}
@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/migrations"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// UpdatePlan records the migrations an update would run so it can be
+// reviewed before being applied
+type UpdatePlan struct {
+	Profile     string     `json:"profile"`
+	FromVersion int        `json:"fromVersion"`
+	ToVersion   int        `json:"toVersion"`
+	Created     string     `json:"created"`
+	Steps       []PlanStep `json:"steps"`
+
+	// Files holds the SHA-256 of each profile file the plan was made
+	// against, so a stale plan can be detected before it is applied
+	Files map[string]string `json:"files"`
+}
+
+// ApplyOptions controls how a saved plan is applied
+type ApplyOptions struct {
+	Force     bool // Apply even if profile files changed since the plan was made
+	Overwrite bool // Let migrations replace existing files (--overwrite)
+	NoBackup  bool
+
+	Clock clock.Clock // Time source for backup names; the system clock if nil
+}
+
+// PlanStep is one migration in an UpdatePlan with the changes it would make
+type PlanStep struct {
+	From        int      `json:"from"`
+	To          int      `json:"to"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Rationale   string   `json:"rationale,omitempty"`
+	Changes     []string `json:"changes"`
+}
+
+// PlanChange is one change in an UpdatePlan with the migration that makes it
+// and why
+type PlanChange struct {
+	Rule      string // Name of the migration
+	From, To  int
+	Change    string
+	Rationale string
+}
+
+// Explain lists every change in the plan with the rule that triggered it
+func (p *UpdatePlan) Explain() []PlanChange {
+	var changes []PlanChange
+	for _, step := range p.Steps {
+		for _, change := range step.Changes {
+			changes = append(changes, PlanChange{
+				Rule:      step.Name,
+				From:      step.From,
+				To:        step.To,
+				Change:    change,
+				Rationale: step.Rationale,
+			})
+		}
+	}
+	return changes
+}
+
+// printPlanExplanation writes the changes in a plan as an indented list,
+// each with its rule and rationale
+func printPlanExplanation(plan *UpdatePlan) {
+	changes := plan.Explain()
+	if len(changes) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Nothing to change (schema version %d -> %d)", plan.FromVersion, plan.ToVersion))
+		return
+	}
+	fmt.Printf("Planned changes (schema version %d -> %d):\n", plan.FromVersion, plan.ToVersion)
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change.Change)
+		fmt.Printf("      rule: %s (%d->%d)\n", change.Rule, change.From, change.To)
+		if change.Rationale != "" {
+			fmt.Printf("      why:  %s\n", change.Rationale)
+		}
+	}
+}
+
+// buildUpdatePlan dry-runs the pending migrations for a profile
+func buildUpdatePlan(profileDir, profileName string, fromVersion int, clk clock.Clock) (*UpdatePlan, error) {
+	ctx := migrations.Context{
+		ProfileDir:  profileDir,
+		ProfileName: profileName,
+		DryRun:      true,
+	}
+	results, version, err := profileMigrations.Run(ctx, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &UpdatePlan{
+		Profile:     profileName,
+		FromVersion: fromVersion,
+		ToVersion:   version,
+		Created:     clock.Or(clk).Now().UTC().Format(time.RFC3339),
+		Steps:       []PlanStep{},
+	}
+	if plan.Files, err = hashProfileFiles(profileDir); err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		plan.Steps = append(plan.Steps, PlanStep{
+			From:        result.Migration.From,
+			To:          result.Migration.To,
+			Name:        result.Migration.Name,
+			Description: result.Migration.Description,
+			Rationale:   result.Migration.Rationale,
+			Changes:     result.Changes,
+		})
+	}
+	return plan, nil
+}
+
+// WritePlan saves an update plan as JSON
+func WritePlan(path string, plan *UpdatePlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// ReadPlan loads an update plan written by WritePlan
+func ReadPlan(path string) (*UpdatePlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	var plan UpdatePlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if plan.Profile == "" {
+		return nil, fmt.Errorf("plan %s does not name a profile", path)
+	}
+	return &plan, nil
+}
+
+// applyUpdatePlan runs the migrations in a plan, refusing if the profile or
+// the migration chain no longer matches what the plan was made against.
+// overwrite lets the migrations replace existing files.
+func applyUpdatePlan(profileDir string, plan *UpdatePlan, fromVersion int, overwrite bool) ([]migrations.Result, error) {
+	if fromVersion != plan.FromVersion {
+		return nil, fmt.Errorf("plan was made at schema version %d but profile '%s' is at version %d", plan.FromVersion, plan.Profile, fromVersion)
+	}
+
+	var planned, pending []string
+	for _, step := range plan.Steps {
+		planned = append(planned, step.Name)
+	}
+	for _, m := range profileMigrations.Pending(fromVersion) {
+		pending = append(pending, m.Name)
+	}
+	if strings.Join(planned, ",") != strings.Join(pending, ",") {
+		return nil, fmt.Errorf("plan migrations (%s) do not match pending migrations (%s)", strings.Join(planned, ", "), strings.Join(pending, ", "))
+	}
+
+	ctx := migrations.Context{
+		ProfileDir:  profileDir,
+		ProfileName: plan.Profile,
+		Force:       overwrite,
+	}
+	results, version, err := profileMigrations.Run(ctx, fromVersion)
+	if err != nil {
+		return results, err
+	}
+	if _, err := recordSchemaVersion(profileDir, plan.Profile, version, false); err != nil {
+		return results, fmt.Errorf("failed to update schema version: %w", err)
+	}
+	return results, nil
+}
+
+// hashProfileFiles returns the SHA-256 of each backed-up profile file that
+// exists
+func hashProfileFiles(profileDir string) (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, file := range backupFiles {
+		data, err := os.ReadFile(filepath.Join(profileDir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		sum := sha256.Sum256(data)
+		hashes[file] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
+
+// driftedFiles lists the files whose content no longer matches the plan,
+// including files created or removed since it was made
+func driftedFiles(profileDir string, plan *UpdatePlan) ([]string, error) {
+	current, err := hashProfileFiles(profileDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for _, file := range backupFiles {
+		if current[file] != plan.Files[file] {
+			drifted = append(drifted, file)
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// ApplyPlan performs exactly the migrations in a plan saved with
+// update --plan-file. It refuses if any profile file changed since the plan
+// was made, unless opts.Force is set.
+func ApplyPlan(profilesDir, planPath string, opts ApplyOptions) error {
+	plan, err := ReadPlan(planPath)
+	if err != nil {
+		return err
+	}
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, plan.Profile)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", plan.Profile, profileDir)
+	}
+
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	if err := checkSchemaCompatible(meta, plan.Profile); err != nil {
+		return err
+	}
+
+	drifted, err := driftedFiles(profileDir, plan)
+	if err != nil {
+		return err
+	}
+	if len(drifted) > 0 {
+		if !opts.Force {
+			return fmt.Errorf("profile '%s' changed since the plan was made (%s); create a new plan or use --force", plan.Profile, strings.Join(drifted, ", "))
+		}
+		ui.PrintWarning(fmt.Sprintf("Applying despite changes since the plan was made: %s", strings.Join(drifted, ", ")))
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Applying plan to profile: %s", plan.Profile))
+	fmt.Printf("  Schema version: %d -> %d\n", plan.FromVersion, plan.ToVersion)
+
+	if len(plan.Steps) == 0 {
+		ui.PrintInfo("Plan has no migrations to apply")
+		return nil
+	}
+
+	if err := runProfileHook(profileDir, hooks.PreUpdate, plan.Profile); err != nil {
+		return fmt.Errorf("apply cancelled: %w", err)
+	}
+
+	if !opts.NoBackup {
+		if _, err := createBackup(profileDir, "update", opts.Clock); err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+
+	results, err := applyUpdatePlan(profileDir, plan, meta.SchemaVersion, opts.Overwrite)
+	for _, result := range results {
+		fmt.Printf("    %d->%d %s\n", result.Migration.From, result.Migration.To, result.Migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	ui.PrintSuccess("Plan applied successfully")
+	printSetupSteps(results, meta, plan.Profile)
+
+	if err := runProfileHook(profileDir, hooks.PostUpdate, plan.Profile); err != nil {
+		return fmt.Errorf("plan was applied but the post-update hook failed: %w", err)
+	}
+	return nil
+}
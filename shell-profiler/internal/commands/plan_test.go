@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+// newV1Profile creates a schema version 1 profile that still uses op inject
+func newV1Profile(t *testing.T, profilesDir, name string) string {
+	t.Helper()
+	profileDir := filepath.Join(profilesDir, name)
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	envrc := "#!/usr/bin/env bash\nexport WORKSPACE_PROFILE=\"" + name + "\"\nexport AWS_CONFIG_FILE=\"$WORKSPACE_HOME/.aws/config\"\n\n" +
+		"# Resolve secrets\nif [ -f .env.secrets.tpl ]; then\n    op inject -i .env.secrets.tpl\nfi\n\ndotenv_if_exists .envrc.local\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".env.secrets.tpl"), []byte("X=op://v/i/f\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.WriteMeta(profileDir, &profile.Meta{SchemaVersion: 1, Name: name, Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	return profileDir
+}
+
+func TestUpdateProfile_PlanFileDoesNotApply(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+	before, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", PlanFile: planPath}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if string(before) != string(after) {
+		t.Error("writing a plan should not modify .envrc")
+	}
+	if meta, _ := profile.ReadMeta(profileDir); meta.SchemaVersion != 1 {
+		t.Errorf("schema version = %d, want 1 after writing a plan", meta.SchemaVersion)
+	}
+
+	plan, err := ReadPlan(planPath)
+	if err != nil {
+		t.Fatalf("ReadPlan() error: %v", err)
+	}
+	if plan.Profile != "acme" || plan.FromVersion != 1 || plan.ToVersion != profileMigrations.Latest() {
+		t.Errorf("plan = %s %d->%d", plan.Profile, plan.FromVersion, plan.ToVersion)
+	}
+	if len(plan.Steps) != len(profileMigrations.Pending(1)) || plan.Steps[0].Name != "envrc-tool-vars" {
+		t.Errorf("plan steps = %+v", plan.Steps)
+	}
+	if len(plan.Steps[0].Changes) == 0 {
+		t.Error("plan steps should list the changes they would make")
+	}
+}
+
+func TestApplyUpdatePlan_MatchesDirectUpdate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	direct := t.TempDir()
+	planned := t.TempDir()
+	directDir := newV1Profile(t, direct, "acme")
+	plannedDir := newV1Profile(t, planned, "acme")
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := UpdateProfile(planned, UpdateOptions{ProfileName: "acme", PlanFile: planPath}); err != nil {
+		t.Fatalf("UpdateProfile(plan) error: %v", err)
+	}
+	plan, err := ReadPlan(planPath)
+	if err != nil {
+		t.Fatalf("ReadPlan() error: %v", err)
+	}
+	if _, err := applyUpdatePlan(plannedDir, plan, 1, false); err != nil {
+		t.Fatalf("applyUpdatePlan() error: %v", err)
+	}
+
+	if err := UpdateProfile(direct, UpdateOptions{ProfileName: "acme", NoBackup: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	for _, file := range []string{".envrc", ".env", ".gitignore"} {
+		want, _ := os.ReadFile(filepath.Join(directDir, file))
+		got, _ := os.ReadFile(filepath.Join(plannedDir, file))
+		if string(got) != string(want) {
+			t.Errorf("%s differs between planned and direct update", file)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(plannedDir, ".env.secrets.tpl")); !os.IsNotExist(err) {
+		t.Error("applied plan should remove .env.secrets.tpl")
+	}
+	if meta, _ := profile.ReadMeta(plannedDir); meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schema version = %d, want %d", meta.SchemaVersion, profileMigrations.Latest())
+	}
+}
+
+func TestApplyUpdatePlan_RejectsVersionMismatch(t *testing.T) {
+	plan := &UpdatePlan{Profile: "acme", FromVersion: 1, ToVersion: profileMigrations.Latest()}
+
+	if _, err := applyUpdatePlan(t.TempDir(), plan, 3, false); err == nil {
+		t.Error("expected error applying a plan made at a different schema version")
+	}
+}
+
+func TestApplyPlan_Clean(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", PlanFile: planPath}); err != nil {
+		t.Fatalf("UpdateProfile(plan) error: %v", err)
+	}
+	if err := ApplyPlan(tmpDir, planPath, ApplyOptions{}); err != nil {
+		t.Fatalf("ApplyPlan() error: %v", err)
+	}
+
+	if meta, _ := profile.ReadMeta(profileDir); meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schema version = %d, want %d", meta.SchemaVersion, profileMigrations.Latest())
+	}
+	if _, err := findBackupAtVersion(profileDir, 1); err != nil {
+		t.Errorf("apply should leave an update backup at version 1: %v", err)
+	}
+
+	// The plan is now stale: applying it again is refused
+	if err := ApplyPlan(tmpDir, planPath, ApplyOptions{}); err == nil {
+		t.Error("re-applying a plan should fail")
+	}
+}
+
+func TestApplyPlan_RefusesDrift(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", PlanFile: planPath}); err != nil {
+		t.Fatalf("UpdateProfile(plan) error: %v", err)
+	}
+
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	f, err := os.OpenFile(envrcPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("export EDITED=1\n") //nolint:errcheck // Test setup
+	f.Close()
+	edited, _ := os.ReadFile(envrcPath)
+
+	err = ApplyPlan(tmpDir, planPath, ApplyOptions{})
+	if err == nil || !strings.Contains(err.Error(), ".envrc") {
+		t.Fatalf("expected drift error naming .envrc, got: %v", err)
+	}
+	if current, _ := os.ReadFile(envrcPath); string(current) != string(edited) {
+		t.Error("a refused plan should not modify the profile")
+	}
+
+	if err := ApplyPlan(tmpDir, planPath, ApplyOptions{Force: true, NoBackup: true}); err != nil {
+		t.Fatalf("ApplyPlan(force) error: %v", err)
+	}
+	if meta, _ := profile.ReadMeta(profileDir); meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schema version = %d after forced apply", meta.SchemaVersion)
+	}
+}
+
+func TestUpdatePlan_ExplainNamesRuleAndRationale(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+
+	plan, err := buildUpdatePlan(profileDir, "acme", 1, nil)
+	if err != nil {
+		t.Fatalf("buildUpdatePlan() error: %v", err)
+	}
+	changes := plan.Explain()
+	if len(changes) == 0 {
+		t.Fatal("expected planned changes for a version 1 profile")
+	}
+	rules := map[string]bool{}
+	for _, m := range profileMigrations.Pending(1) {
+		rules[m.Name] = true
+	}
+	for _, change := range changes {
+		if !rules[change.Rule] || change.Change == "" || change.Rationale == "" {
+			t.Errorf("change %+v lacks a pending rule, a change or a rationale", change)
+		}
+	}
+	if changes[0].Rule != "envrc-tool-vars" || !strings.Contains(changes[0].Change, ".envrc") {
+		t.Errorf("first change = %+v, want the envrc-tool-vars rewrite of .envrc", changes[0])
+	}
+}
+
+func TestUpdateProfile_ExplainDoesNotApply(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+	before, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+
+	out, err := captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", Explain: true})
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if !strings.Contains(out, "      rule: envrc-tool-vars (1->2)\n      why:  tool variables belong in .env") {
+		t.Errorf("explanation missing the rule and rationale:\n%s", out)
+	}
+	after, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if string(before) != string(after) {
+		t.Error("--explain should not modify .envrc")
+	}
+	if meta, _ := profile.ReadMeta(profileDir); meta.SchemaVersion != 1 {
+		t.Errorf("schema version = %d, want 1 after --explain", meta.SchemaVersion)
+	}
+}
+
+func TestProfileMigrations_HaveRationale(t *testing.T) {
+	for _, m := range profileMigrations.All() {
+		if m.Rationale == "" {
+			t.Errorf("migration %q has no rationale for update --explain", m.Name)
+		}
+	}
+}
+
+func TestApplyPlan_ForceKeepsEditsUnlessOverwrite(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, overwrite := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile() error: %v", err)
+		}
+		profileDir := filepath.Join(tmpDir, "acme")
+		meta, _ := profile.ReadMeta(profileDir)
+		meta.SchemaVersion = 8
+		if err := profile.WriteMeta(profileDir, meta); err != nil {
+			t.Fatal(err)
+		}
+		writeStaleEnv(t, profileDir, "GIT_CONFIG_GLOBAL=\"old\"\n", "MY_TWEAK=1\n")
+
+		planPath := filepath.Join(t.TempDir(), "plan.json")
+		if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", PlanFile: planPath}); err != nil {
+			t.Fatalf("UpdateProfile(plan) error: %v", err)
+		}
+		// Drift elsewhere, so applying needs --force
+		if err := os.WriteFile(filepath.Join(profileDir, ".gitconfig"), []byte("[user]\n\tname = Edited\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := captureStdout(t, func() error {
+			return ApplyPlan(tmpDir, planPath, ApplyOptions{Force: true, Overwrite: overwrite, NoBackup: true})
+		}); err != nil {
+			t.Fatalf("ApplyPlan(overwrite=%v) error: %v", overwrite, err)
+		}
+		env, _ := os.ReadFile(filepath.Join(profileDir, ".env"))
+		if kept := strings.Contains(string(env), "MY_TWEAK=1"); kept == overwrite {
+			t.Errorf("overwrite=%v: edits to the managed block kept = %v:\n%s", overwrite, kept, env)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+// ensureProfilesDir checks up front that profilesDir is an existing
+// directory the user can read and write, so that a misconfigured
+// profiles_dir fails with an actionable message rather than partway through
+// a command
+func ensureProfilesDir(profilesDir string) error {
+	return checkProfilesDir(profilesDir, true)
+}
+
+// createProfilesDir is ensureProfilesDir for commands that add profiles: a
+// missing profiles directory is created first
+func createProfilesDir(profilesDir string) error {
+	if _, err := os.Lstat(profilesDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(profilesDir, 0755); err != nil {
+			return errs.Wrapf(errs.ErrProfilesDir, "cannot create profiles directory %s: %v\n  Create it yourself or point profiles_dir (or --profiles-dir) at a writable location", profilesDir, err)
+		}
+	}
+	return ensureProfilesDir(profilesDir)
+}
+
+// checkProfilesDir checks that profilesDir is a readable directory and, if
+// write is set, that profiles can be created in it. A missing directory
+// holds no profiles, so that is reported as ErrProfileNotFound.
+func checkProfilesDir(profilesDir string, write bool) error {
+	info, err := os.Stat(profilesDir)
+	switch {
+	case os.IsNotExist(err):
+		return errs.Wrapf(errs.ErrProfileNotFound, "profiles directory %s does not exist\n  Run 'shell-profiler init', or check that the disk it is on is mounted", profilesDir)
+	case err != nil:
+		return errs.Wrapf(errs.ErrProfilesDir, "cannot access profiles directory %s: %v", profilesDir, err)
+	case !info.IsDir():
+		return errs.Wrapf(errs.ErrProfilesDir, "profiles directory %s is a file, not a directory\n  Move it aside or point profiles_dir (or --profiles-dir) at a directory", profilesDir)
+	}
+
+	dir, err := os.Open(profilesDir)
+	if err == nil {
+		_, err = dir.Readdirnames(1)
+		dir.Close()
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return errs.Wrapf(errs.ErrProfilesDir, "cannot read profiles directory %s: %v\n  Check its permissions (ls -ld %s)", profilesDir, err, profilesDir)
+	}
+	if !write {
+		return nil
+	}
+
+	// Creating a file is the only reliable test: permission bits alone miss
+	// read-only mounts and ACLs
+	probe, err := os.CreateTemp(profilesDir, ".write-test-*")
+	if err != nil {
+		return errs.Wrapf(errs.ErrProfilesDir, "profiles directory %s is not writable: %v\n  Check its permissions (ls -ld %s) and that it is not on a read-only mount", profilesDir, err, profilesDir)
+	}
+	probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", probe.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+func TestEnsureProfilesDir_File(t *testing.T) {
+	profilesDir := filepath.Join(t.TempDir(), "profiles")
+	if err := os.WriteFile(profilesDir, []byte("oops\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ensureProfilesDir(profilesDir)
+	if !errors.Is(err, errs.ErrProfilesDir) || !strings.Contains(err.Error(), "is a file, not a directory") {
+		t.Errorf("ensureProfilesDir() = %v, want a not-a-directory error", err)
+	}
+
+	// Commands fail up front with the same error instead of a late, cryptic one
+	err = CreateProfile(profilesDir, CreateOptions{ProfileName: "acme", Template: "basic"})
+	if !errors.Is(err, errs.ErrProfilesDir) {
+		t.Errorf("CreateProfile() = %v, want ErrProfilesDir", err)
+	}
+	if err := ListProfiles(profilesDir, ListOptions{}); !errors.Is(err, errs.ErrProfilesDir) {
+		t.Errorf("ListProfiles() = %v, want ErrProfilesDir", err)
+	}
+	if _, err := profileNames(profilesDir); !errors.Is(err, errs.ErrProfilesDir) {
+		t.Errorf("profileNames() = %v, want ErrProfilesDir", err)
+	}
+}
+
+func TestEnsureProfilesDir_Unwritable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to any directory")
+	}
+	profilesDir := t.TempDir()
+	if err := os.Chmod(profilesDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(profilesDir, 0755) })
+
+	err := ensureProfilesDir(profilesDir)
+	if !errors.Is(err, errs.ErrProfilesDir) || !strings.Contains(err.Error(), "is not writable") {
+		t.Errorf("ensureProfilesDir() = %v, want a not-writable error", err)
+	}
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		t.Errorf("read-only use should be allowed: %v", err)
+	}
+	err = CreateProfile(profilesDir, CreateOptions{ProfileName: "acme", Template: "basic"})
+	if !errors.Is(err, errs.ErrProfilesDir) {
+		t.Errorf("CreateProfile() = %v, want ErrProfilesDir", err)
+	}
+	if _, err := os.Stat(filepath.Join(profilesDir, "acme")); !os.IsNotExist(err) {
+		t.Error("nothing should be created in an unwritable profiles directory")
+	}
+}
+
+func TestEnsureProfilesDir_Missing(t *testing.T) {
+	profilesDir := filepath.Join(t.TempDir(), "profiles")
+	if err := ensureProfilesDir(profilesDir); !errors.Is(err, errs.ErrProfileNotFound) {
+		t.Errorf("ensureProfilesDir() = %v, want ErrProfileNotFound", err)
+	}
+
+	if err := createProfilesDir(profilesDir); err != nil {
+		t.Fatalf("createProfilesDir() error: %v", err)
+	}
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("profiles directory = %v, %v; want it created and empty", entries, err)
+	}
+}
+
+func TestCommands_CheckProfilesDir(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "acme")
+	commands := map[string]func(profilesDir string) error{
+		"InitGit":          func(dir string) error { return InitGit(dir, GitOptions{ProfileName: "acme"}) },
+		"PullGit":          func(dir string) error { return PullGit(dir, GitOptions{ProfileName: "acme"}) },
+		"PushGit":          func(dir string) error { return PushGit(dir, GitOptions{ProfileName: "acme"}) },
+		"SetRemote":        func(dir string) error { return SetRemote(dir, GitOptions{ProfileName: "acme"}) },
+		"GetGitStatus":     func(dir string) error { return GetGitStatus(dir, GitOptions{}) },
+		"ListDotfiles":     func(dir string) error { return ListDotfiles(dir, DotfilesOptions{ProfileName: "acme"}) },
+		"EditDotfile":      func(dir string) error { return EditDotfile(dir, DotfilesOptions{ProfileName: "acme"}) },
+		"EnvList":          func(dir string) error { return EnvList(dir, "acme", EnvListOptions{}) },
+		"Whoami":           func(dir string) error { return Whoami(dir, dir) },
+		"ProfileExists":    func(dir string) error { return ProfileExists(dir, ExistsOptions{ProfileName: "acme", Quiet: true}) },
+		"SelectProfile":    func(dir string) error { return SelectProfile(dir, SelectOptions{ProfileName: "acme"}) },
+		"CollectionStatus": func(dir string) error { return CollectionStatus(dir) },
+		"CollectionCommit": func(dir string) error {
+			_, err := CollectionCommit(dir, CollectionOptions{})
+			return err
+		},
+		"CollectionPush": func(dir string) error { return CollectionPush(dir, CollectionOptions{}) },
+	}
+
+	profilesDir := filepath.Join(t.TempDir(), "profiles")
+	if err := os.WriteFile(profilesDir, []byte("oops\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, run := range commands {
+		if err := run(profilesDir); !errors.Is(err, errs.ErrProfilesDir) {
+			t.Errorf("%s() = %v, want ErrProfilesDir", name, err)
+		}
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	for name, run := range commands {
+		if name == "ProfileExists" {
+			continue
+		}
+		if err := run(missing); !errors.Is(err, errs.ErrProfileNotFound) || !strings.Contains(err.Error(), "profiles directory") {
+			t.Errorf("%s() = %v, want the missing profiles directory reported", name, err)
+		}
+	}
+	// exists reports a profile in a missing profiles directory as absent
+	var exitErr *errs.ExitError
+	if err := ProfileExists(missing, ExistsOptions{ProfileName: "acme", Quiet: true}); !errors.As(err, &exitErr) || exitErr.Status != ExistsAbsent {
+		t.Errorf("ProfileExists() = %v, want exit status %d", err, ExistsAbsent)
+	}
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// readmeNotesHeading starts the section of README.md that is the user's own
+// and survives regeneration
+const readmeNotesHeading = "## Notes"
+
+// ReadmeOptions selects the profile whose README.md is regenerated
+type ReadmeOptions struct {
+	ProfileName string
+	DryRun      bool
+}
+
+// RegenerateReadme rewrites a profile's README.md from the current template
+// state, keeping a "## Notes" section the user added. It also creates the
+// README of a profile made with --no-readme.
+func RegenerateReadme(profilesDir string, opts ReadmeOptions) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if err := checkProfilesDir(profilesDir, !opts.DryRun); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+	}
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+
+	if !opts.DryRun {
+		lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+	}
+
+	readmePath := filepath.Join(profileDir, "README.md")
+	existing, err := os.ReadFile(readmePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read README.md: %w", err)
+	}
+	notes := readmeNotes(string(existing))
+
+	content := renderREADME(profileDir, opts.ProfileName, meta.Template, meta.Created)
+	if notes != "" {
+		content += "\n" + notes
+	}
+
+	if content == string(existing) {
+		ui.PrintInfo("README.md is already up to date")
+		return nil
+	}
+	if opts.DryRun {
+		ui.PrintInfo(fmt.Sprintf("DRY RUN - Would regenerate %s", readmePath))
+		if notes != "" {
+			fmt.Printf("  Keeping the %s section\n", readmeNotesHeading)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(readmePath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write README.md: %w", err)
+	}
+	if meta.NoReadme && !meta.Legacy {
+		meta.NoReadme = false
+		if err := profile.WriteMeta(profileDir, meta); err != nil {
+			return fmt.Errorf("failed to update %s: %w", profile.MetaFileName, err)
+		}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Regenerated %s", readmePath))
+	if notes != "" {
+		fmt.Printf("  Kept the %s section\n", readmeNotesHeading)
+	}
+	return nil
+}
+
+// readmeNotes returns the "## Notes" section of a README, from its heading
+// up to the next heading of the same or a higher level, or "" if there is
+// none
+func readmeNotes(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	start := -1
+	for i, line := range lines {
+		heading := strings.TrimRight(line, " \t\r\n")
+		if start < 0 {
+			if heading == readmeNotesHeading {
+				start = i
+			}
+			continue
+		}
+		if strings.HasPrefix(heading, "# ") || strings.HasPrefix(heading, "## ") {
+			return sectionText(lines[start:i])
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	return sectionText(lines[start:])
+}
+
+// sectionText joins the lines of a section, ending it with a single newline
+func sectionText(lines []string) string {
+	return strings.TrimRight(strings.Join(lines, ""), "\n") + "\n"
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestCreateProfile_NoReadme(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "quiet", Template: "basic", NoReadme: true}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "quiet")
+	if _, err := os.Stat(filepath.Join(profileDir, "README.md")); !os.IsNotExist(err) {
+		t.Error("--no-readme should not write README.md")
+	}
+	if meta, _ := profile.ReadMeta(profileDir); !meta.NoReadme {
+		t.Error("meta should record NoReadme")
+	}
+
+	if err := RegenerateReadme(tmpDir, ReadmeOptions{ProfileName: "quiet"}); err != nil {
+		t.Fatalf("RegenerateReadme() error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(profileDir, "README.md")); err != nil || !strings.HasPrefix(string(data), "# Workspace Profile: quiet\n") {
+		t.Errorf("regenerate should write README.md, got %q, %v", data, err)
+	}
+	if meta, _ := profile.ReadMeta(profileDir); meta.NoReadme {
+		t.Error("regenerating should clear NoReadme")
+	}
+}
+
+func TestRegenerateReadme_KeepsNotes(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	readmePath := filepath.Join(tmpDir, "acme", "README.md")
+	original, _ := os.ReadFile(readmePath)
+
+	// A stale managed section and user notes in the middle of the file
+	stale := strings.Replace(string(original), "## Setup\n", "## Notes\n\nVPN must be up.\n\n### Contacts\n- ops@acme.example\n\n## Setup\n", 1)
+	stale = strings.Replace(stale, "- WORKSPACE_PROFILE: acme\n", "- WORKSPACE_PROFILE: old-name\n", 1)
+	if err := os.WriteFile(readmePath, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegenerateReadme(tmpDir, ReadmeOptions{ProfileName: "acme"}); err != nil {
+		t.Fatalf("RegenerateReadme() error: %v", err)
+	}
+	data, _ := os.ReadFile(readmePath)
+	content := string(data)
+	want := string(original) + "\n## Notes\n\nVPN must be up.\n\n### Contacts\n- ops@acme.example\n"
+	if content != want {
+		t.Errorf("regenerated README =\n%s\nwant:\n%s", content, want)
+	}
+
+	// Regenerating again changes nothing
+	if err := RegenerateReadme(tmpDir, ReadmeOptions{ProfileName: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(readmePath); string(again) != content {
+		t.Error("regenerating twice should be stable")
+	}
+}
+
+func TestReadmeNotes(t *testing.T) {
+	tests := []struct {
+		content, want string
+	}{
+		{"# P\n\n## Setup\nx\n", ""},
+		{"# P\n## Notes\nmine\n\n\n", "## Notes\nmine\n"},
+		{"## Notes  \nmine\n### Sub\nmore\n## Next\nx\n", "## Notes  \nmine\n### Sub\nmore\n"},
+		{"## Notes and more\nx\n", ""},
+	}
+	for _, tt := range tests {
+		if got := readmeNotes(tt.content); got != tt.want {
+			t.Errorf("readmeNotes(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// DefaultRecentCount is how many profiles recent shows by default
+const DefaultRecentCount = 5
+
+type RecentOptions struct {
+	Count  int         // Profiles to show; DefaultRecentCount if 0
+	Format string      // ui.FormatTable, FormatPlain or FormatJSON; empty for the indented listing
+	Clock  clock.Clock // Time source for the relative ages; the system clock if nil
+}
+
+// configModTime returns the most recent modification time of the files at
+// the top of a profile (.envrc, .env, .gitconfig...). Unlike profileModTime
+// it does not walk tool data, so it stays cheap across many profiles.
+func configModTime(profileDir string) (time.Time, error) {
+	entries, err := os.ReadDir(profileDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var latest time.Time
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || entry.Name() == profile.LockFileName {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// formatAge describes a duration in the largest whole unit, e.g. 3d ago
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	default:
+		return fmt.Sprintf("%dw ago", int(d/(7*24*time.Hour)))
+	}
+}
+
+// RecentProfiles prints the most recently modified profiles, newest first
+func RecentProfiles(profilesDir string, opts RecentOptions) error {
+	if opts.Count < 0 {
+		return fmt.Errorf("count must not be negative")
+	}
+	if opts.Count == 0 {
+		opts.Count = DefaultRecentCount
+	}
+	if opts.Format != "" {
+		if _, err := ui.ParseFormat(opts.Format); err != nil {
+			return err
+		}
+	}
+
+	names, err := profileNames(profilesDir)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 && opts.Format == "" {
+		fmt.Printf("%sNo profiles found%s\n", ui.ColorYellow, ui.ColorReset)
+		return nil
+	}
+
+	modified := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		modTime, err := configModTime(filepath.Join(profilesDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read modification time of %s: %w", name, err)
+		}
+		modified[name] = modTime
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return modified[names[i]].After(modified[names[j]])
+	})
+	if len(names) > opts.Count {
+		names = names[:opts.Count]
+	}
+
+	now := clock.Or(opts.Clock).Now()
+	if opts.Format != "" {
+		table := ui.NewTable("NAME", "MODIFIED", "AGE")
+		for _, name := range names {
+			table.AddRow(name, modified[name].Local().Format("2006-01-02 15:04"), formatAge(now.Sub(modified[name])))
+		}
+		return table.Render(os.Stdout, opts.Format)
+	}
+
+	table := &ui.Table{Indent: "  "}
+	for _, name := range names {
+		modTime := modified[name]
+		table.AddRow(ui.ColorCyan+name+ui.ColorReset, modTime.Local().Format("2006-01-02 15:04"),
+			"("+formatAge(now.Sub(modTime))+")")
+	}
+	return table.Render(os.Stdout, ui.FormatTable)
+}
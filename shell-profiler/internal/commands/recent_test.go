@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+)
+
+func TestRecentProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// p0 is the most recently modified, p6 the least
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("p%d", i)
+		profileDir := newTaggableProfile(t, tmpDir, name)
+		entries, err := os.ReadDir(profileDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		when := now.Add(-time.Duration(i+1) * 24 * time.Hour)
+		for _, entry := range entries {
+			if err := os.Chtimes(filepath.Join(profileDir, entry.Name()), when, when); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Tool data below the top level is not looked at
+	writeFiles(t, filepath.Join(tmpDir, "p6"), ".aws/config")
+
+	recent := func(count int) []string {
+		t.Helper()
+		out, err := captureStdout(t, func() error {
+			return RecentProfiles(tmpDir, RecentOptions{Count: count, Clock: clock.NewFake(now)})
+		})
+		if err != nil {
+			t.Fatalf("RecentProfiles() error: %v", err)
+		}
+		var names []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			names = append(names, strings.Fields(line)[0])
+		}
+		if !strings.Contains(out, "(1d ago)") {
+			t.Errorf("expected a relative age:\n%s", out)
+		}
+		return names
+	}
+
+	if got := recent(0); !reflect.DeepEqual(got, []string{"p0", "p1", "p2", "p3", "p4"}) {
+		t.Errorf("default recent = %v, want the 5 most recent", got)
+	}
+	if got := recent(2); !reflect.DeepEqual(got, []string{"p0", "p1"}) {
+		t.Errorf("recent 2 = %v, want [p0 p1]", got)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:    "just now",
+		5 * time.Minute:     "5m ago",
+		3 * time.Hour:       "3h ago",
+		50 * time.Hour:      "2d ago",
+		30 * 24 * time.Hour: "4w ago",
+	}
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
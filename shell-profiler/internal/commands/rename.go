@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+type RenameOptions struct {
+	ProfileName string
+	NewName     string
+	DryRun      bool
+}
+
+// renamedFiles are the generated files that embed the profile's name or its
+// absolute path
+var renamedFiles = []string{
+	".envrc",
+	".env",
+	".gitconfig",
+	"README.md",
+	".config/1Password/agent.toml",
+	".ssh/config",
+}
+
+// RenameProfile moves a profile to a new name and rewrites the references
+// to its old name and location, so direnv, git and ssh keep working
+func RenameProfile(profilesDir string, opts RenameOptions) error {
+	if opts.ProfileName == "" || opts.NewName == "" {
+		return fmt.Errorf("old and new profile names are required")
+	}
+	if err := validateProfileName(opts.NewName); err != nil {
+		return err
+	}
+	if err := checkProfilesDir(profilesDir, !opts.DryRun); err != nil {
+		return err
+	}
+
+	absProfilesDir, err := filepath.Abs(profilesDir)
+	if err != nil {
+		return err
+	}
+	oldDir := filepath.Join(absProfilesDir, opts.ProfileName)
+	newDir := filepath.Join(absProfilesDir, opts.NewName)
+	if !isProfileDir(oldDir) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, oldDir)
+	}
+	if taken, location := profileNameTaken(absProfilesDir, opts.NewName); taken {
+		return nameTakenError(absProfilesDir, opts.NewName, location)
+	}
+
+	if opts.DryRun {
+		ui.PrintInfo("DRY RUN - Nothing will be renamed")
+		fmt.Printf("  Would move: %s -> %s\n", oldDir, newDir)
+		fmt.Printf("  Would update: %s, .profile-meta\n", strings.Join(renamedFiles, ", "))
+		return nil
+	}
+
+	if os.Getenv("WORKSPACE_PROFILE") == opts.ProfileName {
+		ui.PrintWarning("You are currently in this profile!")
+	}
+
+	lock, err := profile.AcquireLock(oldDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock file moves with the profile
+
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("failed to rename profile: %w", err)
+	}
+	// Backups kept outside the profile are keyed by its name
+	if externalBackupDir != "" {
+		if err := os.Rename(profileBackupDir(oldDir), profileBackupDir(newDir)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move backups: %w", err)
+		}
+	}
+
+	replacer := renameReplacer(opts.ProfileName, opts.NewName, oldDir, newDir)
+	if err := rewriteProfileFiles(newDir, replacer); err != nil {
+		return err
+	}
+	// Also catch paths left behind by an earlier manual move
+	if _, err := fixSSHPaths(newDir, false); err != nil {
+		return err
+	}
+
+	customVaults := false
+	if meta, err := profile.ReadMeta(newDir); err == nil {
+		customVaults = len(meta.Vaults) > 0
+		meta.Name = opts.NewName
+		if err := profile.WriteMeta(newDir, meta); err != nil {
+			return fmt.Errorf("failed to update .profile-meta: %w", err)
+		}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Profile renamed: %s -> %s", opts.ProfileName, opts.NewName))
+	fmt.Printf("  Location: %s\n", newDir)
+	if !customVaults {
+		fmt.Printf("  Secrets now come from the 1Password vault workspace-%s (rename workspace-%s to keep them)\n", opts.NewName, opts.ProfileName)
+	}
+	fmt.Printf("  Allow the updated .envrc with: direnv allow %s\n", newDir)
+	return nil
+}
+
+// renameReplacer rewrites the forms in which the generated files refer to
+// a profile's name and directory
+func renameReplacer(oldName, newName, oldDir, newDir string) *strings.Replacer {
+	return strings.NewReplacer(
+		oldDir+"/", newDir+"/",
+		`"`+oldDir+`"`, `"`+newDir+`"`,
+		"profile: "+oldName+"\n", "profile: "+newName+"\n",
+		"Profile: "+oldName+"\n", "Profile: "+newName+"\n",
+		`WORKSPACE_PROFILE="`+oldName+`"`, `WORKSPACE_PROFILE="`+newName+`"`,
+		"WORKSPACE_PROFILE: "+oldName+"\n", "WORKSPACE_PROFILE: "+newName+"\n",
+		"(workspace-"+oldName+")", "(workspace-"+newName+")",
+	)
+}
+
+// rewriteProfileFiles applies replacer to the renamedFiles of a profile.
+// The hashes .profile-meta holds of files that were as last written are
+// recomputed, so doctor and update do not take the rewrite for a user edit;
+// files the user had already edited stay flagged as such.
+func rewriteProfileFiles(profileDir string, replacer *strings.Replacer) error {
+	meta, metaErr := profile.ReadMeta(profileDir)
+	blocksBefore := managedBlockHashes(profileDir)
+	contentBefore, err := managedContentHash(profileDir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range renamedFiles {
+		if err := rewriteFile(filepath.Join(profileDir, name), replacer); err != nil {
+			return fmt.Errorf("failed to update %s: %w", name, err)
+		}
+	}
+	if metaErr != nil {
+		return nil
+	}
+
+	changed := false
+	for file, hash := range managedBlockHashes(profileDir) {
+		if recorded := meta.ManagedHashes[file]; recorded != "" && recorded == blocksBefore[file] && recorded != hash {
+			meta.ManagedHashes[file] = hash
+			changed = true
+		}
+	}
+	if meta.ContentHash != "" && meta.ContentHash == contentBefore {
+		contentAfter, err := managedContentHash(profileDir)
+		if err != nil {
+			return err
+		}
+		changed = changed || contentAfter != meta.ContentHash
+		meta.ContentHash = contentAfter
+	}
+	if !changed {
+		return nil
+	}
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		return fmt.Errorf("failed to update %s: %w", profile.MetaFileName, err)
+	}
+	return nil
+}
+
+// rewriteFile applies replacer to a file, keeping its mode. Missing files
+// are skipped.
+func rewriteFile(path string, replacer *strings.Replacer) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rewritten := replacer.Replace(string(content))
+	if rewritten == string(content) {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(rewritten), info.Mode().Perm())
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestRenameProfile_RewritesPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "work"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	oldDir := filepath.Join(tmpDir, "acme")
+	newDir := filepath.Join(tmpDir, "acme-corp")
+
+	if err := RenameProfile(tmpDir, RenameOptions{ProfileName: "acme", NewName: "acme-corp"}); err != nil {
+		t.Fatalf("RenameProfile() error: %v", err)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("old profile directory should be gone: %v", err)
+	}
+
+	config, err := os.ReadFile(filepath.Join(newDir, ".ssh", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(config), oldDir+"/") {
+		t.Errorf(".ssh/config still refers to %s:\n%s", oldDir, config)
+	}
+	if !strings.Contains(string(config), "UserKnownHostsFile "+newDir+"/.ssh/known_hosts\n") {
+		t.Errorf("UserKnownHostsFile not moved to %s:\n%s", newDir, config)
+	}
+	if n := strings.Count(string(config), newDir+"/.ssh/"); n < 2 {
+		t.Errorf("expected every .ssh path to be rewritten, found %d:\n%s", n, config)
+	}
+	if info, _ := os.Stat(filepath.Join(newDir, ".ssh", "config")); info.Mode().Perm() != 0600 {
+		t.Errorf(".ssh/config mode = %o, want 0600", info.Mode().Perm())
+	}
+
+	envrc, _ := os.ReadFile(filepath.Join(newDir, ".envrc"))
+	if !strings.Contains(string(envrc), `export WORKSPACE_PROFILE="acme-corp"`) || strings.Contains(string(envrc), `"acme"`) {
+		t.Errorf(".envrc not renamed:\n%s", envrc)
+	}
+	readme, _ := os.ReadFile(filepath.Join(newDir, "README.md"))
+	if strings.Contains(string(readme), oldDir+`"`) || !strings.Contains(string(readme), "# Workspace Profile: acme-corp\n") {
+		t.Errorf("README.md not renamed:\n%s", readme)
+	}
+
+	meta, err := profile.ReadMeta(newDir)
+	if err != nil || meta.Name != "acme-corp" {
+		t.Errorf("meta name = %+v, %v", meta, err)
+	}
+	if findings, _ := checkSSHPaths(newDir, "acme-corp", false); len(findings) != 0 {
+		t.Errorf("renamed profile has stale ssh paths: %+v", findings)
+	}
+}
+
+func TestRenameProfile_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"one", "two"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile() error: %v", err)
+		}
+	}
+
+	if err := RenameProfile(tmpDir, RenameOptions{ProfileName: "missing", NewName: "three"}); !errors.Is(err, errs.ErrProfileNotFound) {
+		t.Errorf("missing profile: err = %v, want ErrProfileNotFound", err)
+	}
+	if err := RenameProfile(tmpDir, RenameOptions{ProfileName: "one", NewName: "two"}); !errors.Is(err, errs.ErrProfileExists) {
+		t.Errorf("existing target: err = %v, want ErrProfileExists", err)
+	}
+	if err := RenameProfile(tmpDir, RenameOptions{ProfileName: "one", NewName: "../escape"}); !errors.Is(err, errs.ErrInvalidName) {
+		t.Errorf("invalid name: err = %v, want ErrInvalidName", err)
+	}
+
+	if err := RenameProfile(tmpDir, RenameOptions{ProfileName: "one", NewName: "three", DryRun: true}); err != nil {
+		t.Fatalf("dry run error: %v", err)
+	}
+	if !isProfileDir(filepath.Join(tmpDir, "one")) {
+		t.Error("dry run should not move the profile")
+	}
+}
+
+func TestRenameProfile_RecomputesHashes(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	oldDir := filepath.Join(tmpDir, "acme")
+	newDir := filepath.Join(tmpDir, "acme-corp")
+	if _, err := recordSchemaVersion(oldDir, "acme", profileMigrations.Latest(), false); err != nil {
+		t.Fatal(err)
+	}
+	// A managed block that refers to the profile's directory, as last written
+	envPath := filepath.Join(oldDir, ".env")
+	env, _ := os.ReadFile(envPath)
+	env = []byte(strings.Replace(string(env), "# >>> profile-manager managed\n", "# >>> profile-manager managed\nACME_DATA=\""+oldDir+"/data\"\n", 1))
+	if err := os.WriteFile(envPath, env, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordManagedHash(oldDir, ".env", string(env)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recordSchemaVersion(oldDir, "acme", profileMigrations.Latest(), false); err != nil {
+		t.Fatal(err)
+	}
+	if !profileUpToDate(oldDir) {
+		t.Fatal("profile should be up to date before the rename")
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return RenameProfile(tmpDir, RenameOptions{ProfileName: "acme", NewName: "acme-corp"})
+	}); err != nil {
+		t.Fatalf("RenameProfile() error: %v", err)
+	}
+
+	env, _ = os.ReadFile(filepath.Join(newDir, ".env"))
+	sections, err := managed.Split(string(env))
+	if err != nil || !strings.Contains(sections.Managed, newDir+"/data") {
+		t.Fatalf("managed block not rewritten: %v\n%s", err, env)
+	}
+	if managedBlockEdited(newDir, ".env", sections.Managed) {
+		t.Error("the rewritten managed block of .env reads as a user edit")
+	}
+	if !profileUpToDate(newDir) {
+		t.Error("the renamed profile's content hash no longer matches its files")
+	}
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// SecretCache names the resolved secrets cache in ResetOptions.Tools
+const SecretCache = "secrets"
+
+type ResetOptions struct {
+	Tools  []string // Tool names or aliases, or SecretCache
+	All    bool     // Every tool cache and the secrets cache
+	DryRun bool
+}
+
+// SecretCacheDir returns where a profile's .envrc caches its resolved
+// environment, ${TMPDIR:-/tmp}/sp-profiles/<name>
+func SecretCacheDir(profileName string) string {
+	tmp := os.Getenv("TMPDIR")
+	if tmp == "" {
+		tmp = "/tmp"
+	}
+	return filepath.Join(tmp, "sp-profiles", profileName)
+}
+
+// resetTargets returns the cache paths to delete for the selected tools
+func resetTargets(profileDir, profileName string, opts ResetOptions) ([]string, error) {
+	var targets []string
+	if opts.All {
+		for _, tool := range tools.All() {
+			for _, cache := range tool.Caches {
+				targets = append(targets, filepath.Join(profileDir, cache))
+			}
+		}
+		return append(targets, SecretCacheDir(profileName)), nil
+	}
+
+	for _, name := range opts.Tools {
+		if name == SecretCache {
+			targets = append(targets, SecretCacheDir(profileName))
+			continue
+		}
+		tool, ok := tools.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tool: %s (available: %s)", name, strings.Join(resettableTools(), ", "))
+		}
+		if len(tool.Caches) == 0 {
+			return nil, fmt.Errorf("%s has no caches to reset (available: %s)", name, strings.Join(resettableTools(), ", "))
+		}
+		for _, cache := range tool.Caches {
+			targets = append(targets, filepath.Join(profileDir, cache))
+		}
+	}
+	return targets, nil
+}
+
+// resettableTools lists the names accepted by ResetOptions.Tools
+func resettableTools() []string {
+	var names []string
+	for _, tool := range tools.All() {
+		if len(tool.Caches) > 0 {
+			names = append(names, tool.Name)
+		}
+	}
+	return append(names, SecretCache)
+}
+
+// ResetProfile deletes cached tokens and other tool state from a profile,
+// leaving its configuration alone. Useful when a tool's auth gets stuck.
+func ResetProfile(profilesDir, profileName string, opts ResetOptions) error {
+	if profileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if !opts.All && len(opts.Tools) == 0 {
+		return fmt.Errorf("specify --tools or --all (tools: %s)", strings.Join(resettableTools(), ", "))
+	}
+	if err := checkProfilesDir(profilesDir, !opts.DryRun); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, profileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", profileName, profileDir)
+	}
+
+	targets, err := resetTargets(profileDir, profileName, opts)
+	if err != nil {
+		return err
+	}
+
+	if !opts.DryRun {
+		lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+	}
+
+	var removed []string
+	for _, target := range targets {
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			continue
+		}
+		if !opts.DryRun {
+			if err := os.RemoveAll(target); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", target, err)
+			}
+		}
+		removed = append(removed, target)
+	}
+
+	if len(removed) == 0 {
+		ui.PrintInfo(fmt.Sprintf("No caches to reset in profile: %s", profileName))
+		return nil
+	}
+	if opts.DryRun {
+		ui.PrintInfo("DRY RUN - Would remove:")
+	} else {
+		ui.PrintSuccess(fmt.Sprintf("Reset caches in profile: %s", profileName))
+	}
+	for _, target := range removed {
+		if rel, err := filepath.Rel(profileDir, target); err == nil && !strings.HasPrefix(rel, "..") {
+			target = rel
+		}
+		fmt.Printf("  %s %s\n", ui.SymbolOK, target)
+	}
+	return nil
+}
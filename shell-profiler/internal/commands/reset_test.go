@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestResetProfile_SelectedTools(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	profilesDir := t.TempDir()
+	profileDir := newTaggableProfile(t, profilesDir, "acme")
+	writeFiles(t, profileDir,
+		".aws/config", ".aws/credentials", ".aws/cli/cache/token.json", ".aws/sso/cache/sso.json",
+		".kube/config", ".kube/cache/discovery/x.json",
+		".gcloud/configurations/config_default", ".gcloud/logs/2026.log",
+	)
+	writeFiles(t, SecretCacheDir("acme"), ".env")
+
+	if _, err := captureStdout(t, func() error {
+		return ResetProfile(profilesDir, "acme", ResetOptions{Tools: []string{"aws", "kube"}})
+	}); err != nil {
+		t.Fatalf("ResetProfile() error: %v", err)
+	}
+
+	for _, gone := range []string{".aws/cli/cache", ".aws/sso/cache", ".kube/cache"} {
+		if exists(filepath.Join(profileDir, gone)) {
+			t.Errorf("%s should be removed", gone)
+		}
+	}
+	for _, kept := range []string{".aws/config", ".aws/credentials", ".kube/config", ".gcloud/logs/2026.log"} {
+		if !exists(filepath.Join(profileDir, kept)) {
+			t.Errorf("%s should be kept", kept)
+		}
+	}
+	if !exists(filepath.Join(SecretCacheDir("acme"), ".env")) {
+		t.Error("the secrets cache was not selected and should be kept")
+	}
+}
+
+func TestResetProfile_All(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	profilesDir := t.TempDir()
+	profileDir := newTaggableProfile(t, profilesDir, "acme")
+	writeFiles(t, profileDir, ".aws/config", ".aws/sso/cache/sso.json", ".gcloud/logs/2026.log", ".gcloud/configurations/config_default")
+	writeFiles(t, SecretCacheDir("acme"), ".env")
+
+	// Dry run leaves everything in place
+	if _, err := captureStdout(t, func() error {
+		return ResetProfile(profilesDir, "acme", ResetOptions{All: true, DryRun: true})
+	}); err != nil {
+		t.Fatalf("ResetProfile(dry run) error: %v", err)
+	}
+	if !exists(filepath.Join(profileDir, ".aws/sso/cache")) {
+		t.Fatal("dry run removed a cache")
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return ResetProfile(profilesDir, "acme", ResetOptions{All: true})
+	}); err != nil {
+		t.Fatalf("ResetProfile() error: %v", err)
+	}
+	for _, gone := range []string{filepath.Join(profileDir, ".aws/sso/cache"), filepath.Join(profileDir, ".gcloud/logs"), SecretCacheDir("acme")} {
+		if exists(gone) {
+			t.Errorf("%s should be removed", gone)
+		}
+	}
+	for _, kept := range []string{".aws/config", ".gcloud/configurations/config_default"} {
+		if !exists(filepath.Join(profileDir, kept)) {
+			t.Errorf("%s should be kept", kept)
+		}
+	}
+}
+
+func TestResetProfile_Errors(t *testing.T) {
+	profilesDir := t.TempDir()
+	newTaggableProfile(t, profilesDir, "acme")
+
+	for name, opts := range map[string]ResetOptions{
+		"no selection":    {},
+		"unknown tool":    {Tools: []string{"nope"}},
+		"tool w/o caches": {Tools: []string{"git"}},
+	} {
+		if err := ResetProfile(profilesDir, "acme", opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/migrations"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// RollbackMigration reverts a profile to an earlier schema version. When every
+// migration in between defines an inverse the inverses are applied; otherwise
+// the newest update backup taken at the target version is restored.
+func RollbackMigration(profilesDir, profileName, toVersion string) error {
+	target, err := strconv.Atoi(strings.TrimSpace(toVersion))
+	if err != nil {
+		return fmt.Errorf("invalid schema version '%s': must be a number", toVersion)
+	}
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, profileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", profileName, profileDir)
+	}
+
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	if err := checkSchemaCompatible(meta, profileName); err != nil {
+		return err
+	}
+	if target < 0 || target >= meta.SchemaVersion {
+		return fmt.Errorf("profile '%s' is at schema version %d; can only roll back to an earlier version", profileName, meta.SchemaVersion)
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Rolling back profile: %s", profileName))
+	fmt.Printf("  Schema version: %d -> %d\n", meta.SchemaVersion, target)
+	fmt.Println()
+
+	// Without inverses for every step, fall back to a pre-migration backup
+	backupPath := ""
+	if m := profileMigrations.Irreversible(target, meta.SchemaVersion); m != nil {
+		backupPath, err = findBackupAtVersion(profileDir, target)
+		if err != nil {
+			return err
+		}
+		if backupPath == "" {
+			return fmt.Errorf("cannot roll back '%s' to schema version %d: migration %q (%d->%d) is irreversible and no backup at version %d was found in %s",
+				profileName, target, m.Name, m.From, m.To, target, profileBackupDir(profileDir))
+		}
+	}
+
+	// Keep the current state recoverable; rollback backups are never restored
+	// by a later rollback since they are not named update_*
+	if _, err := createBackup(profileDir, "rollback", clock.Real{}); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Failed to create backup: %v", err))
+	}
+
+	var changes []string
+	if backupPath == "" {
+		ctx := migrations.Context{ProfileDir: profileDir, ProfileName: profileName}
+		results, _, err := profileMigrations.Rollback(ctx, meta.SchemaVersion, target)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			fmt.Printf("    %d<-%d %s\n", result.Migration.From, result.Migration.To, result.Migration.Name)
+			changes = append(changes, result.Changes...)
+		}
+	} else {
+		restored, err := restoreBackup(profileDir, backupPath)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, fmt.Sprintf("Restored %s from %s", strings.Join(restored, ", "), filepath.Base(backupPath)))
+	}
+
+	if _, err := recordSchemaVersion(profileDir, profileName, target, false); err != nil {
+		return fmt.Errorf("failed to update %s: %w", profile.MetaFileName, err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Profile rolled back to schema version %d", target))
+	if len(changes) > 0 {
+		fmt.Println()
+		fmt.Println("Changes applied:")
+		for _, change := range changes {
+			fmt.Printf("  %s %s\n", ui.SymbolOK, change)
+		}
+	}
+
+	return nil
+}
+
+// findBackupAtVersion returns the newest update backup whose recorded schema
+// version matches, or "" if there is none. Backups without a .profile-meta
+// were taken from legacy profiles and count as version 0.
+func findBackupAtVersion(profileDir string, version int) (string, error) {
+	backupDir := profileBackupDir(profileDir)
+	entries, err := os.ReadDir(backupDir)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "update_") {
+			names = append(names, entry.Name())
+		}
+	}
+	// Newest first: by timestamp, then by the counter of backups taken
+	// within the same second, which must compare as a number
+	sort.Slice(names, func(i, j int) bool {
+		ti, ni := backupNameOrder(names[i], "update_")
+		tj, nj := backupNameOrder(names[j], "update_")
+		if ti != tj {
+			return ti > tj
+		}
+		return ni > nj
+	})
+
+	for _, name := range names {
+		backupPath := filepath.Join(backupDir, name)
+		backupVersion := 0
+		if meta, err := profile.ReadMeta(backupPath); err == nil {
+			backupVersion = meta.SchemaVersion
+		} else if !os.IsNotExist(err) {
+			continue
+		}
+		if backupVersion == version {
+			return backupPath, nil
+		}
+	}
+
+	return "", nil
+}
+
+// backupNameOrder splits a backup name made by createBackup,
+// <kind>_<timestamp>[_<n>], into its timestamp and counter; the first backup
+// of a second has no suffix and counts as 1
+func backupNameOrder(name, prefix string) (string, int) {
+	rest := strings.TrimPrefix(name, prefix)
+	if len(rest) < len(backupTimeLayout) {
+		return rest, 1
+	}
+	timestamp, suffix := rest[:len(backupTimeLayout)], rest[len(backupTimeLayout):]
+	n, err := strconv.Atoi(strings.TrimPrefix(suffix, "_"))
+	if err != nil {
+		n = 1
+	}
+	return timestamp, n
+}
+
+// restoreBackup copies the backed-up files over the profile. Files created
+// after the backup (such as .profile-meta for legacy profiles) are left in
+// place; the caller records the target schema version afterwards.
+func restoreBackup(profileDir, backupPath string) ([]string, error) {
+	var restored []string
+	for _, file := range backupFiles {
+		content, err := os.ReadFile(filepath.Join(backupPath, file))
+		if err != nil {
+			continue
+		}
+		target := filepath.Join(profileDir, file)
+		if err := os.WriteFile(target, content, backupFileMode(file)); err != nil {
+			return restored, fmt.Errorf("failed to restore %s: %w", file, err)
+		}
+		if err := os.Chmod(target, backupFileMode(file)); err != nil {
+			return restored, fmt.Errorf("failed to restore %s: %w", file, err)
+		}
+		restored = append(restored, file)
+	}
+
+	return restored, nil
+}
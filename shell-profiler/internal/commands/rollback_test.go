@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/migrations"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestRollbackMigration_ReversibleRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "rt")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("export WORKSPACE_PROFILE=\"rt\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.WriteMeta(profileDir, &profile.Meta{SchemaVersion: 0, Name: "rt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Apply only the reversible tool-directories migration
+	if _, err := profileMigrations.All()[0].Apply(migrationsContext(profileDir, "rt")); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	if _, err := recordSchemaVersion(profileDir, "rt", 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".azure")); err != nil {
+		t.Fatal("expected .azure to be created by the migration")
+	}
+	// A directory the user has put files in must survive the rollback
+	if err := os.WriteFile(filepath.Join(profileDir, ".kube", "config"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RollbackMigration(tmpDir, "rt", "0"); err != nil {
+		t.Fatalf("RollbackMigration() error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(profileDir, ".azure")); !os.IsNotExist(err) {
+		t.Error("empty .azure should be removed by the rollback")
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".kube", "config")); err != nil {
+		t.Error("non-empty .kube should be kept by the rollback")
+	}
+	meta, _ := profile.ReadMeta(profileDir)
+	if meta.SchemaVersion != 0 {
+		t.Errorf("schema version = %d, want 0", meta.SchemaVersion)
+	}
+
+	// Updating again re-applies the migration
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "rt", NoBackup: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".azure")); err != nil {
+		t.Error("update after rollback should recreate .azure")
+	}
+}
+
+func TestRollbackMigration_RefusesIrreversibleWithoutBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "latest", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "latest")
+	before, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+
+	err := RollbackMigration(tmpDir, "latest", "4")
+	if err == nil {
+		t.Fatal("expected rollback through an irreversible migration to be refused")
+	}
+	if !strings.Contains(err.Error(), "remove-secrets-template") || !strings.Contains(err.Error(), "irreversible") {
+		t.Errorf("error should name the irreversible migration: %v", err)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if string(before) != string(after) {
+		t.Error("refused rollback should not modify .envrc")
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".backups")); !os.IsNotExist(err) {
+		t.Error("refused rollback should not create a backup")
+	}
+	meta, _ := profile.ReadMeta(profileDir)
+	if meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schema version = %d, want unchanged %d", meta.SchemaVersion, profileMigrations.Latest())
+	}
+}
+
+func TestRollbackMigration_RestoresUpdateBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "old")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	envrc := "#!/usr/bin/env bash\nexport WORKSPACE_PROFILE=\"old\"\nop inject -i .env.secrets.tpl\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".env.secrets.tpl"), []byte("X=op://v/i/f\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.WriteMeta(profileDir, &profile.Meta{SchemaVersion: 4, Name: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "old"}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".env.secrets.tpl")); !os.IsNotExist(err) {
+		t.Fatal("update should have removed .env.secrets.tpl")
+	}
+
+	if err := RollbackMigration(tmpDir, "old", "4"); err != nil {
+		t.Fatalf("RollbackMigration() error: %v", err)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if string(got) != envrc {
+		t.Errorf(".envrc not restored from backup:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".env.secrets.tpl")); err != nil {
+		t.Error(".env.secrets.tpl should be restored from backup")
+	}
+	meta, _ := profile.ReadMeta(profileDir)
+	if meta.SchemaVersion != 4 {
+		t.Errorf("schema version = %d, want 4", meta.SchemaVersion)
+	}
+}
+
+func TestRollbackMigration_ExternalBackupDir(t *testing.T) {
+	backups := t.TempDir()
+	SetBackupDir(backups)
+	t.Cleanup(func() { SetBackupDir("") })
+
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "old")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	envrc := "#!/usr/bin/env bash\nexport WORKSPACE_PROFILE=\"old\"\nop inject -i .env.secrets.tpl\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.WriteMeta(profileDir, &profile.Meta{SchemaVersion: 4, Name: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "old"}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".backups")); !os.IsNotExist(err) {
+		t.Error("backups should not be kept in the profile")
+	}
+	if found, _ := filepath.Glob(filepath.Join(backups, "old", "update_*", ".envrc")); len(found) != 1 {
+		t.Fatalf("expected one update backup under %s, got %v", backups, found)
+	}
+
+	if err := RollbackMigration(tmpDir, "old", "4"); err != nil {
+		t.Fatalf("RollbackMigration() error: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(profileDir, ".envrc")); string(got) != envrc {
+		t.Errorf(".envrc not restored from the external backup:\n%s", got)
+	}
+}
+
+func TestRollbackMigration_InvalidVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "p", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	for _, v := range []string{"abc", "-1", "6", "99"} {
+		if err := RollbackMigration(tmpDir, "p", v); err == nil {
+			t.Errorf("RollbackMigration(%q) should fail", v)
+		}
+	}
+}
+
+func migrationsContext(profileDir, name string) migrations.Context {
+	return migrations.Context{ProfileDir: profileDir, ProfileName: name}
+}
+
+func TestFindBackupAtVersion_NewestByCounter(t *testing.T) {
+	profileDir := t.TempDir()
+	backupDir := profileBackupDir(profileDir)
+	// Backups taken within one second: the bare name, then _2 up to _10
+	names := []string{"update_2024-03-09_14-05-07"}
+	for n := 2; n <= 10; n++ {
+		names = append(names, fmt.Sprintf("update_2024-03-09_14-05-07_%d", n))
+	}
+	names = append(names, "update_2024-03-08_23-59-59_11")
+	for _, name := range names {
+		if err := os.MkdirAll(filepath.Join(backupDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := profile.WriteMeta(filepath.Join(backupDir, name), &profile.Meta{SchemaVersion: 4, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := findBackupAtVersion(profileDir, 4)
+	if err != nil {
+		t.Fatalf("findBackupAtVersion() error: %v", err)
+	}
+	if want := filepath.Join(backupDir, "update_2024-03-09_14-05-07_10"); got != want {
+		t.Errorf("findBackupAtVersion() = %s, want %s", got, want)
+	}
+}
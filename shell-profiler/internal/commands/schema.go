@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+)
+
+// Schema is a machine-readable description of the profile layout and
+// configuration, for editors and other external tooling
+type Schema struct {
+	SchemaVersion int                         `json:"schemaVersion"`
+	MetaFile      string                      `json:"metaFile"`
+	Templates     []templates.ProfileTemplate `json:"templates"`
+	Directories   []string                    `json:"directories"`
+	EnvVars       []tools.EnvVar              `json:"envVars"`
+	Tools         []tools.Tool                `json:"tools"`
+	ConfigFile    string                      `json:"configFile"`
+	ConfigKeys    []config.KeyInfo            `json:"configKeys"`
+}
+
+// BuildSchema assembles the schema from the tool registry, the template list
+// and the migration chain so it always matches what create and update do
+func BuildSchema() Schema {
+	return Schema{
+		SchemaVersion: profileMigrations.Latest(),
+		MetaFile:      profile.MetaFileName,
+		Templates:     templates.Builtin(),
+		Directories:   tools.Dirs(),
+		EnvVars:       tools.EnvVars(),
+		Tools:         tools.All(),
+		ConfigFile:    "~/.profile-manager",
+		ConfigKeys:    config.Keys(),
+	}
+}
+
+// ShowSchema prints the schema as indented JSON
+func ShowSchema() error {
+	content, err := json.MarshalIndent(BuildSchema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(os.Stdout, string(content)); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShowSchema_ListsTemplatesAndManagedVars(t *testing.T) {
+	out, err := captureStdout(t, ShowSchema)
+	if err != nil {
+		t.Fatalf("ShowSchema() error: %v", err)
+	}
+
+	var schema Schema
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v\n%s", err, out)
+	}
+
+	var templateNames []string
+	for _, tmpl := range schema.Templates {
+		templateNames = append(templateNames, tmpl.Name)
+	}
+	if strings.Join(templateNames, ",") != "basic,personal,work,client" {
+		t.Errorf("templates = %v, want [basic personal work client]", templateNames)
+	}
+
+	vars := make(map[string]bool)
+	for _, v := range schema.EnvVars {
+		vars[v.Name] = true
+	}
+	for _, name := range []string{"GIT_CONFIG_GLOBAL", "AWS_CONFIG_FILE", "KUBECONFIG", "CLOUDSDK_CONFIG", "CLAUDE_CONFIG_DIR"} {
+		if !vars[name] {
+			t.Errorf("schema missing managed env var %s", name)
+		}
+	}
+
+	if schema.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schemaVersion = %d, want %d", schema.SchemaVersion, profileMigrations.Latest())
+	}
+	if len(schema.ConfigKeys) == 0 || schema.ConfigKeys[0].Key != "profiles_dir" {
+		t.Errorf("configKeys = %+v, want profiles_dir", schema.ConfigKeys)
+	}
+}
+
+func TestSchema_MatchesCreatedProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "s", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "s")
+
+	schema := BuildSchema()
+	for _, dir := range schema.Directories {
+		if _, err := os.Stat(filepath.Join(profileDir, dir)); err != nil {
+			t.Errorf("schema directory %s not created: %v", dir, err)
+		}
+	}
+
+	env, _ := os.ReadFile(filepath.Join(profileDir, ".env"))
+	for _, v := range schema.EnvVars {
+		if !strings.Contains(string(env), v.Name+"=") {
+			t.Errorf("schema env var %s not in generated .env", v.Name)
+		}
+	}
+}
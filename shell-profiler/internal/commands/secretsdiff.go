@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/secrets"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+type SecretsDiffOptions struct {
+	Format string // "text" (default) or "json"
+}
+
+// SecretsDiff is the difference between the secrets a profile's vaults hold
+// and those its .env.example documents. It holds names only.
+type SecretsDiff struct {
+	Profile      string   `json:"profile"`
+	Vaults       []string `json:"vaults"`
+	Undocumented []string `json:"undocumented"` // In a vault, not in .env.example
+	Missing      []string `json:"missing"`      // In .env.example, in no vault
+}
+
+// DiffSecrets lists the variables the named profile, or else the active
+// profile, would load from its secret backend and compares them with the
+// ones documented in .env.example, or the tool registry's examples if it
+// has none. Secret values are never shown.
+func DiffSecrets(profilesDir, profileName string, opts SecretsDiffOptions) error {
+	if opts.Format != "" && opts.Format != "text" && opts.Format != "json" {
+		return fmt.Errorf("unknown format: %s (use text or json)", opts.Format)
+	}
+	if profileName == "" {
+		active, ok := profile.ActiveProfileIn(profilesDir)
+		if !ok {
+			return fmt.Errorf("no profile specified and WORKSPACE_PROFILE is not set")
+		}
+		profileName = active
+	}
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
+	profileDir := filepath.Join(profilesDir, profileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", profileName, profileDir)
+	}
+
+	diff, err := diffProfileSecrets(profileDir, profileName)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == "json" {
+		content, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode secrets diff: %w", err)
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Secrets for profile %s (vaults: %s)", profileName, strings.Join(diff.Vaults, ", ")))
+	if len(diff.Undocumented) == 0 && len(diff.Missing) == 0 {
+		ui.PrintSuccess("Every secret in the vaults is documented in .env.example, and every documented one is in a vault")
+		return nil
+	}
+	if len(diff.Undocumented) > 0 {
+		fmt.Println("  In a vault but not documented in .env.example:")
+		for _, name := range diff.Undocumented {
+			fmt.Printf("    + %s\n", name)
+		}
+	}
+	if len(diff.Missing) > 0 {
+		fmt.Println("  Documented in .env.example but in no vault:")
+		for _, name := range diff.Missing {
+			fmt.Printf("    - %s\n", name)
+		}
+	}
+	return nil
+}
+
+// diffProfileSecrets lists a profile's secrets through its backend and
+// compares them with its documented examples
+func diffProfileSecrets(profileDir, profileName string) (*SecretsDiff, error) {
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	backend, ok := secretBackend(meta)
+	if !ok {
+		return nil, fmt.Errorf("unknown secret backend %q", meta.SecretBackend)
+	}
+	lister, ok := backend.(secrets.KeyLister)
+	if !ok {
+		return nil, fmt.Errorf("secret backend %s cannot list its secrets", backend.Name())
+	}
+
+	vaults := meta.Vaults
+	if len(vaults) == 0 {
+		vaults = []string{secrets.OnePassword{}.VaultName(profileName)}
+	}
+	keys, err := lister.Keys(secrets.KeyOptions{
+		Vaults:        vaults,
+		Account:       meta.OpAccount,
+		FieldPrefix:   meta.OpFieldPrefix,
+		ExcludeFields: meta.OpExcludeFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	documented, err := documentedSecrets(profileDir, meta.NoCloud)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SecretsDiff{Profile: profileName, Vaults: vaults, Undocumented: []string{}, Missing: []string{}}
+	inVault := map[string]bool{}
+	for _, key := range keys {
+		inVault[key] = true
+		if !documented[key] {
+			diff.Undocumented = append(diff.Undocumented, key)
+		}
+	}
+	for name := range documented {
+		if !inVault[name] {
+			diff.Missing = append(diff.Missing, name)
+		}
+	}
+	sort.Strings(diff.Undocumented)
+	sort.Strings(diff.Missing)
+	return diff, nil
+}
+
+// documentedSecrets returns the variable names in a profile's .env.example,
+// or the tool registry's examples if the profile has none
+func documentedSecrets(profileDir string, noCloud bool) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(profileDir, ".env.example"))
+	if os.IsNotExist(err) {
+		data, err = []byte(renderEnvExample(noCloud)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .env.example: %w", err)
+	}
+	return envExampleNames(string(data)), nil
+}
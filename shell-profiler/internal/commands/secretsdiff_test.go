@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeOpVault puts an `op` on PATH serving a vault with a GitHub item and a
+// database item, and returns the log of its arguments
+func fakeOpVault(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "op.log")
+	script := `#!/bin/sh
+echo "$*" >> "` + logPath + `"
+case "$1 $2 $3" in
+"item list "*) echo '[{"id":"gh"},{"id":"db"}]' ;;
+"item get gh") echo '{"title":"GitHub","fields":[
+  {"id":"f1","label":"token","type":"CONCEALED","value":"ghp_s3cret"},
+  {"id":"f2","label":"one-time password","type":"OTP","value":"otpauth://x"},
+  {"id":"notesPlain","label":"notesPlain","type":"STRING","value":"notes"}]}' ;;
+"item get db") echo '{"title":"Prod DB","fields":[
+  {"id":"f1","label":"password","type":"CONCEALED","value":"hunter2"},
+  {"id":"f2","label":"username","type":"STRING","value":""},
+  {"id":"f3","label":"url","type":"URL","value":"https://db.example"}]}' ;;
+*) exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func TestDiffSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic", OpExcludeFields: []string{"URL"}}); err != nil {
+		t.Fatal(err)
+	}
+	example := "# GITHUB_TOKEN=your-token\n# STRIPE_KEY=your-stripe-key\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "acme", ".env.example"), []byte(example), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logPath := fakeOpVault(t)
+
+	out, err := captureStdout(t, func() error {
+		return DiffSecrets(tmpDir, "acme", SecretsDiffOptions{Format: "json"})
+	})
+	if err != nil {
+		t.Fatalf("DiffSecrets() error: %v", err)
+	}
+	var diff SecretsDiff
+	if err := json.Unmarshal([]byte(out), &diff); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if strings.Join(diff.Undocumented, ",") != "PROD_DB_PASSWORD" {
+		t.Errorf("Undocumented = %v, want [PROD_DB_PASSWORD]", diff.Undocumented)
+	}
+	if strings.Join(diff.Missing, ",") != "STRIPE_KEY" {
+		t.Errorf("Missing = %v, want [STRIPE_KEY]", diff.Missing)
+	}
+	for _, value := range []string{"ghp_s3cret", "hunter2", "otpauth"} {
+		if strings.Contains(out, value) {
+			t.Errorf("output reveals secret value %q:\n%s", value, out)
+		}
+	}
+
+	log, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(log), "item list --vault workspace-acme --format json") {
+		t.Errorf("op should list the profile's vault, got:\n%s", log)
+	}
+}
+
+func TestDiffSecrets_Vaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	opts := CreateOptions{ProfileName: "acme", Template: "basic", Vaults: []string{"personal", "team"}, OpAccount: "acme.1password.com"}
+	if err := CreateProfile(tmpDir, opts); err != nil {
+		t.Fatal(err)
+	}
+	logPath := fakeOpVault(t)
+
+	out, err := captureStdout(t, func() error {
+		return DiffSecrets(tmpDir, "acme", SecretsDiffOptions{})
+	})
+	if err != nil {
+		t.Fatalf("DiffSecrets() error: %v", err)
+	}
+	// Documented examples come from the registry's .env.example
+	for _, want := range []string{"+ GITHUB_TOKEN", "+ PROD_DB_URL", "- ANTHROPIC_API_KEY"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in report:\n%s", want, out)
+		}
+	}
+
+	log, _ := os.ReadFile(logPath)
+	for _, want := range []string{
+		"item list --vault personal --format json --account acme.1password.com",
+		"item list --vault team --format json --account acme.1password.com",
+	} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("expected op call %q, got:\n%s", want, log)
+		}
+	}
+}
@@ -11,12 +11,16 @@ import (
 )
 
 type SelectOptions struct {
-	ProfileName string
-	AllowDirenv bool
+	ProfileName     string
+	AllowDirenv     bool
+	IncludeArchived bool // Also offer profiles under archived/
 }
 
 // SelectProfile allows the user to interactively select and switch to a profile
 func SelectProfile(profilesDir string, opts SelectOptions) error {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
 	// Get list of profiles
 	entries, err := os.ReadDir(profilesDir)
 	if err != nil {
@@ -37,6 +41,14 @@ func SelectProfile(profilesDir string, opts SelectOptions) error {
 		}
 	}
 
+	if opts.IncludeArchived {
+		for _, name := range archivedProfiles(profilesDir) {
+			key := filepath.Join(archivedDirName, name)
+			profiles = append(profiles, key)
+			profileDetails[key] = filepath.Join(profilesDir, key)
+		}
+	}
+
 	if len(profiles) == 0 {
 		return fmt.Errorf("no profiles found")
 	}
@@ -91,17 +103,7 @@ func SelectProfile(profilesDir string, opts SelectOptions) error {
 				fmt.Println()
 				ui.PrintWarning("direnv needs to be allowed for this profile")
 				if opts.AllowDirenv {
-					// Try to allow direnv
-					allowCmd := exec.Command("direnv", "allow")
-					allowCmd.Dir = profilePath
-					allowCmd.Stdout = os.Stdout
-					allowCmd.Stderr = os.Stderr
-					if err := allowCmd.Run(); err != nil {
-						ui.PrintWarning(fmt.Sprintf("Failed to allow direnv: %v", err))
-						fmt.Println("  You may need to run 'direnv allow' manually")
-					} else {
-						ui.PrintSuccess("direnv allowed")
-					}
+					allowProfile(profilePath)
 				} else {
 					fmt.Println("  Run 'direnv allow' after changing to the directory")
 				}
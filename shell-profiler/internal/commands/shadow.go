@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// wrappedCommands are shadowed on purpose by the profile's bin/
+var wrappedCommands = map[string]bool{
+	"ssh": true, // Uses the profile's .ssh/config
+}
+
+// Shadow is an executable in a profile's bin/ that hides a system command
+type Shadow struct {
+	Name       string
+	SystemPath string // What the command resolves to without the profile
+}
+
+// ShadowedCommands lists executables in the profile's bin/ that shadow a
+// command found on PATH once the profile's bin/ is removed from it
+func ShadowedCommands(profileDir string) ([]Shadow, error) {
+	binDir := filepath.Join(profileDir, "bin")
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", binDir, err)
+	}
+
+	var shadows []Shadow
+	for _, entry := range entries {
+		if entry.IsDir() || wrappedCommands[entry.Name()] {
+			continue
+		}
+		if !isExecutable(filepath.Join(binDir, entry.Name())) {
+			continue
+		}
+		if systemPath := lookPathExcluding(entry.Name(), binDir); systemPath != "" {
+			shadows = append(shadows, Shadow{Name: entry.Name(), SystemPath: systemPath})
+		}
+	}
+	sort.Slice(shadows, func(i, j int) bool { return shadows[i].Name < shadows[j].Name })
+	return shadows, nil
+}
+
+// lookPathExcluding resolves name on PATH, skipping the excluded directory
+func lookPathExcluding(name, exclude string) string {
+	exclude = filepath.Clean(exclude)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" || filepath.Clean(dir) == exclude {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if isExecutable(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0
+}
+
+func checkShadowing(profileDir, _ string, _ bool) ([]Finding, error) {
+	shadows, err := ShadowedCommands(profileDir)
+	var findings []Finding
+	for _, s := range shadows {
+		findings = append(findings, Finding{
+			Check:    "shadowing",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("bin/%s shadows %s", s.Name, s.SystemPath),
+		})
+	}
+	return findings, err
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShadowedCommands(t *testing.T) {
+	profileDir := t.TempDir()
+	binDir := filepath.Join(profileDir, "bin")
+	systemDir := t.TempDir()
+	for dir, names := range map[string][]string{
+		binDir:    {"git", "ssh", "my-script"},
+		systemDir: {"git", "ssh"},
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// The profile's bin/ is first on PATH, as direnv leaves it
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+systemDir)
+
+	findings, err := checkShadowing(profileDir, "acme", false)
+	if err != nil {
+		t.Fatalf("checkShadowing() error: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1 for bin/git: %+v", len(findings), findings)
+	}
+	want := "bin/git shadows " + filepath.Join(systemDir, "git")
+	if findings[0].Message != want || findings[0].Severity != SeverityWarning {
+		t.Errorf("finding = %+v, want warning %q", findings[0], want)
+	}
+	for _, f := range findings {
+		if strings.Contains(f.Message, "ssh") || strings.Contains(f.Message, "my-script") {
+			t.Errorf("unexpected finding: %s", f.Message)
+		}
+	}
+}
+
+func TestShadowedCommands_NoBinDir(t *testing.T) {
+	shadows, err := ShadowedCommands(t.TempDir())
+	if err != nil || len(shadows) != 0 {
+		t.Errorf("ShadowedCommands() = %v, %v; want none", shadows, err)
+	}
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+// sshWorkspaceHome stands for the profile directory in .ssh/config paths
+// with --relative-ssh-paths. OpenSSH 8.4 and later expand it from the
+// environment, which direnv and the bin/ssh wrapper both set.
+const sshWorkspaceHome = "${WORKSPACE_HOME}"
+
+// Header notes of .ssh/config on how its paths are written
+const (
+	sshAbsolutePathsNote = `# Note: SSH config files don't support environment variable expansion.
+# All paths are absolute paths to ensure they work regardless of current directory.
+`
+	sshRelativePathsNote = `# Note: paths use ${WORKSPACE_HOME}, which OpenSSH 8.4+ expands from the
+# environment set by direnv or bin/ssh, so the profile can be moved freely.
+`
+)
+
+// sshWrapperHome is the line of bin/ssh locating the profile, and
+// sshWrapperExportHome its form exporting it for ${WORKSPACE_HOME} paths
+const (
+	sshWrapperHome       = `WORKSPACE_HOME="$(dirname "$SCRIPT_DIR")"`
+	sshWrapperExportHome = "export " + sshWrapperHome
+)
+
+// staleSSHPath is a path in .ssh/config into the .ssh directory of a profile
+// location that no longer exists, as left behind by moving the profile
+type staleSSHPath struct {
+	Line    int
+	Keyword string
+	Path    string
+	OldDir  string // The profile directory the path was written for
+}
+
+// findStaleSSHPaths returns the IdentityFile and UserKnownHostsFile paths in
+// an ssh config that point into the .ssh directory of a missing directory
+// other than profileDir. Paths elsewhere, such as a shared key in ~/.ssh,
+// are left alone.
+func findStaleSSHPaths(content, profileDir string) []staleSSHPath {
+	current := map[string]bool{profileDir: true}
+	if real, err := filepath.EvalSymlinks(profileDir); err == nil {
+		current[real] = true
+	}
+
+	var stale []staleSSHPath
+	for _, d := range parseSSHConfig(content) {
+		keyword := strings.ToLower(d.Keyword)
+		if keyword != "identityfile" && keyword != "userknownhostsfile" {
+			continue
+		}
+		// UserKnownHostsFile takes several files
+		for _, path := range d.Args {
+			if !filepath.IsAbs(path) || strings.Contains(path, "%") {
+				continue
+			}
+			i := strings.Index(path, "/.ssh/")
+			if i < 0 {
+				continue
+			}
+			oldDir := path[:i]
+			if current[oldDir] {
+				continue
+			}
+			if _, err := os.Stat(oldDir); err == nil {
+				continue
+			}
+			stale = append(stale, staleSSHPath{Line: d.Line, Keyword: d.Keyword, Path: path, OldDir: oldDir})
+		}
+	}
+	return stale
+}
+
+// fixSSHPaths rewrites the stale paths of .ssh/config to the profile's
+// current location, including those in commented-out examples, and returns
+// what was stale
+func fixSSHPaths(profileDir string, dryRun bool) ([]staleSSHPath, error) {
+	profileDir, err := filepath.Abs(profileDir)
+	if err != nil {
+		return nil, err
+	}
+	configPath := filepath.Join(profileDir, ".ssh", "config")
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .ssh/config: %w", err)
+	}
+
+	stale := findStaleSSHPaths(string(content), profileDir)
+	if len(stale) == 0 || dryRun {
+		return stale, nil
+	}
+
+	fixed := string(content)
+	for _, s := range stale {
+		fixed = strings.ReplaceAll(fixed, s.OldDir+"/.ssh/", profileDir+"/.ssh/")
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(configPath, []byte(fixed), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write .ssh/config: %w", err)
+	}
+	return stale, nil
+}
+
+// convertSSHPathsRelative rewrites the absolute paths into the profile in
+// .ssh/config, stale ones included, as ${WORKSPACE_HOME} paths, makes bin/ssh
+// export WORKSPACE_HOME and records the choice in .profile-meta
+func convertSSHPathsRelative(profileDir string, dryRun bool) (bool, error) {
+	profileDir, err := filepath.Abs(profileDir)
+	if err != nil {
+		return false, err
+	}
+	configPath := filepath.Join(profileDir, ".ssh", "config")
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read .ssh/config: %w", err)
+	}
+
+	dirs := []string{profileDir}
+	if real, err := filepath.EvalSymlinks(profileDir); err == nil && real != profileDir {
+		dirs = append(dirs, real)
+	}
+	for _, s := range findStaleSSHPaths(string(content), profileDir) {
+		dirs = append(dirs, s.OldDir)
+	}
+	converted := strings.Replace(string(content), sshAbsolutePathsNote, sshRelativePathsNote, 1)
+	for _, dir := range dirs {
+		converted = strings.ReplaceAll(converted, dir+"/.ssh/", sshWorkspaceHome+"/.ssh/")
+	}
+
+	wrapperPath := filepath.Join(profileDir, "bin", "ssh")
+	wrapper, err := os.ReadFile(wrapperPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read bin/ssh: %w", err)
+	}
+	exported := string(wrapper)
+	if !strings.Contains(exported, sshWrapperExportHome) {
+		exported = strings.Replace(exported, sshWrapperHome, sshWrapperExportHome, 1)
+	}
+
+	meta, metaErr := profile.ReadMeta(profileDir)
+	changed := converted != string(content) || exported != string(wrapper) || (metaErr == nil && !meta.RelativeSSHPaths)
+	if !changed || dryRun {
+		return changed, nil
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(configPath, []byte(converted), info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to write .ssh/config: %w", err)
+	}
+	if exported != string(wrapper) {
+		if err := os.WriteFile(wrapperPath, []byte(exported), 0755); err != nil {
+			return false, fmt.Errorf("failed to write bin/ssh: %w", err)
+		}
+	}
+	if metaErr == nil && !meta.RelativeSSHPaths {
+		meta.RelativeSSHPaths = true
+		if err := profile.WriteMeta(profileDir, meta); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// checkSSHPaths reports .ssh/config paths into the profile's old location
+// after it was moved, rewriting them with fix
+func checkSSHPaths(profileDir, _ string, fix bool) ([]Finding, error) {
+	stale, err := fixSSHPaths(profileDir, !fix)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(profileDir)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, s := range stale {
+		findings = append(findings, Finding{
+			Check:    "ssh-paths",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf(".ssh/config line %d: %s %s is under %s, which no longer exists", s.Line, s.Keyword, s.Path, s.OldDir),
+			Repair:   fmt.Sprintf("rewrite it to %s", strings.Replace(s.Path, s.OldDir+"/.ssh/", absDir+"/.ssh/", 1)),
+			Fixed:    fix,
+		})
+	}
+	return findings, nil
+}
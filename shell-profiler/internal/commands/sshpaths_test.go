@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func TestCheckSSHPaths_MovedProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldDir := filepath.Join(tmpDir, "old-name")
+	profileDir := filepath.Join(tmpDir, "new-name")
+	home := filepath.Join(tmpDir, "home")
+	for _, dir := range []string{filepath.Join(profileDir, ".ssh"), filepath.Join(home, ".ssh")} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := "Host *\n" +
+		"    UserKnownHostsFile " + oldDir + "/.ssh/known_hosts\n" +
+		"    IdentityFile " + home + "/.ssh/id_ed25519\n\n" +
+		"Host github.com\n" +
+		"    IdentityFile \"" + oldDir + "/.ssh/id_ed25519_github\"\n" +
+		"#     IdentityFile " + oldDir + "/.ssh/id_ed25519_gitlab\n" +
+		"    IdentityFile " + profileDir + "/.ssh/id_ed25519_work\n"
+	configPath := filepath.Join(profileDir, ".ssh", "config")
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := checkSSHPaths(profileDir, "new-name", false)
+	if err != nil {
+		t.Fatalf("checkSSHPaths() error: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want the two paths under the old location: %+v", len(findings), findings)
+	}
+	if !strings.Contains(findings[0].Message, "line 2: UserKnownHostsFile "+oldDir+"/.ssh/known_hosts") {
+		t.Errorf("finding = %q", findings[0].Message)
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != config {
+		t.Error("checking without --fix should not change .ssh/config")
+	}
+
+	findings, err = checkSSHPaths(profileDir, "new-name", true)
+	if err != nil || len(findings) != 2 || !findings[0].Fixed {
+		t.Fatalf("checkSSHPaths(fix) = %+v, %v", findings, err)
+	}
+	content, _ := os.ReadFile(configPath)
+	if strings.Contains(string(content), oldDir) {
+		t.Errorf("old location left in .ssh/config:\n%s", content)
+	}
+	for _, want := range []string{
+		"UserKnownHostsFile " + profileDir + "/.ssh/known_hosts",
+		"IdentityFile \"" + profileDir + "/.ssh/id_ed25519_github\"",
+		"#     IdentityFile " + profileDir + "/.ssh/id_ed25519_gitlab",
+		"IdentityFile " + home + "/.ssh/id_ed25519",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf(".ssh/config missing %q:\n%s", want, content)
+		}
+	}
+	if info, _ := os.Stat(configPath); info.Mode().Perm() != 0600 {
+		t.Errorf(".ssh/config mode = %o, want 0600", info.Mode().Perm())
+	}
+
+	if findings, _ := checkSSHPaths(profileDir, "new-name", false); len(findings) != 0 {
+		t.Errorf("fixed config still reported: %+v", findings)
+	}
+}
+
+func TestCheckSSHPaths_FreshProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "fresh", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	findings, err := checkSSHPaths(filepath.Join(tmpDir, "fresh"), "fresh", false)
+	if err != nil || len(findings) != 0 {
+		t.Errorf("checkSSHPaths() = %+v, %v; want nothing on a new profile", findings, err)
+	}
+}
+
+func TestCreateProfile_RelativeSSHPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "portable", Template: "basic", RelativeSSHPaths: true})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "portable")
+
+	config, _ := os.ReadFile(filepath.Join(profileDir, ".ssh", "config"))
+	if strings.Contains(string(config), tmpDir) {
+		t.Errorf(".ssh/config has absolute paths:\n%s", config)
+	}
+	for _, want := range []string{
+		"UserKnownHostsFile ${WORKSPACE_HOME}/.ssh/known_hosts\n",
+		"#     IdentityFile ${WORKSPACE_HOME}/.ssh/id_ed25519_github\n",
+		"# Note: paths use ${WORKSPACE_HOME}",
+	} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf(".ssh/config missing %q:\n%s", want, config)
+		}
+	}
+	wrapper, _ := os.ReadFile(filepath.Join(profileDir, "bin", "ssh"))
+	if !strings.Contains(string(wrapper), sshWrapperExportHome) {
+		t.Errorf("bin/ssh should export WORKSPACE_HOME:\n%s", wrapper)
+	}
+	if meta, err := profile.ReadMeta(profileDir); err != nil || !meta.RelativeSSHPaths {
+		t.Errorf("meta should record relativeSshPaths: %+v, %v", meta, err)
+	}
+
+	// ssh itself resolves the paths from the environment
+	if ssh, err := exec.LookPath("ssh"); err == nil {
+		cmd := exec.Command(ssh, "-G", "-F", filepath.Join(profileDir, ".ssh", "config"), "example.com")
+		cmd.Env = append(os.Environ(), "WORKSPACE_HOME="+profileDir)
+		out, err := cmd.Output()
+		if err == nil && !strings.Contains(string(out), "userknownhostsfile "+profileDir+"/.ssh/known_hosts") {
+			t.Errorf("ssh -G did not expand ${WORKSPACE_HOME}:\n%s", out)
+		}
+	}
+}
+
+func TestConvertSSHPathsRelative(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "fixed", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "fixed")
+	configPath := filepath.Join(profileDir, ".ssh", "config")
+	before, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(before), profileDir+"/.ssh/known_hosts") {
+		t.Fatalf("default .ssh/config should use absolute paths:\n%s", before)
+	}
+
+	if converted, err := convertSSHPathsRelative(profileDir, true); err != nil || !converted {
+		t.Fatalf("dry run = %v, %v", converted, err)
+	}
+	if after, _ := os.ReadFile(configPath); string(after) != string(before) {
+		t.Fatal("dry run should not change .ssh/config")
+	}
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "fixed", RelativeSSHPaths: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	after, _ := os.ReadFile(configPath)
+	if strings.Contains(string(after), tmpDir) || !strings.Contains(string(after), "UserKnownHostsFile ${WORKSPACE_HOME}/.ssh/known_hosts") {
+		t.Errorf(".ssh/config not converted:\n%s", after)
+	}
+	if strings.Contains(string(after), sshAbsolutePathsNote) {
+		t.Errorf("header still says paths are absolute:\n%s", after)
+	}
+	if meta, _ := profile.ReadMeta(profileDir); !meta.RelativeSSHPaths {
+		t.Error("meta should record relativeSshPaths")
+	}
+	if converted, _ := convertSSHPathsRelative(profileDir, false); converted {
+		t.Error("a second conversion should change nothing")
+	}
+
+	// The profile was already up to date, yet the rewrite is backed up and
+	// can be restored
+	backups, _ := filepath.Glob(filepath.Join(profileDir, ".backups", "update_*"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one update backup, found %v", backups)
+	}
+	if backedUp, _ := os.ReadFile(filepath.Join(backups[0], ".ssh", "config")); string(backedUp) != string(before) {
+		t.Fatalf("backup does not hold the original .ssh/config:\n%s", backedUp)
+	}
+	if _, err := restoreBackup(profileDir, backups[0]); err != nil {
+		t.Fatalf("restoreBackup() error: %v", err)
+	}
+	if restored, _ := os.ReadFile(configPath); string(restored) != string(before) {
+		t.Errorf(".ssh/config not restored:\n%s", restored)
+	}
+	if info, err := os.Stat(configPath); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("restored .ssh/config mode = %o, want 0600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/util"
+)
+
+// weakSSHAlgorithms are the ciphers, key exchanges, MACs and signature
+// algorithms the ssh policy check flags, by the ssh_config keyword that
+// selects them
+var weakSSHAlgorithms = map[string][]string{
+	"ciphers": {
+		"3des-cbc", "aes128-cbc", "aes192-cbc", "aes256-cbc", "blowfish-cbc",
+		"cast128-cbc", "arcfour", "arcfour128", "arcfour256", "rijndael-cbc@lysator.liu.se",
+	},
+	"kexalgorithms": {
+		"diffie-hellman-group1-sha1", "diffie-hellman-group14-sha1",
+		"diffie-hellman-group-exchange-sha1",
+	},
+	"macs": {
+		"hmac-md5", "hmac-md5-96", "hmac-md5-etm@openssh.com", "hmac-md5-96-etm@openssh.com",
+		"hmac-sha1", "hmac-sha1-96", "hmac-sha1-etm@openssh.com", "hmac-sha1-96-etm@openssh.com",
+		"hmac-ripemd160", "umac-64@openssh.com", "umac-64-etm@openssh.com",
+	},
+	"hostkeyalgorithms":        {"ssh-dss", "ssh-rsa"},
+	"pubkeyacceptedalgorithms": {"ssh-dss", "ssh-rsa"},
+	"pubkeyacceptedkeytypes":   {"ssh-dss", "ssh-rsa"},
+}
+
+// sshDirective is one keyword/value line of an ssh config
+type sshDirective struct {
+	Line    int
+	Keyword string // As written
+	Value   string
+	Args    []string // Value split into arguments, unquoted
+}
+
+// parseSSHConfig returns the directives of an ssh config, skipping comments
+// and blank lines. Keywords may be separated from values by spaces or "=".
+func parseSSHConfig(content string) []sshDirective {
+	var directives []sshDirective
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		end := strings.IndexAny(line, " \t=")
+		if end < 0 {
+			continue
+		}
+		value := strings.TrimLeft(line[end:], " \t")
+		value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+		directives = append(directives, sshDirective{
+			Line:    lineNum,
+			Keyword: line[:end],
+			Value:   strings.Trim(value, `"`),
+			Args:    util.SSHFields(value),
+		})
+	}
+	return directives
+}
+
+// checkSSHPolicy flags .ssh/config options that weaken ssh and identity
+// files using keys below the configured strength
+func checkSSHPolicy(profileDir, _ string, _ bool) ([]Finding, error) {
+	content, err := os.ReadFile(filepath.Join(profileDir, ".ssh", "config"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .ssh/config: %w", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	allowed := make(map[string]bool)
+	for _, name := range cfg.SSHAllowAlgorithms {
+		allowed[strings.ToLower(name)] = true
+	}
+
+	var findings []Finding
+	warn := func(d sshDirective, format string, args ...interface{}) {
+		findings = append(findings, Finding{
+			Check:    "ssh-policy",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf(".ssh/config line %d: ", d.Line) + fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, d := range parseSSHConfig(string(content)) {
+		keyword := strings.ToLower(d.Keyword)
+		switch keyword {
+		case "stricthostkeychecking":
+			if v := strings.ToLower(d.Value); v == "no" || v == "off" {
+				warn(d, "%s %s accepts any host key; use \"accept-new\" or \"yes\"", d.Keyword, d.Value)
+			}
+		case "userknownhostsfile":
+			if d.Value == "/dev/null" {
+				warn(d, "%s /dev/null never remembers host keys; point it at .ssh/known_hosts", d.Keyword)
+			}
+		case "identityfile":
+			path := strings.Replace(d.Value, sshWorkspaceHome, profileDir, 1)
+			if msg := checkIdentityFile(path, cfg.MinRSABits()); msg != "" {
+				warn(d, "%s", msg)
+			}
+		default:
+			weak, ok := weakSSHAlgorithms[keyword]
+			if !ok || strings.HasPrefix(d.Value, "-") {
+				continue
+			}
+			var found []string
+			for _, name := range strings.Split(strings.TrimLeft(d.Value, "+^"), ",") {
+				name = strings.ToLower(strings.TrimSpace(name))
+				if !allowed[name] && slices.Contains(weak, name) {
+					found = append(found, name)
+				}
+			}
+			if len(found) > 0 {
+				warn(d, "%s enables weak algorithms %s; remove them or add them to ssh_allow_algorithms",
+					d.Keyword, strings.Join(found, ", "))
+			}
+		}
+	}
+	return findings, nil
+}
+
+// checkIdentityFile describes why the key at path is too weak, or returns ""
+// if it is acceptable or cannot be read
+func checkIdentityFile(path string, minRSABits int) string {
+	if strings.Contains(path, "%") {
+		return ""
+	}
+	if strings.HasPrefix(path, "~") {
+		path = config.ExpandPath(path)
+	}
+	if !filepath.IsAbs(path) {
+		return ""
+	}
+
+	keyType, bits, err := sshKeyStrength(path)
+	if err != nil {
+		return ""
+	}
+	switch keyType {
+	case "ssh-dss":
+		return fmt.Sprintf("IdentityFile %s is a DSA key, which OpenSSH no longer accepts; replace it with an ed25519 key (ssh-keygen -t ed25519)", path)
+	case "ssh-rsa":
+		if bits < minRSABits {
+			return fmt.Sprintf("IdentityFile %s is a %d-bit RSA key, below the %d-bit minimum; replace it with an ed25519 key (ssh-keygen -t ed25519)", path, bits, minRSABits)
+		}
+	}
+	return ""
+}
+
+// errUnknownKeyFormat is returned for keys sshKeyStrength cannot inspect,
+// such as encrypted PEM keys without a .pub file
+var errUnknownKeyFormat = errors.New("unknown key format")
+
+// sshKeyStrength returns the type of the ssh key at path and, for RSA keys,
+// its size in bits. The public key is read from path.pub when it exists,
+// otherwise from the private key itself.
+func sshKeyStrength(path string) (string, int, error) {
+	if pub, err := os.ReadFile(path + ".pub"); err == nil {
+		fields := strings.Fields(string(pub))
+		if len(fields) < 2 {
+			return "", 0, errUnknownKeyFormat
+		}
+		blob, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return "", 0, errUnknownKeyFormat
+		}
+		return parseSSHPublicKey(blob)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", 0, errUnknownKeyFormat
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", 0, errUnknownKeyFormat
+		}
+		return "ssh-rsa", key.N.BitLen(), nil
+	case "DSA PRIVATE KEY":
+		return "ssh-dss", 0, nil
+	case "OPENSSH PRIVATE KEY":
+		// The public key is stored unencrypted after the cipher, KDF, KDF
+		// options and key count
+		rest, ok := bytes.CutPrefix(block.Bytes, []byte("openssh-key-v1\x00"))
+		if !ok {
+			return "", 0, errUnknownKeyFormat
+		}
+		for i := 0; i < 3; i++ {
+			if _, rest, ok = readSSHString(rest); !ok {
+				return "", 0, errUnknownKeyFormat
+			}
+		}
+		if len(rest) < 4 {
+			return "", 0, errUnknownKeyFormat
+		}
+		pub, _, ok := readSSHString(rest[4:])
+		if !ok {
+			return "", 0, errUnknownKeyFormat
+		}
+		return parseSSHPublicKey(pub)
+	}
+	return "", 0, errUnknownKeyFormat
+}
+
+// parseSSHPublicKey returns the type of an ssh wire-format public key and,
+// for RSA keys, the size of its modulus
+func parseSSHPublicKey(blob []byte) (string, int, error) {
+	keyType, rest, ok := readSSHString(blob)
+	if !ok {
+		return "", 0, errUnknownKeyFormat
+	}
+	if string(keyType) != "ssh-rsa" {
+		return string(keyType), 0, nil
+	}
+	// ssh-rsa keys are the exponent then the modulus
+	_, rest, ok = readSSHString(rest)
+	if !ok {
+		return "", 0, errUnknownKeyFormat
+	}
+	modulus, _, ok := readSSHString(rest)
+	if !ok {
+		return "", 0, errUnknownKeyFormat
+	}
+	return "ssh-rsa", new(big.Int).SetBytes(modulus).BitLen(), nil
+}
+
+// readSSHString reads a length-prefixed string from ssh wire format
+func readSSHString(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 4 {
+		return nil, nil, false
+	}
+	n := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(n) {
+		return nil, nil, false
+	}
+	return data[4 : 4+n], data[4+n:], true
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSSHConfig writes .ssh/config for a profile in a fresh directory
+func writeSSHConfig(t *testing.T, content string) string {
+	t.Helper()
+	profileDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(profileDir, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".ssh", "config"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return profileDir
+}
+
+// writeRSAPublicKey writes path.pub holding an RSA public key of the given size
+func writeRSAPublicKey(t *testing.T, path string, bits int) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blob []byte
+	for _, field := range [][]byte{[]byte("ssh-rsa"), big.NewInt(int64(key.E)).Bytes(), key.N.Bytes()} {
+		blob = binary.BigEndian.AppendUint32(blob, uint32(len(field)))
+		blob = append(blob, field...)
+	}
+	pub := "ssh-rsa " + base64.StdEncoding.EncodeToString(blob) + " test\n"
+	if err := os.WriteFile(path+".pub", []byte(pub), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckSSHPolicy_FlagsWeakDirectives(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profileDir := writeSSHConfig(t, `Host *
+    StrictHostKeyChecking no
+    Ciphers aes256-gcm@openssh.com,3des-cbc
+    KexAlgorithms=+diffie-hellman-group1-sha1
+    MACs hmac-sha2-256,hmac-md5
+    # Ciphers arcfour
+`)
+
+	findings, err := checkSSHPolicy(profileDir, "p", false)
+	if err != nil {
+		t.Fatalf("checkSSHPolicy() error: %v", err)
+	}
+	if len(findings) != 4 {
+		t.Fatalf("got %d findings, want 4: %+v", len(findings), findings)
+	}
+	for i, want := range []string{"line 2: StrictHostKeyChecking no", "3des-cbc", "diffie-hellman-group1-sha1", "hmac-md5"} {
+		if !strings.Contains(findings[i].Message, want) {
+			t.Errorf("finding %d = %q, want it to mention %q", i, findings[i].Message, want)
+		}
+		if findings[i].Check != "ssh-policy" || findings[i].Severity != SeverityWarning {
+			t.Errorf("finding %d = %+v, want an ssh-policy warning", i, findings[i])
+		}
+	}
+	if strings.Contains(findings[1].Message, "aes256-gcm") {
+		t.Errorf("strong cipher flagged: %q", findings[1].Message)
+	}
+}
+
+func TestCheckSSHPolicy_CleanConfigPasses(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profileDir := writeSSHConfig(t, "")
+	keyPath := filepath.Join(profileDir, ".ssh", "id_rsa")
+	writeRSAPublicKey(t, keyPath, 3072)
+	if err := os.WriteFile(filepath.Join(profileDir, ".ssh", "config"), []byte(`Host *
+    StrictHostKeyChecking accept-new
+    UserKnownHostsFile `+filepath.Join(profileDir, ".ssh", "known_hosts")+`
+    Ciphers -3des-cbc
+    IdentityFile `+keyPath+`
+    IdentityFile `+filepath.Join(profileDir, ".ssh", "missing")+`
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := checkSSHPolicy(profileDir, "p", false)
+	if err != nil {
+		t.Fatalf("checkSSHPolicy() error: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("clean config has findings: %+v", findings)
+	}
+}
+
+func TestCheckSSHPolicy_WeakRSAKey(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	profileDir := writeSSHConfig(t, "")
+	keyPath := filepath.Join(profileDir, ".ssh", "id_rsa")
+	writeRSAPublicKey(t, keyPath, 2048)
+	if err := os.WriteFile(filepath.Join(profileDir, ".ssh", "config"), []byte("IdentityFile "+keyPath+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := checkSSHPolicy(profileDir, "p", false)
+	if err != nil {
+		t.Fatalf("checkSSHPolicy() error: %v", err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "2048-bit RSA key, below the 3072-bit minimum") {
+		t.Fatalf("findings = %+v, want one weak RSA key", findings)
+	}
+
+	// ssh_min_rsa_bits relaxes the policy
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte("ssh_min_rsa_bits=2048\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	findings, err = checkSSHPolicy(profileDir, "p", false)
+	if err != nil {
+		t.Fatalf("checkSSHPolicy() error: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("findings with ssh_min_rsa_bits=2048: %+v", findings)
+	}
+}
+
+func TestCheckSSHPolicy_AllowedAlgorithms(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte("ssh_allow_algorithms=hmac-sha1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profileDir := writeSSHConfig(t, "Host legacy\n    MACs hmac-sha1,hmac-md5\n")
+
+	findings, err := checkSSHPolicy(profileDir, "p", false)
+	if err != nil {
+		t.Fatalf("checkSSHPolicy() error: %v", err)
+	}
+	if len(findings) != 1 || strings.Contains(findings[0].Message, "hmac-sha1") {
+		t.Errorf("findings = %+v, want only hmac-md5 flagged", findings)
+	}
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+type TagOptions struct {
+	ProfileName string
+	Tags        []string
+}
+
+// AddTags adds tags to a profile's .profile-meta
+func AddTags(profilesDir string, opts TagOptions) error {
+	return modifyTags(profilesDir, opts, func(meta *profile.Meta) []string {
+		return meta.AddTags(opts.Tags...)
+	}, "Added")
+}
+
+// RemoveTags removes tags from a profile's .profile-meta
+func RemoveTags(profilesDir string, opts TagOptions) error {
+	return modifyTags(profilesDir, opts, func(meta *profile.Meta) []string {
+		return meta.RemoveTags(opts.Tags...)
+	}, "Removed")
+}
+
+func modifyTags(profilesDir string, opts TagOptions, apply func(*profile.Meta) []string, verb string) error {
+	if opts.ProfileName == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if len(opts.Tags) == 0 {
+		return fmt.Errorf("at least one tag is required")
+	}
+	for _, tag := range opts.Tags {
+		if err := profile.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+	if err := ensureProfilesDir(profilesDir); err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(profilesDir, opts.ProfileName)
+	if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); os.IsNotExist(err) {
+		return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+	}
+
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	if err := checkSchemaCompatible(meta, opts.ProfileName); err != nil {
+		return err
+	}
+
+	changed := apply(meta)
+	if len(changed) == 0 {
+		ui.PrintInfo(fmt.Sprintf("No tags changed on profile: %s", opts.ProfileName))
+		return nil
+	}
+
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		return err
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("%s tags on %s: %s", verb, opts.ProfileName, strings.Join(changed, ", ")))
+	return nil
+}
+
+// ListTags prints the tags of one profile, or every tag in use with the
+// profiles carrying it when no profile name is given
+func ListTags(profilesDir string, opts TagOptions) error {
+	if opts.ProfileName != "" {
+		profileDir := filepath.Join(profilesDir, opts.ProfileName)
+		if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); os.IsNotExist(err) {
+			return errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		}
+
+		meta, err := profile.LoadMeta(profileDir)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+		}
+		if len(meta.Tags) == 0 {
+			ui.PrintInfo(fmt.Sprintf("Profile %s has no tags", opts.ProfileName))
+			return nil
+		}
+		for _, tag := range meta.Tags {
+			fmt.Println(tag)
+		}
+		return nil
+	}
+
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil {
+		return fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	tagged := make(map[string][]string)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == ".git" {
+			continue
+		}
+		profileDir := filepath.Join(profilesDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(profileDir, ".envrc")); err != nil {
+			continue
+		}
+		meta, err := profile.LoadMeta(profileDir)
+		if err != nil {
+			continue
+		}
+		for _, tag := range meta.Tags {
+			tagged[tag] = append(tagged[tag], entry.Name())
+		}
+	}
+
+	if len(tagged) == 0 {
+		ui.PrintInfo("No tagged profiles found")
+		return nil
+	}
+
+	tags := make([]string, 0, len(tagged))
+	for tag := range tagged {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		fmt.Printf("%s%s%s (%d): %s\n", ui.ColorCyan, tag, ui.ColorReset, len(tagged[tag]), strings.Join(tagged[tag], ", "))
+	}
+	return nil
+}
+
+// profileHasTags reports whether a profile carries every one of the given tags
+func profileHasTags(profileDir string, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return false
+	}
+	return meta.HasAllTags(tags)
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+)
+
+func newTaggableProfile(t *testing.T, profilesDir, name string) string {
+	t.Helper()
+	profileDir := filepath.Join(profilesDir, name)
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("export WORKSPACE_PROFILE=\""+name+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return profileDir
+}
+
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	orig := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	runErr := fn()
+	w.Close()
+	os.Stdout = orig
+
+	out, _ := io.ReadAll(r)
+	return string(out), runErr
+}
+
+func TestAddTags_RemoveTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := newTaggableProfile(t, tmpDir, "acme")
+
+	if err := AddTags(tmpDir, TagOptions{ProfileName: "acme", Tags: []string{"client", "team:platform"}}); err != nil {
+		t.Fatalf("AddTags() error: %v", err)
+	}
+
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		t.Fatalf("ReadMeta() error: %v", err)
+	}
+	if strings.Join(meta.Tags, ",") != "client,team:platform" {
+		t.Errorf("Tags = %v, want [client team:platform]", meta.Tags)
+	}
+
+	if err := RemoveTags(tmpDir, TagOptions{ProfileName: "acme", Tags: []string{"team:platform"}}); err != nil {
+		t.Fatalf("RemoveTags() error: %v", err)
+	}
+
+	meta, _ = profile.ReadMeta(profileDir)
+	if strings.Join(meta.Tags, ",") != "client" {
+		t.Errorf("Tags = %v, want [client]", meta.Tags)
+	}
+}
+
+func TestAddTags_RejectsInvalidTag(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := newTaggableProfile(t, tmpDir, "acme")
+
+	err := AddTags(tmpDir, TagOptions{ProfileName: "acme", Tags: []string{"ok", "not ok"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid tag") {
+		t.Fatalf("expected invalid tag error, got: %v", err)
+	}
+	if _, err := os.Stat(profile.MetaPath(profileDir)); !os.IsNotExist(err) {
+		t.Error("no tags should be written when any tag is invalid")
+	}
+}
+
+func TestAddTags_MissingProfile(t *testing.T) {
+	err := AddTags(t.TempDir(), TagOptions{ProfileName: "nope", Tags: []string{"client"}})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected missing profile error, got: %v", err)
+	}
+}
+
+func TestListProfiles_FiltersByTag(t *testing.T) {
+	tmpDir := t.TempDir()
+	newTaggableProfile(t, tmpDir, "acme")
+	newTaggableProfile(t, tmpDir, "globex")
+	newTaggableProfile(t, tmpDir, "personal")
+
+	if err := AddTags(tmpDir, TagOptions{ProfileName: "acme", Tags: []string{"client"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddTags(tmpDir, TagOptions{ProfileName: "globex", Tags: []string{"client", "archived"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Tags: []string{"client"}})
+	})
+	if err != nil {
+		t.Fatalf("ListProfiles() error: %v", err)
+	}
+	if !strings.Contains(out, "acme") || !strings.Contains(out, "globex") {
+		t.Errorf("expected client-tagged profiles in output:\n%s", out)
+	}
+	if strings.Contains(out, "personal") {
+		t.Errorf("untagged profile should be filtered out:\n%s", out)
+	}
+	if !strings.Contains(out, "Total profiles: 2") {
+		t.Errorf("expected 2 profiles in summary:\n%s", out)
+	}
+
+	out, _ = captureStdout(t, func() error {
+		return ListProfiles(tmpDir, ListOptions{Tags: []string{"client", "archived"}})
+	})
+	if strings.Contains(out, "acme") || !strings.Contains(out, "globex") {
+		t.Errorf("multiple tags should all have to match:\n%s", out)
+	}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+type TemplatesOptions struct {
+	Names       []string // Templates to lint; all of them if empty
+	TemplateDir string   // Searched before the user template directory
+}
+
+// LintTemplates checks the user-provided files of each template for syntax
+// errors and references to fields the templates are not rendered with
+func LintTemplates(opts TemplatesOptions) error {
+	source := templates.DefaultSource(opts.TemplateDir)
+	names := opts.Names
+	if len(names) == 0 {
+		names = source.Names()
+	}
+
+	failed := 0
+	for _, name := range names {
+		if !source.Exists(name) {
+			return errs.Wrapf(errs.ErrInvalidTemplate, "template '%s' not found (available: %s)", name, strings.Join(source.Names(), ", "))
+		}
+		if err := source.Lint(name); err != nil {
+			failed++
+			fmt.Printf("  %s%s %s%s\n", ui.ColorRed, ui.SymbolErr, name, ui.ColorReset)
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Printf("      %s\n", line)
+			}
+			continue
+		}
+		fmt.Printf("  %s%s%s %s\n", ui.ColorGreen, ui.SymbolOK, ui.ColorReset, name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d template(s) failed lint", failed)
+	}
+	return nil
+}
+
+// ListTemplatesJSON prints the built-in templates and the defaults each sets
+// as indented JSON, for wizards embedding create
+func ListTemplatesJSON() error {
+	content, err := json.MarshalIndent(templates.Builtin(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode templates: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(os.Stdout, string(content)); err != nil {
+		return fmt.Errorf("failed to write templates: %w", err)
+	}
+	return nil
+}
+
+// DefaultPreviewProfile is the profile name templates are previewed with
+// when none is given
+const DefaultPreviewProfile = "example"
+
+// PreviewTemplate renders the files a template produces for a profile
+// without writing anything, keyed by file name
+func PreviewTemplate(templateName, profileName string) (map[string]string, error) {
+	return previewTemplate(templates.DefaultSource(""), templateName, profileName)
+}
+
+func previewTemplate(source *templates.Source, templateName, profileName string) (map[string]string, error) {
+	if !source.Exists(templateName) {
+		return nil, errs.Wrapf(errs.ErrInvalidTemplate, "template '%s' not found (available: %s)", templateName, strings.Join(source.Names(), ", "))
+	}
+
+	envrc, err := source.RenderEnvrcWith(profileName, templateName, templates.EnvrcOptions{Watch: true, LocatedHome: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render .envrc template: %w", err)
+	}
+	env, err := source.RenderEnv(profileName, templateName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render .env template: %w", err)
+	}
+	gitconfig, err := source.RenderGitconfigWith(profileName, templateName, "", "", templates.GitconfigOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render .gitconfig template: %w", err)
+	}
+
+	return map[string]string{
+		".envrc":     envrc,
+		".env":       env,
+		".gitconfig": gitconfig,
+		".gitignore": profileGitignore,
+	}, nil
+}
+
+// ShowTemplatePreview prints the files PreviewTemplate renders for the
+// template in opts.Names, each under a header with its name
+func ShowTemplatePreview(opts TemplatesOptions, profileName string) error {
+	if len(opts.Names) != 1 {
+		return fmt.Errorf("preview takes exactly one template name")
+	}
+	if profileName == "" {
+		profileName = DefaultPreviewProfile
+	}
+	files, err := previewTemplate(templates.DefaultSource(opts.TemplateDir), opts.Names[0], profileName)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s==> %s <==%s\n", ui.ColorBlue, name, ui.ColorReset)
+		fmt.Print(files[name])
+	}
+	return nil
+}
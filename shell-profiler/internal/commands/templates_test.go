@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+func TestListTemplatesJSON(t *testing.T) {
+	out, err := captureStdout(t, ListTemplatesJSON)
+	if err != nil {
+		t.Fatalf("ListTemplatesJSON() error: %v", err)
+	}
+
+	var listed []templates.ProfileTemplate
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+
+	byName := make(map[string]templates.ProfileTemplate)
+	for _, tmpl := range listed {
+		byName[tmpl.Name] = tmpl
+	}
+	for _, name := range templates.Names() {
+		tmpl, ok := byName[name]
+		if !ok {
+			t.Errorf("missing builtin template %s", name)
+			continue
+		}
+		if tmpl.Description == "" || tmpl.Color == "" {
+			t.Errorf("%s: description and color should be set: %+v", name, tmpl)
+		}
+	}
+
+	if got := byName["personal"].CredentialCacheTimeout; got != 3600 {
+		t.Errorf("personal credentialCacheTimeout = %d, want 3600", got)
+	}
+	if got := byName["work"].CredentialCacheTimeout; got != 7200 {
+		t.Errorf("work credentialCacheTimeout = %d, want 7200", got)
+	}
+	if got := byName["basic"].CredentialCacheTimeout; got != 0 {
+		t.Errorf("basic credentialCacheTimeout = %d, want 0", got)
+	}
+	if got := byName["client"].Color; got != "#ff9500" {
+		t.Errorf("client color = %s, want #ff9500", got)
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, tc := range []struct {
+		template string
+		marker   string // Only in this template's .gitconfig
+	}{
+		{"personal", "# Personal project settings"},
+		{"work", "helper = cache --timeout=7200"},
+	} {
+		files, err := PreviewTemplate(tc.template, "demo")
+		if err != nil {
+			t.Fatalf("PreviewTemplate(%s) error: %v", tc.template, err)
+		}
+		for _, name := range []string{".envrc", ".env", ".gitconfig", ".gitignore"} {
+			if _, ok := files[name]; !ok {
+				t.Errorf("%s preview missing %s", tc.template, name)
+			}
+		}
+		for _, name := range []string{".envrc", ".env", ".gitconfig"} {
+			if !strings.Contains(files[name], "# Template: "+tc.template) {
+				t.Errorf("%s preview of %s missing template marker:\n%s", tc.template, name, files[name])
+			}
+		}
+		if !strings.Contains(files[".envrc"], `export WORKSPACE_PROFILE="demo"`) {
+			t.Errorf("%s .envrc not rendered for profile demo:\n%s", tc.template, files[".envrc"])
+		}
+		if !strings.Contains(files[".gitconfig"], tc.marker) {
+			t.Errorf("%s .gitconfig missing %q:\n%s", tc.template, tc.marker, files[".gitconfig"])
+		}
+	}
+}
+
+func TestPreviewTemplate_UnknownTemplate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := PreviewTemplate("missing", "demo"); err == nil {
+		t.Error("PreviewTemplate(missing) succeeded")
+	}
+}
+
+func TestLintTemplates_ASCIISymbols(t *testing.T) {
+	orig := ui.ASCIISymbols()
+	t.Cleanup(func() { ui.SetASCIISymbols(orig) })
+
+	for ascii, want := range map[bool]string{true: "[OK] basic\n", false: "✓ basic\n"} {
+		ui.SetASCIISymbols(ascii)
+		out, err := captureStdout(t, func() error { return LintTemplates(TemplatesOptions{Names: []string{"basic"}}) })
+		if err != nil {
+			t.Fatalf("LintTemplates() error: %v", err)
+		}
+		if !strings.HasSuffix(out, want) {
+			t.Errorf("ascii=%v: output %q, want it to end in %q", ascii, out, want)
+		}
+	}
+}
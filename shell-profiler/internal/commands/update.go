@@ -1,30 +1,161 @@
 package commands
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+	"github.com/neverprepared/shell-profile-manager/internal/hooks"
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
+	"github.com/neverprepared/shell-profile-manager/internal/migrations"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/secrets"
 	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
 	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
 type UpdateOptions struct {
 	ProfileName string
-	Force       bool
 	DryRun      bool
 	NoBackup    bool
+	Allow       bool   // Run `direnv allow` after updating
+	PlanFile    string // Write the update plan here instead of applying it
+	Explain     bool   // Print the planned changes with their rules instead of applying them
+	CreateVault bool   // Create the profile's vault when migrating to vault discovery
+
+	// RelativeSSHPaths converts .ssh/config to ${WORKSPACE_HOME} paths
+	RelativeSSHPaths bool
+
+	Clock clock.Clock // Time source for backup names; the system clock if nil
+
+	Overwrite         bool // Let migrations replace existing files (--overwrite)
+	SkipBackupConfirm bool // Continue without asking if the backup fails (--skip-backup-confirm)
+
+	// Force is the original all-or-nothing flag, kept for compatibility:
+	// it implies both Overwrite and SkipBackupConfirm
+	Force bool
+}
+
+func (o UpdateOptions) overwrite() bool {
+	return o.Overwrite || o.Force
+}
+
+func (o UpdateOptions) skipBackupConfirm() bool {
+	return o.SkipBackupConfirm || o.Force
+}
+
+// vaultMigrated reports whether the update switched the profile to vault
+// discovery, which only loads secrets once the vault exists
+func vaultMigrated(results []migrations.Result) bool {
+	for _, result := range results {
+		if result.Migration.Name == "vault-discovery" && len(result.Changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// secretBackend returns the profile's secret backend
+func secretBackend(meta *profile.Meta) (secrets.Backend, bool) {
+	name := meta.SecretBackend
+	if name == "" {
+		name = profile.DefaultSecretBackend
+	}
+	return secrets.Lookup(name)
+}
+
+// printSetupSteps prints the secret backend's setup instructions when the
+// update switched the profile to vault discovery
+func printSetupSteps(results []migrations.Result, meta *profile.Meta, profileName string) {
+	if !vaultMigrated(results) {
+		return
+	}
+	backend, ok := secretBackend(meta)
+	if !ok {
+		return
+	}
+
+	fmt.Println()
+	ui.PrintInfo(fmt.Sprintf("Next steps to load secrets from %s:", backend.Name()))
+	for i, step := range backend.SetupSteps(profileName) {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
+}
+
+// provisionVault creates the profile's vault after the vault-discovery
+// migration. Failures are warnings: the setup steps cover doing it by hand.
+func provisionVault(results []migrations.Result, meta *profile.Meta, profileName string) {
+	if !vaultMigrated(results) {
+		return
+	}
+	backend, ok := secretBackend(meta)
+	if !ok {
+		return
+	}
+	provisioner, ok := backend.(secrets.Provisioner)
+	if !ok {
+		ui.PrintWarning(fmt.Sprintf("%s cannot create vaults automatically", backend.Name()))
+		return
+	}
+
+	fmt.Println()
+	created, err := provisioner.Provision(profileName)
+	switch {
+	case errors.Is(err, secrets.ErrCLINotFound), errors.Is(err, secrets.ErrNotAuthenticated):
+		ui.PrintWarning(fmt.Sprintf("Skipping vault creation: %v", err))
+	case err != nil:
+		ui.PrintWarning(fmt.Sprintf("Vault creation failed: %v", err))
+	case created:
+		ui.PrintSuccess(fmt.Sprintf("Created %s vault for '%s'", backend.Name(), profileName))
+	default:
+		ui.PrintInfo(fmt.Sprintf("%s vault for '%s' already exists", backend.Name(), profileName))
+	}
+}
+
+// migrationContext is the context the update's migrations run with; only
+// the overwrite gate reaches them
+func (o UpdateOptions) migrationContext(profileDir string) migrations.Context {
+	return migrations.Context{
+		ProfileDir:  profileDir,
+		ProfileName: o.ProfileName,
+		DryRun:      o.DryRun,
+		Force:       o.overwrite(),
+	}
 }
 
 // UpdateProfile updates an existing profile with new features
 func UpdateProfile(profilesDir string, opts UpdateOptions) error {
+	_, err := updateProfile(profilesDir, opts)
+	return err
+}
+
+// updateProfile is UpdateProfile, also reporting whether anything in the
+// profile changed (or, in a dry run, would change)
+func updateProfile(profilesDir string, opts UpdateOptions) (changed bool, err error) {
+	if err := checkProfilesDir(profilesDir, !opts.DryRun && !opts.Explain); err != nil {
+		return false, err
+	}
+	// Without a terminal to prompt on, default to the active profile
+	if opts.ProfileName == "" && !ui.IsInteractive() {
+		if active, ok := profile.ActiveProfileIn(profilesDir); ok {
+			ui.PrintInfo(fmt.Sprintf("Using active profile: %s", active))
+			opts.ProfileName = active
+		}
+	}
+
 	// If no profile name provided, show interactive selection
 	if opts.ProfileName == "" {
 		entries, err := os.ReadDir(profilesDir)
 		if err != nil {
-			return fmt.Errorf("failed to read profiles directory: %w", err)
+			return false, fmt.Errorf("failed to read profiles directory: %w", err)
 		}
 
 		var profiles []string
@@ -39,12 +170,12 @@ func UpdateProfile(profilesDir string, opts UpdateOptions) error {
 		}
 
 		if len(profiles) == 0 {
-			return fmt.Errorf("no profiles found")
+			return false, fmt.Errorf("no profiles found")
 		}
 
 		selected, err := ui.SelectProfile(profiles, "Select profile to update:")
 		if err != nil {
-			return err
+			return false, err
 		}
 		opts.ProfileName = selected
 	}
@@ -53,74 +184,150 @@ func UpdateProfile(profilesDir string, opts UpdateOptions) error {
 
 	// Check if profile exists
 	if _, err := os.Stat(profileDir); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
+		return false, errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", opts.ProfileName, profileDir)
 	}
 
 	envrcPath := filepath.Join(profileDir, ".envrc")
 	if _, err := os.Stat(envrcPath); os.IsNotExist(err) {
-		return fmt.Errorf("profile '%s' does not appear to be a valid profile (missing .envrc)", opts.ProfileName)
+		return false, errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not appear to be a valid profile (missing .envrc)", opts.ProfileName)
 	}
 
+	lock, err := profile.AcquireLock(profileDir, profile.DefaultLockTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer lock.Release() //nolint:errcheck // Lock is released on exit; nothing to recover
+
 	ui.PrintInfo(fmt.Sprintf("Updating profile: %s", opts.ProfileName))
 	fmt.Printf("  Location: %s\n", profileDir)
 	fmt.Println()
 
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	if err := checkSchemaCompatible(meta, opts.ProfileName); err != nil {
+		return false, err
+	}
+
+	// Save what the update would do for review, without applying it
+	if opts.PlanFile != "" {
+		plan, err := buildUpdatePlan(profileDir, opts.ProfileName, meta.SchemaVersion, opts.Clock)
+		if err != nil {
+			return false, err
+		}
+		if err := WritePlan(opts.PlanFile, plan); err != nil {
+			return false, err
+		}
+		ui.PrintSuccess(fmt.Sprintf("Wrote update plan to %s", opts.PlanFile))
+		fmt.Printf("  Schema version: %d -> %d (%d migration(s))\n", plan.FromVersion, plan.ToVersion, len(plan.Steps))
+		return false, nil
+	}
+
+	if opts.Explain {
+		plan, err := buildUpdatePlan(profileDir, opts.ProfileName, meta.SchemaVersion, opts.Clock)
+		if err != nil {
+			return false, err
+		}
+		ui.PrintInfo("DRY RUN - No changes were made")
+		printPlanExplanation(plan)
+		return false, nil
+	}
+
+	// Work out what the update will do before changing anything, so the
+	// pre-update hook and the backup come ahead of every write. Extra
+	// directories are the user's, not a migration's: any that went missing
+	// are re-created on every update, whatever the schema version.
+	pending := profileMigrations.Pending(meta.SchemaVersion)
+	migrate := len(pending) > 0 || meta.Legacy
+	extraDirs, err := createExtraDirs(profileDir, profileExtraDirs(profileDir), true)
+	if err != nil {
+		return false, err
+	}
+	convertSSH := false
+	if opts.RelativeSSHPaths {
+		if convertSSH, err = convertSSHPathsRelative(profileDir, true); err != nil {
+			return false, fmt.Errorf("failed to convert .ssh/config paths: %w", err)
+		}
+	}
+
+	if !migrate && len(extraDirs) == 0 && !convertSSH {
+		if _, err := recordSchemaVersion(profileDir, opts.ProfileName, meta.SchemaVersion, opts.DryRun); err != nil {
+			return false, fmt.Errorf("failed to update %s: %w", profile.MetaFileName, err)
+		}
+		ui.PrintInfo(fmt.Sprintf("Profile is already up to date (schema version %d)", meta.SchemaVersion))
+		return false, nil
+	}
+
+	if !opts.DryRun {
+		if err := runProfileHook(profileDir, hooks.PreUpdate, opts.ProfileName); err != nil {
+			return false, fmt.Errorf("update cancelled: %w", err)
+		}
+	}
+
 	// Create backup unless --no-backup is specified
 	if !opts.NoBackup && !opts.DryRun {
-		if err := createBackup(profileDir, opts.ProfileName); err != nil {
+		if _, err := createBackup(profileDir, "update", opts.Clock); err != nil {
 			ui.PrintWarning(fmt.Sprintf("Failed to create backup: %v", err))
-			if !opts.Force {
+			if !opts.skipBackupConfirm() {
 				confirmed, err := ui.Confirm("Continue without backup?", false)
 				if err != nil || !confirmed {
-					return fmt.Errorf("update cancelled")
+					return false, fmt.Errorf("update cancelled")
 				}
 			}
 		}
 	}
 
+	// direnv must re-allow a changed .envrc
+	envrcBefore, _ := os.ReadFile(envrcPath)
+
 	// Track what was updated
 	updates := []string{}
-
-	// Update directories
-	if updated, err := updateDirectories(profileDir, opts.DryRun); err != nil {
-		return fmt.Errorf("failed to update directories: %w", err)
-	} else if len(updated) > 0 {
-		updates = append(updates, fmt.Sprintf("Created directories: %s", strings.Join(updated, ", ")))
-	}
-
-	// Update .envrc (remove tool-specific vars that belong in .env)
-	if updated, err := updateEnvrc(profileDir, opts.ProfileName, opts.DryRun, opts.Force); err != nil {
-		return fmt.Errorf("failed to update .envrc: %w", err)
-	} else if updated {
-		updates = append(updates, "Updated .envrc (moved tool-specific vars to .env)")
+	if convertSSH {
+		if _, err := convertSSHPathsRelative(profileDir, opts.DryRun); err != nil {
+			return false, fmt.Errorf("failed to convert .ssh/config paths: %w", err)
+		}
+		updates = append(updates, "Rewrote .ssh/config paths as ${WORKSPACE_HOME}")
 	}
-
-	// Update .env with tool-specific environment variables
-	if updated, err := updateEnvFile(profileDir, opts.ProfileName, opts.DryRun); err != nil {
-		return fmt.Errorf("failed to update .env: %w", err)
-	} else if updated {
-		updates = append(updates, "Updated .env with tool-specific environment variables")
+	if len(extraDirs) > 0 {
+		if extraDirs, err = createExtraDirs(profileDir, profileExtraDirs(profileDir), opts.DryRun); err != nil {
+			return false, err
+		}
+		updates = append(updates, fmt.Sprintf("Created directories: %s", strings.Join(extraDirs, ", ")))
 	}
 
-	// Update .gitignore
-	if updated, err := updateGitignore(profileDir, opts.DryRun, opts.Force); err != nil {
-		return fmt.Errorf("failed to update .gitignore: %w", err)
-	} else if updated {
-		updates = append(updates, "Updated .gitignore with new patterns")
+	var results []migrations.Result
+	version := meta.SchemaVersion
+	if migrate {
+		// Migrations that need to ask something stop the spinner first, so
+		// it does not redraw over the question
+		spinner := ui.NewSpinner()
+		ctx := opts.migrationContext(profileDir)
+		ctx.Pause = spinner.Stop
+		spinner.Start(fmt.Sprintf("Running %d migration(s)", len(pending)))
+		results, version, err = profileMigrations.Run(ctx, meta.SchemaVersion)
+		spinner.Stop()
+		if err != nil {
+			return true, err
+		}
+		for _, result := range results {
+			updates = append(updates, result.Changes...)
+		}
 	}
 
-	// Remove .env.secrets.tpl (replaced by vault discovery in .envrc)
-	if updated, err := removeSecretsTemplate(profileDir, opts.DryRun); err != nil {
-		return fmt.Errorf("failed to remove .env.secrets.tpl: %w", err)
-	} else if updated {
-		updates = append(updates, "Removed .env.secrets.tpl (secrets now auto-discovered from vault)")
+	// Record the schema version reached (creating .profile-meta for legacy profiles)
+	if created, err := recordSchemaVersion(profileDir, opts.ProfileName, version, opts.DryRun); err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", profile.MetaFileName, err)
+	} else if created {
+		updates = append(updates, fmt.Sprintf("Created %s from existing profile headers", profile.MetaFileName))
 	}
 
-	// Replace op inject with vault discovery in .envrc
-	if updated, err := updateEnvrcVaultDiscovery(profileDir, opts.ProfileName, opts.DryRun); err != nil {
-		return fmt.Errorf("failed to update .envrc with vault discovery: %w", err)
-	} else if updated {
-		updates = append(updates, "Replaced op inject with vault discovery in .envrc")
+	if version != meta.SchemaVersion {
+		fmt.Printf("  Schema version: %d -> %d\n", meta.SchemaVersion, version)
+		for _, result := range results {
+			fmt.Printf("    %d->%d %s\n", result.Migration.From, result.Migration.To, result.Migration.Name)
+		}
+		fmt.Println()
 	}
 
 	// Summary
@@ -141,35 +348,109 @@ func UpdateProfile(profilesDir string, opts UpdateOptions) error {
 			fmt.Println()
 			fmt.Println("Updates applied:")
 			for _, update := range updates {
-				fmt.Printf("  ✓ %s\n", update)
+				fmt.Printf("  %s %s\n", ui.SymbolOK, update)
 			}
 		} else {
 			ui.PrintInfo("Profile is already up to date")
 		}
+
+		if opts.CreateVault {
+			provisionVault(results, meta, opts.ProfileName)
+		}
+		printSetupSteps(results, meta, opts.ProfileName)
+
+		if envrcAfter, _ := os.ReadFile(envrcPath); opts.Allow || !bytes.Equal(envrcBefore, envrcAfter) {
+			allow := opts.Allow
+			if !allow && ui.IsInteractive() {
+				fmt.Println()
+				allow, _ = ui.Confirm(".envrc changed. Run 'direnv allow'?", true)
+			}
+			if allow {
+				allowProfile(profileDir)
+			}
+		}
+
+		if err := runProfileHook(profileDir, hooks.PostUpdate, opts.ProfileName); err != nil {
+			return true, fmt.Errorf("profile '%s' was updated but the post-update hook failed: %w", opts.ProfileName, err)
+		}
 	}
 
-	return nil
+	return len(updates) > 0, nil
+}
+
+// externalBackupDir is the directory backups are kept in, in a subdirectory
+// per profile name. Empty keeps them in each profile's .backups.
+var externalBackupDir string
+
+// SetBackupDir keeps backups under dir instead of inside the profiles (the
+// backup_dir config key and the global --backup-dir flag)
+func SetBackupDir(dir string) {
+	externalBackupDir = dir
+}
+
+// profileBackupDir returns the directory holding a profile's backups
+func profileBackupDir(profileDir string) string {
+	if externalBackupDir == "" {
+		return filepath.Join(profileDir, ".backups")
+	}
+	return filepath.Join(externalBackupDir, filepath.Base(profileDir))
+}
+
+// backupFiles are the profile files copied into .backups before an update.
+// .profile-meta is included so a backup records the schema version it holds.
+var backupFiles = []string{
+	".envrc",
+	".env",
+	".env.secrets.tpl",
+	".gitconfig",
+	".gitignore",
+	".ssh/config", // Rewritten by update --relative-ssh-paths
+	profile.MetaFileName,
 }
 
-func createBackup(profileDir, _profileName string) error {
-	backupDir := filepath.Join(profileDir, ".backups")
+// backupTimeLayout formats the timestamp in backup directory names
+const backupTimeLayout = "2006-01-02_15-04-05"
+
+// backupFileMode returns the mode a profile file is written with when it is
+// backed up or restored
+func backupFileMode(file string) os.FileMode {
+	switch file {
+	case ".env":
+		return envFileMode
+	case ".ssh/config":
+		return 0600
+	}
+	return 0644
+}
+
+// createBackup copies the profile's important files into
+// <backup dir>/<kind>_<timestamp>/, or <kind>_<timestamp>_<n>/ if that is
+// taken, and returns the backup path
+func createBackup(profileDir, kind string, clk clock.Clock) (string, error) {
+	backupDir := profileBackupDir(profileDir)
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	backupPath := filepath.Join(backupDir, fmt.Sprintf("update_%s", timestamp))
+	timestamp := clock.Or(clk).Now().Format(backupTimeLayout)
+	base := filepath.Join(backupDir, fmt.Sprintf("%s_%s", kind, timestamp))
 
-	// Copy important files
-	filesToBackup := []string{
-		".envrc",
-		".env",
-		".env.secrets.tpl",
-		".gitconfig",
-		".gitignore",
+	// Backups made within the same second get a counter instead of
+	// overwriting each other
+	backupPath := base
+	for n := 2; ; n++ {
+		err := os.Mkdir(backupPath, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		backupPath = fmt.Sprintf("%s_%d", base, n)
 	}
 
-	for _, file := range filesToBackup {
+	// Copy important files
+	for _, file := range backupFiles {
 		src := filepath.Join(profileDir, file)
 		if _, err := os.Stat(src); err == nil {
 			content, err := os.ReadFile(src)
@@ -182,32 +463,134 @@ func createBackup(profileDir, _profileName string) error {
 				continue
 			}
 
-			if err := os.WriteFile(backupFile, content, 0644); err != nil {
+			if err := os.WriteFile(backupFile, content, backupFileMode(file)); err != nil {
 				continue
 			}
 		}
 	}
 
 	ui.PrintInfo(fmt.Sprintf("Backup created: %s", backupPath))
-	return nil
+	return backupPath, nil
+}
+
+// recordSchemaVersion persists the schema version a profile has been migrated
+// to, along with the hash of its files. Legacy profiles get a .profile-meta
+// seeded from their header comments, in which case created is true.
+func recordSchemaVersion(profileDir, profileName string, version int, dryRun bool) (created bool, err error) {
+	meta, err := profile.LoadMeta(profileDir)
+	if err != nil {
+		return false, err
+	}
+
+	if dryRun {
+		return meta.Legacy, nil
+	}
+
+	hash, err := managedContentHash(profileDir)
+	if err != nil {
+		return false, err
+	}
+	if !meta.Legacy && meta.Name == profileName && meta.SchemaVersion == version && meta.ContentHash == hash {
+		return false, nil
+	}
+	meta.Name = profileName
+	meta.SchemaVersion = version
+	meta.ContentHash = hash
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		return false, err
+	}
+
+	return meta.Legacy, nil
+}
+
+// managedContentHash hashes the files migrations change, which are the backed
+// up files other than .profile-meta itself
+func managedContentHash(profileDir string) (string, error) {
+	h := sha256.New()
+	for _, file := range backupFiles {
+		if file == profile.MetaFileName {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(profileDir, file))
+		if os.IsNotExist(err) {
+			fmt.Fprintf(h, "%s\x00-\x00", file)
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", file, len(content))
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// profileUpToDate is the fast check used by MigrateAll: the profile has a
+// .profile-meta at the latest schema version whose recorded hash matches its
+// files. Profiles without one need the full update.
+func profileUpToDate(profileDir string) bool {
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil || meta.SchemaVersion != profileMigrations.Latest() || meta.ContentHash == "" {
+		return false
+	}
+	hash, err := managedContentHash(profileDir)
+	return err == nil && hash == meta.ContentHash
 }
 
-func updateDirectories(profileDir string, dryRun bool) ([]string, error) {
-	requiredDirs := []string{
-		".config/1Password",
-		".config/claude",
-		".config/gemini",
-		".ssh",
-		".aws",
-		".azure",
-		".gcloud",
-		".kube",
-		"bin",
-		"code",
+// MigrateAll updates every profile in profilesDir. Profiles that are already
+// up to date and unchanged since their last update are skipped without
+// taking their lock or running the migrations' detection.
+func MigrateAll(profilesDir string, opts UpdateOptions) error {
+	if opts.PlanFile != "" {
+		return fmt.Errorf("--plan-file cannot be used with --all")
+	}
+	names, err := profileNames(profilesDir)
+	if err != nil {
+		return err
 	}
 
+	var skipped, failed []string
+	updated := 0
+	for _, name := range names {
+		if !opts.RelativeSSHPaths && profileUpToDate(filepath.Join(profilesDir, name)) {
+			skipped = append(skipped, name)
+			continue
+		}
+		profileOpts := opts
+		profileOpts.ProfileName = name
+		changed, err := updateProfile(profilesDir, profileOpts)
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("%s: %v", name, err))
+			failed = append(failed, name)
+			continue
+		}
+		if changed {
+			updated++
+		} else {
+			skipped = append(skipped, name)
+		}
+		fmt.Println()
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Checked %d profile(s): %d updated, %d unchanged, %d failed", len(names), updated, len(skipped), len(failed)))
+	if len(skipped) > 0 {
+		fmt.Printf("  Unchanged: %s\n", strings.Join(skipped, ", "))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update %d profile(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// updateDirectories creates the tool directories a profile is missing and
+// tightens .ssh permissions; pause, if set, is called before warning
+func updateDirectories(profileDir string, dryRun bool, pause func()) ([]string, error) {
 	var created []string
-	for _, dir := range requiredDirs {
+	dirs := tools.Dirs()
+	if profileNoCloud(profileDir) {
+		dirs = tools.LocalDirs()
+	}
+	for _, dir := range dirs {
 		fullPath := filepath.Join(profileDir, dir)
 		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
 			if !dryRun {
@@ -218,12 +601,20 @@ func updateDirectories(profileDir string, dryRun bool) ([]string, error) {
 			created = append(created, dir)
 		}
 	}
+	extra, err := createExtraDirs(profileDir, profileExtraDirs(profileDir), dryRun)
+	if err != nil {
+		return nil, err
+	}
+	created = append(created, extra...)
 
 	// Set SSH directory permissions
 	sshDir := filepath.Join(profileDir, ".ssh")
 	if _, err := os.Stat(sshDir); err == nil && !dryRun {
 		if err := os.Chmod(sshDir, 0700); err != nil {
 			// Non-fatal, just warn
+			if pause != nil {
+				pause()
+			}
 			ui.PrintWarning(fmt.Sprintf("Failed to set SSH directory permissions: %v", err))
 		}
 	}
@@ -231,6 +622,38 @@ func updateDirectories(profileDir string, dryRun bool) ([]string, error) {
 	return created, nil
 }
 
+// toolDirectories are the per-tool config directories added by the
+// tool-directories migration that can safely be removed again when empty
+var toolDirectories = []string{
+	".config/1Password",
+	".config/claude",
+	".config/gemini",
+	".azure",
+	".gcloud",
+	".kube",
+}
+
+// revertDirectories removes tool config directories that are still empty.
+// Directories holding any files are left alone.
+func revertDirectories(profileDir string, dryRun bool) ([]string, error) {
+	var removed []string
+	for _, dir := range toolDirectories {
+		fullPath := filepath.Join(profileDir, dir)
+		entries, err := os.ReadDir(fullPath)
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(fullPath); err != nil {
+				return nil, fmt.Errorf("failed to remove directory %s: %w", dir, err)
+			}
+		}
+		removed = append(removed, dir)
+	}
+
+	return removed, nil
+}
+
 func updateEnvrc(profileDir, _profileName string, dryRun, _force bool) (bool, error) {
 	envrcPath := filepath.Join(profileDir, ".envrc")
 	content, err := os.ReadFile(envrcPath)
@@ -242,21 +665,7 @@ func updateEnvrc(profileDir, _profileName string, dryRun, _force bool) (bool, er
 	updated := false
 
 	// Tool-specific variable names that belong in .env, not .envrc
-	toolVars := []string{
-		"XDG_CONFIG_HOME",
-		"SSH_AUTH_SOCK",
-		"GIT_CONFIG_GLOBAL",
-		"GIT_SSH_COMMAND",
-		"AWS_CONFIG_FILE",
-		"AWS_SHARED_CREDENTIALS_FILE",
-		"KUBECONFIG",
-		"TF_CLI_CONFIG_FILE",
-		"TF_PLUGIN_CACHE_DIR",
-		"AZURE_CONFIG_DIR",
-		"CLOUDSDK_CONFIG",
-		"CLAUDE_CONFIG_DIR",
-		"GEMINI_CONFIG_DIR",
-	}
+	toolVars := tools.EnvVarNames()
 
 	// Remove tool-specific export lines and their preceding comments from .envrc
 	lines := strings.Split(envrcContent, "\n")
@@ -349,94 +758,277 @@ func updateEnvrc(profileDir, _profileName string, dryRun, _force bool) (bool, er
 	return updated, nil
 }
 
-func updateEnvFile(profileDir, profileName string, dryRun bool) (bool, error) {
+// envAddedMarker headed the variables appended to .env by update before
+// managed variables were fenced
+const envAddedMarker = "# Added by shell-profiler update"
+
+// updateEnvFile creates .env, or regenerates the managed block of an existing
+// one from the template. Variables outside the block are left alone. Flat
+// files from before the block existed are migrated by fenceEnvFile. A block
+// the user edited since it was written is only replaced with force or once
+// confirmed; pause, if set, is called before warning about it.
+func updateEnvFile(profileDir, profileName string, dryRun, force bool, pause func()) (bool, error) {
 	envPath := filepath.Join(profileDir, ".env")
 
-	// Check if .env already exists
-	if _, err := os.Stat(envPath); os.IsNotExist(err) {
-		// Create new .env file from template
-		if !dryRun {
-			// Determine template type from .envrc or default to "basic"
-			templateType := "basic"
-			envrcPath := filepath.Join(profileDir, ".envrc")
-			if data, err := os.ReadFile(envrcPath); err == nil {
-				lines := strings.Split(string(data), "\n")
-				for _, line := range lines {
-					if strings.HasPrefix(line, "# Template:") {
-						parts := strings.SplitN(line, ":", 2)
-						if len(parts) == 2 {
-							templateType = strings.TrimSpace(parts[1])
-							break
-						}
-					}
-				}
-			}
+	// Determine template type from .profile-meta (or legacy headers)
+	templateType := "basic"
+	var overrides map[string]string
+	if meta, err := profile.LoadMeta(profileDir); err == nil {
+		templateType = meta.Template
+		overrides = meta.Overrides
+	}
 
-			envContent, err := templates.RenderEnv(profileName, templateType)
-			if err != nil {
-				return false, fmt.Errorf("failed to render .env template: %w", err)
-			}
+	generated, err := templates.RenderEnv(profileName, templateType)
+	if err != nil {
+		return false, fmt.Errorf("failed to render .env template: %w", err)
+	}
+	if profileNoCloud(profileDir) {
+		generated = stripCloudEnv(generated)
+	}
+	generated = applyEnvOverrides(generated, overrides)
 
-			if err := os.WriteFile(envPath, []byte(envContent), 0644); err != nil {
+	envContent, err := os.ReadFile(envPath)
+	if os.IsNotExist(err) {
+		if !dryRun {
+			if err := writeEnvFile(envPath, []byte(generated)); err != nil {
 				return false, fmt.Errorf("failed to write .env: %w", err)
 			}
+			if err := recordManagedHash(profileDir, ".env", generated); err != nil {
+				return false, err
+			}
 		}
 		return true, nil
 	}
-
-	// File exists - ADDITIVE ONLY: check for missing variables and append them
-	envContent, err := os.ReadFile(envPath)
 	if err != nil {
 		return false, fmt.Errorf("failed to read .env: %w", err)
 	}
 
 	content := string(envContent)
-	updated := false
+	regenerated, err := managed.Regenerate(content, generated)
+	if errors.Is(err, managed.ErrNoBlock) {
+		regenerated, err = fenceEnvFile(content, generated)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to regenerate managed variables in .env: %w", err)
+	}
+	if regenerated == content {
+		return false, nil
+	}
 
-	// Required variables with their values (from template)
-	requiredVars := map[string]string{
-		"GIT_CONFIG_GLOBAL":           `"$WORKSPACE_HOME/.gitconfig"`,
-		"GIT_SSH_COMMAND":             `"ssh -F $WORKSPACE_HOME/.ssh/config"`,
-		"XDG_CONFIG_HOME":             `"$WORKSPACE_HOME/.config"`,
-		"SSH_AUTH_SOCK":               `"$HOME/Library/Group Containers/2BUA8C4S2C.com.1password/t/agent.sock"`,
-		"AWS_CONFIG_FILE":             `"$WORKSPACE_HOME/.aws/config"`,
-		"AWS_SHARED_CREDENTIALS_FILE": `"$WORKSPACE_HOME/.aws/credentials"`,
-		"KUBECONFIG":                  `"$WORKSPACE_HOME/.kube/config"`,
-		"TF_CLI_CONFIG_FILE":          `"$WORKSPACE_HOME/.terraformrc"`,
-		"AZURE_CONFIG_DIR":            `"$WORKSPACE_HOME/.azure"`,
-		"CLOUDSDK_CONFIG":             `"$WORKSPACE_HOME/.gcloud"`,
-		"CLAUDE_CONFIG_DIR":           `"$WORKSPACE_HOME/.config/claude"`,
-		"GEMINI_CONFIG_DIR":           `"$WORKSPACE_HOME/.config/gemini"`,
-	}
-
-	// Find missing variables
-	var missingVars []string
-	for varName := range requiredVars {
-		if !strings.Contains(content, varName+"=") {
-			missingVars = append(missingVars, varName)
-			updated = true
+	if current, err := managed.Split(content); err == nil && !force && managedBlockEdited(profileDir, ".env", current.Managed) {
+		if pause != nil {
+			pause()
+		}
+		ui.PrintWarning("The managed block of .env was edited since it was generated; updating it replaces those edits")
+		if !dryRun && !confirmOverwrite(".env") {
+			ui.PrintInfo("Kept the edited managed block of .env (update with --overwrite to replace it)")
+			return false, nil
 		}
 	}
 
-	if updated && !dryRun {
-		// APPEND missing variables only - preserve existing content
-		appendContent := ""
-		if !strings.HasSuffix(content, "\n") {
-			appendContent = "\n"
+	if !dryRun {
+		if err := writeEnvFile(envPath, []byte(regenerated)); err != nil {
+			return false, fmt.Errorf("failed to write .env: %w", err)
+		}
+		if err := recordManagedHash(profileDir, ".env", regenerated); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// managedFiles are the profile files with a managed block
+var managedFiles = []string{".env"}
+
+// managedBlockHashes returns the hash of the managed block of each of
+// managedFiles that has one
+func managedBlockHashes(profileDir string) map[string]string {
+	hashes := make(map[string]string)
+	for _, file := range managedFiles {
+		content, err := os.ReadFile(filepath.Join(profileDir, file))
+		if err != nil {
+			continue
+		}
+		if sections, err := managed.Split(string(content)); err == nil {
+			hashes[file] = managed.Hash(sections.Managed)
 		}
-		appendContent += "\n# Added by shell-profiler update\n"
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return hashes
+}
 
-		for _, varName := range missingVars {
-			appendContent += varName + "=" + requiredVars[varName] + "\n"
+// managedBlockEdited reports whether block, the current managed block of
+// file, differs from the one last written. Without a recorded hash there is
+// no telling, so it is taken as unedited.
+func managedBlockEdited(profileDir, file, block string) bool {
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		return false
+	}
+	recorded := meta.ManagedHashes[file]
+	return recorded != "" && recorded != managed.Hash(block)
+}
+
+// recordManagedHash records the hash of the managed block of content, just
+// written to file. Legacy profiles without a .profile-meta get one when the
+// update records their schema version, so they are skipped here.
+func recordManagedHash(profileDir, file, content string) error {
+	sections, err := managed.Split(content)
+	if err != nil {
+		return nil
+	}
+	meta, err := profile.ReadMeta(profileDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", profile.MetaFileName, err)
+	}
+	hash := managed.Hash(sections.Managed)
+	if meta.ManagedHashes[file] == hash {
+		return nil
+	}
+	if meta.ManagedHashes == nil {
+		meta.ManagedHashes = make(map[string]string)
+	}
+	meta.ManagedHashes[file] = hash
+	return profile.WriteMeta(profileDir, meta)
+}
+
+// confirmOverwrite asks whether to replace the user's edits to file. Without
+// a terminal to ask on, and without --yes, the edits are kept.
+func confirmOverwrite(file string) bool {
+	if !ui.AssumeYes() && !ui.IsInteractive() {
+		return false
+	}
+	confirmed, err := ui.Confirm(fmt.Sprintf("Overwrite your edits to the managed block of %s?", file), false)
+	return err == nil && confirmed
+}
+
+// fenceEnvFile migrates a .env without a managed block. Managed variables
+// and the template comments around them are replaced by the generated block,
+// placed where the first of them was; everything else is kept.
+func fenceEnvFile(content, generated string) (string, error) {
+	fresh, err := managed.Split(generated)
+	if err != nil {
+		return "", fmt.Errorf("generated .env: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for _, name := range tools.EnvVarNames() {
+		names[name] = true
+	}
+	comments := map[string]bool{envAddedMarker: true}
+	for _, line := range strings.Split(fresh.Managed, "\n") {
+		if strings.HasPrefix(line, "#") {
+			comments[line] = true
 		}
+	}
 
-		newContent := content + appendContent
-		if err := os.WriteFile(envPath, []byte(newContent), 0644); err != nil {
-			return false, fmt.Errorf("failed to write .env: %w", err)
+	var kept []string
+	insertAt := -1
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		name, _, isAssignment := strings.Cut(strings.TrimPrefix(trimmed, "export "), "=")
+		if comments[trimmed] || (isAssignment && names[strings.TrimSpace(name)]) {
+			if insertAt < 0 {
+				insertAt = len(kept)
+			}
+			continue
 		}
+		kept = append(kept, line)
+	}
+	if insertAt < 0 {
+		insertAt = len(kept)
 	}
 
-	return updated, nil
+	before := joinEnvLines(kept[:insertAt])
+	if before != "" {
+		before += "\n"
+	}
+	after := joinEnvLines(kept[insertAt:])
+	if after != "" {
+		after = "\n" + after
+	}
+	return before + managed.Fence(fresh.Managed) + after, nil
+}
+
+// joinEnvLines joins lines left after removing managed variables, dropping
+// the runs of blank lines the removal leaves behind
+func joinEnvLines(lines []string) string {
+	var b strings.Builder
+	blank := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		b.WriteString(line + "\n")
+	}
+	joined := strings.TrimRight(b.String(), "\n")
+	if joined == "" {
+		return ""
+	}
+	return joined + "\n"
+}
+
+// removeEnvVars deletes the definitions of the named variables from .env,
+// together with the comment lines directly above them, and returns the names
+// it removed. Fence comments are never removed.
+func removeEnvVars(profileDir string, names []string, dryRun bool) ([]string, error) {
+	envPath := filepath.Join(profileDir, ".env")
+	data, err := os.ReadFile(envPath)
+	if os.IsNotExist(err) || len(names) == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	remove := make(map[string]bool)
+	for _, name := range names {
+		remove[name] = true
+	}
+
+	var kept, removed []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		name, _, isAssignment := strings.Cut(strings.TrimPrefix(trimmed, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !isAssignment || !remove[name] {
+			kept = append(kept, line)
+			continue
+		}
+
+		for len(kept) > 0 {
+			prev := strings.TrimSpace(kept[len(kept)-1])
+			if !strings.HasPrefix(prev, "#") || prev == managed.Begin || prev == managed.End {
+				break
+			}
+			kept = kept[:len(kept)-1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if !dryRun {
+		if err := writeEnvFile(envPath, []byte(strings.Join(kept, "\n"))); err != nil {
+			return nil, fmt.Errorf("failed to write .env: %w", err)
+		}
+	}
+	return removed, nil
 }
 
 func updateGitignore(profileDir string, dryRun, _force bool) (bool, error) {
@@ -452,6 +1044,9 @@ func updateGitignore(profileDir string, dryRun, _force bool) (bool, error) {
 .env
 .envrc.local
 
+# Profile manager lock file
+.lock
+
 # SSH keys and sensitive files
 .ssh/id_*
 .ssh/*.pem
@@ -518,6 +1113,9 @@ dist/
 build/
 *.log
 `
+			if profileNoCloud(profileDir) {
+				gitignoreContent = stripCloudGitignore(gitignoreContent)
+			}
 			if err := os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644); err != nil {
 				return false, fmt.Errorf("failed to create .gitignore: %w", err)
 			}
@@ -545,6 +1143,13 @@ build/
 		".config/claude/":            "# Claude Code configuration (may contain API keys and sensitive data)",
 		".config/gemini/":            "# Gemini CLI configuration (may contain API keys and sensitive data)",
 	}
+	if profileNoCloud(profileDir) {
+		for pattern := range requiredPatterns {
+			if tools.IsCloudPath(pattern) {
+				delete(requiredPatterns, pattern)
+			}
+		}
+	}
 
 	// Group patterns by comment
 	patternsByComment := make(map[string][]string)
@@ -634,6 +1239,25 @@ func removeSecretsTemplate(profileDir string, dryRun bool) (bool, error) {
 	return true, nil
 }
 
+// tightenEnvFile restricts an existing .env to envFileMode
+func tightenEnvFile(profileDir string, dryRun bool) (bool, error) {
+	envPath := filepath.Join(profileDir, ".env")
+	info, err := os.Stat(envPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if info.Mode().Perm()&^envFileMode == 0 {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	return true, os.Chmod(envPath, envFileMode)
+}
+
 func updateEnvrcVaultDiscovery(profileDir, profileName string, dryRun bool) (bool, error) {
 	envrcPath := filepath.Join(profileDir, ".envrc")
 	content, err := os.ReadFile(envrcPath)
@@ -693,6 +1317,8 @@ func updateEnvrcVaultDiscovery(profileDir, profileName string, dryRun bool) (boo
 		envrcContent = strings.Join(cleaned, "\n")
 	}
 
+	// The block runs under direnv, so it reports progress with log_status
+	// lines. Long-running Go-side work uses ui.Spinner instead.
 	vaultDiscoveryBlock := fmt.Sprintf(`
 # Resolve profile environment (template .env + 1Password secrets)
 # Cached in volatile storage with configurable expiration
@@ -730,15 +1356,7 @@ if [ "$_refresh_cache" = true ]; then
     if command -v op &>/dev/null && command -v jq &>/dev/null; then
         _op_ids=$(op item list --vault "$_op_vault" --format json 2>/dev/null | jq -r '.[].id' 2>/dev/null)
         if [ -n "$_op_ids" ]; then
-            # Start progress indicator (background process that prints dots)
-            (
-                while true; do
-                    printf "." >&2
-                    sleep 1
-                done
-            ) &
-            _progress_pid=$!
-
+            log_status "Loading secrets from 1Password vault: $_op_vault"
             echo "" >> "$_sp_env"
             for _op_id in $_op_ids; do
                 op item get "$_op_id" --format json 2>/dev/null | jq -r '
@@ -749,11 +1367,6 @@ if [ "$_refresh_cache" = true ]; then
                 ' >> "$_sp_env" 2>/dev/null
             done
 
-            # Stop progress indicator
-            kill $_progress_pid 2>/dev/null
-            wait $_progress_pid 2>/dev/null
-            printf "\n" >&2
-
             log_status "Loaded secrets from 1Password vault: $_op_vault"
         fi
     fi
@@ -771,7 +1384,7 @@ dotenv_if_exists "$_sp_env"
 		trimmed := strings.TrimSpace(line)
 		// Remove "dotenv_if_exists .env" but keep ".envrc.local" and other dotenv lines
 		if trimmed == "dotenv_if_exists .env" || strings.Contains(trimmed, "# Load environment variables from .env file") ||
-		   (strings.HasPrefix(trimmed, "# Tool-specific") && strings.Contains(trimmed, "belong in .env")) {
+			(strings.HasPrefix(trimmed, "# Tool-specific") && strings.Contains(trimmed, "belong in .env")) {
 			continue
 		}
 		cleanedLines = append(cleanedLines, line)
@@ -808,3 +1421,51 @@ dotenv_if_exists "$_sp_env"
 
 	return true, nil
 }
+
+// envrcWatchBlock is the watch_file directive added to .envrc by the
+// envrc-watch-file migration, matching envrc.tpl
+const envrcWatchBlock = `# Reload when .env changes. The secrets cache is not watched here: this file
+# rewrites it, and only when .env is newer or the cache has expired
+watch_file .env
+`
+
+// updateEnvrcWatch adds watch_file .env to the .envrc, and makes the secrets
+// cache rebuild when .env is newer than it so the reload picks up the change
+func updateEnvrcWatch(profileDir string, dryRun bool) (bool, error) {
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	content, err := os.ReadFile(envrcPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read .envrc: %w", err)
+	}
+
+	envrcContent := string(content)
+	if strings.Contains(envrcContent, "watch_file .env\n") {
+		return false, nil
+	}
+
+	// Insert before the cache resolution, else before loading local overrides
+	insertAt := strings.Index(envrcContent, "# Resolve profile environment")
+	if insertAt == -1 {
+		insertAt = strings.Index(envrcContent, "dotenv_if_exists .envrc.local")
+	}
+	if insertAt == -1 {
+		envrcContent = strings.TrimRight(envrcContent, "\n") + "\n\n" + envrcWatchBlock
+	} else {
+		envrcContent = envrcContent[:insertAt] + envrcWatchBlock + "\n" + envrcContent[insertAt:]
+	}
+
+	envrcContent = strings.Replace(envrcContent,
+		"if [ ! -f \"$_sp_env\" ]; then\n    _refresh_cache=true\nelif command -v stat",
+		"if [ ! -f \"$_sp_env\" ]; then\n    _refresh_cache=true\nelif [ .env -nt \"$_sp_env\" ]; then\n    # .env changed since the cache was built\n    _refresh_cache=true\nelif command -v stat",
+		1)
+
+	if dryRun {
+		return true, nil
+	}
+
+	if err := os.WriteFile(envrcPath, []byte(envrcContent), 0644); err != nil {
+		return false, fmt.Errorf("failed to write .envrc: %w", err)
+	}
+
+	return true, nil
+}
@@ -1,10 +1,18 @@
 package commands
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
 )
 
 // --- updateEnvrc tests ---
@@ -139,7 +147,7 @@ log_status "done"
 func TestUpdateEnvFile_CreatesNewWhenMissing(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	updated, err := updateEnvFile(tmpDir, "test", false)
+	updated, err := updateEnvFile(tmpDir, "test", false, false, nil)
 	if err != nil {
 		t.Fatalf("updateEnvFile() error: %v", err)
 	}
@@ -180,7 +188,7 @@ AWS_CONFIG_FILE="$WORKSPACE_HOME/.aws/config"
 		t.Fatal(err)
 	}
 
-	updated, err := updateEnvFile(tmpDir, "test", false)
+	updated, err := updateEnvFile(tmpDir, "test", false, false, nil)
 	if err != nil {
 		t.Fatalf("updateEnvFile() error: %v", err)
 	}
@@ -199,32 +207,231 @@ AWS_CONFIG_FILE="$WORKSPACE_HOME/.aws/config"
 	}
 }
 
-func TestUpdateEnvFile_NoChangeWhenAllPresent(t *testing.T) {
+func TestUpdateEnvFile_NoChangeWhenCurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if _, err := updateEnvFile(tmpDir, "test", false, false, nil); err != nil {
+		t.Fatalf("updateEnvFile() error: %v", err)
+	}
+	envPath := filepath.Join(tmpDir, ".env")
+	data, _ := os.ReadFile(envPath)
+	if err := os.WriteFile(envPath, append(data, "\n# Mine\nMY_VAR=1\n"...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := updateEnvFile(tmpDir, "test", false, false, nil)
+	if err != nil {
+		t.Fatalf("updateEnvFile() error: %v", err)
+	}
+	if updated {
+		t.Error("expected update=false when the managed block is current")
+	}
+}
+
+func TestUpdateEnvFile_RegeneratesManagedBlock(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	existing := "# My settings\nMY_TOKEN_PATH=\"~/token\"\n\n" +
+		managed.Fence("OBSOLETE_TOOL_HOME=\"$WORKSPACE_HOME/.obsolete\"\nGIT_CONFIG_GLOBAL=\"old\"\n") +
+		"\nEXTRA=1\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := updateEnvFile(tmpDir, "test", false, false, nil)
+	if err != nil {
+		t.Fatalf("updateEnvFile() error: %v", err)
+	}
+	if !updated {
+		t.Error("expected update=true for a stale managed block")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, ".env"))
+	content := string(data)
+	if strings.Contains(content, "OBSOLETE_TOOL_HOME") || strings.Contains(content, `GIT_CONFIG_GLOBAL="old"`) {
+		t.Errorf("obsolete managed vars should be regenerated away:\n%s", content)
+	}
+	if !strings.HasPrefix(content, "# My settings\nMY_TOKEN_PATH=\"~/token\"\n\n"+managed.Begin+"\n") {
+		t.Errorf("user vars before the block should be kept:\n%s", content)
+	}
+	if !strings.HasSuffix(content, managed.End+"\n\nEXTRA=1\n") {
+		t.Errorf("user vars after the block should be kept:\n%s", content)
+	}
+	if !strings.Contains(content, `GIT_CONFIG_GLOBAL="$WORKSPACE_HOME/.gitconfig"`) {
+		t.Error("managed block should be regenerated from the template")
+	}
+}
+
+// writeStaleEnv gives the profile a .env whose managed block was generated
+// as block, recording its hash, then applies edit to the block
+func writeStaleEnv(t *testing.T, profileDir, block, edit string) {
+	t.Helper()
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.ManagedHashes = map[string]string{".env": managed.Hash(block)}
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+	content := "MINE=1\n" + managed.Fence(block+edit)
+	if err := os.WriteFile(filepath.Join(profileDir, ".env"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateEnvFile_EditedManagedBlock(t *testing.T) {
 	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "test", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	profileDir := filepath.Join(tmpDir, "test")
+	if meta, _ := profile.ReadMeta(profileDir); meta.ManagedHashes[".env"] == "" {
+		t.Fatal("CreateProfile() did not record the .env managed block hash")
+	}
+	stale := "GIT_CONFIG_GLOBAL=\"old\"\n"
 
-	allVars := `GIT_CONFIG_GLOBAL="x"
-GIT_SSH_COMMAND="x"
-XDG_CONFIG_HOME="x"
-SSH_AUTH_SOCK="x"
-AWS_CONFIG_FILE="x"
-AWS_SHARED_CREDENTIALS_FILE="x"
-KUBECONFIG="x"
-TF_CLI_CONFIG_FILE="x"
-AZURE_CONFIG_DIR="x"
-CLOUDSDK_CONFIG="x"
-CLAUDE_CONFIG_DIR="x"
-GEMINI_CONFIG_DIR="x"
+	// Untouched since it was generated: updated without a warning
+	writeStaleEnv(t, profileDir, stale, "")
+	var updated, paused bool
+	pause := func() { paused = true }
+	out, err := captureStdout(t, func() (err error) {
+		updated, err = updateEnvFile(profileDir, "test", false, false, pause)
+		return err
+	})
+	if err != nil || !updated {
+		t.Fatalf("updateEnvFile() = %v, %v; want an update", updated, err)
+	}
+	if strings.Contains(out, "WARNING") || paused {
+		t.Errorf("untouched block triggered a warning (paused %v):\n%s", paused, out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(profileDir, ".env")); strings.Contains(string(data), `"old"`) {
+		t.Errorf("stale block not regenerated:\n%s", data)
+	}
+	if !managedBlockEdited(profileDir, ".env", stale) {
+		t.Error("hash not refreshed after regenerating")
+	}
+
+	// Edited by the user: warned and, without a terminal, kept
+	writeStaleEnv(t, profileDir, stale, "MY_TWEAK=1\n")
+	out, err = captureStdout(t, func() (err error) {
+		updated, err = updateEnvFile(profileDir, "test", false, false, pause)
+		return err
+	})
+	if err != nil || updated {
+		t.Fatalf("updateEnvFile() = %v, %v; want the edits kept", updated, err)
+	}
+	if !paused {
+		t.Error("progress output was not paused before the warning")
+	}
+	if !strings.Contains(out, "WARNING: The managed block of .env was edited") {
+		t.Errorf("edited block did not trigger a warning:\n%s", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(profileDir, ".env")); !strings.Contains(string(data), "MY_TWEAK=1") {
+		t.Errorf("edits were overwritten:\n%s", data)
+	}
+
+	// --overwrite replaces them
+	if _, err := captureStdout(t, func() (err error) {
+		updated, err = updateEnvFile(profileDir, "test", false, true, nil)
+		return err
+	}); err != nil || !updated {
+		t.Fatalf("forced updateEnvFile() = %v, %v", updated, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(profileDir, ".env")); strings.Contains(string(data), "MY_TWEAK=1") || !strings.HasPrefix(string(data), "MINE=1\n") {
+		t.Errorf("forced update did not replace only the managed block:\n%s", data)
+	}
+}
+
+func TestUpdateEnvFile_FencesFlatFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	existing := `# Environment variables for workspace profile: test
+
+# Git configuration
+GIT_CONFIG_GLOBAL="$WORKSPACE_HOME/.gitconfig"
+
+# Mine
+MY_VAR="keep"
+
+# Added by shell-profiler update
+CLAUDE_CONFIG_DIR="$WORKSPACE_HOME/.config/claude"
 `
-	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte(allVars), 0644); err != nil {
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte(existing), 0600); err != nil {
 		t.Fatal(err)
 	}
 
-	updated, err := updateEnvFile(tmpDir, "test", false)
+	if _, err := updateEnvFile(tmpDir, "test", false, false, nil); err != nil {
+		t.Fatalf("updateEnvFile() error: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, ".env"))
+	sections, err := managed.Split(string(data))
+	if err != nil {
+		t.Fatalf("managed.Split() error: %v\n%s", err, data)
+	}
+	if sections.Before != "# Environment variables for workspace profile: test\n\n" {
+		t.Errorf("Before = %q", sections.Before)
+	}
+	if sections.After != "\n# Mine\nMY_VAR=\"keep\"\n" {
+		t.Errorf("After = %q", sections.After)
+	}
+	for _, v := range tools.RequiredEnvVars() {
+		if !strings.Contains(sections.Managed, v.Name+"=") {
+			t.Errorf("managed block should define %s", v.Name)
+		}
+	}
+
+	// A second update has nothing left to do
+	updated, err := updateEnvFile(tmpDir, "test", false, false, nil)
 	if err != nil {
 		t.Fatalf("updateEnvFile() error: %v", err)
 	}
 	if updated {
-		t.Error("expected update=false when all vars present")
+		t.Error("expected update=false after fencing")
+	}
+}
+
+func TestRemoveEnvVars_RemovesDeprecated(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	existing := `# Mine
+MY_VAR="keep"
+
+# Old tool configuration
+# (no longer used)
+OLD_TOOL_CONFIG="$WORKSPACE_HOME/.oldtool"
+` + managed.Fence(`GIT_CONFIG_GLOBAL="$WORKSPACE_HOME/.gitconfig"
+# Old tool cache
+export OLD_TOOL_CACHE="x"`) + `
+OLD_TOOL_CONFIG_BACKUP="keep"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := removeEnvVars(tmpDir, []string{"OLD_TOOL_CONFIG", "OLD_TOOL_CACHE"}, false)
+	if err != nil {
+		t.Fatalf("removeEnvVars() error: %v", err)
+	}
+	if strings.Join(removed, ",") != "OLD_TOOL_CONFIG,OLD_TOOL_CACHE" {
+		t.Errorf("removed = %v", removed)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, ".env"))
+	want := `# Mine
+MY_VAR="keep"
+
+` + managed.Fence(`GIT_CONFIG_GLOBAL="$WORKSPACE_HOME/.gitconfig"`) + `
+OLD_TOOL_CONFIG_BACKUP="keep"
+`
+	if string(data) != want {
+		t.Errorf(".env =\n%s\nwant\n%s", data, want)
+	}
+
+	removed, err = removeEnvVars(tmpDir, []string{"OLD_TOOL_CONFIG"}, false)
+	if err != nil || removed != nil {
+		t.Errorf("second removeEnvVars() = %v, %v, want nothing removed", removed, err)
 	}
 }
 
@@ -433,7 +640,7 @@ func TestUpdateDirectories_CreatesMissing(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	created, err := updateDirectories(profileDir, false)
+	created, err := updateDirectories(profileDir, false, nil)
 	if err != nil {
 		t.Fatalf("updateDirectories() error: %v", err)
 	}
@@ -471,7 +678,7 @@ func TestUpdateDirectories_SetsSSHPermissions(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err := updateDirectories(tmpDir, false)
+	_, err := updateDirectories(tmpDir, false, nil)
 	if err != nil {
 		t.Fatalf("updateDirectories() error: %v", err)
 	}
@@ -481,3 +688,610 @@ func TestUpdateDirectories_SetsSSHPermissions(t *testing.T) {
 		t.Errorf(".ssh permissions = %o, want 0700", info.Mode().Perm())
 	}
 }
+
+// --- recordSchemaVersion tests ---
+
+func TestRecordSchemaVersion_CreatesFromLegacyHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	envrcContent := "#!/usr/bin/env bash\n# Workspace profile: legacy\n# Template: client\n# Created: 2024-01-01 00:00:00 UTC\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".envrc"), []byte(envrcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := recordSchemaVersion(tmpDir, "legacy", 4, false)
+	if err != nil {
+		t.Fatalf("recordSchemaVersion() error: %v", err)
+	}
+	if !created {
+		t.Error("expected created=true when .profile-meta is missing")
+	}
+
+	meta, err := profile.ReadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadMeta() error: %v", err)
+	}
+	if meta.SchemaVersion != 4 {
+		t.Errorf("meta schemaVersion = %d, want 4", meta.SchemaVersion)
+	}
+	if meta.Template != "client" {
+		t.Errorf("meta template = %q, want client", meta.Template)
+	}
+	if meta.Created != "2024-01-01 00:00:00 UTC" {
+		t.Errorf("meta created = %q, want 2024-01-01 00:00:00 UTC", meta.Created)
+	}
+}
+
+func TestRecordSchemaVersion_BumpsExisting(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := profile.WriteMeta(tmpDir, &profile.Meta{SchemaVersion: 1, Name: "x", Template: "work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := recordSchemaVersion(tmpDir, "x", 6, false)
+	if err != nil {
+		t.Fatalf("recordSchemaVersion() error: %v", err)
+	}
+	if created {
+		t.Error("expected created=false when .profile-meta already exists")
+	}
+
+	meta, _ := profile.ReadMeta(tmpDir)
+	if meta.SchemaVersion != 6 {
+		t.Errorf("meta schemaVersion = %d, want 6", meta.SchemaVersion)
+	}
+	if meta.Template != "work" {
+		t.Errorf("meta template = %q, want work (preserved)", meta.Template)
+	}
+}
+
+func TestRecordSchemaVersion_DryRunDoesNotWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if _, err := recordSchemaVersion(tmpDir, "x", 6, true); err != nil {
+		t.Fatalf("recordSchemaVersion() error: %v", err)
+	}
+	if _, err := os.Stat(profile.MetaPath(tmpDir)); !os.IsNotExist(err) {
+		t.Error("dry run should not write .profile-meta")
+	}
+}
+
+// --- migration chain tests ---
+
+func TestProfileMigrations_ChainIsContiguous(t *testing.T) {
+	all := profileMigrations.All()
+	if len(all) == 0 {
+		t.Fatal("expected registered migrations")
+	}
+	for i, m := range all {
+		if m.From != i || m.To != i+1 {
+			t.Errorf("migration %q is %d->%d, want %d->%d", m.Name, m.From, m.To, i, i+1)
+		}
+	}
+}
+
+func TestUpdateProfile_V1ProfileRunsRemainingChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "v1")
+
+	// A version-1 profile still using op inject and .env.secrets.tpl
+	envrcContent := `#!/usr/bin/env bash
+export WORKSPACE_PROFILE="v1"
+export AWS_CONFIG_FILE="$WORKSPACE_HOME/.aws/config"
+
+# Resolve secrets
+if [ -f .env.secrets.tpl ]; then
+    op inject -i .env.secrets.tpl
+fi
+
+dotenv_if_exists .envrc.local
+`
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte(envrcContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".env.secrets.tpl"), []byte("X=op://v/i/f\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.WriteMeta(profileDir, &profile.Meta{SchemaVersion: 1, Name: "v1", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := profileMigrations.Pending(1)
+	var names []string
+	for _, m := range pending {
+		names = append(names, m.Name)
+	}
+	wantNames := []string{"envrc-tool-vars", "env-file", "gitignore-patterns", "remove-secrets-template", "vault-discovery", "env-permissions", "gitattributes", "env-managed-block", "deprecated-env-vars", "git-default-branch", "envrc-watch-file", "envrc-located-home"}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Errorf("pending migrations for v1 = %v, want %v", names, wantNames)
+	}
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "v1", NoBackup: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	// Version 0->1 (tool directories) must not have run
+	if _, err := os.Stat(filepath.Join(profileDir, ".azure")); !os.IsNotExist(err) {
+		t.Error("tool-directories migration should be skipped for a v1 profile")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if strings.Contains(string(data), "export AWS_CONFIG_FILE=") {
+		t.Error("envrc-tool-vars migration should have removed AWS_CONFIG_FILE")
+	}
+	if !strings.Contains(string(data), "op item list") {
+		t.Error("vault-discovery migration should have added vault discovery")
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".env.secrets.tpl")); !os.IsNotExist(err) {
+		t.Error("remove-secrets-template migration should have removed .env.secrets.tpl")
+	}
+
+	meta, _ := profile.ReadMeta(profileDir)
+	if meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schema version = %d, want %d", meta.SchemaVersion, profileMigrations.Latest())
+	}
+}
+
+func TestUpdateProfile_CurrentProfileIsNoOp(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "current", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "current")
+
+	before, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "current"}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if string(before) != string(after) {
+		t.Error("update of a current profile should not modify .envrc")
+	}
+	if _, err := os.Stat(filepath.Join(profileDir, ".backups")); !os.IsNotExist(err) {
+		t.Error("update of a current profile should not create a backup")
+	}
+}
+
+func TestUpdateProfile_FailsWhileLocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "busy", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "busy")
+
+	lock, err := profile.AcquireLock(profileDir, 0)
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+	defer lock.Release()
+
+	origTimeout := profile.DefaultLockTimeout
+	profile.DefaultLockTimeout = 100 * time.Millisecond
+	defer func() { profile.DefaultLockTimeout = origTimeout }()
+
+	err = UpdateProfile(tmpDir, UpdateOptions{ProfileName: "busy"})
+	if !errors.Is(err, profile.ErrLocked) {
+		t.Fatalf("UpdateProfile() error = %v, want ErrLocked", err)
+	}
+
+	// Once released, the update goes through
+	lock.Release()
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "busy"}); err != nil {
+		t.Errorf("UpdateProfile() after release error: %v", err)
+	}
+}
+
+func TestUpdateProfile_DefaultsToActiveProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	profileDir := filepath.Join(tmpDir, "active")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("export WORKSPACE_PROFILE=\"active\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WORKSPACE_PROFILE", "active")
+
+	// go test runs without a terminal on stdin, so no selection prompt is shown
+	if err := UpdateProfile(tmpDir, UpdateOptions{NoBackup: true}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	meta, err := profile.ReadMeta(profileDir)
+	if err != nil {
+		t.Fatalf("active profile should have been updated: %v", err)
+	}
+	if meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("schema version = %d, want %d", meta.SchemaVersion, profileMigrations.Latest())
+	}
+}
+
+func TestCreateProfile_EnvFileIsPrivate(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	for file, want := range map[string]os.FileMode{".env": 0600, ".env.example": 0644} {
+		info, err := os.Stat(filepath.Join(tmpDir, "acme", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %04o, want %04o", file, got, want)
+		}
+	}
+}
+
+func TestUpdateProfile_TightensEnvFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	profileDir := filepath.Join(tmpDir, "acme")
+	envPath := filepath.Join(profileDir, ".env")
+	if err := os.Chmod(envPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := profile.ReadMeta(profileDir)
+	meta.SchemaVersion = 6
+	if err := profile.WriteMeta(profileDir, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme"}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	info, _ := os.Stat(envPath)
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf(".env mode = %04o after update, want 0600", got)
+	}
+
+	// The backup taken before the update is private too
+	backup, err := findBackupAtVersion(profileDir, 6)
+	if err != nil || backup == "" {
+		t.Fatalf("expected an update backup: %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(backup, ".env")); info.Mode().Perm() != 0600 {
+		t.Errorf("backed-up .env mode = %04o, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestUpdateOptions_GranularForce(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          UpdateOptions
+		overwrite     bool
+		skipBackupAsk bool
+	}{
+		{"none", UpdateOptions{}, false, false},
+		{"overwrite", UpdateOptions{Overwrite: true}, true, false},
+		{"skip backup confirm", UpdateOptions{SkipBackupConfirm: true}, false, true},
+		{"force implies both", UpdateOptions{Force: true}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.migrationContext("/p").Force; got != tt.overwrite {
+				t.Errorf("migration Force = %v, want %v", got, tt.overwrite)
+			}
+			if got := tt.opts.skipBackupConfirm(); got != tt.skipBackupAsk {
+				t.Errorf("skipBackupConfirm() = %v, want %v", got, tt.skipBackupAsk)
+			}
+		})
+	}
+}
+
+func TestUpdateProfile_BackupFailureGate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// A file where .backups should be makes the backup fail
+	breakBackups := func(t *testing.T, profileDir string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(profileDir, ".backups"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("overwrite still asks", func(t *testing.T) {
+		// Without a terminal the confirmation prompt fails, cancelling the update
+		devNull, err := os.Open(os.DevNull)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer devNull.Close()
+		origStdin := os.Stdin
+		os.Stdin = devNull
+		defer func() { os.Stdin = origStdin }()
+
+		tmpDir := t.TempDir()
+		breakBackups(t, newV1Profile(t, tmpDir, "acme"))
+		_, err = captureStdout(t, func() error {
+			return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", Overwrite: true})
+		})
+		if err == nil || !strings.Contains(err.Error(), "update cancelled") {
+			t.Errorf("expected the backup confirmation to cancel the update, got: %v", err)
+		}
+	})
+
+	t.Run("skip backup confirm continues", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		breakBackups(t, newV1Profile(t, tmpDir, "acme"))
+		if _, err := captureStdout(t, func() error {
+			return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", SkipBackupConfirm: true})
+		}); err != nil {
+			t.Errorf("UpdateProfile() error: %v", err)
+		}
+	})
+}
+
+func TestUpdateProfile_BackupNamedByClock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	profileDir := newV1Profile(t, tmpDir, "acme")
+	fake := clock.NewFake(time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local))
+
+	if _, err := captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", Clock: fake})
+	}); err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+
+	backup := filepath.Join(profileDir, ".backups", "update_2024-05-06_07-08-09")
+	if _, err := os.Stat(filepath.Join(backup, ".envrc")); err != nil {
+		t.Errorf("expected backup at %s: %v", backup, err)
+	}
+}
+
+func TestCreateBackup_SameSecond(t *testing.T) {
+	profileDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := clock.NewFake(time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local))
+
+	first, err := createBackup(profileDir, "update", fake)
+	if err != nil {
+		t.Fatalf("createBackup() error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := createBackup(profileDir, "update", fake)
+	if err != nil {
+		t.Fatalf("createBackup() error: %v", err)
+	}
+
+	if filepath.Base(first) != "update_2024-05-06_07-08-09" || filepath.Base(second) != "update_2024-05-06_07-08-09_2" {
+		t.Errorf("backup paths = %s, %s", first, second)
+	}
+	for path, want := range map[string]string{first: "first", second: "second"} {
+		data, err := os.ReadFile(filepath.Join(path, ".envrc"))
+		if err != nil || string(data) != want {
+			t.Errorf("%s/.envrc = %q (%v), want %q", path, data, err, want)
+		}
+	}
+}
+
+func TestUpdateProfile_PrintsSecretSetupSteps(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	newV1Profile(t, tmpDir, "acme")
+
+	out, err := captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true})
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	if !strings.Contains(out, "Next steps to load secrets from 1password") || !strings.Contains(out, "op vault create workspace-acme") {
+		t.Errorf("expected 1Password setup steps after the vault migration:\n%s", out)
+	}
+
+	// Nothing to migrate, so no guidance the second time
+	out, _ = captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true})
+	})
+	if strings.Contains(out, "Next steps to load secrets") {
+		t.Errorf("setup steps should only follow the vault migration:\n%s", out)
+	}
+}
+
+func TestUpdateProfile_CreateVault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	newV1Profile(t, tmpDir, "acme")
+
+	// A signed-in op with no vaults, logging what it is asked to do
+	bin := t.TempDir()
+	logPath := filepath.Join(bin, "op.log")
+	script := "#!/bin/sh\necho \"$*\" >> " + logPath + "\n[ \"$1 $2\" = \"vault list\" ] && echo '[]'\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(bin, "op"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	out, err := captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true, CreateVault: true})
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error: %v", err)
+	}
+	log, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(log), "vault create workspace-acme") {
+		t.Errorf("expected op vault create during the vault migration, op calls:\n%s", log)
+	}
+	if !strings.Contains(out, "Created 1password vault for 'acme'") {
+		t.Errorf("expected the vault creation to be reported:\n%s", out)
+	}
+
+	// The migration has already run, so op is not called again
+	os.Remove(logPath)
+	if _, err := captureStdout(t, func() error {
+		return UpdateProfile(tmpDir, UpdateOptions{ProfileName: "acme", NoBackup: true, CreateVault: true})
+	}); err != nil {
+		t.Fatalf("second UpdateProfile() error: %v", err)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Error("op should only be called when the vault migration runs")
+	}
+}
+
+func TestUpdateEnvrcWatch_MatchesTemplate(t *testing.T) {
+	created := time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)
+	source := templates.NewSource()
+	old, err := source.RenderEnvrcWith("svc", "basic", templates.EnvrcOptions{Created: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := source.RenderEnvrcWith("svc", "basic", templates.EnvrcOptions{Created: created, Watch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profileDir := t.TempDir()
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	if err := os.WriteFile(envrcPath, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if updated, err := updateEnvrcWatch(profileDir, true); err != nil || !updated {
+		t.Fatalf("dry run updateEnvrcWatch() = %v, %v; want an update", updated, err)
+	}
+	if got, _ := os.ReadFile(envrcPath); string(got) != old {
+		t.Error("dry run changed .envrc")
+	}
+
+	if updated, err := updateEnvrcWatch(profileDir, false); err != nil || !updated {
+		t.Fatalf("updateEnvrcWatch() = %v, %v; want an update", updated, err)
+	}
+	if got, _ := os.ReadFile(envrcPath); string(got) != want {
+		t.Errorf(".envrc =\n%s\nwant\n%s", got, want)
+	}
+
+	if updated, err := updateEnvrcWatch(profileDir, false); err != nil || updated {
+		t.Errorf("second updateEnvrcWatch() = %v, %v; want no change", updated, err)
+	}
+}
+
+func TestMigrateAll_SkipsUnchangedProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"current", "stale", "edited"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+	}
+	// The first run records each profile's content hash
+	if _, err := captureStdout(t, func() error { return MigrateAll(tmpDir, UpdateOptions{NoBackup: true}) }); err != nil {
+		t.Fatalf("MigrateAll() error: %v", err)
+	}
+	for _, name := range []string{"current", "stale", "edited"} {
+		if !profileUpToDate(filepath.Join(tmpDir, name)) {
+			t.Fatalf("%s not up to date after MigrateAll()", name)
+		}
+	}
+
+	staleDir := filepath.Join(tmpDir, "stale")
+	meta, _ := profile.ReadMeta(staleDir)
+	meta.SchemaVersion = profileMigrations.Latest() - 1
+	if err := profile.WriteMeta(staleDir, meta); err != nil {
+		t.Fatal(err)
+	}
+	gitignore := filepath.Join(tmpDir, "edited", ".gitignore")
+	content, _ := os.ReadFile(gitignore)
+	if err := os.WriteFile(gitignore, append(content, "scratch/\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fast path does not take the lock, so a held one does not matter
+	lock, err := profile.AcquireLock(filepath.Join(tmpDir, "current"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+
+	out, err := captureStdout(t, func() error { return MigrateAll(tmpDir, UpdateOptions{NoBackup: true}) })
+	if err != nil {
+		t.Fatalf("MigrateAll() error: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "Updating profile: current") {
+		t.Errorf("unchanged profile was not skipped:\n%s", out)
+	}
+	for _, name := range []string{"stale", "edited"} {
+		if !strings.Contains(out, "Updating profile: "+name) {
+			t.Errorf("%s was skipped:\n%s", name, out)
+		}
+	}
+	if meta, _ := profile.ReadMeta(staleDir); meta.SchemaVersion != profileMigrations.Latest() {
+		t.Errorf("stale profile at schema version %d, want %d", meta.SchemaVersion, profileMigrations.Latest())
+	}
+	if !profileUpToDate(filepath.Join(tmpDir, "edited")) {
+		t.Error("edited profile's hash not refreshed")
+	}
+}
+
+func TestMigrateAll_SkipsFreshProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"alpha", "bravo"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+		if !profileUpToDate(filepath.Join(tmpDir, name)) {
+			t.Errorf("just-created %s is not up to date", name)
+		}
+	}
+
+	out, err := captureStdout(t, func() error { return MigrateAll(tmpDir, UpdateOptions{NoBackup: true}) })
+	if err != nil {
+		t.Fatalf("MigrateAll() error: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "Updating profile:") {
+		t.Errorf("fresh profiles took the full update:\n%s", out)
+	}
+	if !strings.Contains(out, "0 updated, 2 unchanged") {
+		t.Errorf("fresh profiles counted as updated:\n%s", out)
+	}
+}
+
+func TestMigrateAll_CountsOnlyChangedProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"alpha", "bravo"} {
+		if err := CreateProfile(tmpDir, CreateOptions{ProfileName: name, Template: "basic"}); err != nil {
+			t.Fatalf("CreateProfile(%s) error: %v", name, err)
+		}
+	}
+	// A user edit sends alpha down the full update, which has nothing to do
+	gitignore := filepath.Join(tmpDir, "alpha", ".gitignore")
+	content, _ := os.ReadFile(gitignore)
+	if err := os.WriteFile(gitignore, append(content, "scratch/\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error { return MigrateAll(tmpDir, UpdateOptions{NoBackup: true}) })
+	if err != nil {
+		t.Fatalf("MigrateAll() error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Updating profile: alpha") || !strings.Contains(out, "0 updated, 2 unchanged") {
+		t.Errorf("profile with nothing to update counted as updated:\n%s", out)
+	}
+}
+
+func TestProfileUpToDate_RequiresMeta(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "legacy", Template: "basic"}); err != nil {
+		t.Fatal(err)
+	}
+	profileDir := filepath.Join(tmpDir, "legacy")
+	if err := os.Remove(profile.MetaPath(profileDir)); err != nil {
+		t.Fatal(err)
+	}
+	if profileUpToDate(profileDir) {
+		t.Error("profile without .profile-meta should take the full update path")
+	}
+}
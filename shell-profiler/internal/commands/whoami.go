@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/profile"
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
+)
+
+// Whoami prints the identity of the active profile, taken from
+// WORKSPACE_PROFILE or else the profile enclosing cwd
+func Whoami(profilesDir, cwd string) error {
+	if err := checkProfilesDir(profilesDir, false); err != nil {
+		return err
+	}
+	name, dir := "", ""
+	if active, ok := profile.ActiveProfileIn(profilesDir); ok {
+		name, dir = active, filepath.Join(profilesDir, active)
+	} else {
+		var err error
+		name, dir, err = profile.ResolveProfileIn(profilesDir, cwd, "")
+		if err != nil {
+			return fmt.Errorf("no active profile: WORKSPACE_PROFILE is not set and %s is not inside a profile", cwd)
+		}
+	}
+
+	WarnSchemaCompatibility(dir, name)
+	id := profile.LoadIdentity(name, dir)
+
+	ui.PrintInfo(fmt.Sprintf("Active profile: %s", id.Profile))
+	fmt.Printf("  Location:    %s\n", id.ProfileDir)
+	fmt.Printf("  Git name:    %s\n", orNotSet(id.GitName))
+	fmt.Printf("  Git email:   %s\n", orNotSet(id.GitEmail))
+	fmt.Printf("  AWS config:  %s\n", orNotSet(id.AWSConfigFile))
+	if id.SecretVault != "" {
+		fmt.Printf("  Secrets:     %s (%s)\n", strings.Join(id.SecretVaults, ", "), id.SecretBackend)
+	} else {
+		fmt.Printf("  Secrets:     %s\n", orNotSet(id.SecretBackend))
+	}
+	fmt.Printf("  SSH config:  %s\n", orNotSet(id.SSHConfig))
+
+	return nil
+}
+
+func orNotSet(value string) string {
+	if value == "" {
+		return "(not set)"
+	}
+	return value
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWhoami_ActiveProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "work", GitName: "Jane Doe", GitEmail: "jane@acme.example"})
+	if err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "other", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+	t.Setenv("WORKSPACE_PROFILE", "acme")
+
+	// WORKSPACE_PROFILE wins over the directory we happen to be in
+	out, err := captureStdout(t, func() error {
+		return Whoami(tmpDir, filepath.Join(tmpDir, "other"))
+	})
+	if err != nil {
+		t.Fatalf("Whoami() error: %v", err)
+	}
+
+	profileDir := filepath.Join(tmpDir, "acme")
+	for _, want := range []string{
+		"Active profile: acme",
+		"Git name:    Jane Doe",
+		"Git email:   jane@acme.example",
+		"AWS config:  " + filepath.Join(profileDir, ".aws", "config"),
+		"Secrets:     workspace-acme (1password)",
+		"SSH config:  " + filepath.Join(profileDir, ".ssh", "config"),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("whoami output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWhoami_FromCwd(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+	tmpDir := t.TempDir()
+	if err := CreateProfile(tmpDir, CreateOptions{ProfileName: "acme", Template: "basic"}); err != nil {
+		t.Fatalf("CreateProfile() error: %v", err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return Whoami(tmpDir, filepath.Join(tmpDir, "acme", ".aws"))
+	})
+	if err != nil {
+		t.Fatalf("Whoami() error: %v", err)
+	}
+	if !strings.Contains(out, "Active profile: acme") {
+		t.Errorf("expected profile resolved from cwd:\n%s", out)
+	}
+}
+
+func TestWhoami_NoActiveProfile(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+
+	err := Whoami(t.TempDir(), t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "no active profile") {
+		t.Errorf("expected no active profile error, got: %v", err)
+	}
+}
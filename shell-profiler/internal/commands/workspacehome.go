@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+)
+
+// pwdWorkspaceHome reports whether an .envrc line sets WORKSPACE_HOME from
+// $PWD, as .envrc files generated before templates.WorkspaceHomeLine did
+func pwdWorkspaceHome(line string) bool {
+	line = strings.TrimPrefix(strings.TrimSpace(line), "export ")
+	switch line {
+	case `WORKSPACE_HOME="$PWD"`, `WORKSPACE_HOME=$PWD`, `WORKSPACE_HOME="${PWD}"`:
+		return true
+	}
+	return false
+}
+
+// updateEnvrcWorkspaceHome derives WORKSPACE_HOME from the .envrc's own
+// location instead of $PWD, which is the symlink's path for a symlinked
+// profile
+func updateEnvrcWorkspaceHome(profileDir string, dryRun bool) (bool, error) {
+	envrcPath := filepath.Join(profileDir, ".envrc")
+	content, err := os.ReadFile(envrcPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read .envrc: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	updated := false
+	for i, line := range lines {
+		if pwdWorkspaceHome(line) {
+			lines[i] = templates.WorkspaceHomeLine
+			updated = true
+		}
+	}
+	if !updated || dryRun {
+		return updated, nil
+	}
+
+	if err := os.WriteFile(envrcPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return false, fmt.Errorf("failed to write .envrc: %w", err)
+	}
+	return true, nil
+}
+
+// checkWorkspaceHome reports an .envrc that sets WORKSPACE_HOME from $PWD,
+// or not at all
+func checkWorkspaceHome(profileDir, profileName string, fix bool) ([]Finding, error) {
+	content, err := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	set := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if pwdWorkspaceHome(line) {
+			if fix {
+				if _, err := updateEnvrcWorkspaceHome(profileDir, false); err != nil {
+					return nil, err
+				}
+			}
+			return []Finding{{
+				Check:    "workspace-home",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("WORKSPACE_HOME is set from $PWD, which is the symlink for a symlinked profile (run: shell-profiler update %s)", profileName),
+				Repair:   fmt.Sprintf("replace %q in .envrc with %q", strings.TrimSpace(line), templates.WorkspaceHomeLine),
+				Fixed:    fix,
+			}}, nil
+		}
+		if strings.HasPrefix(strings.TrimPrefix(strings.TrimSpace(line), "export "), "WORKSPACE_HOME=") {
+			set = true
+		}
+	}
+	if !set {
+		return []Finding{{
+			Check:    "workspace-home",
+			Severity: SeverityError,
+			Message:  ".envrc does not set WORKSPACE_HOME",
+		}}, nil
+	}
+	return nil, nil
+}
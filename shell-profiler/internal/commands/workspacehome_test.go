@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
+)
+
+// writePWDEnvrc gives a profile an .envrc that sets WORKSPACE_HOME from $PWD
+func writePWDEnvrc(t *testing.T, profileDir string) {
+	t.Helper()
+	envrc := "#!/usr/bin/env bash\nexport WORKSPACE_PROFILE=\"old\"\n" + templates.PWDWorkspaceHomeLine + "\n\nPATH_add bin\n"
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateEnvrcWorkspaceHome(t *testing.T) {
+	profileDir := t.TempDir()
+	writePWDEnvrc(t, profileDir)
+
+	updated, err := updateEnvrcWorkspaceHome(profileDir, true)
+	if err != nil || !updated {
+		t.Fatalf("dry run = %v, %v; want an update", updated, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	if !strings.Contains(string(content), templates.PWDWorkspaceHomeLine) {
+		t.Fatal("dry run should not write .envrc")
+	}
+
+	if updated, err := updateEnvrcWorkspaceHome(profileDir, false); err != nil || !updated {
+		t.Fatalf("updateEnvrcWorkspaceHome() = %v, %v", updated, err)
+	}
+	content, _ = os.ReadFile(filepath.Join(profileDir, ".envrc"))
+	want := "export WORKSPACE_PROFILE=\"old\"\n" + templates.WorkspaceHomeLine + "\n\nPATH_add bin\n"
+	if !strings.Contains(string(content), want) {
+		t.Errorf(".envrc = %q, want it to contain %q", content, want)
+	}
+
+	if updated, _ := updateEnvrcWorkspaceHome(profileDir, false); updated {
+		t.Error("a second update should change nothing")
+	}
+}
+
+func TestCheckWorkspaceHome(t *testing.T) {
+	profileDir := t.TempDir()
+	writePWDEnvrc(t, profileDir)
+
+	findings, err := checkWorkspaceHome(profileDir, "old", false)
+	if err != nil || len(findings) != 1 || findings[0].Severity != SeverityWarning {
+		t.Fatalf("checkWorkspaceHome() = %+v, %v; want one warning", findings, err)
+	}
+
+	findings, err = checkWorkspaceHome(profileDir, "old", true)
+	if err != nil || len(findings) != 1 || !findings[0].Fixed {
+		t.Fatalf("checkWorkspaceHome(fix) = %+v, %v; want a fixed finding", findings, err)
+	}
+	if findings, _ := checkWorkspaceHome(profileDir, "old", false); len(findings) != 0 {
+		t.Errorf("fixed .envrc still reported: %+v", findings)
+	}
+
+	if err := os.WriteFile(filepath.Join(profileDir, ".envrc"), []byte("export WORKSPACE_PROFILE=\"old\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	findings, _ = checkWorkspaceHome(profileDir, "old", false)
+	if len(findings) != 1 || findings[0].Severity != SeverityError {
+		t.Errorf("missing WORKSPACE_HOME: %+v, want one error", findings)
+	}
+}
@@ -4,29 +4,141 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 const (
-	configFileName = ".profile-manager"
+	configFileName     = ".profile-manager"
+	yamlConfigFileName = ".profile-manager.yaml"
+)
+
+// Config file formats
+const (
+	FormatFlat = "flat" // key=value lines in ~/.profile-manager
+	FormatYAML = "yaml" // ~/.profile-manager.yaml
 )
 
 // Config holds the profile manager configuration
 type Config struct {
-	ProfilesDir string `json:"profiles_dir"`
+	ProfilesDir    string `json:"profiles_dir"`
+	TemplateDir    string `json:"template_dir,omitempty"`
+	PostCreateHook string `json:"post_create_hook,omitempty"`
+	PreUpdateHook  string `json:"pre_update_hook,omitempty"`
+	PostUpdateHook string `json:"post_update_hook,omitempty"`
+	PreDeleteHook  string `json:"pre_delete_hook,omitempty"`
+
+	// BackupDir keeps backups outside the profiles, in a subdirectory per
+	// profile name; empty means each profile's .backups
+	BackupDir string `json:"backup_dir,omitempty"`
+
+	// OpAccount is the 1Password account new profiles use (op --account)
+	OpAccount string `json:"op_account,omitempty"`
+	// OpFieldPrefix and OpExcludeFields select the 1Password fields new
+	// profiles export
+	OpFieldPrefix   string   `json:"op_field_prefix,omitempty"`
+	OpExcludeFields []string `json:"op_exclude_fields,omitempty"`
+
+	// SSHMinRSABits is the smallest RSA IdentityFile doctor accepts; zero
+	// means DefaultSSHMinRSABits
+	SSHMinRSABits int `json:"ssh_min_rsa_bits,omitempty"`
+	// SSHAllowAlgorithms lists weak ssh algorithms doctor should not flag
+	SSHAllowAlgorithms []string `json:"ssh_allow_algorithms,omitempty"`
+
+	// Templates holds per-template defaults; only the YAML format can set them
+	Templates map[string]TemplateDefaults `json:"templates,omitempty"`
+
+	// Format is the file format the config was loaded from, and is written
+	// back by SaveConfig. Empty means the format of the existing config file.
+	Format string `json:"-"`
+}
+
+// DefaultSSHMinRSABits is the smallest RSA key accepted by the ssh policy
+// check unless ssh_min_rsa_bits says otherwise
+const DefaultSSHMinRSABits = 3072
+
+// MinRSABits returns the configured minimum RSA key size
+func (c *Config) MinRSABits() int {
+	if c.SSHMinRSABits > 0 {
+		return c.SSHMinRSABits
+	}
+	return DefaultSSHMinRSABits
+}
+
+// TemplateDefaults are values used when creating a profile from a template
+// unless given on the command line
+type TemplateDefaults struct {
+	GitName  string `json:"git_name,omitempty"`
+	GitEmail string `json:"git_email,omitempty"`
+}
+
+// Hook returns the script configured for a lifecycle hook
+// (post-create, pre-update, post-update or pre-delete)
+func (c *Config) Hook(name string) string {
+	switch name {
+	case "post-create":
+		return c.PostCreateHook
+	case "pre-update":
+		return c.PreUpdateHook
+	case "post-update":
+		return c.PostUpdateHook
+	case "pre-delete":
+		return c.PreDeleteHook
+	}
+	return ""
 }
 
-// GetConfigPath returns the path to the config file
+// KeyInfo describes a key accepted in the config file
+type KeyInfo struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Default     string `json:"default"`
+}
+
+// Keys returns the keys accepted in the config file
+func Keys() []KeyInfo {
+	return []KeyInfo{
+		{Key: "profiles_dir", Description: "Directory containing workspace profiles (~ and $VARS are expanded)", Default: "~/workspaces/profiles"},
+		{Key: "template_dir", Description: "Directory searched for templates before ~/.config/profile-manager/templates", Default: ""},
+		{Key: "post_create_hook", Description: "Script run after a profile is created, with WORKSPACE_PROFILE and WORKSPACE_HOME set", Default: ""},
+		{Key: "pre_update_hook", Description: "Script run before a profile is updated; a non-zero exit cancels the update", Default: ""},
+		{Key: "post_update_hook", Description: "Script run after a profile is updated", Default: ""},
+		{Key: "pre_delete_hook", Description: "Script run before a profile is deleted; a non-zero exit cancels the delete", Default: ""},
+		{Key: "backup_dir", Description: "Directory for backups, one subdirectory per profile, instead of each profile's .backups", Default: ""},
+		{Key: "op_account", Description: "1Password account (op --account) new profiles discover secrets in; op's default account if empty", Default: ""},
+		{Key: "op_field_prefix", Description: "Only export 1Password fields whose label starts with this prefix (e.g. env:), without it", Default: ""},
+		{Key: "op_exclude_fields", Description: "Comma-separated 1Password field labels never exported as variables", Default: ""},
+		{Key: "ssh_min_rsa_bits", Description: "Smallest RSA key size doctor accepts for an ssh IdentityFile", Default: strconv.Itoa(DefaultSSHMinRSABits)},
+		{Key: "ssh_allow_algorithms", Description: "Comma-separated weak ssh ciphers, key exchanges or MACs doctor should allow", Default: ""},
+		{Key: "templates.<name>.git_name", Description: "Default git user.name for profiles created from <name> (YAML config only)", Default: ""},
+		{Key: "templates.<name>.git_email", Description: "Default git user.email for profiles created from <name> (YAML config only)", Default: ""},
+	}
+}
+
+// GetConfigPath returns the path to the config file: ~/.profile-manager.yaml
+// if it exists, otherwise ~/.profile-manager
 func GetConfigPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
+	yamlPath := filepath.Join(homeDir, yamlConfigFileName)
+	if _, err := os.Stat(yamlPath); err == nil {
+		return yamlPath, nil
+	}
 	return filepath.Join(homeDir, configFileName), nil
 }
 
-// LoadConfig loads the configuration from ~/.profile-manager
-// Returns default config if file doesn't exist
+// formatOf returns the config format stored at path
+func formatOf(path string) string {
+	if strings.HasSuffix(path, ".yaml") {
+		return FormatYAML
+	}
+	return FormatFlat
+}
+
+// LoadConfig loads the configuration from ~/.profile-manager.yaml or
+// ~/.profile-manager. Returns default config if neither file exists.
 func LoadConfig() (*Config, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -44,9 +156,33 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse simple key=value format
+	var config *Config
+	if formatOf(configPath) == FormatYAML {
+		config, err = parseYAMLConfig(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+		}
+	} else {
+		config = parseFlatConfig(string(content))
+	}
+	config.Format = formatOf(configPath)
+
+	// If profiles_dir is empty, use default
+	if config.ProfilesDir == "" {
+		defaultConfig, err := GetDefaultConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default config: %w", err)
+		}
+		config.ProfilesDir = defaultConfig.ProfilesDir
+	}
+
+	return config, nil
+}
+
+// parseFlatConfig parses the key=value format of ~/.profile-manager
+func parseFlatConfig(content string) *Config {
 	config := &Config{}
-	lines := strings.Split(string(content), "\n")
+	lines := strings.Split(content, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -58,29 +194,98 @@ func LoadConfig() (*Config, error) {
 			continue
 		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+		config.set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	return config
+}
 
-		switch key {
-		case "profiles_dir":
-			// Expand ~ in path
-			config.ProfilesDir = expandPath(value)
+// set applies a top-level key shared by both config formats; unknown keys
+// are ignored
+func (c *Config) set(key, value string) {
+	switch key {
+	case "profiles_dir":
+		// Expand ~ in path
+		c.ProfilesDir = ExpandPath(value)
+	case "template_dir":
+		if value != "" {
+			c.TemplateDir = ExpandPath(value)
+		}
+	case "post_create_hook":
+		if value != "" {
+			c.PostCreateHook = ExpandPath(value)
+		}
+	case "pre_update_hook":
+		if value != "" {
+			c.PreUpdateHook = ExpandPath(value)
+		}
+	case "post_update_hook":
+		if value != "" {
+			c.PostUpdateHook = ExpandPath(value)
+		}
+	case "pre_delete_hook":
+		if value != "" {
+			c.PreDeleteHook = ExpandPath(value)
+		}
+	case "backup_dir":
+		if value != "" {
+			c.BackupDir = ExpandPath(value)
+		}
+	case "op_account":
+		c.OpAccount = value
+	case "op_field_prefix":
+		c.OpFieldPrefix = value
+	case "op_exclude_fields":
+		c.OpExcludeFields = nil
+		for _, label := range strings.Split(value, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				c.OpExcludeFields = append(c.OpExcludeFields, label)
+			}
+		}
+	case "ssh_min_rsa_bits":
+		if bits, err := strconv.Atoi(value); err == nil && bits > 0 {
+			c.SSHMinRSABits = bits
+		}
+	case "ssh_allow_algorithms":
+		c.SSHAllowAlgorithms = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				c.SSHAllowAlgorithms = append(c.SSHAllowAlgorithms, name)
+			}
 		}
 	}
+}
 
-	// If profiles_dir is empty, use default
-	if config.ProfilesDir == "" {
-		defaultConfig, err := GetDefaultConfig()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get default config: %w", err)
-		}
-		config.ProfilesDir = defaultConfig.ProfilesDir
+// onePasswordEntries returns the 1Password keys that are set, as written by
+// SaveConfig
+func (c *Config) onePasswordEntries() [][2]string {
+	var entries [][2]string
+	if c.OpAccount != "" {
+		entries = append(entries, [2]string{"op_account", c.OpAccount})
+	}
+	if c.OpFieldPrefix != "" {
+		entries = append(entries, [2]string{"op_field_prefix", c.OpFieldPrefix})
 	}
+	if len(c.OpExcludeFields) > 0 {
+		entries = append(entries, [2]string{"op_exclude_fields", strings.Join(c.OpExcludeFields, ",")})
+	}
+	return entries
+}
 
-	return config, nil
+// sshPolicyEntries returns the ssh policy keys that differ from the defaults,
+// as written by SaveConfig
+func (c *Config) sshPolicyEntries() [][2]string {
+	var entries [][2]string
+	if c.SSHMinRSABits > 0 && c.SSHMinRSABits != DefaultSSHMinRSABits {
+		entries = append(entries, [2]string{"ssh_min_rsa_bits", strconv.Itoa(c.SSHMinRSABits)})
+	}
+	if len(c.SSHAllowAlgorithms) > 0 {
+		entries = append(entries, [2]string{"ssh_allow_algorithms", strings.Join(c.SSHAllowAlgorithms, ",")})
+	}
+	return entries
 }
 
-// SaveConfig saves the configuration to ~/.profile-manager
+// SaveConfig saves the configuration in the format it was loaded from,
+// defaulting to the format of the existing config file
 func SaveConfig(config *Config) error {
 	configPath, err := GetConfigPath()
 	if err != nil {
@@ -93,10 +298,20 @@ func SaveConfig(config *Config) error {
 		return fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	profilesDir := config.ProfilesDir
-	if strings.HasPrefix(profilesDir, homeDir) {
-		profilesDir = "~" + profilesDir[len(homeDir):]
+	format := config.Format
+	if format == "" {
+		format = formatOf(configPath)
+	}
+	if format == FormatYAML {
+		configPath = filepath.Join(homeDir, yamlConfigFileName)
+		if err := os.WriteFile(configPath, []byte(formatYAMLConfig(config, homeDir)), 0644); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+		return nil
 	}
+	configPath = filepath.Join(homeDir, configFileName)
+
+	profilesDir := abbreviateHome(config.ProfilesDir, homeDir)
 
 	// Write config file
 	content := fmt.Sprintf(`# Profile Manager Configuration
@@ -105,6 +320,26 @@ func SaveConfig(config *Config) error {
 
 profiles_dir=%s
 `, profilesDir)
+	if config.TemplateDir != "" {
+		content += fmt.Sprintf("template_dir=%s\n", abbreviateHome(config.TemplateDir, homeDir))
+	}
+	for _, hook := range []struct{ key, path string }{
+		{"post_create_hook", config.PostCreateHook},
+		{"pre_update_hook", config.PreUpdateHook},
+		{"post_update_hook", config.PostUpdateHook},
+		{"pre_delete_hook", config.PreDeleteHook},
+		{"backup_dir", config.BackupDir},
+	} {
+		if hook.path != "" {
+			content += fmt.Sprintf("%s=%s\n", hook.key, abbreviateHome(hook.path, homeDir))
+		}
+	}
+	for _, entry := range config.onePasswordEntries() {
+		content += fmt.Sprintf("%s=%s\n", entry[0], entry[1])
+	}
+	for _, entry := range config.sshPolicyEntries() {
+		content += fmt.Sprintf("%s=%s\n", entry[0], entry[1])
+	}
 
 	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
@@ -125,8 +360,16 @@ func GetDefaultConfig() (*Config, error) {
 	}, nil
 }
 
-// expandPath expands ~ and environment variables in a path
-func expandPath(path string) string {
+// abbreviateHome rewrites paths inside the home directory using ~ notation
+func abbreviateHome(path, homeDir string) string {
+	if strings.HasPrefix(path, homeDir) {
+		return "~" + path[len(homeDir):]
+	}
+	return path
+}
+
+// ExpandPath expands ~ and environment variables in a path
+func ExpandPath(path string) string {
 	// Expand ~
 	if strings.HasPrefix(path, "~") {
 		homeDir, err := os.UserHomeDir()
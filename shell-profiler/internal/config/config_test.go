@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -13,10 +14,10 @@ func TestExpandPath_Tilde(t *testing.T) {
 		t.Fatalf("failed to get home dir: %v", err)
 	}
 
-	got := expandPath("~/foo")
+	got := ExpandPath("~/foo")
 	want := filepath.Join(home, "foo")
 	if got != want {
-		t.Errorf("expandPath(~/foo) = %q, want %q", got, want)
+		t.Errorf("ExpandPath(~/foo) = %q, want %q", got, want)
 	}
 }
 
@@ -26,9 +27,9 @@ func TestExpandPath_TildeOnly(t *testing.T) {
 		t.Fatalf("failed to get home dir: %v", err)
 	}
 
-	got := expandPath("~")
+	got := ExpandPath("~")
 	if got != home {
-		t.Errorf("expandPath(~) = %q, want %q", got, home)
+		t.Errorf("ExpandPath(~) = %q, want %q", got, home)
 	}
 }
 
@@ -36,24 +37,24 @@ func TestExpandPath_EnvVar(t *testing.T) {
 	tmpDir := t.TempDir()
 	t.Setenv("TEST_EXPAND_DIR", tmpDir)
 
-	got := expandPath("$TEST_EXPAND_DIR/foo")
+	got := ExpandPath("$TEST_EXPAND_DIR/foo")
 	want := filepath.Join(tmpDir, "foo")
 	if got != want {
-		t.Errorf("expandPath($TEST_EXPAND_DIR/foo) = %q, want %q", got, want)
+		t.Errorf("ExpandPath($TEST_EXPAND_DIR/foo) = %q, want %q", got, want)
 	}
 }
 
 func TestExpandPath_AbsoluteUnchanged(t *testing.T) {
-	got := expandPath("/usr/local")
+	got := ExpandPath("/usr/local")
 	if got != "/usr/local" {
-		t.Errorf("expandPath(/usr/local) = %q, want /usr/local", got)
+		t.Errorf("ExpandPath(/usr/local) = %q, want /usr/local", got)
 	}
 }
 
 func TestExpandPath_CleanPath(t *testing.T) {
-	got := expandPath("./foo/../bar")
+	got := ExpandPath("./foo/../bar")
 	if got != "bar" {
-		t.Errorf("expandPath(./foo/../bar) = %q, want bar", got)
+		t.Errorf("ExpandPath(./foo/../bar) = %q, want bar", got)
 	}
 }
 
@@ -173,7 +174,7 @@ func TestLoadConfig_EmptyProfilesDir(t *testing.T) {
 	t.Setenv("HOME", tmpDir)
 
 	configPath := filepath.Join(tmpDir, ".profile-manager")
-	// expandPath("") returns "." via filepath.Clean, which is non-empty,
+	// ExpandPath("") returns "." via filepath.Clean, which is non-empty,
 	// so the default fallback does not trigger. This is the actual code behavior.
 	content := "profiles_dir=\n"
 	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
@@ -185,9 +186,9 @@ func TestLoadConfig_EmptyProfilesDir(t *testing.T) {
 		t.Fatalf("LoadConfig() error: %v", err)
 	}
 
-	// expandPath("") → filepath.Clean("") → "."
+	// ExpandPath("") → filepath.Clean("") → "."
 	if cfg.ProfilesDir != "." {
-		t.Errorf("ProfilesDir = %q, want %q (expandPath of empty string)", cfg.ProfilesDir, ".")
+		t.Errorf("ProfilesDir = %q, want %q (ExpandPath of empty string)", cfg.ProfilesDir, ".")
 	}
 }
 
@@ -273,3 +274,219 @@ func TestSaveConfig_NonHomePathStaysAbsolute(t *testing.T) {
 		t.Errorf("config should keep absolute path, got:\n%s", content)
 	}
 }
+
+func TestLoadConfig_TemplateDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	content := "profiles_dir=/custom/profiles\ntemplate_dir=~/company-templates\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".profile-manager"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if want := filepath.Join(tmpDir, "company-templates"); cfg.TemplateDir != want {
+		t.Errorf("TemplateDir = %q, want %q", cfg.TemplateDir, want)
+	}
+}
+
+func TestSaveConfig_WritesTemplateDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	if err := SaveConfig(&Config{ProfilesDir: "/p", TemplateDir: filepath.Join(tmpDir, "tpl")}); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(tmpDir, ".profile-manager"))
+	if !strings.Contains(string(content), "template_dir=~/tpl\n") {
+		t.Errorf("config missing template_dir:\n%s", content)
+	}
+}
+
+func TestConfig_BackupDirRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	if err := SaveConfig(&Config{ProfilesDir: "/p", BackupDir: filepath.Join(tmpDir, "backups")}); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(tmpDir, ".profile-manager"))
+	if !strings.Contains(string(content), "backup_dir=~/backups\n") {
+		t.Errorf("config missing backup_dir:\n%s", content)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if want := filepath.Join(tmpDir, "backups"); cfg.BackupDir != want {
+		t.Errorf("BackupDir = %q, want %q", cfg.BackupDir, want)
+	}
+}
+
+func TestLoadConfig_PostCreateHook(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	content := "post_create_hook=~/bin/setup-profile.sh\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".profile-manager"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if want := filepath.Join(tmpDir, "bin", "setup-profile.sh"); cfg.PostCreateHook != want {
+		t.Errorf("PostCreateHook = %q, want %q", cfg.PostCreateHook, want)
+	}
+}
+
+func TestLoadConfig_SSHPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	content := "ssh_min_rsa_bits=4096\nssh_allow_algorithms=hmac-sha1, diffie-hellman-group14-sha1\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".profile-manager"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.MinRSABits() != 4096 {
+		t.Errorf("MinRSABits() = %d, want 4096", cfg.MinRSABits())
+	}
+	if want := []string{"hmac-sha1", "diffie-hellman-group14-sha1"}; strings.Join(cfg.SSHAllowAlgorithms, " ") != strings.Join(want, " ") {
+		t.Errorf("SSHAllowAlgorithms = %v, want %v", cfg.SSHAllowAlgorithms, want)
+	}
+
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	saved, _ := os.ReadFile(filepath.Join(tmpDir, ".profile-manager"))
+	if !strings.Contains(string(saved), "ssh_min_rsa_bits=4096\n") ||
+		!strings.Contains(string(saved), "ssh_allow_algorithms=hmac-sha1,diffie-hellman-group14-sha1\n") {
+		t.Errorf("saved config missing ssh policy:\n%s", saved)
+	}
+
+	if got := (&Config{}).MinRSABits(); got != DefaultSSHMinRSABits {
+		t.Errorf("default MinRSABits() = %d, want %d", got, DefaultSSHMinRSABits)
+	}
+}
+
+func TestLoadConfig_YAMLRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	content := `# Profile Manager Configuration
+profiles_dir: ~/work/profiles
+template_dir: "~/company-templates"  # quoted
+pre_delete_hook: ~/bin/guard.sh
+
+templates:
+  work:
+    git_name: "Jane Doe"
+    git_email: jane@acme.com
+  personal:
+    git_email: 'jane@example.com'
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".profile-manager.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.Format != FormatYAML {
+		t.Errorf("Format = %q, want %q", cfg.Format, FormatYAML)
+	}
+	if want := filepath.Join(tmpDir, "work", "profiles"); cfg.ProfilesDir != want {
+		t.Errorf("ProfilesDir = %q, want %q", cfg.ProfilesDir, want)
+	}
+	if want := filepath.Join(tmpDir, "company-templates"); cfg.TemplateDir != want {
+		t.Errorf("TemplateDir = %q, want %q", cfg.TemplateDir, want)
+	}
+	if want := filepath.Join(tmpDir, "bin", "guard.sh"); cfg.PreDeleteHook != want {
+		t.Errorf("PreDeleteHook = %q, want %q", cfg.PreDeleteHook, want)
+	}
+	wantTemplates := map[string]TemplateDefaults{
+		"work":     {GitName: "Jane Doe", GitEmail: "jane@acme.com"},
+		"personal": {GitEmail: "jane@example.com"},
+	}
+	if !reflect.DeepEqual(cfg.Templates, wantTemplates) {
+		t.Errorf("Templates = %+v, want %+v", cfg.Templates, wantTemplates)
+	}
+
+	// Saving writes back to the YAML file, not the flat one
+	cfg.PostCreateHook = filepath.Join(tmpDir, "bin", "setup.sh")
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".profile-manager")); !os.IsNotExist(err) {
+		t.Errorf("SaveConfig should not create the flat config (err=%v)", err)
+	}
+
+	reloaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() after save error: %v", err)
+	}
+	cfg.Format, reloaded.Format = "", ""
+	if !reflect.DeepEqual(reloaded, cfg) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", reloaded, cfg)
+	}
+}
+
+func TestLoadConfig_LegacyFlatRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	content := "profiles_dir=~/profiles\npost_create_hook=~/bin/setup.sh\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".profile-manager"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.Format != FormatFlat {
+		t.Errorf("Format = %q, want %q", cfg.Format, FormatFlat)
+	}
+
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".profile-manager.yaml")); !os.IsNotExist(err) {
+		t.Errorf("SaveConfig should not create a YAML config (err=%v)", err)
+	}
+	saved, _ := os.ReadFile(filepath.Join(tmpDir, ".profile-manager"))
+	for _, want := range []string{"profiles_dir=~/profiles\n", "post_create_hook=~/bin/setup.sh\n"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("flat config missing %q:\n%s", want, saved)
+		}
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := map[string]string{
+		"bad indentation": "templates:\n    work:\n  personal:\n",
+		"tab indentation": "templates:\n\twork: x\n",
+		"list":            "profiles_dir:\n  - a\n",
+		"no colon":        "profiles_dir\n",
+		"duplicate key":   "profiles_dir: a\nprofiles_dir: b\n",
+		"unterminated":    "profiles_dir: \"abc\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseYAML(content); err == nil {
+				t.Errorf("parseYAML(%q) expected error", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML used by ~/.profile-manager.yaml:
+// nested mappings of scalar values, with comments and quoted strings.
+// Leaves are strings and nested mappings are map[string]interface{}.
+func parseYAML(content string) (map[string]interface{}, error) {
+	type frame struct {
+		indent int
+		values map[string]interface{}
+	}
+
+	root := make(map[string]interface{})
+	stack := []frame{{indent: 0, values: root}}
+
+	// A key with no value opens a mapping whose indent is set by its first entry
+	var pending map[string]interface{}
+	pendingIndent := 0
+
+	for i, raw := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		body := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(body, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNum)
+		}
+		indent := len(raw) - len(body)
+
+		if pending != nil {
+			if indent > pendingIndent {
+				stack = append(stack, frame{indent: indent, values: pending})
+			}
+			pending = nil
+		}
+		for len(stack) > 1 && indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		if indent != top.indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", lineNum)
+		}
+
+		key, value, err := splitYAMLLine(strings.TrimSpace(body))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if _, exists := top.values[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNum, key)
+		}
+
+		if value == "" {
+			child := make(map[string]interface{})
+			top.values[key] = child
+			pending, pendingIndent = child, indent
+			continue
+		}
+		top.values[key] = value
+	}
+
+	return root, nil
+}
+
+// splitYAMLLine splits "key: value # comment" into its key and unquoted value
+func splitYAMLLine(line string) (key, value string, err error) {
+	if strings.HasPrefix(line, "- ") || line == "-" {
+		return "", "", fmt.Errorf("lists are not supported")
+	}
+
+	// The key ends at the first colon followed by a space or the end of line
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == ':' && (i+1 == len(line) || line[i+1] == ' ') {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", "", fmt.Errorf("expected \"key: value\", got %q", line)
+	}
+
+	key = strings.TrimSpace(line[:colon])
+	value = strings.TrimSpace(line[colon+1:])
+
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string for %q", key)
+		}
+		value, err = strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid string for %q: %w", key, err)
+		}
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", "", fmt.Errorf("unterminated string for %q", key)
+		}
+		value = strings.ReplaceAll(value[1:end], "''", "'")
+	case strings.HasPrefix(value, "#"):
+		value = ""
+	default:
+		if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+		if value == "~" || value == "null" {
+			value = ""
+		}
+	}
+
+	return key, value, nil
+}
+
+// closingQuote returns the index of the quote ending a double-quoted string
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseYAMLConfig parses ~/.profile-manager.yaml. Top-level keys are the same
+// as in the flat format; templates maps a template name to its defaults.
+func parseYAMLConfig(content string) (*Config, error) {
+	doc, err := parseYAML(content)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	for key, value := range doc {
+		if key == "templates" {
+			templates, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("templates must be a mapping")
+			}
+			for name, raw := range templates {
+				fields, ok := raw.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("templates.%s must be a mapping", name)
+				}
+				defaults := TemplateDefaults{}
+				for field, v := range fields {
+					s, _ := v.(string)
+					switch field {
+					case "git_name":
+						defaults.GitName = s
+					case "git_email":
+						defaults.GitEmail = s
+					}
+				}
+				if config.Templates == nil {
+					config.Templates = make(map[string]TemplateDefaults)
+				}
+				config.Templates[name] = defaults
+			}
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			config.set(key, v)
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return nil, fmt.Errorf("%s must be a value, not a mapping", key)
+			}
+			config.set(key, "")
+		}
+	}
+
+	return config, nil
+}
+
+// formatYAMLConfig renders a config as ~/.profile-manager.yaml
+func formatYAMLConfig(config *Config, homeDir string) string {
+	var b strings.Builder
+	b.WriteString("# Profile Manager Configuration\n")
+	b.WriteString("# You can edit this file manually if needed\n\n")
+
+	fmt.Fprintf(&b, "profiles_dir: %s\n", yamlScalar(abbreviateHome(config.ProfilesDir, homeDir)))
+	for _, entry := range []struct{ key, path string }{
+		{"template_dir", config.TemplateDir},
+		{"post_create_hook", config.PostCreateHook},
+		{"pre_update_hook", config.PreUpdateHook},
+		{"post_update_hook", config.PostUpdateHook},
+		{"pre_delete_hook", config.PreDeleteHook},
+		{"backup_dir", config.BackupDir},
+	} {
+		if entry.path != "" {
+			fmt.Fprintf(&b, "%s: %s\n", entry.key, yamlScalar(abbreviateHome(entry.path, homeDir)))
+		}
+	}
+	for _, entry := range config.onePasswordEntries() {
+		fmt.Fprintf(&b, "%s: %s\n", entry[0], yamlScalar(entry[1]))
+	}
+	for _, entry := range config.sshPolicyEntries() {
+		fmt.Fprintf(&b, "%s: %s\n", entry[0], yamlScalar(entry[1]))
+	}
+
+	if len(config.Templates) > 0 {
+		names := make([]string, 0, len(config.Templates))
+		for name := range config.Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteString("\ntemplates:\n")
+		for _, name := range names {
+			defaults := config.Templates[name]
+			fmt.Fprintf(&b, "  %s:\n", name)
+			if defaults.GitName != "" {
+				fmt.Fprintf(&b, "    git_name: %s\n", yamlScalar(defaults.GitName))
+			}
+			if defaults.GitEmail != "" {
+				fmt.Fprintf(&b, "    git_email: %s\n", yamlScalar(defaults.GitEmail))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// yamlScalar quotes a value when writing it bare would change its meaning
+func yamlScalar(s string) string {
+	if s == "" || s == "~" || s == "null" || strings.ContainsAny(s, `:#"'`) ||
+		strings.TrimSpace(s) != s || strings.HasPrefix(s, "-") {
+		return strconv.Quote(s)
+	}
+	return s
+}
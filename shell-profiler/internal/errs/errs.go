@@ -0,0 +1,110 @@
+// Package errs defines sentinel errors for common failures so callers can
+// tell them apart with errors.Is, and the stable codes the --json error
+// output reports for them
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors. Wrap them with Wrapf (or fmt.Errorf and %w) to keep a
+// descriptive message while letting callers match the kind of failure.
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrProfileExists   = errors.New("profile already exists")
+	ErrInvalidName     = errors.New("invalid profile name")
+	ErrInvalidTemplate = errors.New("invalid template")
+	ErrDirenvNotFound  = errors.New("direnv not found")
+	ErrProfilesDir     = errors.New("profiles directory unusable")
+)
+
+// Code identifies a kind of failure. Codes are part of the CLI's JSON
+// output and must not change once released.
+type Code string
+
+const (
+	Unknown          Code = "Unknown"
+	ProfileNotFound  Code = "ProfileNotFound"
+	ProfileExists    Code = "ProfileExists"
+	InvalidName      Code = "InvalidName"
+	TemplateNotFound Code = "TemplateNotFound"
+	DirenvNotFound   Code = "DirenvNotFound"
+	ProfilesDir      Code = "ProfilesDirUnusable"
+)
+
+// codes maps each sentinel to its code
+var codes = []struct {
+	err  error
+	code Code
+}{
+	{ErrProfileNotFound, ProfileNotFound},
+	{ErrProfileExists, ProfileExists},
+	{ErrInvalidName, InvalidName},
+	{ErrInvalidTemplate, TemplateNotFound},
+	{ErrDirenvNotFound, DirenvNotFound},
+	{ErrProfilesDir, ProfilesDir},
+}
+
+// wrapped is a sentinel with a descriptive message, which is shown in its
+// place
+type wrapped struct {
+	err     error
+	message string
+}
+
+func (w *wrapped) Error() string {
+	return w.message
+}
+
+func (w *wrapped) Unwrap() error {
+	return w.err
+}
+
+// Wrapf returns an error with the formatted message that matches sentinel
+// under errors.Is
+func Wrapf(sentinel error, format string, args ...interface{}) error {
+	return &wrapped{err: sentinel, message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf returns the code of the sentinel in err's chain, or Unknown if
+// there is none
+func CodeOf(err error) Code {
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return Unknown
+}
+
+// ExitError makes the CLI exit with Status instead of 1. Err, if any, is
+// reported as usual; without it nothing is printed.
+type ExitError struct {
+	Status int
+	Err    error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitStatus returns the process exit status for err: 0 for nil, the status
+// of an ExitError in its chain, and 1 otherwise
+func ExitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Status
+	}
+	return 1
+}
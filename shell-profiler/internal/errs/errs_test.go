@@ -0,0 +1,62 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrapf(t *testing.T) {
+	err := Wrapf(ErrProfileNotFound, "profile '%s' does not exist", "acme")
+	if err.Error() != "profile 'acme' does not exist" {
+		t.Errorf("Error() = %q, want the descriptive message", err.Error())
+	}
+	if !errors.Is(err, ErrProfileNotFound) {
+		t.Error("errors.Is(err, ErrProfileNotFound) = false")
+	}
+	if errors.Is(err, ErrProfileExists) {
+		t.Error("errors.Is(err, ErrProfileExists) = true")
+	}
+
+	// Further wrapping keeps the sentinel reachable
+	if outer := fmt.Errorf("update cancelled: %w", err); !errors.Is(outer, ErrProfileNotFound) {
+		t.Error("sentinel lost through fmt.Errorf wrapping")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Code
+	}{
+		{Wrapf(ErrProfileNotFound, "missing"), ProfileNotFound},
+		{fmt.Errorf("context: %w", Wrapf(ErrProfileExists, "exists")), ProfileExists},
+		{fmt.Errorf("bad: %w", ErrInvalidName), InvalidName},
+		{Wrapf(ErrInvalidTemplate, "invalid template: x"), TemplateNotFound},
+		{ErrDirenvNotFound, DirenvNotFound},
+		{Wrapf(ErrProfilesDir, "not a directory"), ProfilesDir},
+		{fmt.Errorf("plain failure"), Unknown},
+	}
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Errorf("CodeOf(%q) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{fmt.Errorf("plain failure"), 1},
+		{&ExitError{Status: 2}, 2},
+		{fmt.Errorf("wrapped: %w", &ExitError{Status: 3, Err: ErrProfileNotFound}), 3},
+	}
+	for _, tt := range tests {
+		if got := ExitStatus(tt.err); got != tt.want {
+			t.Errorf("ExitStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Hook names, passed to scripts as PROFILE_HOOK
+const (
+	PostCreate = "post-create"
+	PreUpdate  = "pre-update"
+	PostUpdate = "post-update"
+	PreDelete  = "pre-delete"
+)
+
+// Result is the outcome of running a hook script
+type Result struct {
+	ExitCode int
+	Output   string // Combined stdout and stderr
+}
+
+// Run executes a hook script from the profile directory with
+// WORKSPACE_PROFILE, WORKSPACE_HOME and PROFILE_HOOK set. A non-zero exit is
+// returned as an error alongside the result so the output can be shown.
+func Run(script, hook, profileName, profileDir string) (*Result, error) {
+	cmd := exec.Command(script)
+	cmd.Dir = profileDir
+	cmd.Env = append(os.Environ(),
+		"WORKSPACE_PROFILE="+profileName,
+		"WORKSPACE_HOME="+profileDir,
+		"PROFILE_HOOK="+hook,
+	)
+
+	output, err := cmd.CombinedOutput()
+	result := &Result{Output: strings.TrimRight(string(output), "\n")}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+			return result, fmt.Errorf("%s hook %s exited with code %d", hook, script, result.ExitCode)
+		}
+		result.ExitCode = -1
+		return result, fmt.Errorf("failed to run %s hook %s: %w", hook, script, err)
+	}
+
+	return result, nil
+}
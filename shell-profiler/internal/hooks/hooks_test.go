@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun_PassesProfileEnv(t *testing.T) {
+	profileDir := t.TempDir()
+	script := writeScript(t, `echo "$WORKSPACE_PROFILE|$WORKSPACE_HOME|$PROFILE_HOOK|$(pwd)"`+"\n")
+
+	result, err := Run(script, PostCreate, "acme", profileDir)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	want := "acme|" + profileDir + "|post-create|" + profileDir
+	if result.Output != want || result.ExitCode != 0 {
+		t.Errorf("Run() = %q (exit %d), want %q (exit 0)", result.Output, result.ExitCode, want)
+	}
+}
+
+func TestRun_ReportsExitCode(t *testing.T) {
+	script := writeScript(t, "echo boom >&2\nexit 3\n")
+
+	result, err := Run(script, PostCreate, "acme", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "exited with code 3") {
+		t.Fatalf("expected exit code error, got: %v", err)
+	}
+	if result.ExitCode != 3 || result.Output != "boom" {
+		t.Errorf("result = %+v, want exit 3 with output boom", result)
+	}
+}
+
+func TestRun_MissingScript(t *testing.T) {
+	result, err := Run(filepath.Join(t.TempDir(), "missing.sh"), PostCreate, "acme", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "failed to run post-create hook") {
+		t.Fatalf("expected run error, got: %v", err)
+	}
+	if result.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1", result.ExitCode)
+	}
+}
@@ -0,0 +1,110 @@
+// Package managed separates the content shell-profiler generates from what
+// users add to the same file. Generated content sits between fence comments:
+//
+//	# >>> profile-manager managed
+//	...
+//	# <<< profile-manager managed
+//
+// Regenerating a file replaces only the fenced block, so lines users add
+// before or after it survive.
+package managed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fence comments around the managed block
+const (
+	Begin = "# >>> profile-manager managed"
+	End   = "# <<< profile-manager managed"
+)
+
+// ErrNoBlock is returned when content has no managed block
+var ErrNoBlock = errors.New("no profile-manager managed block")
+
+// Sections is a file split around its managed block
+type Sections struct {
+	Before  string // User content before the Begin fence
+	Managed string // Content between the fences, excluding them
+	After   string // User content after the End fence
+}
+
+// Split finds the managed block in content. It fails with ErrNoBlock if
+// there is none, and on unbalanced or repeated fences.
+func Split(content string) (Sections, error) {
+	lines := strings.SplitAfter(content, "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case Begin:
+			if begin >= 0 {
+				return Sections{}, fmt.Errorf("line %d: second %q fence", i+1, Begin)
+			}
+			begin = i
+		case End:
+			if begin < 0 {
+				return Sections{}, fmt.Errorf("line %d: %q fence without %q", i+1, End, Begin)
+			}
+			if end >= 0 {
+				return Sections{}, fmt.Errorf("line %d: second %q fence", i+1, End)
+			}
+			end = i
+		}
+	}
+	if begin < 0 {
+		return Sections{}, ErrNoBlock
+	}
+	if end < 0 {
+		return Sections{}, fmt.Errorf("%q fence is never closed", Begin)
+	}
+
+	return Sections{
+		Before:  strings.Join(lines[:begin], ""),
+		Managed: strings.Join(lines[begin+1:end], ""),
+		After:   strings.Join(lines[end+1:], ""),
+	}, nil
+}
+
+// Fence wraps a block in the managed fences
+func Fence(block string) string {
+	if block != "" && !strings.HasSuffix(block, "\n") {
+		block += "\n"
+	}
+	return Begin + "\n" + block + End + "\n"
+}
+
+// Join reassembles sections split by Split
+func (s Sections) Join() string {
+	before := s.Before
+	if before != "" && !strings.HasSuffix(before, "\n") {
+		before += "\n"
+	}
+	return before + Fence(s.Managed) + s.After
+}
+
+// Regenerate replaces the managed block of existing with the managed block
+// of generated, a freshly rendered copy of the file. Content outside the
+// fences of existing is kept as is.
+func Regenerate(existing, generated string) (string, error) {
+	fresh, err := Split(generated)
+	if err != nil {
+		return "", fmt.Errorf("generated content: %w", err)
+	}
+	current, err := Split(existing)
+	if err != nil {
+		return "", err
+	}
+	current.Managed = fresh.Managed
+	return current.Join(), nil
+}
+
+// Hash identifies the content of a managed block, so that edits made to it
+// after it was generated can be detected
+func Hash(block string) string {
+	sum := sha256.Sum256([]byte(block))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package managed
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	content := "# header\n" + Begin + "\nA=1\nB=2\n" + End + "\nUSER=1\n"
+
+	s, err := Split(content)
+	if err != nil {
+		t.Fatalf("Split() error: %v", err)
+	}
+	if s.Before != "# header\n" || s.Managed != "A=1\nB=2\n" || s.After != "USER=1\n" {
+		t.Errorf("Split() = %+v", s)
+	}
+	if got := s.Join(); got != content {
+		t.Errorf("Join() = %q, want %q", got, content)
+	}
+}
+
+func TestSplit_Errors(t *testing.T) {
+	if _, err := Split("A=1\n"); !errors.Is(err, ErrNoBlock) {
+		t.Errorf("Split() without fences error = %v, want ErrNoBlock", err)
+	}
+	for name, content := range map[string]string{
+		"unclosed":     Begin + "\nA=1\n",
+		"end first":    End + "\n" + Begin + "\n",
+		"second begin": Begin + "\n" + Begin + "\n" + End + "\n",
+		"second end":   Begin + "\n" + End + "\n" + End + "\n",
+	} {
+		if _, err := Split(content); err == nil || errors.Is(err, ErrNoBlock) {
+			t.Errorf("%s: expected a fence error, got %v", name, err)
+		}
+	}
+}
+
+func TestRegenerate_KeepsUserContent(t *testing.T) {
+	existing := "# my notes\nMY_TOKEN_PATH=~/t\n" +
+		Begin + "\nOLD_VAR=1\nKEEP=old\n" + End +
+		"\n\n# added by me\nEXTRA=1\n"
+	generated := "# header\n" + Begin + "\nKEEP=new\nNEW_VAR=1\n" + End + "\n"
+
+	got, err := Regenerate(existing, generated)
+	if err != nil {
+		t.Fatalf("Regenerate() error: %v", err)
+	}
+	want := "# my notes\nMY_TOKEN_PATH=~/t\n" +
+		Begin + "\nKEEP=new\nNEW_VAR=1\n" + End +
+		"\n\n# added by me\nEXTRA=1\n"
+	if got != want {
+		t.Errorf("Regenerate() =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "OLD_VAR") {
+		t.Error("the old managed block should be replaced")
+	}
+
+	if _, err := Regenerate("A=1\n", generated); !errors.Is(err, ErrNoBlock) {
+		t.Errorf("Regenerate() of unfenced content error = %v, want ErrNoBlock", err)
+	}
+}
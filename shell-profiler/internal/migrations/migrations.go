@@ -0,0 +1,168 @@
+package migrations
+
+import (
+	"fmt"
+)
+
+// Context carries the profile being migrated and the update options
+type Context struct {
+	ProfileDir  string
+	ProfileName string
+	DryRun      bool
+	Force       bool
+
+	// Pause stops the caller's progress output before a migration warns or
+	// prompts; nil when there is none
+	Pause func()
+}
+
+// Migration upgrades a profile from one schema version to the next.
+// Apply must be idempotent and return a description of each change it made
+// (or would make, in dry-run mode); an empty result means nothing changed.
+// Revert is the optional inverse of Apply; migrations without one are
+// irreversible and can only be undone by restoring a backup.
+type Migration struct {
+	From        int
+	To          int
+	Name        string
+	Description string
+	Rationale   string // Why a profile needs the change, shown by update --explain
+	Apply       func(ctx Context) ([]string, error)
+	Revert      func(ctx Context) ([]string, error)
+}
+
+// Reversible reports whether the migration defines an inverse
+func (m Migration) Reversible() bool {
+	return m.Revert != nil
+}
+
+// Result records the outcome of a single applied migration
+type Result struct {
+	Migration Migration
+	Changes   []string
+}
+
+// Registry is an ordered chain of migrations starting at schema version 0
+type Registry struct {
+	migrations []Migration
+}
+
+// NewRegistry builds a registry, verifying that migrations form a contiguous
+// chain where each step moves exactly one version forward
+func NewRegistry(migrations ...Migration) (*Registry, error) {
+	version := 0
+	for _, m := range migrations {
+		if m.Name == "" {
+			return nil, fmt.Errorf("migration %d->%d has no name", m.From, m.To)
+		}
+		if m.Apply == nil {
+			return nil, fmt.Errorf("migration %q has no Apply function", m.Name)
+		}
+		if m.From != version || m.To != version+1 {
+			return nil, fmt.Errorf("migration %q is %d->%d, expected %d->%d", m.Name, m.From, m.To, version, version+1)
+		}
+		version = m.To
+	}
+
+	return &Registry{migrations: migrations}, nil
+}
+
+// MustRegistry is like NewRegistry but panics on an invalid chain
+func MustRegistry(migrations ...Migration) *Registry {
+	r, err := NewRegistry(migrations...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Latest returns the schema version reached after all migrations
+func (r *Registry) Latest() int {
+	if len(r.migrations) == 0 {
+		return 0
+	}
+	return r.migrations[len(r.migrations)-1].To
+}
+
+// All returns every registered migration in order
+func (r *Registry) All() []Migration {
+	return append([]Migration(nil), r.migrations...)
+}
+
+// Pending returns the migrations that must run to bring a profile at the
+// given schema version up to date
+func (r *Registry) Pending(version int) []Migration {
+	var pending []Migration
+	for _, m := range r.migrations {
+		if m.From >= version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Run applies every pending migration in order, stopping at the first error.
+// It returns the results of the migrations that ran and the version reached.
+func (r *Registry) Run(ctx Context, version int) ([]Result, int, error) {
+	var results []Result
+	for _, m := range r.Pending(version) {
+		changes, err := m.Apply(ctx)
+		if err != nil {
+			return results, version, fmt.Errorf("migration %q (%d->%d) failed: %w", m.Name, m.From, m.To, err)
+		}
+		results = append(results, Result{Migration: m, Changes: changes})
+		version = m.To
+	}
+	return results, version, nil
+}
+
+// Between returns the migrations that move a profile from one schema version
+// to a later one, in the order they are applied
+func (r *Registry) Between(from, to int) []Migration {
+	var between []Migration
+	for _, m := range r.migrations {
+		if m.From >= from && m.To <= to {
+			between = append(between, m)
+		}
+	}
+	return between
+}
+
+// Irreversible returns the first migration between the two versions that has
+// no inverse, or nil if rolling back from version to target needs only inverses
+func (r *Registry) Irreversible(target, version int) *Migration {
+	for _, m := range r.Between(target, version) {
+		if !m.Reversible() {
+			return &m
+		}
+	}
+	return nil
+}
+
+// Rollback reverts a profile at the given version down to target by applying
+// inverses newest first. Nothing is reverted if any step is irreversible.
+// It returns the results of the inverses that ran and the version reached.
+func (r *Registry) Rollback(ctx Context, version, target int) ([]Result, int, error) {
+	if target < 0 || target >= version {
+		return nil, version, fmt.Errorf("cannot roll back from schema version %d to %d", version, target)
+	}
+	if version > r.Latest() {
+		return nil, version, fmt.Errorf("schema version %d is newer than the latest known version %d", version, r.Latest())
+	}
+	if m := r.Irreversible(target, version); m != nil {
+		return nil, version, fmt.Errorf("migration %q (%d->%d) is irreversible", m.Name, m.From, m.To)
+	}
+
+	between := r.Between(target, version)
+	var results []Result
+	for i := len(between) - 1; i >= 0; i-- {
+		m := between[i]
+		changes, err := m.Revert(ctx)
+		if err != nil {
+			return results, version, fmt.Errorf("rollback of migration %q (%d->%d) failed: %w", m.Name, m.From, m.To, err)
+		}
+		results = append(results, Result{Migration: m, Changes: changes})
+		version = m.From
+	}
+	return results, version, nil
+}
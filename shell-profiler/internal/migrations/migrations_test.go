@@ -0,0 +1,181 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func recorder(calls *[]string, name string, changes ...string) func(Context) ([]string, error) {
+	return func(_ Context) ([]string, error) {
+		*calls = append(*calls, name)
+		return changes, nil
+	}
+}
+
+func TestNewRegistry_RejectsGaps(t *testing.T) {
+	noop := func(Context) ([]string, error) { return nil, nil }
+
+	_, err := NewRegistry(
+		Migration{From: 0, To: 1, Name: "a", Apply: noop},
+		Migration{From: 2, To: 3, Name: "b", Apply: noop},
+	)
+	if err == nil {
+		t.Fatal("expected error for non-contiguous chain")
+	}
+	if !strings.Contains(err.Error(), `"b"`) {
+		t.Errorf("error should name the offending migration: %v", err)
+	}
+}
+
+func TestNewRegistry_RejectsMissingApply(t *testing.T) {
+	if _, err := NewRegistry(Migration{From: 0, To: 1, Name: "a"}); err == nil {
+		t.Fatal("expected error for migration without Apply")
+	}
+}
+
+func TestRegistry_Latest(t *testing.T) {
+	noop := func(Context) ([]string, error) { return nil, nil }
+
+	empty := MustRegistry()
+	if empty.Latest() != 0 {
+		t.Errorf("empty Latest() = %d, want 0", empty.Latest())
+	}
+
+	r := MustRegistry(
+		Migration{From: 0, To: 1, Name: "a", Apply: noop},
+		Migration{From: 1, To: 2, Name: "b", Apply: noop},
+	)
+	if r.Latest() != 2 {
+		t.Errorf("Latest() = %d, want 2", r.Latest())
+	}
+}
+
+func TestRegistry_RunFromVersion(t *testing.T) {
+	var calls []string
+	r := MustRegistry(
+		Migration{From: 0, To: 1, Name: "a", Apply: recorder(&calls, "a")},
+		Migration{From: 1, To: 2, Name: "b", Apply: recorder(&calls, "b", "changed b")},
+		Migration{From: 2, To: 3, Name: "c", Apply: recorder(&calls, "c")},
+	)
+
+	results, version, err := r.Run(Context{}, 1)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if version != 3 {
+		t.Errorf("version = %d, want 3", version)
+	}
+	if strings.Join(calls, ",") != "b,c" {
+		t.Errorf("ran %v, want [b c]", calls)
+	}
+	if len(results) != 2 || len(results[0].Changes) != 1 || results[0].Changes[0] != "changed b" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestRegistry_RunCurrentIsNoOp(t *testing.T) {
+	var calls []string
+	r := MustRegistry(
+		Migration{From: 0, To: 1, Name: "a", Apply: recorder(&calls, "a")},
+	)
+
+	results, version, err := r.Run(Context{}, 1)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(calls) != 0 || len(results) != 0 {
+		t.Errorf("expected no migrations to run, ran %v", calls)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+}
+
+func TestRegistry_RunStopsAtFailure(t *testing.T) {
+	var calls []string
+	boom := errors.New("boom")
+	r := MustRegistry(
+		Migration{From: 0, To: 1, Name: "a", Apply: recorder(&calls, "a")},
+		Migration{From: 1, To: 2, Name: "b", Apply: func(Context) ([]string, error) { return nil, boom }},
+		Migration{From: 2, To: 3, Name: "c", Apply: recorder(&calls, "c")},
+	)
+
+	_, version, err := r.Run(Context{}, 0)
+	if !errors.Is(err, boom) {
+		t.Fatalf("Run() error = %v, want wrapped boom", err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1 (last successful migration)", version)
+	}
+	if strings.Join(calls, ",") != "a" {
+		t.Errorf("ran %v, want [a]", calls)
+	}
+}
+
+func TestRegistry_RollbackRoundTrip(t *testing.T) {
+	state := 0
+	step := func(n int) Migration {
+		return Migration{
+			From: n - 1, To: n, Name: fmt.Sprintf("step-%d", n),
+			Apply:  func(Context) ([]string, error) { state = n; return []string{"applied"}, nil },
+			Revert: func(Context) ([]string, error) { state = n - 1; return []string{"reverted"}, nil },
+		}
+	}
+	r := MustRegistry(step(1), step(2), step(3))
+
+	if _, _, err := r.Run(Context{}, 0); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	results, version, err := r.Rollback(Context{}, 3, 1)
+	if err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	if version != 1 || state != 1 {
+		t.Errorf("version = %d, state = %d, want 1 and 1", version, state)
+	}
+	if len(results) != 2 || results[0].Migration.Name != "step-3" || results[1].Migration.Name != "step-2" {
+		t.Errorf("expected step-3 then step-2 to be reverted, got %+v", results)
+	}
+
+	if _, version, err = r.Run(Context{}, version); err != nil || version != 3 || state != 3 {
+		t.Errorf("re-running after rollback: version = %d, state = %d, err = %v", version, state, err)
+	}
+}
+
+func TestRegistry_RollbackRefusesIrreversible(t *testing.T) {
+	var calls []string
+	r := MustRegistry(
+		Migration{From: 0, To: 1, Name: "a", Apply: recorder(&calls, "a")},
+		Migration{From: 1, To: 2, Name: "b", Apply: recorder(&calls, "b"), Revert: recorder(&calls, "revert-b")},
+	)
+
+	_, version, err := r.Rollback(Context{}, 2, 0)
+	if err == nil {
+		t.Fatal("expected error rolling back through an irreversible migration")
+	}
+	if !strings.Contains(err.Error(), `"a"`) || !strings.Contains(err.Error(), "irreversible") {
+		t.Errorf("error should name the irreversible migration: %v", err)
+	}
+	if version != 2 || len(calls) != 0 {
+		t.Errorf("nothing should be reverted: version = %d, calls = %v", version, calls)
+	}
+
+	// Rolling back only the reversible step is allowed
+	if _, version, err = r.Rollback(Context{}, 2, 1); err != nil || version != 1 {
+		t.Errorf("Rollback(2, 1) = %d, %v; want 1, nil", version, err)
+	}
+}
+
+func TestRegistry_RollbackRejectsInvalidTarget(t *testing.T) {
+	noop := func(Context) ([]string, error) { return nil, nil }
+	r := MustRegistry(Migration{From: 0, To: 1, Name: "a", Apply: noop, Revert: noop})
+
+	for _, target := range []int{1, 2, -1} {
+		if _, _, err := r.Rollback(Context{}, 1, target); err == nil {
+			t.Errorf("Rollback(1, %d) should fail", target)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+)
+
+// ActiveProfile returns the profile named by WORKSPACE_PROFILE (set by the
+// profile's .envrc while direnv is active) if it exists under the configured
+// profiles directory
+func ActiveProfile() (string, bool) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", false
+	}
+	return ActiveProfileIn(cfg.ProfilesDir)
+}
+
+// ActiveProfileIn is like ActiveProfile but validates against the given
+// profiles directory
+func ActiveProfileIn(profilesDir string) (string, bool) {
+	name := os.Getenv("WORKSPACE_PROFILE")
+	if name == "" || name != filepath.Base(name) {
+		return "", false
+	}
+	if _, err := os.Stat(filepath.Join(profilesDir, name, ".envrc")); err != nil {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,43 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestActiveProfile_Detected(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	profilesDir := filepath.Join(home, "profiles")
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte("profiles_dir="+profilesDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeProfile(t, filepath.Join(profilesDir, "acme"), "acme")
+	t.Setenv("WORKSPACE_PROFILE", "acme")
+
+	name, ok := ActiveProfile()
+	if !ok || name != "acme" {
+		t.Errorf("ActiveProfile() = %q, %v; want acme, true", name, ok)
+	}
+}
+
+func TestActiveProfileIn_Unset(t *testing.T) {
+	t.Setenv("WORKSPACE_PROFILE", "")
+
+	if name, ok := ActiveProfileIn(t.TempDir()); ok {
+		t.Errorf("ActiveProfileIn() = %q, true; want no active profile", name)
+	}
+}
+
+func TestActiveProfileIn_MustExist(t *testing.T) {
+	profilesDir := t.TempDir()
+
+	for _, name := range []string{"gone", "../escape", "a/b"} {
+		t.Setenv("WORKSPACE_PROFILE", name)
+		if _, ok := ActiveProfileIn(profilesDir); ok {
+			t.Errorf("ActiveProfileIn() accepted %q, which is not a profile under %s", name, profilesDir)
+		}
+	}
+}
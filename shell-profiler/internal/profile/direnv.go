@@ -0,0 +1,73 @@
+package profile
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// AllowState is whether direnv will load a profile's .envrc
+type AllowState string
+
+const (
+	AllowAllowed AllowState = "allowed"
+	AllowBlocked AllowState = "blocked" // Never allowed, denied, or changed since
+	AllowUnknown AllowState = "unknown" // direnv is missing or its status unreadable
+)
+
+// DirenvAllowState asks `direnv status`, run in the profile directory,
+// whether the profile's .envrc is allowed. An .envrc that is not allowed is
+// silently ignored by direnv.
+func DirenvAllowState(profileDir string) AllowState {
+	direnv, err := exec.LookPath("direnv")
+	if err != nil {
+		return AllowUnknown
+	}
+	cmd := exec.Command(direnv, "status")
+	cmd.Dir = profileDir
+	output, err := cmd.Output()
+	if err != nil {
+		return AllowUnknown
+	}
+	return parseDirenvStatus(string(output), filepath.Join(profileDir, ".envrc"))
+}
+
+// parseDirenvStatus reads the "Found RC" section of `direnv status` output.
+// direnv 2.33+ prints "Found RC allowed 0" (0 allowed, 1 not allowed,
+// 2 denied); older versions print true or false.
+func parseDirenvStatus(output, envrcPath string) AllowState {
+	foundPath := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if path, ok := strings.CutPrefix(line, "Found RC path "); ok {
+			foundPath = path
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "Found RC allowed ")
+		if !ok {
+			continue
+		}
+		// direnv may have found an .envrc other than the profile's
+		if foundPath != "" && !sameFile(foundPath, envrcPath) {
+			return AllowUnknown
+		}
+		switch strings.TrimSpace(value) {
+		case "0", "true":
+			return AllowAllowed
+		case "1", "2", "false":
+			return AllowBlocked
+		}
+		return AllowUnknown
+	}
+	return AllowUnknown
+}
+
+func sameFile(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,55 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stubDirenvStatus puts a fake direnv on PATH whose status prints output
+func stubDirenvStatus(t *testing.T, output string) {
+	t.Helper()
+	binDir := t.TempDir()
+	script := "#!/bin/sh\ncat <<'EOF'\n" + output + "EOF\n"
+	if err := os.WriteFile(filepath.Join(binDir, "direnv"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestDirenvAllowState(t *testing.T) {
+	profileDir := t.TempDir()
+	envrc := filepath.Join(profileDir, ".envrc")
+	if err := os.WriteFile(envrc, []byte("export X=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		want   AllowState
+	}{
+		{"allowed", "direnv exec path /usr/bin/direnv\nFound RC path " + envrc + "\nFound RC allowed 0\n", AllowAllowed},
+		{"not allowed", "Found RC path " + envrc + "\nFound RC allowed 1\n", AllowBlocked},
+		{"denied", "Found RC path " + envrc + "\nFound RC allowed 2\n", AllowBlocked},
+		{"legacy true", "Found RC path " + envrc + "\nFound RC allowed true\n", AllowAllowed},
+		{"legacy false", "Found RC path " + envrc + "\nFound RC allowed false\n", AllowBlocked},
+		{"other envrc", "Found RC path /elsewhere/.envrc\nFound RC allowed 0\n", AllowUnknown},
+		{"no rc", "direnv exec path /usr/bin/direnv\nNo .envrc or .env found\n", AllowUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubDirenvStatus(t, tt.status)
+			if got := DirenvAllowState(profileDir); got != tt.want {
+				t.Errorf("DirenvAllowState() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirenvAllowState_NoDirenv(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if got := DirenvAllowState(t.TempDir()); got != AllowUnknown {
+		t.Errorf("DirenvAllowState() = %q, want %q", got, AllowUnknown)
+	}
+}
@@ -0,0 +1,165 @@
+package profile
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gitLookPath finds the git binary; replaced in tests to force the fallback
+// parser
+var gitLookPath = exec.LookPath
+
+// GitConfigValue returns the effective value of key, such as "user.email", in
+// a gitconfig file: the last one set, as git reports it. Sections other than
+// the key's own, like [includeIf ...] or [user "x"], are not considered and
+// included files are not followed. Without git installed the file is parsed
+// directly.
+func GitConfigValue(configFile, key string) string {
+	if git, err := gitLookPath("git"); err == nil {
+		output, err := exec.Command(git, "config", "--file", configFile, "--get", key).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	values, err := readGitConfig(configFile)
+	if err != nil {
+		return ""
+	}
+	return values[normalizeGitConfigKey(key)]
+}
+
+// GitIdentity returns user.name and user.email from a gitconfig file
+func GitIdentity(configFile string) (name, email string) {
+	return GitConfigValue(configFile, "user.name"), GitConfigValue(configFile, "user.email")
+}
+
+// normalizeGitConfigKey lower-cases the section and variable names of a key,
+// which git compares case-insensitively, keeping any subsection as is
+func normalizeGitConfigKey(key string) string {
+	first := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if first < 0 {
+		return strings.ToLower(key)
+	}
+	return strings.ToLower(key[:first]) + key[first:last] + strings.ToLower(key[last:])
+}
+
+// readGitConfig parses a gitconfig file into normalized keys and their last
+// value. It follows git-config(1) for section headers, comments, quoting,
+// escapes and line continuations; malformed lines are skipped.
+func readGitConfig(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(file)
+	pending := ""
+	for scanner.Scan() {
+		line := pending + scanner.Text()
+		pending = ""
+		if strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) {
+			pending = strings.TrimSuffix(line, `\`)
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			section = parseGitConfigSection(line[1:end])
+			line = strings.TrimSpace(line[end+1:])
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' || section == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if i := strings.IndexAny(name, "#; \t"); i >= 0 {
+			name = name[:i]
+		}
+		if name == "" {
+			continue
+		}
+		if !hasValue {
+			// A bare variable is a boolean true
+			value = "true"
+		} else {
+			value = parseGitConfigValue(value)
+		}
+		values[section+"."+strings.ToLower(name)] = value
+	}
+	return values, scanner.Err()
+}
+
+// parseGitConfigSection returns the normalized name of a section header's
+// contents: `user`, `includeIf "gitdir:~/work/"` or the legacy `branch.main`
+func parseGitConfigSection(header string) string {
+	header = strings.TrimSpace(header)
+	name, sub, ok := strings.Cut(header, " ")
+	if !ok {
+		if dot := strings.Index(header, "."); dot >= 0 {
+			return strings.ToLower(header[:dot]) + "." + strings.ToLower(header[dot+1:])
+		}
+		return strings.ToLower(header)
+	}
+	sub = strings.TrimSpace(sub)
+	sub = strings.TrimSuffix(strings.TrimPrefix(sub, `"`), `"`)
+	sub = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(sub)
+	return strings.ToLower(name) + "." + sub
+}
+
+// parseGitConfigValue unquotes a raw value, dropping a trailing comment and
+// surrounding whitespace outside of double quotes
+func parseGitConfigValue(raw string) string {
+	var b strings.Builder
+	quoted := false
+	// Whitespace is only kept once followed by something else
+	space := ""
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			b.WriteString(space)
+			space = ""
+			continue
+		case c == '\\' && i+1 < len(raw):
+			i++
+			b.WriteString(space)
+			space = ""
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			default:
+				b.WriteByte(raw[i])
+			}
+			continue
+		case !quoted && (c == '#' || c == ';'):
+			return b.String()
+		case !quoted && (c == ' ' || c == '\t'):
+			if b.Len() > 0 {
+				space += string(c)
+			}
+			continue
+		}
+		b.WriteString(space)
+		space = ""
+		b.WriteByte(c)
+	}
+	return b.String()
+}
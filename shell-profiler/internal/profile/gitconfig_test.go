@@ -0,0 +1,72 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const tolerantGitconfig = `# Identity for acme
+[user]
+	name = Old Name
+[includeIf "gitdir:~/personal/"]
+	path = ~/.gitconfig-personal
+[user "signing"]
+	email = signing@acme.example
+[User]
+	Name="Jane \"JD\" Doe"   ; quoted, with a comment
+	email   =   jane@acme.example # trailing comment
+[core]
+	editor = vim
+	bare
+`
+
+// withGit runs fn both with git, if installed, and with the fallback parser
+func withGit(t *testing.T, fn func(t *testing.T)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err == nil {
+		t.Run("git", fn)
+	}
+	t.Run("parser", func(t *testing.T) {
+		orig := gitLookPath
+		t.Cleanup(func() { gitLookPath = orig })
+		gitLookPath = func(string) (string, error) { return "", errors.New("not found") }
+		fn(t)
+	})
+}
+
+func TestGitIdentity_Tolerant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitconfig")
+	if err := os.WriteFile(path, []byte(tolerantGitconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withGit(t, func(t *testing.T) {
+		name, email := GitIdentity(path)
+		if name != `Jane "JD" Doe` {
+			t.Errorf("name = %q, want the last [user] value", name)
+		}
+		if email != "jane@acme.example" {
+			t.Errorf("email = %q, want the [user] value, not a subsection's", email)
+		}
+		if got := GitConfigValue(path, "core.editor"); got != "vim" {
+			t.Errorf("core.editor = %q", got)
+		}
+		if got := GitConfigValue(path, "includeIf.gitdir:~/personal/.path"); got != "~/.gitconfig-personal" {
+			t.Errorf("includeIf path = %q", got)
+		}
+		if got := GitConfigValue(path, "user.signingkey"); got != "" {
+			t.Errorf("unset key = %q, want empty", got)
+		}
+	})
+}
+
+func TestGitIdentity_MissingFile(t *testing.T) {
+	withGit(t, func(t *testing.T) {
+		if name, email := GitIdentity(filepath.Join(t.TempDir(), "missing")); name != "" || email != "" {
+			t.Errorf("GitIdentity() = %q, %q for a missing file", name, email)
+		}
+	})
+}
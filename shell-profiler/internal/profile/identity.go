@@ -0,0 +1,139 @@
+package profile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/secrets"
+	"github.com/neverprepared/shell-profile-manager/internal/tools"
+)
+
+// Identity is who a profile acts as: its git author, AWS config, secret
+// vault and SSH config
+type Identity struct {
+	Profile       string
+	ProfileDir    string
+	GitName       string
+	GitEmail      string
+	AWSConfigFile string
+	SecretBackend string
+	SecretVault   string // The first of SecretVaults
+	SSHConfig     string
+
+	// SecretVaults are the vaults the .envrc loads secrets from, in order
+	SecretVaults []string
+
+	// SSHIdentityFiles are the IdentityFile paths in the SSH config
+	SSHIdentityFiles []string
+}
+
+// LoadIdentity reads a profile's identity from its .gitconfig, .env and
+// .profile-meta. Values not overridden in .env fall back to the defaults
+// from the tool registry.
+func LoadIdentity(name, dir string) *Identity {
+	env := toolDefaults()
+	for key, value := range readEnvFile(filepath.Join(dir, ".env")) {
+		env[key] = value
+	}
+	expand := func(value string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(value, "${WORKSPACE_HOME}", dir), "$WORKSPACE_HOME", dir)
+	}
+
+	id := &Identity{
+		Profile:       name,
+		ProfileDir:    dir,
+		AWSConfigFile: expand(env["AWS_CONFIG_FILE"]),
+		SecretBackend: DefaultSecretBackend,
+	}
+
+	gitConfig := filepath.Join(dir, ".gitconfig")
+	if _, err := os.Stat(gitConfig); err == nil {
+		id.GitName, id.GitEmail = GitIdentity(gitConfig)
+	}
+
+	// GIT_SSH_COMMAND is "ssh -F <config>"
+	fields := strings.Fields(expand(env["GIT_SSH_COMMAND"]))
+	for i, field := range fields {
+		if field == "-F" && i+1 < len(fields) {
+			id.SSHConfig = fields[i+1]
+		}
+	}
+	if id.SSHConfig != "" {
+		id.SSHIdentityFiles = readIdentityFiles(id.SSHConfig, expand)
+	}
+
+	meta, err := ReadMeta(dir)
+	if err == nil && meta.SecretBackend != "" {
+		id.SecretBackend = meta.SecretBackend
+	}
+	if id.SecretBackend == DefaultSecretBackend {
+		// Matches the vaults discovered by the .envrc
+		id.SecretVaults = []string{secrets.OnePassword{}.VaultName(name)}
+		if err == nil && len(meta.Vaults) > 0 {
+			id.SecretVaults = meta.Vaults
+		}
+		id.SecretVault = id.SecretVaults[0]
+	}
+
+	return id
+}
+
+// readIdentityFiles returns the IdentityFile paths in an SSH config,
+// with ~ and $WORKSPACE_HOME expanded
+func readIdentityFiles(path string, expand func(string) string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	homeDir, _ := os.UserHomeDir()
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "IdentityFile") {
+			continue
+		}
+		value := expand(strings.Trim(strings.Join(fields[1:], " "), `"`))
+		if homeDir != "" && (value == "~" || strings.HasPrefix(value, "~/")) {
+			value = filepath.Join(homeDir, value[1:])
+		}
+		files = append(files, filepath.Clean(value))
+	}
+	return files
+}
+
+func toolDefaults() map[string]string {
+	env := map[string]string{}
+	for _, v := range tools.EnvVars() {
+		env[v.Name] = v.Value
+	}
+	return env
+}
+
+// readEnvFile parses KEY=VALUE lines from a dotenv file
+func readEnvFile(path string) map[string]string {
+	env := map[string]string{}
+	file, err := os.Open(path)
+	if err != nil {
+		return env
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return env
+}
@@ -0,0 +1,60 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadIdentity(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "acme")
+	gitconfig := "[user]\n\tname = Jane Doe\n\temail = jane@acme.example\n"
+	if err := os.WriteFile(filepath.Join(dir, ".gitconfig"), []byte(gitconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := "# overrides\nAWS_CONFIG_FILE=\"$WORKSPACE_HOME/aws/acme.conf\"\nGIT_SSH_COMMAND=\"ssh -F $WORKSPACE_HOME/.ssh/config\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(env), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sshConfig := "Host github.com\n    # IdentityFile ignored\n    IdentityFile $WORKSPACE_HOME/.ssh/id_github\n"
+	if err := os.WriteFile(filepath.Join(dir, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	id := LoadIdentity("acme", dir)
+
+	if id.GitName != "Jane Doe" || id.GitEmail != "jane@acme.example" {
+		t.Errorf("git identity = %q <%q>", id.GitName, id.GitEmail)
+	}
+	if want := filepath.Join(dir, "aws", "acme.conf"); id.AWSConfigFile != want {
+		t.Errorf("AWSConfigFile = %q, want %q", id.AWSConfigFile, want)
+	}
+	if want := filepath.Join(dir, ".ssh", "config"); id.SSHConfig != want {
+		t.Errorf("SSHConfig = %q, want %q", id.SSHConfig, want)
+	}
+	if want := []string{filepath.Join(dir, ".ssh", "id_github")}; len(id.SSHIdentityFiles) != 1 || id.SSHIdentityFiles[0] != want[0] {
+		t.Errorf("SSHIdentityFiles = %v, want %v", id.SSHIdentityFiles, want)
+	}
+	if id.SecretVault != "workspace-acme" || id.SecretBackend != DefaultSecretBackend {
+		t.Errorf("secrets = %q (%q), want workspace-acme (%s)", id.SecretVault, id.SecretBackend, DefaultSecretBackend)
+	}
+}
+
+func TestLoadIdentity_Defaults(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "bare")
+
+	id := LoadIdentity("bare", dir)
+
+	if id.GitName != "" || id.GitEmail != "" {
+		t.Errorf("git identity should be empty without .gitconfig, got %q <%q>", id.GitName, id.GitEmail)
+	}
+	if want := filepath.Join(dir, ".aws", "config"); id.AWSConfigFile != want {
+		t.Errorf("AWSConfigFile = %q, want registry default %q", id.AWSConfigFile, want)
+	}
+}
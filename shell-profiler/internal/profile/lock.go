@@ -0,0 +1,70 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the name of the lock file held by mutating commands
+const LockFileName = ".lock"
+
+// DefaultLockTimeout is how long mutating commands wait for a profile lock
+var DefaultLockTimeout = 5 * time.Second
+
+// lockPollInterval is how often a blocked Lock retries the flock
+const lockPollInterval = 50 * time.Millisecond
+
+// ErrLocked is returned when a profile lock cannot be acquired in time
+var ErrLocked = errors.New("profile is locked by another process")
+
+// Lock is an exclusive advisory lock on a profile directory
+type Lock struct {
+	file *os.File
+}
+
+// AcquireLock takes an exclusive flock on the profile's .lock file, waiting
+// up to timeout for another process to release it. A zero timeout fails
+// immediately if the profile is already locked.
+func AcquireLock(profileDir string, timeout time.Duration) (*Lock, error) {
+	lockPath := filepath.Join(profileDir, LockFileName)
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock profile: %w", err)
+		}
+		if !time.Now().Before(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%w (waited %s): %s", ErrLocked, timeout, profileDir)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Release drops the lock. The lock file itself is left in place so that a
+// waiting process never ends up locking an unlinked file.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	defer func() { l.file = nil }()
+
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock profile: %w", err)
+	}
+	return l.file.Close()
+}
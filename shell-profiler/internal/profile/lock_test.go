@@ -0,0 +1,61 @@
+package profile
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock_SecondLockFailsWhileHeld(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lock, err := AcquireLock(tmpDir, 0)
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+	defer lock.Release()
+
+	start := time.Now()
+	_, err = AcquireLock(tmpDir, 150*time.Millisecond)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("second AcquireLock() error = %v, want ErrLocked", err)
+	}
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Errorf("second AcquireLock() returned after %s, want it to wait for the timeout", waited)
+	}
+}
+
+func TestAcquireLock_WaitsForRelease(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lock, err := AcquireLock(tmpDir, 0)
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.Release()
+	}()
+
+	second, err := AcquireLock(tmpDir, 2*time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock() should succeed once the first lock is released: %v", err)
+	}
+	if err := second.Release(); err != nil {
+		t.Errorf("Release() error: %v", err)
+	}
+}
+
+func TestLock_ReleaseIsIdempotent(t *testing.T) {
+	lock, err := AcquireLock(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("AcquireLock() error: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Errorf("second Release() error: %v", err)
+	}
+}
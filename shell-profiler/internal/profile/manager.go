@@ -5,7 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/neverprepared/shell-profile-manager/internal/ui"
 )
 
 type Manager struct {
@@ -48,17 +51,17 @@ func (m *Manager) ShowInfo() error {
 	if gitConfig != "" {
 		if _, err := os.Stat(gitConfig); err == nil {
 			// Get git config values
-			if name := getGitConfig(gitConfig, "user.name"); name != "" {
+			if name := GitConfigValue(gitConfig, "user.name"); name != "" {
 				fmt.Printf("  User Name:     %s\n", name)
 			} else {
 				fmt.Println("  User Name:     Not set")
 			}
-			if email := getGitConfig(gitConfig, "user.email"); email != "" {
+			if email := GitConfigValue(gitConfig, "user.email"); email != "" {
 				fmt.Printf("  User Email:    %s\n", email)
 			} else {
 				fmt.Println("  User Email:    Not set")
 			}
-			if branch := getGitConfig(gitConfig, "init.defaultBranch"); branch != "" {
+			if branch := GitConfigValue(gitConfig, "init.defaultBranch"); branch != "" {
 				fmt.Printf("  Default Branch: %s\n", branch)
 			} else {
 				fmt.Println("  Default Branch: Not set")
@@ -94,6 +97,95 @@ func (m *Manager) ShowInfo() error {
 	return nil
 }
 
+// ShowProfile displays the recorded configuration of a profile that need not
+// be active, reading its files rather than the current environment
+func (m *Manager) ShowProfile(name, dir string) error {
+	fmt.Printf("=== Workspace Profile: %s ===\n", name)
+	fmt.Println()
+	fmt.Printf("Profile Name:    %s\n", name)
+	fmt.Printf("Profile Home:    %s\n", dir)
+	if active := os.Getenv("WORKSPACE_PROFILE"); active == name {
+		fmt.Println("Status:          active")
+	}
+
+	if meta, err := LoadMeta(dir); err == nil {
+		fmt.Printf("Template:        %s\n", meta.Template)
+		if meta.TemplateURL != "" {
+			fmt.Printf("Template source: %s\n", meta.TemplateURL)
+		}
+		if meta.Created != "" {
+			fmt.Printf("Created:         %s\n", meta.Created)
+		}
+		if len(meta.Tags) > 0 {
+			fmt.Printf("Tags:            %s\n", strings.Join(meta.Tags, ", "))
+		}
+		fmt.Printf("Schema Version:  %d\n", meta.SchemaVersion)
+	}
+	fmt.Printf("direnv:          %s\n", DirenvAllowState(dir))
+	fmt.Println()
+
+	gitConfig := filepath.Join(dir, ".gitconfig")
+	fmt.Println("Git Configuration:")
+	fmt.Printf("  Config File:   %s\n", gitConfig)
+	if _, err := os.Stat(gitConfig); err == nil {
+		for _, item := range []struct{ label, key string }{
+			{"User Name:     ", "user.name"},
+			{"User Email:    ", "user.email"},
+			{"Default Branch:", "init.defaultBranch"},
+		} {
+			value := GitConfigValue(gitConfig, item.key)
+			if value == "" {
+				value = "Not set"
+			}
+			fmt.Printf("  %s %s\n", item.label, value)
+		}
+	} else {
+		fmt.Println("  Warning: Config file not found")
+	}
+
+	return nil
+}
+
+// ShowProfileFormat displays what ShowProfile does as fields in a ui
+// output format, for --format
+func (m *Manager) ShowProfileFormat(name, dir, format string) error {
+	return ui.RenderFields(os.Stdout, ProfileFields(name, dir), format)
+}
+
+// ProfileFields returns the recorded configuration of a profile as
+// field/value pairs. Unset values are empty.
+func ProfileFields(name, dir string) [][2]string {
+	status := ""
+	if os.Getenv("WORKSPACE_PROFILE") == name {
+		status = "active"
+	}
+	fields := [][2]string{
+		{"name", name},
+		{"home", dir},
+		{"status", status},
+	}
+
+	var template, templateSource, created, tags, schemaVersion string
+	if meta, err := LoadMeta(dir); err == nil {
+		template, templateSource, created = meta.Template, meta.TemplateURL, meta.Created
+		tags = strings.Join(meta.Tags, ",")
+		schemaVersion = strconv.Itoa(meta.SchemaVersion)
+	}
+	gitConfig := filepath.Join(dir, ".gitconfig")
+	return append(fields, [][2]string{
+		{"template", template},
+		{"template_source", templateSource},
+		{"created", created},
+		{"tags", tags},
+		{"schema_version", schemaVersion},
+		{"direnv", string(DirenvAllowState(dir))},
+		{"git_config", gitConfig},
+		{"git_user_name", GitConfigValue(gitConfig, "user.name")},
+		{"git_user_email", GitConfigValue(gitConfig, "user.email")},
+		{"git_default_branch", GitConfigValue(gitConfig, "init.defaultBranch")},
+	}...)
+}
+
 // listProfiles lists all available profiles
 func (m *Manager) listProfiles() error {
 	entries, err := os.ReadDir(m.profilesDir)
@@ -114,16 +206,6 @@ func (m *Manager) listProfiles() error {
 	return nil
 }
 
-// getGitConfig reads a git config value from a specific config file
-func getGitConfig(configFile, key string) string {
-	cmd := exec.Command("git", "config", "--file", configFile, key)
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(output))
-}
-
 // ShowDirenvStatus shows the status of direnv
 func ShowDirenvStatus() error {
 	// Check if direnv is installed
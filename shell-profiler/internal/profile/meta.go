@@ -0,0 +1,174 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MetaFileName is the name of the metadata file stored in each profile directory
+	MetaFileName = ".profile-meta"
+
+	// DefaultSecretBackend is the secret backend used by generated profiles
+	DefaultSecretBackend = "1password"
+)
+
+// Meta holds the metadata recorded for a profile at creation time.
+// SchemaVersion is the profile layout version reached by the update
+// migrations; legacy profiles without a metadata file are version 0.
+type Meta struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Name          string   `json:"name"`
+	Template      string   `json:"template"`
+	TemplateURL   string   `json:"templateUrl,omitempty"` // Set for git+ template sources
+	Created       string   `json:"created"`
+	SecretBackend string   `json:"secretBackend"`
+	Tags          []string `json:"tags,omitempty"`
+	NoCloud       bool     `json:"noCloud,omitempty"`   // Created without the cloud tools
+	NoReadme      bool     `json:"noReadme,omitempty"`  // Created without README.md
+	ExtraDirs     []string `json:"extraDirs,omitempty"` // User directories as PATH[:MODE]
+	OpAccount     string   `json:"opAccount,omitempty"` // 1Password account secrets are discovered in
+
+	// OpFieldPrefix and OpExcludeFields select the 1Password fields the
+	// .envrc exports (create --op-field-prefix and --op-exclude-field)
+	OpFieldPrefix   string   `json:"opFieldPrefix,omitempty"`
+	OpExcludeFields []string `json:"opExcludeFields,omitempty"`
+	IncludeTOTP     bool     `json:"includeTotp,omitempty"` // One-time passwords exported (create --include-totp)
+	// Vaults are the 1Password vaults the .envrc loads secrets from, in
+	// order (create --vault); workspace-<name> if empty
+	Vaults []string `json:"vaults,omitempty"`
+
+	// RelativeSSHPaths is set when .ssh/config refers to the profile as
+	// ${WORKSPACE_HOME} rather than by its absolute path
+	RelativeSSHPaths bool `json:"relativeSshPaths,omitempty"`
+
+	// Hooks maps a lifecycle hook name (e.g. "pre-delete") to a script,
+	// relative to the profile directory unless absolute. These take
+	// precedence over the hooks in ~/.profile-manager.
+	Hooks map[string]string `json:"hooks,omitempty"`
+
+	// ContentHash is the hash of the generated files when the profile was
+	// last updated, so update --all can skip profiles that have not changed
+	ContentHash string `json:"contentHash,omitempty"`
+
+	// Overrides maps managed .env variables to custom values that update
+	// keeps instead of the template's, e.g. a shared KUBECONFIG
+	Overrides map[string]string `json:"overrides,omitempty"`
+
+	// ManagedHashes maps a file to the hash of its managed block as last
+	// written, to tell whether the user has edited it since
+	ManagedHashes map[string]string `json:"managedHashes,omitempty"`
+
+	// Legacy is set when the metadata was recovered from header comments
+	// rather than read from a .profile-meta file. It is never persisted.
+	Legacy bool `json:"-"`
+}
+
+// MetaPath returns the path to the metadata file for a profile directory
+func MetaPath(profileDir string) string {
+	return filepath.Join(profileDir, MetaFileName)
+}
+
+// ReadMeta reads the .profile-meta file from a profile directory.
+// The returned error satisfies os.IsNotExist if the file is missing.
+func ReadMeta(profileDir string) (*Meta, error) {
+	content, err := os.ReadFile(MetaPath(profileDir))
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Meta{}
+	if err := json.Unmarshal(content, meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MetaFileName, err)
+	}
+
+	return meta, nil
+}
+
+// WriteMeta writes the .profile-meta file to a profile directory
+func WriteMeta(profileDir string, meta *Meta) error {
+	content, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", MetaFileName, err)
+	}
+	content = append(content, '\n')
+
+	if err := os.WriteFile(MetaPath(profileDir), content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", MetaFileName, err)
+	}
+
+	return nil
+}
+
+// LoadMeta returns the metadata for a profile, preferring the .profile-meta
+// file and falling back to scraping the .envrc and README.md header comments
+// for profiles created before the metadata file existed.
+func LoadMeta(profileDir string) (*Meta, error) {
+	meta, err := ReadMeta(profileDir)
+	if err == nil {
+		return meta, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return legacyMeta(profileDir), nil
+}
+
+// legacyMeta recovers what it can from the header comments of .envrc and README.md
+func legacyMeta(profileDir string) *Meta {
+	meta := &Meta{
+		Name:          filepath.Base(profileDir),
+		SecretBackend: DefaultSecretBackend,
+		Legacy:        true,
+	}
+
+	// .envrc headers look like "# Template: work" and "# Created: ..."
+	if data, err := os.ReadFile(filepath.Join(profileDir, ".envrc")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := headerValue(line, "# Template:"); ok && meta.Template == "" {
+				meta.Template = value
+			}
+			if value, ok := headerValue(line, "# Created:"); ok && meta.Created == "" {
+				meta.Created = value
+			}
+			if value, ok := headerValue(line, "# Workspace profile:"); ok {
+				meta.Name = value
+			}
+		}
+	}
+
+	// README.md headers look like "Template: work" and "Created: ..."
+	if meta.Template == "" || meta.Created == "" {
+		if data, err := os.ReadFile(filepath.Join(profileDir, "README.md")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if value, ok := headerValue(line, "Template:"); ok && meta.Template == "" {
+					meta.Template = value
+				}
+				if value, ok := headerValue(line, "Created:"); ok && meta.Created == "" {
+					meta.Created = value
+				}
+			}
+		}
+	}
+
+	if meta.Template == "" {
+		meta.Template = "basic"
+	}
+
+	return meta
+}
+
+func headerValue(line, prefix string) (string, bool) {
+	if !strings.HasPrefix(line, prefix) {
+		return "", false
+	}
+	value := strings.TrimSpace(strings.TrimPrefix(line, prefix))
+	if value == "" {
+		return "", false
+	}
+	return value, true
+}
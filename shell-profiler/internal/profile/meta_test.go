@@ -0,0 +1,152 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteMeta_ReadMetaRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	want := &Meta{
+		SchemaVersion: 3,
+		Name:          "work",
+		Template:      "work",
+		Created:       "2024-11-29 14:30:45 UTC",
+		SecretBackend: DefaultSecretBackend,
+	}
+	if err := WriteMeta(tmpDir, want); err != nil {
+		t.Fatalf("WriteMeta() error: %v", err)
+	}
+
+	got, err := ReadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadMeta() error: %v", err)
+	}
+
+	if got.SchemaVersion != want.SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", got.SchemaVersion, want.SchemaVersion)
+	}
+	if got.Name != want.Name || got.Template != want.Template || got.Created != want.Created || got.SecretBackend != want.SecretBackend {
+		t.Errorf("ReadMeta() = %+v, want %+v", got, want)
+	}
+	if got.Legacy {
+		t.Error("metadata read from file should not be marked legacy")
+	}
+}
+
+func TestReadMeta_MissingFile(t *testing.T) {
+	_, err := ReadMeta(t.TempDir())
+	if !os.IsNotExist(err) {
+		t.Errorf("ReadMeta() error = %v, want not-exist error", err)
+	}
+}
+
+func TestReadMeta_InvalidJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(MetaPath(tmpDir), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadMeta(tmpDir); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestLoadMeta_PrefersMetaFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	envrc := "#!/usr/bin/env bash\n# Workspace profile: test\n# Template: personal\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMeta(tmpDir, &Meta{Name: "test", Template: "client"}); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := LoadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadMeta() error: %v", err)
+	}
+	if meta.Template != "client" {
+		t.Errorf("Template = %q, want client (from .profile-meta)", meta.Template)
+	}
+}
+
+func TestLoadMeta_LegacyEnvrcHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	envrc := `#!/usr/bin/env bash
+# Workspace profile: acme
+# Template: work
+# Created: 2024-01-02 03:04:05 UTC
+
+export WORKSPACE_PROFILE="acme"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := LoadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadMeta() error: %v", err)
+	}
+	if !meta.Legacy {
+		t.Error("expected legacy metadata")
+	}
+	if meta.SchemaVersion != 0 {
+		t.Errorf("SchemaVersion = %d, want 0 for legacy profiles", meta.SchemaVersion)
+	}
+	if meta.Name != "acme" {
+		t.Errorf("Name = %q, want acme", meta.Name)
+	}
+	if meta.Template != "work" {
+		t.Errorf("Template = %q, want work", meta.Template)
+	}
+	if meta.Created != "2024-01-02 03:04:05 UTC" {
+		t.Errorf("Created = %q, want 2024-01-02 03:04:05 UTC", meta.Created)
+	}
+}
+
+func TestLoadMeta_LegacyReadmeFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// .envrc with its header comments removed by the user
+	if err := os.WriteFile(filepath.Join(tmpDir, ".envrc"), []byte("export WORKSPACE_PROFILE=\"x\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	readme := "# Workspace Profile: x\n\nTemplate: personal\nCreated: 2023-05-06 07:08:09 UTC\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "README.md"), []byte(readme), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := LoadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadMeta() error: %v", err)
+	}
+	if meta.Template != "personal" {
+		t.Errorf("Template = %q, want personal", meta.Template)
+	}
+	if meta.Created != "2023-05-06 07:08:09 UTC" {
+		t.Errorf("Created = %q, want 2023-05-06 07:08:09 UTC", meta.Created)
+	}
+}
+
+func TestLoadMeta_LegacyDefaultsToBasic(t *testing.T) {
+	tmpDir := filepath.Join(t.TempDir(), "bare")
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := LoadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadMeta() error: %v", err)
+	}
+	if meta.Template != "basic" {
+		t.Errorf("Template = %q, want basic", meta.Template)
+	}
+	if meta.Name != "bare" {
+		t.Errorf("Name = %q, want bare (directory name)", meta.Name)
+	}
+}
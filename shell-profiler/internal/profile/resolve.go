@@ -0,0 +1,70 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/neverprepared/shell-profile-manager/internal/config"
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+// workspaceProfilePattern matches the WORKSPACE_PROFILE export in a profile's .envrc
+var workspaceProfilePattern = regexp.MustCompile(`(?m)^\s*export\s+WORKSPACE_PROFILE=["']?([^"'\s]+)["']?`)
+
+// ResolveProfile determines which profile a read-only command should act on.
+// An explicit --profile flag is resolved against the configured profiles
+// directory; otherwise the profile containing cwd is detected by walking up
+// to the nearest .envrc that exports WORKSPACE_PROFILE.
+func ResolveProfile(cwd, flag string) (name, dir string, err error) {
+	var profilesDir string
+	if flag != "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return "", "", err
+		}
+		profilesDir = cfg.ProfilesDir
+	}
+	return ResolveProfileIn(profilesDir, cwd, flag)
+}
+
+// ResolveProfileIn is like ResolveProfile but resolves --profile against the
+// given profiles directory
+func ResolveProfileIn(profilesDir, cwd, flag string) (name, dir string, err error) {
+	if flag != "" {
+		dir = filepath.Join(profilesDir, flag)
+		if _, err := os.Stat(filepath.Join(dir, ".envrc")); err != nil {
+			return "", "", errs.Wrapf(errs.ErrProfileNotFound, "profile '%s' does not exist at: %s", flag, dir)
+		}
+		return flag, dir, nil
+	}
+
+	if name, dir, ok := findEnclosingProfile(cwd); ok {
+		return name, dir, nil
+	}
+
+	return "", "", fmt.Errorf("no profile specified and %s is not inside a profile (use --profile NAME)", cwd)
+}
+
+// findEnclosingProfile walks up from dir looking for a profile .envrc
+func findEnclosingProfile(dir string) (string, string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", false
+	}
+
+	for {
+		if content, err := os.ReadFile(filepath.Join(dir, ".envrc")); err == nil {
+			if match := workspaceProfilePattern.FindSubmatch(content); match != nil {
+				return string(match[1]), dir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,93 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/errs"
+)
+
+func writeProfile(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	envrc := "#!/usr/bin/env bash\nexport WORKSPACE_PROFILE=\"" + name + "\"\nexport WORKSPACE_HOME=\"$PWD\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ".envrc"), []byte(envrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveProfile_ExplicitFlag(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	profilesDir := filepath.Join(home, "profiles")
+	if err := os.WriteFile(filepath.Join(home, ".profile-manager"), []byte("profiles_dir="+profilesDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeProfile(t, filepath.Join(profilesDir, "acme"), "acme")
+
+	// The flag wins even when cwd is somewhere unrelated
+	name, dir, err := ResolveProfile(t.TempDir(), "acme")
+	if err != nil {
+		t.Fatalf("ResolveProfile() error: %v", err)
+	}
+	if name != "acme" || dir != filepath.Join(profilesDir, "acme") {
+		t.Errorf("ResolveProfile() = %q, %q; want acme, %s", name, dir, filepath.Join(profilesDir, "acme"))
+	}
+
+	if _, _, err := ResolveProfile(t.TempDir(), "missing"); !errors.Is(err, errs.ErrProfileNotFound) {
+		t.Errorf("expected missing profile error, got: %v", err)
+	}
+}
+
+func TestResolveProfile_DetectsFromCwd(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	profileDir := filepath.Join(t.TempDir(), "somewhere", "client-x")
+	writeProfile(t, profileDir, "client-x")
+	nested := filepath.Join(profileDir, "code", "repo", "src")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	name, dir, err := ResolveProfile(nested, "")
+	if err != nil {
+		t.Fatalf("ResolveProfile() error: %v", err)
+	}
+	if name != "client-x" || dir != profileDir {
+		t.Errorf("ResolveProfile() = %q, %q; want client-x, %s", name, dir, profileDir)
+	}
+}
+
+func TestResolveProfile_SkipsUnrelatedEnvrc(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	profileDir := filepath.Join(t.TempDir(), "outer")
+	writeProfile(t, profileDir, "outer")
+
+	// A project .envrc without WORKSPACE_PROFILE is not a profile
+	project := filepath.Join(profileDir, "code", "app")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".envrc"), []byte("export FOO=bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, _, err := ResolveProfile(project, "")
+	if err != nil || name != "outer" {
+		t.Errorf("ResolveProfile() = %q, %v; want outer", name, err)
+	}
+}
+
+func TestResolveProfile_NotInProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, _, err := ResolveProfile(t.TempDir(), ""); err == nil {
+		t.Error("expected error outside of any profile")
+	}
+}
@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// tagPattern limits tags to a simple charset so they are safe to use as CLI
+// arguments and filters. Colons allow namespaced tags such as "team:platform".
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,63}$`)
+
+// ValidateTag checks that a tag uses only letters, digits, '_', '.', ':' and '-'
+func ValidateTag(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag '%s': must start with a letter or digit and contain only letters, digits, '_', '.', ':' or '-' (max 64 characters)", tag)
+	}
+	return nil
+}
+
+// HasTag reports whether the profile carries the given tag
+func (m *Meta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllTags reports whether the profile carries every one of the given tags
+func (m *Meta) HasAllTags(tags []string) bool {
+	for _, tag := range tags {
+		if !m.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddTags adds tags that are not already present, keeping the list sorted.
+// It returns the tags that were actually added.
+func (m *Meta) AddTags(tags ...string) []string {
+	var added []string
+	for _, tag := range tags {
+		if !m.HasTag(tag) {
+			m.Tags = append(m.Tags, tag)
+			added = append(added, tag)
+		}
+	}
+	sort.Strings(m.Tags)
+	return added
+}
+
+// RemoveTags removes the given tags and returns the ones that were present
+func (m *Meta) RemoveTags(tags ...string) []string {
+	var removed []string
+	kept := m.Tags[:0]
+	for _, t := range m.Tags {
+		drop := false
+		for _, tag := range tags {
+			if t == tag {
+				drop = true
+				break
+			}
+		}
+		if drop {
+			removed = append(removed, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	m.Tags = kept
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+	return removed
+}
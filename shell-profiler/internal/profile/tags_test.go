@@ -0,0 +1,64 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTag(t *testing.T) {
+	valid := []string{"client", "archived", "team:platform", "v1.2", "a_b-c", "X"}
+	for _, tag := range valid {
+		if err := ValidateTag(tag); err != nil {
+			t.Errorf("ValidateTag(%q) error: %v", tag, err)
+		}
+	}
+
+	invalid := []string{"", "-leading", "has space", "semi;colon", "slash/tag", strings.Repeat("a", 65)}
+	for _, tag := range invalid {
+		if err := ValidateTag(tag); err == nil {
+			t.Errorf("ValidateTag(%q) should fail", tag)
+		}
+	}
+}
+
+func TestMeta_AddRemoveTags(t *testing.T) {
+	meta := &Meta{}
+
+	added := meta.AddTags("work", "client", "work")
+	if strings.Join(added, ",") != "work,client" {
+		t.Errorf("AddTags() added = %v, want [work client]", added)
+	}
+	if strings.Join(meta.Tags, ",") != "client,work" {
+		t.Errorf("Tags = %v, want sorted [client work]", meta.Tags)
+	}
+	if added := meta.AddTags("client"); len(added) != 0 {
+		t.Errorf("re-adding an existing tag should be a no-op, added %v", added)
+	}
+
+	if !meta.HasAllTags([]string{"client", "work"}) || meta.HasAllTags([]string{"client", "other"}) {
+		t.Error("HasAllTags() returned the wrong result")
+	}
+
+	removed := meta.RemoveTags("client", "missing")
+	if strings.Join(removed, ",") != "client" {
+		t.Errorf("RemoveTags() removed = %v, want [client]", removed)
+	}
+	meta.RemoveTags("work")
+	if meta.Tags != nil {
+		t.Errorf("Tags = %v, want nil once empty", meta.Tags)
+	}
+}
+
+func TestMeta_TagsRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := WriteMeta(tmpDir, &Meta{Name: "x", Tags: []string{"client"}}); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := ReadMeta(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadMeta() error: %v", err)
+	}
+	if !meta.HasTag("client") {
+		t.Errorf("Tags = %v, want [client]", meta.Tags)
+	}
+}
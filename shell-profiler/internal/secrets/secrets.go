@@ -0,0 +1,224 @@
+// Package secrets describes the secret backends a profile's .envrc can load
+// secrets from
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Backend is a secret store a profile loads secrets from
+type Backend interface {
+	// Name is the identifier recorded as secretBackend in .profile-meta
+	Name() string
+
+	// SetupSteps lists what the user must do before secrets load for a
+	// profile, as commands or short instructions
+	SetupSteps(profileName string) []string
+}
+
+var backends = map[string]Backend{}
+
+// Register makes a backend available by name, replacing any with the same name
+func Register(b Backend) {
+	backends[b.Name()] = b
+}
+
+// Lookup returns the backend registered under name
+func Lookup(name string) (Backend, bool) {
+	b, ok := backends[name]
+	return b, ok
+}
+
+// Names returns the registered backend names, sorted
+func Names() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(OnePassword{})
+}
+
+// OnePassword loads every item in a per-profile 1Password vault, exporting
+// each field as TITLE_LABEL
+type OnePassword struct{}
+
+func (OnePassword) Name() string {
+	return "1password"
+}
+
+// VaultName is the vault the generated .envrc discovers secrets in
+func (OnePassword) VaultName(profileName string) string {
+	return "workspace-" + profileName
+}
+
+func (o OnePassword) SetupSteps(profileName string) []string {
+	vault := o.VaultName(profileName)
+	return []string{
+		"Sign in to the 1Password CLI (op and jq must be on PATH): op signin",
+		fmt.Sprintf("Create the profile's vault: op vault create %s", vault),
+		fmt.Sprintf("Add items to %s; each field is exported as TITLE_LABEL (item \"github\", field \"token\" -> GITHUB_TOKEN)", vault),
+		"Reload the profile: direnv reload",
+	}
+}
+
+// Provisioner is implemented by backends that can create a profile's secret
+// store. Provision is idempotent: it reports created=false if the store
+// already exists.
+type Provisioner interface {
+	Provision(profileName string) (created bool, err error)
+}
+
+var (
+	// ErrCLINotFound means the backend's CLI is not on PATH
+	ErrCLINotFound = errors.New("secret backend CLI not found")
+	// ErrNotAuthenticated means the backend's CLI is not signed in
+	ErrNotAuthenticated = errors.New("secret backend CLI is not signed in")
+)
+
+// Provision creates the profile's vault unless `op vault list` shows it
+// already exists
+func (o OnePassword) Provision(profileName string) (bool, error) {
+	if _, err := exec.LookPath("op"); err != nil {
+		return false, fmt.Errorf("%w: op", ErrCLINotFound)
+	}
+	if err := exec.Command("op", "whoami").Run(); err != nil {
+		return false, fmt.Errorf("%w: run 'op signin' first", ErrNotAuthenticated)
+	}
+
+	vault := o.VaultName(profileName)
+	output, err := exec.Command("op", "vault", "list", "--format", "json").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list 1Password vaults: %w", err)
+	}
+	var vaults []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &vaults); err != nil {
+		return false, fmt.Errorf("failed to parse 1Password vault list: %w", err)
+	}
+	for _, v := range vaults {
+		if v.Name == vault {
+			return false, nil
+		}
+	}
+
+	output, err = exec.Command("op", "vault", "create", vault).CombinedOutput()
+	if err != nil {
+		// Created concurrently since we listed
+		if strings.Contains(strings.ToLower(string(output)), "already exists") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create 1Password vault %s: %s", vault, strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
+
+// KeyOptions selects the secrets a profile's .envrc loads, as recorded in
+// its .profile-meta
+type KeyOptions struct {
+	Vaults        []string // In load order
+	Account       string   // op --account; the default account if empty
+	FieldPrefix   string   // Only fields whose label has this prefix
+	ExcludeFields []string // Field labels skipped, ignoring case
+}
+
+// KeyLister is implemented by backends that can list the names of the
+// variables a profile would load, without fetching their values for display
+type KeyLister interface {
+	Keys(opts KeyOptions) ([]string, error)
+}
+
+// nonAlnum matches the runs of characters a variable name cannot contain
+var nonAlnum = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// VarName is the variable the .envrc exports a 1Password field as: the item
+// title and field label joined, with every run of other characters turned
+// into a single underscore, in upper case
+func VarName(title, label string) string {
+	name := nonAlnum.ReplaceAllString(title+"_"+label, "_")
+	return strings.ToUpper(strings.Trim(name, "_"))
+}
+
+// opItem is the part of `op item get --format json` that decides which
+// fields are exported
+type opItem struct {
+	Title  string `json:"title"`
+	Fields []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+		Type  string `json:"type"`
+		Value any    `json:"value"`
+	} `json:"fields"`
+}
+
+// Keys lists the variables the generated .envrc would export from the
+// vaults, applying the same field rules. Values are read only to skip empty
+// fields and are never returned.
+func (o OnePassword) Keys(opts KeyOptions) ([]string, error) {
+	if _, err := exec.LookPath("op"); err != nil {
+		return nil, fmt.Errorf("%w: op", ErrCLINotFound)
+	}
+	opItemCmd := func(verb string, args ...string) ([]byte, error) {
+		args = append([]string{"item", verb}, args...)
+		if opts.Account != "" {
+			args = append(args, "--account", opts.Account)
+		}
+		return exec.Command("op", args...).Output()
+	}
+	excluded := map[string]bool{}
+	for _, label := range opts.ExcludeFields {
+		excluded[strings.ToLower(label)] = true
+	}
+
+	seen := map[string]bool{}
+	for _, vault := range opts.Vaults {
+		output, err := opItemCmd("list", "--vault", vault, "--format", "json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list items in 1Password vault %s (is op signed in?): %w", vault, err)
+		}
+		var items []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(output, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse 1Password item list: %w", err)
+		}
+
+		for _, ref := range items {
+			output, err := opItemCmd("get", ref.ID, "--format", "json")
+			if err != nil {
+				return nil, fmt.Errorf("failed to read 1Password item %s: %w", ref.ID, err)
+			}
+			var item opItem
+			if err := json.Unmarshal(output, &item); err != nil {
+				return nil, fmt.Errorf("failed to parse 1Password item %s: %w", ref.ID, err)
+			}
+			for _, field := range item.Fields {
+				if field.Value == nil || field.Value == "" || field.Label == "" || field.ID == "notesPlain" || field.Type == "OTP" {
+					continue
+				}
+				if !strings.HasPrefix(field.Label, opts.FieldPrefix) || excluded[strings.ToLower(field.Label)] {
+					continue
+				}
+				seen[VarName(item.Title, strings.TrimPrefix(field.Label, opts.FieldPrefix))] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
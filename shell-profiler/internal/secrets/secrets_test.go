@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookup_OnePassword(t *testing.T) {
+	b, ok := Lookup("1password")
+	if !ok {
+		t.Fatalf("1password backend not registered (have %v)", Names())
+	}
+	if _, ok := Lookup("vault-of-holding"); ok {
+		t.Error("Lookup of an unknown backend should fail")
+	}
+
+	steps := strings.Join(b.SetupSteps("acme"), "\n")
+	if !strings.Contains(steps, "op vault create workspace-acme") {
+		t.Errorf("guidance should say how to create the vault:\n%s", steps)
+	}
+	if !strings.Contains(steps, "Add items to workspace-acme") {
+		t.Errorf("guidance should name the vault to add items to:\n%s", steps)
+	}
+}
+
+// fakeOp puts an `op` on PATH that logs its arguments, lists the given vault
+// names and succeeds at whoami only when signedIn is set. It returns the log.
+func fakeOp(t *testing.T, signedIn bool, vaults ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "op.log")
+
+	var list []string
+	for _, v := range vaults {
+		list = append(list, `{"id":"x","name":"`+v+`"}`)
+	}
+	whoami := "exit 0"
+	if !signedIn {
+		whoami = `echo "[ERROR] account is not signed in" >&2; exit 1`
+	}
+	script := `#!/bin/sh
+echo "$*" >> "` + logPath + `"
+case "$1 $2" in
+"whoami "*) ` + whoami + ` ;;
+"vault list") echo '[` + strings.Join(list, ",") + `]' ;;
+"vault create") exit 0 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestOnePassword_ProvisionCreatesMissingVault(t *testing.T) {
+	logPath := fakeOp(t, true, "Personal")
+
+	created, err := OnePassword{}.Provision("acme")
+	if err != nil {
+		t.Fatalf("Provision() error: %v", err)
+	}
+	if !created {
+		t.Error("Provision() should report the vault as created")
+	}
+	if log := readLog(t, logPath); !strings.Contains(log, "vault create workspace-acme") {
+		t.Errorf("op vault create was not invoked:\n%s", log)
+	}
+}
+
+func TestOnePassword_ProvisionSkipsExistingVault(t *testing.T) {
+	logPath := fakeOp(t, true, "Personal", "workspace-acme")
+
+	created, err := OnePassword{}.Provision("acme")
+	if err != nil {
+		t.Fatalf("Provision() error: %v", err)
+	}
+	if created {
+		t.Error("Provision() should not report an existing vault as created")
+	}
+	if log := readLog(t, logPath); strings.Contains(log, "vault create") {
+		t.Errorf("op vault create should not run for an existing vault:\n%s", log)
+	}
+}
+
+func TestOnePassword_ProvisionNotSignedIn(t *testing.T) {
+	logPath := fakeOp(t, false)
+
+	_, err := OnePassword{}.Provision("acme")
+	if !errors.Is(err, ErrNotAuthenticated) {
+		t.Fatalf("Provision() error = %v, want ErrNotAuthenticated", err)
+	}
+	if log := readLog(t, logPath); strings.Contains(log, "vault") {
+		t.Errorf("no vault commands should run when not signed in:\n%s", log)
+	}
+}
+
+func TestOnePassword_ProvisionWithoutCLI(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if _, err := (OnePassword{}).Provision("acme"); !errors.Is(err, ErrCLINotFound) {
+		t.Fatalf("Provision() error = %v, want ErrCLINotFound", err)
+	}
+}
+
+func TestVarName(t *testing.T) {
+	for title, want := range map[string]string{
+		"github":       "GITHUB_API_TOKEN",
+		"Prod DB (eu)": "PROD_DB_EU_API_TOKEN",
+		"__x__":        "X_API_TOKEN",
+	} {
+		if got := VarName(title, "api token"); got != want {
+			t.Errorf("VarName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -2,9 +2,11 @@
 # Template: {{.Template}}
 #
 # This file is loaded by direnv via dotenv_if_exists in .envrc
-# Add tool-specific paths and non-secret config here (not in .envrc)
+# Add tool-specific paths and non-secret config here (not in .envrc),
+# outside the managed block: it is regenerated by shell-profiler update
 # Secrets are loaded automatically from 1Password vault (workspace-{{.ProfileName}})
 
+# >>> profile-manager managed
 # Git configuration
 GIT_CONFIG_GLOBAL="$WORKSPACE_HOME/.gitconfig"
 
@@ -50,3 +52,4 @@ CLAUDE_CONFIG_DIR="$WORKSPACE_HOME/.config/claude"
 # Gemini CLI configuration
 # Point Gemini CLI to workspace-specific config directory
 GEMINI_CONFIG_DIR="$WORKSPACE_HOME/.config/gemini"
+# <<< profile-manager managed
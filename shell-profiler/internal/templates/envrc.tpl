@@ -3,14 +3,22 @@
 # Template: {{.Template}}
 # Created: {{.CreatedAt}}
 
+# >>> profile-manager managed
 # Workspace identification
 export WORKSPACE_PROFILE="{{.ProfileName}}"
+{{if .LocatedHome -}}
+export WORKSPACE_HOME="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd -P)"
+{{- else -}}
 export WORKSPACE_HOME="$PWD"
+{{- end}}
 
 # Add custom bin directory to PATH (before system paths)
 # The bin/ssh wrapper uses the profile-specific SSH config
 # Git will automatically use bin/ssh since it's first in PATH
 PATH_add bin
+{{- range .PathAdd}}
+PATH_add {{quotePath .}}
+{{- end}}
 
 # Load global profile settings (exports only)
 # Environment variables work with direnv, aliases and functions do not
@@ -22,16 +30,39 @@ if [[ -d "$GLOBAL_DIR" ]]; then
     fi
 fi
 
+{{- if .Watch}}
+
+# Reload when .env changes. The secrets cache is not watched here: this file
+# rewrites it, and only when .env is newer or the cache has expired
+watch_file .env
+{{- end}}
+
 # Resolve profile environment (template .env + 1Password secrets)
 # Cached in volatile storage with configurable expiration
 _sp_cache="${TMPDIR:-/tmp}/sp-profiles/${WORKSPACE_PROFILE}"
+{{- if .EncryptCache}}
+# The cache is encrypted at rest with a per-user key (requires openssl)
+_sp_env="${_sp_cache}/.env.enc"
+_sp_key="$HOME/.config/profile-manager/cache.key"
+{{- else}}
 _sp_env="${_sp_cache}/.env"
+{{- end}}
 _sp_cache_hours="${SP_CACHE_HOURS:-2}"  # Default: 2 hours
+{{- if .IncludeTOTP}}
+# Items with a one-time password, as "VAR ITEM_ID" lines; the codes themselves
+# are never cached
+_sp_totp="${_sp_cache}/totp"
+{{- end}}
 
 # Check if cache exists and is fresh
 _refresh_cache=false
 if [ ! -f "$_sp_env" ]; then
     _refresh_cache=true
+{{- if .Watch}}
+elif [ .env -nt "$_sp_env" ]; then
+    # .env changed since the cache was built
+    _refresh_cache=true
+{{- end}}
 elif command -v stat &>/dev/null; then
     # Check cache age (in hours)
     if [[ "$OSTYPE" == "darwin"* ]]; then
@@ -51,45 +82,83 @@ fi
 
 if [ "$_refresh_cache" = true ]; then
     mkdir -p "$_sp_cache" && chmod 700 "$_sp_cache"
+{{- if .EncryptCache}}
+    # Build the plaintext in a temporary file, then encrypt it into the cache
+    _sp_enc="$_sp_env"
+    _sp_env="$(mktemp "${_sp_cache}/.env.XXXXXX")"
+{{- end}}
     # Start with template (tool paths, non-secret config)
     cp .env "$_sp_env"
-    # Append 1Password secrets
-    _op_vault="workspace-${WORKSPACE_PROFILE}"
+    # Append 1Password secrets, vault by vault so later vaults win on collision
+{{- if .OpAccount}}
+    _op_account={{shellQuote .OpAccount}}
+{{- end}}
+{{- if .IncludeTOTP}}
+    : > "$_sp_totp"
+{{- end}}
     if command -v op &>/dev/null && command -v jq &>/dev/null; then
-        _op_ids=$(op item list --vault "$_op_vault" --format json 2>/dev/null | jq -r '.[].id' 2>/dev/null)
-        if [ -n "$_op_ids" ]; then
-            # Start progress indicator (background process that prints dots)
-            (
-                while true; do
-                    printf "." >&2
-                    sleep 1
+        for _op_vault in {{.OpVaults}}; do
+            _op_ids=$(op item list {{if .OpAccount}}--account "$_op_account" {{end}}--vault "$_op_vault" --format json 2>/dev/null | jq -r '.[].id' 2>/dev/null)
+            if [ -n "$_op_ids" ]; then
+                log_status "Loading secrets from 1Password vault: $_op_vault"
+                echo "" >> "$_sp_env"
+                for _op_id in $_op_ids; do
+                    _op_item=$(op item get "$_op_id" {{if .OpAccount}}--account "$_op_account" {{end}}--format json 2>/dev/null)
+                    printf '%s\n' "$_op_item" | jq -r '
+                        .title as $t |
+                        .fields[] |
+                        select(.value != "" and .value != null and .label != "" and .label != null and .id != "notesPlain" and .type != "OTP"{{.OpFieldFilter}}) |
+                        ($t + "_" + {{.OpFieldLabel}} | gsub("[^A-Za-z0-9]"; "_") | gsub("_+"; "_") | gsub("^_|_$"; "") | ascii_upcase) + "=" + (.value | @sh)
+                    ' >> "$_sp_env" 2>/dev/null
+{{- if .IncludeTOTP}}
+                    printf '%s\n' "$_op_item" | jq -r --arg id "$_op_id" '
+                        select(any(.fields[]?; .type == "OTP")) |
+                        (.title + "_TOTP" | gsub("[^A-Za-z0-9]"; "_") | gsub("_+"; "_") | gsub("^_|_$"; "") | ascii_upcase) + " " + $id
+                    ' >> "$_sp_totp" 2>/dev/null
+{{- end}}
                 done
-            ) &
-            _progress_pid=$!
-
-            echo "" >> "$_sp_env"
-            for _op_id in $_op_ids; do
-                op item get "$_op_id" --format json 2>/dev/null | jq -r '
-                    .title as $t |
-                    .fields[] |
-                    select(.value != "" and .value != null and .label != "" and .label != null and .id != "notesPlain" and .type != "OTP") |
-                    ($t + "_" + .label | gsub("[^A-Za-z0-9]"; "_") | gsub("_+"; "_") | gsub("^_|_$"; "") | ascii_upcase) + "=" + (.value | @sh)
-                ' >> "$_sp_env" 2>/dev/null
-            done
 
-            # Stop progress indicator
-            kill $_progress_pid 2>/dev/null
-            wait $_progress_pid 2>/dev/null
-            printf "\n" >&2
-
-            log_status "Loaded secrets from 1Password vault: $_op_vault"
-        fi
+                log_status "Loaded secrets from 1Password vault: $_op_vault"
+            fi
+        done
     fi
     chmod 600 "$_sp_env"
+{{- if .EncryptCache}}
+    if command -v openssl &>/dev/null; then
+        if [ ! -f "$_sp_key" ]; then
+            mkdir -p "$(dirname "$_sp_key")"
+            (umask 077 && openssl rand -hex 32 > "$_sp_key")
+        fi
+        openssl enc -aes-256-cbc -pbkdf2 -salt -pass "file:$_sp_key" -in "$_sp_env" -out "$_sp_enc" && chmod 600 "$_sp_enc"
+    else
+        log_error "openssl not found; cannot encrypt the secrets cache"
+    fi
+    rm -f "$_sp_env"
+    _sp_env="$_sp_enc"
+{{- end}}
 fi
 
 # Load the resolved environment (template + secrets)
+{{- if .EncryptCache}}
+# Decrypted through a pipe so the plaintext never touches disk. dotenv only
+# accepts a regular file, so this does what it does with direnv itself
+if [ -f "$_sp_env" ] && command -v openssl &>/dev/null; then
+    eval "$(openssl enc -d -aes-256-cbc -pbkdf2 -pass "file:$_sp_key" -in "$_sp_env" | "$direnv" dotenv bash /dev/stdin)"
+fi
+{{- else}}
 dotenv_if_exists "$_sp_env"
+{{- end}}
+{{- if .IncludeTOTP}}
+
+# Export each item's current one-time password as <TITLE>_TOTP, fetched on
+# every load since a code is only valid for about 30 seconds
+if [ -f "$_sp_totp" ] && command -v op &>/dev/null; then
+    while read -r _op_totp_var _op_id; do
+        _op_totp=$(op item get "$_op_id" {{if .OpAccount}}--account {{shellQuote .OpAccount}} {{end}}--otp 2>/dev/null) &&
+            export "$_op_totp_var=$_op_totp"
+    done < "$_sp_totp"
+fi
+{{- end}}
 
 # Load local overrides
 dotenv_if_exists .envrc.local
@@ -97,29 +166,7 @@ dotenv_if_exists .envrc.local
 # ============================================================================
 # WELCOME MESSAGE
 # ============================================================================
-log_status "Loaded workspace profile: $WORKSPACE_PROFILE"
-echo "   CLAUDE_CONFIG_DIR: $CLAUDE_CONFIG_DIR"
-echo "   Orchestration: Available"
-echo "   AWS Config: $AWS_CONFIG_FILE"
-echo "   Kubeconfig: $KUBECONFIG"
+{{template "welcome" .}}
 
-# Set iTerm2 tab color{{if eq .Template "personal"}} (blue #19baff){{else if eq .Template "work"}} (green #28c940){{else if eq .Template "client"}} (orange #ff9500){{else}} (gray #7e7f80){{end}}
-if [[ "$TERM_PROGRAM" == "iTerm.app" ]]; then
-{{if eq .Template "personal"}}  # Personal: Blue (#19baff)
-  echo -ne "\033]6;1;bg;red;brightness;25\a"
-  echo -ne "\033]6;1;bg;green;brightness;186\a"
-  echo -ne "\033]6;1;bg;blue;brightness;255\a"
-{{else if eq .Template "work"}}  # Work: Green (#28c940)
-  echo -ne "\033]6;1;bg;red;brightness;40\a"
-  echo -ne "\033]6;1;bg;green;brightness;201\a"
-  echo -ne "\033]6;1;bg;blue;brightness;64\a"
-{{else if eq .Template "client"}}  # Client: Orange (#ff9500)
-  echo -ne "\033]6;1;bg;red;brightness;255\a"
-  echo -ne "\033]6;1;bg;green;brightness;149\a"
-  echo -ne "\033]6;1;bg;blue;brightness;0\a"
-{{else}}  # Basic: Gray (#7e7f80)
-  echo -ne "\033]6;1;bg;red;brightness;126\a"
-  echo -ne "\033]6;1;bg;green;brightness;127\a"
-  echo -ne "\033]6;1;bg;blue;brightness;128\a"
-{{end}}  echo -ne "\033]1;[$WORKSPACE_PROFILE]\007"
-fi
+{{template "colors" .}}
+# <<< profile-manager managed
@@ -1,5 +1,10 @@
 # Git configuration for workspace profile: {{.ProfileName}}
 # Template: {{.Template}}
+{{- range .Includes}}
+
+[include]
+    path = {{.}}
+{{- end}}
 
 [user]
     name = {{.GitName}}
@@ -11,7 +16,7 @@
     whitespace = trailing-space,space-before-tab
 
 [init]
-    defaultBranch = main
+    defaultBranch = {{.DefaultBranch}}
 
 [push]
     default = current
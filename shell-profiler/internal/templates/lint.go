@@ -0,0 +1,198 @@
+package templates
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// dataTypes is the data each template file is rendered with
+var dataTypes = map[string]reflect.Type{
+	EnvrcFile:     reflect.TypeOf(EnvrcData{}),
+	EnvFile:       reflect.TypeOf(EnvData{}),
+	GitconfigFile: reflect.TypeOf(GitconfigData{}),
+}
+
+// LintTemplate parses a template file and checks that every field it
+// references exists on the data it is rendered with. name is the file name
+// (envrc.tpl, env.tpl or gitconfig.tpl), optionally prefixed with the
+// template name, and is used in error messages.
+func LintTemplate(name, content string) error {
+	file := name[strings.LastIndex(name, "/")+1:]
+	dataType, ok := dataTypes[file]
+	if !ok {
+		return fmt.Errorf("%s: unknown template file (expected one of %s, %s, %s)", name, EnvrcFile, EnvFile, GitconfigFile)
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(content)
+	if err != nil {
+		return fmt.Errorf("%s has a syntax error: %w", name, err)
+	}
+
+	l := &linter{root: dataType}
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		l.tree = t.Tree
+		l.walk(t.Tree.Root, dataType)
+		if l.err != nil {
+			return l.err
+		}
+	}
+	return nil
+}
+
+// linter walks a parse tree tracking the type of dot. A nil type means dot
+// is unknown (inside range, or after a non-struct value) and is not checked.
+type linter struct {
+	tree *parse.Tree
+	root reflect.Type
+	err  error
+}
+
+func (l *linter) walk(node parse.Node, dot reflect.Type) {
+	if l.err != nil || node == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		l.walk(n.Pipe, dot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			l.walk(cmd, dot)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			l.walk(arg, dot)
+		}
+	case *parse.FieldNode:
+		l.checkFields(n, dot, n.Ident)
+	case *parse.VariableNode:
+		// $.Field refers to the top-level data
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			l.checkFields(n, l.root, n.Ident[1:])
+		}
+	case *parse.IfNode:
+		l.walk(n.Pipe, dot)
+		l.walk(n.List, dot)
+		l.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		l.walk(n.Pipe, dot)
+		l.walk(n.List, l.pipeType(n.Pipe, dot))
+		l.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		l.walk(n.Pipe, dot)
+		l.walk(n.List, nil)
+		l.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		l.walk(n.Pipe, dot)
+	}
+}
+
+// checkFields follows a chain of field or method names from typ, failing on
+// the first that does not exist
+func (l *linter) checkFields(node parse.Node, typ reflect.Type, idents []string) {
+	for _, ident := range idents {
+		if typ == nil || typ.Kind() != reflect.Struct {
+			return
+		}
+		if method, ok := typ.MethodByName(ident); ok {
+			typ = nil
+			if method.Type.NumOut() > 0 {
+				typ = method.Type.Out(0)
+			}
+			continue
+		}
+		field, ok := typ.FieldByName(ident)
+		if !ok {
+			location, _ := l.tree.ErrorContext(node)
+			l.err = fmt.Errorf("%s: unknown field .%s (available: %s)", location, ident, strings.Join(fieldNames(typ), ", "))
+			return
+		}
+		typ = field.Type
+	}
+}
+
+// pipeType is the type a with block sets dot to, when it is a plain field
+func (l *linter) pipeType(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok {
+		return nil
+	}
+	typ := dot
+	for _, ident := range field.Ident {
+		if typ == nil || typ.Kind() != reflect.Struct {
+			return nil
+		}
+		f, ok := typ.FieldByName(ident)
+		if !ok {
+			return nil
+		}
+		typ = f.Type
+	}
+	return typ
+}
+
+// fieldNames lists the exported fields and methods of a struct, including
+// promoted ones
+func fieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for _, name := range fieldNames(f.Type) {
+				if _, promoted := typ.MethodByName(name); !promoted {
+					names = append(names, name)
+				}
+			}
+			continue
+		}
+		if f.IsExported() {
+			names = append(names, f.Name)
+		}
+	}
+	for i := 0; i < typ.NumMethod(); i++ {
+		names = append(names, typ.Method(i).Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lint checks every file a template provides in the search directories.
+// Embedded files are not checked: they are tested with the package.
+func (s *Source) Lint(templateName string) error {
+	var problems []string
+	for _, file := range []string{EnvrcFile, EnvFile, GitconfigFile} {
+		content, origin, err := s.Lookup(templateName, file)
+		if err != nil {
+			return err
+		}
+		if origin == "embedded" {
+			continue
+		}
+		if err := LintTemplate(templateName+"/"+file, content); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
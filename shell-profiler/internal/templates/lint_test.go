@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintTemplate_Valid(t *testing.T) {
+	for file, content := range embedded {
+		if err := LintTemplate(file, content); err != nil {
+			t.Errorf("embedded %s should lint clean: %v", file, err)
+		}
+	}
+
+	content := "# {{.ProfileName}}\n{{with .Template}}T={{.}}{{end}}\n{{if $.EncryptCache}}enc{{end}}\n"
+	if err := LintTemplate("team/"+EnvrcFile, content); err != nil {
+		t.Errorf("LintTemplate() error: %v", err)
+	}
+}
+
+func TestLintTemplate_UnknownField(t *testing.T) {
+	content := "[user]\n    name = {{.GitName}}\n    email = {{.GitEmial}}\n"
+
+	err := LintTemplate("team/"+GitconfigFile, content)
+	if err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+	for _, want := range []string{"team/gitconfig.tpl:3", ".GitEmial", "GitEmail"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+
+	// Fields valid for one file are not valid for another
+	if err := LintTemplate(EnvFile, "{{.GitName}}"); err == nil {
+		t.Error("env.tpl is not rendered with GitName")
+	}
+	if err := LintTemplate(EnvrcFile, "{{$.Nope}}"); err == nil {
+		t.Error("expected an error for an unknown $ field")
+	}
+}
+
+func TestLintTemplate_SyntaxError(t *testing.T) {
+	err := LintTemplate("team/"+EnvFile, "X={{.ProfileName}\n")
+	if err == nil || !strings.Contains(err.Error(), "team/env.tpl has a syntax error") {
+		t.Errorf("expected a syntax error naming the template, got %v", err)
+	}
+}
+
+func TestSource_RenderLintsUserTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "team", EnvFile, "PROFILE={{.ProfileNam}}\n")
+
+	source := NewSource(dir)
+	_, err := source.RenderEnv("acme", "team")
+	if err == nil || !strings.Contains(err.Error(), "unknown field .ProfileNam") {
+		t.Errorf("RenderEnv() error = %v, want the lint error", err)
+	}
+	if err := source.Lint("team"); err == nil {
+		t.Error("Lint() should report the bad field")
+	}
+	if err := source.Lint("basic"); err != nil {
+		t.Errorf("Lint() of a built-in template: %v", err)
+	}
+}
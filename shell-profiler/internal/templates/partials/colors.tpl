@@ -0,0 +1,20 @@
+# Set iTerm2 tab color{{if eq .Template "personal"}} (blue #19baff){{else if eq .Template "work"}} (green #28c940){{else if eq .Template "client"}} (orange #ff9500){{else}} (gray #7e7f80){{end}}
+if [[ "$TERM_PROGRAM" == "iTerm.app" ]]; then
+{{if eq .Template "personal"}}  # Personal: Blue (#19baff)
+  echo -ne "\033]6;1;bg;red;brightness;25\a"
+  echo -ne "\033]6;1;bg;green;brightness;186\a"
+  echo -ne "\033]6;1;bg;blue;brightness;255\a"
+{{else if eq .Template "work"}}  # Work: Green (#28c940)
+  echo -ne "\033]6;1;bg;red;brightness;40\a"
+  echo -ne "\033]6;1;bg;green;brightness;201\a"
+  echo -ne "\033]6;1;bg;blue;brightness;64\a"
+{{else if eq .Template "client"}}  # Client: Orange (#ff9500)
+  echo -ne "\033]6;1;bg;red;brightness;255\a"
+  echo -ne "\033]6;1;bg;green;brightness;149\a"
+  echo -ne "\033]6;1;bg;blue;brightness;0\a"
+{{else}}  # Basic: Gray (#7e7f80)
+  echo -ne "\033]6;1;bg;red;brightness;126\a"
+  echo -ne "\033]6;1;bg;green;brightness;127\a"
+  echo -ne "\033]6;1;bg;blue;brightness;128\a"
+{{end}}  echo -ne "\033]1;[$WORKSPACE_PROFILE]\007"
+fi
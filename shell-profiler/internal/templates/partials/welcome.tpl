@@ -0,0 +1,5 @@
+log_status "Loaded workspace profile: $WORKSPACE_PROFILE"
+echo "   CLAUDE_CONFIG_DIR: $CLAUDE_CONFIG_DIR"
+echo "   Orchestration: Available"
+echo "   AWS Config: $AWS_CONFIG_FILE"
+echo "   Kubeconfig: $KUBECONFIG"
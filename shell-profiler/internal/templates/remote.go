@@ -0,0 +1,159 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remotePrefix marks a template spec as a git source, e.g.
+// git+https://github.com/acme/templates#golang
+const remotePrefix = "git+"
+
+// DefaultRemoteTTL is how long a cached template repository is used before
+// it is fetched again
+const DefaultRemoteTTL = 24 * time.Hour
+
+// RemoteOptions controls how remote template repositories are cached
+type RemoteOptions struct {
+	CacheDir string        // Defaults to DefaultCacheDir()
+	TTL      time.Duration // Defaults to DefaultRemoteTTL
+	Refresh  bool          // Fetch even if the cache is still fresh
+}
+
+// Fetched is a remote template repository available on disk
+type Fetched struct {
+	Dir string
+
+	// FetchErr is set when updating the repository failed and a previously
+	// cached copy is being used instead
+	FetchErr error
+}
+
+// IsRemote reports whether a template spec refers to a git repository
+func IsRemote(spec string) bool {
+	return strings.HasPrefix(spec, remotePrefix)
+}
+
+// ParseRemote splits a git+<url>#<name> spec into the repository URL and the
+// template name inside it
+func ParseRemote(spec string) (url, name string, err error) {
+	if !IsRemote(spec) {
+		return "", "", fmt.Errorf("not a remote template: %s", spec)
+	}
+
+	url, name, found := strings.Cut(strings.TrimPrefix(spec, remotePrefix), "#")
+	if !found || name == "" {
+		return "", "", fmt.Errorf("remote template %s must name a template after '#', e.g. git+https://host/repo#golang", spec)
+	}
+	if url == "" {
+		return "", "", fmt.Errorf("remote template %s is missing a repository URL", spec)
+	}
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", "", fmt.Errorf("invalid template name in %s: %s", spec, name)
+	}
+
+	return url, name, nil
+}
+
+// DefaultCacheDir returns $XDG_CACHE_HOME/profile-manager/templates
+// (default ~/.cache/profile-manager/templates)
+func DefaultCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		cacheHome = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheHome, "profile-manager", "templates")
+}
+
+// FetchRemote makes the repository at url available in the cache, cloning it
+// on first use and fetching it again once the cached copy is older than the
+// TTL (or when Refresh is set). If fetching fails but a cached copy exists,
+// the cached copy is returned with FetchErr set.
+func FetchRemote(url string, opts RemoteOptions) (*Fetched, error) {
+	if opts.CacheDir == "" {
+		opts.CacheDir = DefaultCacheDir()
+	}
+	if opts.TTL == 0 {
+		opts.TTL = DefaultRemoteTTL
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])[:16]
+	repoDir := filepath.Join(opts.CacheDir, key)
+	stampPath := repoDir + ".fetched"
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
+		if !opts.Refresh {
+			if info, err := os.Stat(stampPath); err == nil && time.Since(info.ModTime()) < opts.TTL {
+				return &Fetched{Dir: repoDir}, nil
+			}
+		}
+
+		if err := updateRepo(repoDir); err != nil {
+			return &Fetched{Dir: repoDir, FetchErr: fmt.Errorf("failed to update %s: %w", url, err)}, nil
+		}
+		touch(stampPath)
+		return &Fetched{Dir: repoDir}, nil
+	}
+
+	if err := os.MkdirAll(opts.CacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create template cache: %w", err)
+	}
+
+	// Clone next to the final location so a failed clone leaves no partial cache
+	tmpDir, err := os.MkdirTemp(opts.CacheDir, key+".tmp-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template cache: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := runGit("", "clone", "--quiet", "--depth", "1", url, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	os.RemoveAll(repoDir)
+	if err := os.Rename(tmpDir, repoDir); err != nil {
+		return nil, fmt.Errorf("failed to cache %s: %w", url, err)
+	}
+	touch(stampPath)
+
+	return &Fetched{Dir: repoDir}, nil
+}
+
+func updateRepo(repoDir string) error {
+	if err := runGit(repoDir, "fetch", "--quiet", "--depth", "1", "origin"); err != nil {
+		return err
+	}
+	return runGit(repoDir, "reset", "--quiet", "--hard", "FETCH_HEAD")
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Never block on a credential prompt
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func touch(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		os.WriteFile(path, nil, 0644) //nolint:errcheck // A missing stamp only causes an extra fetch
+	}
+}
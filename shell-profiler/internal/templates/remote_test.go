@@ -0,0 +1,176 @@
+package templates
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTemplateRepo creates a local git repository to stand in for a remote
+// template source and returns its file:// URL
+func newTemplateRepo(t *testing.T) (dir, url string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir = t.TempDir()
+	gitRun(t, dir, "init", "--quiet")
+	commitTemplate(t, dir, "# v1\n")
+	return dir, "file://" + dir
+}
+
+func commitTemplate(t *testing.T, repoDir, content string) {
+	t.Helper()
+	writeTemplateFile(t, repoDir, "golang", EnvFile, content)
+	gitRun(t, repoDir, "add", "-A")
+	gitRun(t, repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "update")
+}
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
+
+func readCachedEnv(t *testing.T, fetched *Fetched) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fetched.Dir, "golang", EnvFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseRemote(t *testing.T) {
+	url, name, err := ParseRemote("git+https://github.com/acme/templates#golang")
+	if err != nil {
+		t.Fatalf("ParseRemote() error: %v", err)
+	}
+	if url != "https://github.com/acme/templates" || name != "golang" {
+		t.Errorf("ParseRemote() = %q, %q", url, name)
+	}
+
+	for _, spec := range []string{
+		"basic",
+		"git+https://github.com/acme/templates",
+		"git+https://github.com/acme/templates#",
+		"git+#golang",
+		"git+https://github.com/acme/templates#../etc",
+	} {
+		if _, _, err := ParseRemote(spec); err == nil {
+			t.Errorf("ParseRemote(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestFetchRemote_ClonesIntoCache(t *testing.T) {
+	_, url := newTemplateRepo(t)
+	cacheDir := t.TempDir()
+
+	fetched, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	if !strings.HasPrefix(fetched.Dir, cacheDir) {
+		t.Errorf("Dir = %q, want under %q", fetched.Dir, cacheDir)
+	}
+	if got := readCachedEnv(t, fetched); got != "# v1\n" {
+		t.Errorf("cached env.tpl = %q", got)
+	}
+	if !NewSource(fetched.Dir).Exists("golang") {
+		t.Error("cached checkout not usable as a template source")
+	}
+}
+
+func TestFetchRemote_ReusesFreshCache(t *testing.T) {
+	repoDir, url := newTemplateRepo(t)
+	cacheDir := t.TempDir()
+
+	if _, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir}); err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	commitTemplate(t, repoDir, "# v2\n")
+
+	fetched, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir})
+	if err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	if got := readCachedEnv(t, fetched); got != "# v1\n" {
+		t.Errorf("cache within TTL should not be refetched, got %q", got)
+	}
+
+	// Once the TTL has passed the cache is updated
+	fetched, err = FetchRemote(url, RemoteOptions{CacheDir: cacheDir, TTL: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	if got := readCachedEnv(t, fetched); got != "# v2\n" {
+		t.Errorf("expired cache not refetched, got %q", got)
+	}
+}
+
+func TestFetchRemote_Refresh(t *testing.T) {
+	repoDir, url := newTemplateRepo(t)
+	cacheDir := t.TempDir()
+
+	if _, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir}); err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	commitTemplate(t, repoDir, "# v2\n")
+
+	fetched, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir, Refresh: true})
+	if err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	if fetched.FetchErr != nil {
+		t.Errorf("unexpected FetchErr: %v", fetched.FetchErr)
+	}
+	if got := readCachedEnv(t, fetched); got != "# v2\n" {
+		t.Errorf("refresh did not update cache, got %q", got)
+	}
+}
+
+func TestFetchRemote_FallsBackToCache(t *testing.T) {
+	repoDir, url := newTemplateRepo(t)
+	cacheDir := t.TempDir()
+
+	if _, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir}); err != nil {
+		t.Fatalf("FetchRemote() error: %v", err)
+	}
+	if err := os.RemoveAll(repoDir); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err := FetchRemote(url, RemoteOptions{CacheDir: cacheDir, Refresh: true})
+	if err != nil {
+		t.Fatalf("FetchRemote() should fall back to the cache, got: %v", err)
+	}
+	if fetched.FetchErr == nil {
+		t.Error("FetchErr should report the failed update")
+	}
+	if got := readCachedEnv(t, fetched); got != "# v1\n" {
+		t.Errorf("cached env.tpl = %q", got)
+	}
+}
+
+func TestFetchRemote_CloneFailure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	cacheDir := t.TempDir()
+
+	_, err := FetchRemote("file://"+filepath.Join(t.TempDir(), "missing"), RemoteOptions{CacheDir: cacheDir})
+	if err == nil || !strings.Contains(err.Error(), "failed to clone") {
+		t.Fatalf("expected clone error, got: %v", err)
+	}
+	entries, _ := os.ReadDir(cacheDir)
+	if len(entries) != 0 {
+		t.Errorf("failed clone left %d entries in the cache", len(entries))
+	}
+}
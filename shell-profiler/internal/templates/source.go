@@ -0,0 +1,282 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+)
+
+// Template file names, looked up as <dir>/<template-name>/<file> in each
+// search directory before falling back to the embedded copy
+const (
+	EnvrcFile     = "envrc.tpl"
+	EnvFile       = "env.tpl"
+	GitconfigFile = "gitconfig.tpl"
+)
+
+// PartialsDir is the subdirectory of a template directory holding partials:
+// <dir>/partials/<name>.tpl is included as {{template "<name>" .}} from any
+// template file. It is not itself a template.
+const PartialsDir = "partials"
+
+var embedded = map[string]string{
+	EnvrcFile:     envrcTemplate,
+	EnvFile:       envTemplate,
+	GitconfigFile: gitconfigTemplate,
+}
+
+// Source resolves template files from an ordered list of directories, falling
+// back to the embedded templates. A template is a subdirectory named after it;
+// any file it does not provide comes from the next source in line.
+type Source struct {
+	dirs  []string
+	clock clock.Clock
+}
+
+// NewSource returns a source searching dirs in order. Empty entries are ignored,
+// so NewSource() renders from the embedded templates only.
+func NewSource(dirs ...string) *Source {
+	s := &Source{}
+	for _, dir := range dirs {
+		if dir != "" {
+			s.dirs = append(s.dirs, dir)
+		}
+	}
+	return s
+}
+
+// WithClock sets the time source for the creation time stamped in rendered
+// headers, the system clock by default, and returns s
+func (s *Source) WithClock(c clock.Clock) *Source {
+	s.clock = c
+	return s
+}
+
+// DefaultSource searches templateDir (from --template-dir or the template_dir
+// config key), then the user template directory, then the embedded templates
+func DefaultSource(templateDir string) *Source {
+	return NewSource(templateDir, UserTemplateDir())
+}
+
+// UserTemplateDir returns the per-user template directory,
+// $XDG_CONFIG_HOME/profile-manager/templates (default ~/.config/...)
+func UserTemplateDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "profile-manager", "templates")
+}
+
+// Dirs returns the directories searched before the embedded templates
+func (s *Source) Dirs() []string {
+	return append([]string(nil), s.dirs...)
+}
+
+// Exists reports whether a template is built in or provided by a search directory
+func (s *Source) Exists(name string) bool {
+	if IsValid(name) {
+		return true
+	}
+	if name == "" || name != filepath.Base(name) || name == PartialsDir {
+		return false
+	}
+	for _, dir := range s.dirs {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the built-in templates followed by any additional templates
+// found in the search directories, sorted by name
+func (s *Source) Names() []string {
+	names := Names()
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+
+	var custom []string
+	for _, dir := range s.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !seen[entry.Name()] && entry.Name() != PartialsDir {
+				seen[entry.Name()] = true
+				custom = append(custom, entry.Name())
+			}
+		}
+	}
+	sort.Strings(custom)
+
+	return append(names, custom...)
+}
+
+// Lookup returns the contents of a template file and where it came from
+// ("embedded" for the built-in copy)
+func (s *Source) Lookup(templateName, file string) (content, origin string, err error) {
+	for _, dir := range s.dirs {
+		path := filepath.Join(dir, templateName, file)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return string(data), path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+	}
+
+	content, ok := embedded[file]
+	if !ok {
+		return "", "", fmt.Errorf("unknown template file: %s", file)
+	}
+	return content, "embedded", nil
+}
+
+// Partials returns the partials available to template files by name. Those in
+// search directories take precedence over the built-in ones, in search order.
+func (s *Source) Partials() (map[string]string, error) {
+	partials := make(map[string]string)
+
+	entries, err := embeddedPartials.ReadDir(PartialsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in partials: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := embeddedPartials.ReadFile(PartialsDir + "/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in partial %s: %w", entry.Name(), err)
+		}
+		partials[partialName(entry.Name())] = trimPartial(data)
+	}
+
+	for i := len(s.dirs) - 1; i >= 0; i-- {
+		paths, err := filepath.Glob(filepath.Join(s.dirs[i], PartialsDir, "*.tpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read partial %s: %w", path, err)
+			}
+			partials[partialName(path)] = trimPartial(data)
+		}
+	}
+	return partials, nil
+}
+
+func partialName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".tpl")
+}
+
+// trimPartial drops the file's final newline so an include on a line of its
+// own renders the partial's lines without an extra blank one
+func trimPartial(data []byte) string {
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+func (s *Source) render(templateName, file string, data interface{}) (string, error) {
+	text, origin, err := s.Lookup(templateName, file)
+	if err != nil {
+		return "", err
+	}
+
+	// User templates are linted first so a typo names the template and field
+	// rather than failing deep in the render
+	if origin != "embedded" {
+		if err := LintTemplate(templateName+"/"+file, text); err != nil {
+			return "", fmt.Errorf("invalid template (%s): %w", origin, err)
+		}
+	}
+
+	partials, err := s.Partials()
+	if err != nil {
+		return "", err
+	}
+	tmpl := template.New(file).Funcs(funcs)
+	for name, partial := range partials {
+		if _, err := tmpl.New(name).Parse(partial); err != nil {
+			return "", fmt.Errorf("failed to parse partial %s: %w", name, err)
+		}
+	}
+
+	if _, err := tmpl.Parse(text); err != nil {
+		return "", fmt.Errorf("failed to parse %s template (%s): %w", file, origin, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template (%s): %w", file, origin, err)
+	}
+
+	return buf.String(), nil
+}
+
+// RenderEnvrc renders the .envrc template with the provided data
+func (s *Source) RenderEnvrc(profileName, templateType string) (string, error) {
+	return s.RenderEnvrcWith(profileName, templateType, EnvrcOptions{})
+}
+
+// RenderEnvrcWith renders the .envrc template with optional features enabled
+func (s *Source) RenderEnvrcWith(profileName, templateType string, opts EnvrcOptions) (string, error) {
+	created := opts.Created
+	if created.IsZero() {
+		created = clock.Or(s.clock).Now()
+	}
+	return s.render(templateType, EnvrcFile, EnvrcData{
+		ProfileName:  profileName,
+		Template:     templateType,
+		CreatedAt:    created.UTC().Format(CreatedAtLayout),
+		EnvrcOptions: opts,
+	})
+}
+
+// RenderEnv renders the .env template with the provided data
+func (s *Source) RenderEnv(profileName, templateType string) (string, error) {
+	return s.render(templateType, EnvFile, EnvData{
+		ProfileName: profileName,
+		Template:    templateType,
+	})
+}
+
+// RenderGitconfig renders the .gitconfig template with the provided data
+func (s *Source) RenderGitconfig(profileName, templateType, gitName, gitEmail string) (string, error) {
+	return s.RenderGitconfigWith(profileName, templateType, gitName, gitEmail, GitconfigOptions{})
+}
+
+// RenderGitconfigWith renders the .gitconfig template with optional features enabled
+func (s *Source) RenderGitconfigWith(profileName, templateType, gitName, gitEmail string, opts GitconfigOptions) (string, error) {
+	// Default values if not provided
+	if gitName == "" {
+		gitName = DefaultGitName
+	}
+	if gitEmail == "" {
+		gitEmail = DefaultGitEmail
+	}
+	if opts.DefaultBranch == "" {
+		opts.DefaultBranch = DefaultBranchName
+	}
+
+	return s.render(templateType, GitconfigFile, GitconfigData{
+		ProfileName:      profileName,
+		Template:         templateType,
+		GitName:          gitName,
+		GitEmail:         gitEmail,
+		GitconfigOptions: opts,
+	})
+}
@@ -0,0 +1,557 @@
+package templates
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/clock"
+)
+
+func writeTemplateFile(t *testing.T, dir, name, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSource_LoadsCustomTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "golang", EnvFile, "# golang env for {{.ProfileName}}\nGOPATH=\"$WORKSPACE_HOME/go\"\n")
+
+	source := NewSource(dir)
+	if !source.Exists("golang") {
+		t.Fatal("Exists(golang) = false, want true")
+	}
+
+	env, err := source.RenderEnv("svc", "golang")
+	if err != nil {
+		t.Fatalf("RenderEnv() error: %v", err)
+	}
+	if !strings.Contains(env, "# golang env for svc") || !strings.Contains(env, "GOPATH=") {
+		t.Errorf("custom env.tpl not used:\n%s", env)
+	}
+
+	// Files the template doesn't provide fall back to the embedded copy
+	envrc, err := source.RenderEnvrc("svc", "golang")
+	if err != nil {
+		t.Fatalf("RenderEnvrc() error: %v", err)
+	}
+	if !strings.Contains(envrc, "export WORKSPACE_PROFILE=\"svc\"") || !strings.Contains(envrc, "# Template: golang") {
+		t.Errorf("embedded envrc.tpl fallback not used:\n%s", envrc)
+	}
+}
+
+func TestSource_CustomShadowsBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "work", GitconfigFile, "[user]\n\tname = {{.GitName}}\n\t# company override\n")
+
+	gitconfig, err := NewSource(dir).RenderGitconfig("acme", "work", "Jane", "jane@example.com")
+	if err != nil {
+		t.Fatalf("RenderGitconfig() error: %v", err)
+	}
+	if !strings.Contains(gitconfig, "# company override") {
+		t.Errorf("custom work template should shadow the builtin:\n%s", gitconfig)
+	}
+
+	_, origin, err := NewSource(dir).Lookup("work", EnvFile)
+	if err != nil || origin != "embedded" {
+		t.Errorf("Lookup(work, env.tpl) origin = %q, %v; want embedded", origin, err)
+	}
+}
+
+func TestSource_ResolutionOrder(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	writeTemplateFile(t, first, "team", EnvFile, "FIRST=1\n")
+	writeTemplateFile(t, second, "team", EnvFile, "SECOND=1\n")
+	writeTemplateFile(t, second, "other", EnvFile, "OTHER=1\n")
+
+	source := NewSource("", first, second)
+	env, err := source.RenderEnv("p", "team")
+	if err != nil {
+		t.Fatalf("RenderEnv() error: %v", err)
+	}
+	if strings.TrimSpace(env) != "FIRST=1" {
+		t.Errorf("earlier directory should win, got %q", env)
+	}
+
+	names := strings.Join(source.Names(), ",")
+	if names != "basic,personal,work,client,other,team" {
+		t.Errorf("Names() = %s", names)
+	}
+}
+
+func TestSource_Exists(t *testing.T) {
+	source := NewSource(t.TempDir())
+	if !source.Exists("basic") {
+		t.Error("builtin templates should always exist")
+	}
+	for _, name := range []string{"missing", "", "../etc"} {
+		if source.Exists(name) {
+			t.Errorf("Exists(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestDefaultSource_UsesUserTemplateDir(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	userDir := filepath.Join(configHome, "profile-manager", "templates")
+	writeTemplateFile(t, userDir, "mine", EnvFile, "USER=1\n")
+	custom := t.TempDir()
+	writeTemplateFile(t, custom, "mine", EnvFile, "CUSTOM=1\n")
+
+	env, err := DefaultSource("").RenderEnv("p", "mine")
+	if err != nil || strings.TrimSpace(env) != "USER=1" {
+		t.Errorf("user template dir not used: %q, %v", env, err)
+	}
+
+	env, err = DefaultSource(custom).RenderEnv("p", "mine")
+	if err != nil || strings.TrimSpace(env) != "CUSTOM=1" {
+		t.Errorf("--template-dir should take precedence over the user dir: %q, %v", env, err)
+	}
+}
+
+func TestSource_RenderEnvrcEncryptCache(t *testing.T) {
+	plain, err := NewSource().RenderEnvrc("svc", "basic")
+	if err != nil {
+		t.Fatalf("RenderEnvrc() error: %v", err)
+	}
+	if strings.Contains(plain, "openssl") {
+		t.Errorf("default envrc should not encrypt the cache:\n%s", plain)
+	}
+	encrypted, err := NewSource().RenderEnvrcWith("svc", "basic", EnvrcOptions{EncryptCache: true})
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not installed")
+	}
+	// direnv's stdlib runs the .envrc with $direnv set to its own binary.
+	// Without direnv installed, stand in for "direnv dotenv bash FILE" with
+	// a script that, like direnv, reads FILE with no regular-file check.
+	direnv, err := exec.LookPath("direnv")
+	if err != nil {
+		direnv = filepath.Join(t.TempDir(), "direnv")
+		stub := "#!/usr/bin/env bash\nwhile IFS= read -r l; do [[ $l == *=* ]] && printf 'export %s\\n' \"$l\"; done < \"$3\"\n"
+		if err := os.WriteFile(direnv, []byte(stub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("GREETING='hello world'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".envrc"), []byte(encrypted), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Minimal stand-ins for the rest of direnv's stdlib; dotenv refuses
+	// anything but a regular file, as direnv's does
+	stdlib := `PATH_add() { :; }; watch_file() { :; }; log_status() { :; }; log_error() { :; }
+dotenv() { [[ -f $1 ]] || return 1; eval "$("$direnv" dotenv bash "$1")"; }
+dotenv_if_exists() { [[ -f $1 ]] && dotenv "$1"; return 0; }
+`
+	// Run twice: the first load builds the cache, the second reads it back
+	for _, run := range []string{"build", "reuse"} {
+		cmd := exec.Command(bash, "-c", stdlib+`source ./.envrc >/dev/null && printf %s "$GREETING"`)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "HOME="+dir, "TMPDIR="+dir, "direnv="+direnv)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: sourcing .envrc failed: %v", run, err)
+		}
+		if string(out) != "hello world" {
+			t.Errorf("%s: GREETING = %q, want the decrypted value", run, out)
+		}
+	}
+	cache := filepath.Join(dir, "sp-profiles", "svc")
+	if _, err := os.Stat(filepath.Join(cache, ".env.enc")); err != nil {
+		t.Errorf("encrypted cache not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cache, ".env")); !os.IsNotExist(err) {
+		t.Error("plaintext cache left on disk")
+	}
+}
+
+func TestSource_UserTemplateIncludesPartials(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, PartialsDir, "banner.tpl", "# == {{.ProfileName}} ==\n")
+	writeTemplateFile(t, dir, "team", EnvFile, "{{template \"banner\" .}}\nTEAM=1\n")
+	writeTemplateFile(t, dir, "team", EnvrcFile, "export WORKSPACE_PROFILE=\"{{.ProfileName}}\"\n{{template \"colors\" .}}\n")
+
+	source := NewSource(dir)
+	env, err := source.RenderEnv("acme", "team")
+	if err != nil {
+		t.Fatalf("RenderEnv() error: %v", err)
+	}
+	if want := "# == acme ==\nTEAM=1\n"; env != want {
+		t.Errorf("RenderEnv() = %q, want %q", env, want)
+	}
+
+	// Built-in partials are available to user templates
+	envrc, err := source.RenderEnvrc("acme", "team")
+	if err != nil {
+		t.Fatalf("RenderEnvrc() error: %v", err)
+	}
+	if !strings.Contains(envrc, "# Set iTerm2 tab color") || !strings.HasSuffix(envrc, "fi\n") {
+		t.Errorf("built-in colors partial not included:\n%s", envrc)
+	}
+
+	// The partials directory is not a template
+	if source.Exists(PartialsDir) {
+		t.Error("partials/ should not be offered as a template")
+	}
+	for _, name := range source.Names() {
+		if name == PartialsDir {
+			t.Errorf("Names() lists the partials directory: %v", source.Names())
+		}
+	}
+}
+
+func TestSource_UserPartialOverridesBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, PartialsDir, "welcome.tpl", "log_status \"Hi from {{.ProfileName}}\"\n")
+
+	envrc, err := NewSource(dir).RenderEnvrc("acme", "basic")
+	if err != nil {
+		t.Fatalf("RenderEnvrc() error: %v", err)
+	}
+	if !strings.Contains(envrc, "log_status \"Hi from acme\"\n") {
+		t.Errorf("user welcome partial not used:\n%s", envrc)
+	}
+	if strings.Contains(envrc, "Orchestration: Available") {
+		t.Errorf("built-in welcome partial should be replaced:\n%s", envrc)
+	}
+}
+
+func TestSource_RenderEnvrcPathAdd(t *testing.T) {
+	envrc, err := NewSource().RenderEnvrcWith("acme", "basic", EnvrcOptions{
+		PathAdd: []string{"code/app/node_modules/.bin", "/opt/acme/bin"},
+	})
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	want := "PATH_add bin\nPATH_add \"code/app/node_modules/.bin\"\nPATH_add \"/opt/acme/bin\"\n"
+	if !strings.Contains(envrc, want) {
+		t.Errorf("expected the extra PATH_add lines after bin:\n%s", envrc)
+	}
+
+	plain, _ := NewSource().RenderEnvrc("acme", "basic")
+	if strings.Count(plain, "PATH_add") != 1 {
+		t.Errorf("only PATH_add bin expected by default:\n%s", plain)
+	}
+}
+
+func TestSource_RenderEnvrcWatch(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		envrc, err := NewSource().RenderEnvrcWith("acme", "basic", EnvrcOptions{Watch: true, EncryptCache: encrypt})
+		if err != nil {
+			t.Fatalf("RenderEnvrcWith() error: %v", err)
+		}
+		if !strings.Contains(envrc, "\nwatch_file .env\n") {
+			t.Errorf("watch_file .env missing:\n%s", envrc)
+		}
+		if !strings.Contains(envrc, `elif [ .env -nt "$_sp_env" ]; then`) {
+			t.Errorf("cache not rebuilt when .env changes:\n%s", envrc)
+		}
+		// Watching the cache the .envrc itself rewrites would reload in a loop
+		for _, line := range strings.Split(envrc, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "watch_file") && strings.TrimSpace(line) != "watch_file .env" {
+				t.Errorf("unexpected watch: %q", line)
+			}
+		}
+	}
+
+	plain, _ := NewSource().RenderEnvrc("acme", "basic")
+	if strings.Contains(plain, "watch_file") || strings.Contains(plain, "-nt") {
+		t.Errorf("watch_file expected only with Watch:\n%s", plain)
+	}
+}
+
+func TestSource_RenderEnvrcLocatedHome(t *testing.T) {
+	envrc, err := NewSource().RenderEnvrcWith("acme", "basic", EnvrcOptions{LocatedHome: true})
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	if !strings.Contains(envrc, "export WORKSPACE_PROFILE=\"acme\"\n"+WorkspaceHomeLine+"\n\n") {
+		t.Errorf("expected the location-derived WORKSPACE_HOME:\n%s", envrc)
+	}
+	if strings.Contains(envrc, PWDWorkspaceHomeLine) {
+		t.Errorf("WORKSPACE_HOME should not come from $PWD:\n%s", envrc)
+	}
+
+	plain, _ := NewSource().RenderEnvrc("acme", "basic")
+	if !strings.Contains(plain, "\n"+PWDWorkspaceHomeLine+"\n\n") {
+		t.Errorf("default envrc should keep $PWD:\n%s", plain)
+	}
+}
+
+func TestSource_RenderEnvrcOpAccount(t *testing.T) {
+	envrc, err := NewSource().RenderEnvrcWith("acme", "basic", EnvrcOptions{OpAccount: "acme.1password.com"})
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	for _, want := range []string{
+		"_op_account=acme.1password.com\n",
+		`op item list --account "$_op_account" --vault "$_op_vault"`,
+		`op item get "$_op_id" --account "$_op_account" --format json`,
+	} {
+		if !strings.Contains(envrc, want) {
+			t.Errorf("expected %q in .envrc:\n%s", want, envrc)
+		}
+	}
+
+	plain, _ := NewSource().RenderEnvrc("acme", "basic")
+	if strings.Contains(plain, "--account") || strings.Contains(plain, "_op_account") {
+		t.Errorf("default .envrc should use op's default account:\n%s", plain)
+	}
+	if !strings.Contains(plain, `op item list --vault "$_op_vault"`) {
+		t.Errorf("default .envrc lost vault discovery:\n%s", plain)
+	}
+}
+
+func TestSource_RenderEnvrcIncludeTOTP(t *testing.T) {
+	plain, _ := NewSource().RenderEnvrc("acme", "basic")
+	if strings.Contains(plain, "--otp") || strings.Contains(plain, "_sp_totp") {
+		t.Errorf("default .envrc should not export one-time passwords:\n%s", plain)
+	}
+
+	opts := EnvrcOptions{IncludeTOTP: true, OpAccount: "acme.1password.com"}
+	envrc, err := NewSource().RenderEnvrcWith("acme", "basic", opts)
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	for _, want := range []string{
+		`_sp_totp="${_sp_cache}/totp"`,
+		`.type != "OTP") |`, // Still kept out of the cache
+		`op item get "$_op_id" --account acme.1password.com --otp`,
+		`export "$_op_totp_var=$_op_totp"`,
+	} {
+		if !strings.Contains(envrc, want) {
+			t.Errorf("expected %q in .envrc:\n%s", want, envrc)
+		}
+	}
+	if strings.Index(envrc, "--otp") < strings.Index(envrc, `dotenv_if_exists "$_sp_env"`) {
+		t.Error("one-time passwords should be fetched after the cache is loaded, not cached")
+	}
+
+	jq, err := exec.LookPath("jq")
+	if err != nil {
+		return
+	}
+	// The index program is the jq -r --arg call that writes "VAR ID" lines
+	start := strings.Index(envrc, `--arg id "$_op_id" '`)
+	program := envrc[start+len(`--arg id "$_op_id" '`):]
+	program = program[:strings.Index(program, "'")]
+	for item, want := range map[string]string{
+		`{"title": "GitHub CI", "fields": [{"label": "one-time password", "type": "OTP", "value": "otpauth://x"}]}`: "GITHUB_CI_TOTP item1\n",
+		`{"title": "db", "fields": [{"label": "password", "type": "CONCEALED", "value": "x"}]}`:                     "",
+	} {
+		cmd := exec.Command(jq, "-r", "--arg", "id", "item1", program)
+		cmd.Stdin = strings.NewReader(item)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("jq failed: %v\n%s", err, out)
+		}
+		if string(out) != want {
+			t.Errorf("index for %s = %q, want %q", item, out, want)
+		}
+	}
+}
+
+func TestSource_RenderEnvrcVaults(t *testing.T) {
+	plain, _ := NewSource().RenderEnvrc("acme", "basic")
+	if !strings.Contains(plain, `for _op_vault in "workspace-${WORKSPACE_PROFILE}"; do`) {
+		t.Errorf("default .envrc should discover secrets in the profile's vault:\n%s", plain)
+	}
+
+	opts := EnvrcOptions{Vaults: []string{"personal", "Team Shared", "it's"}}
+	envrc, err := NewSource().RenderEnvrcWith("acme", "basic", opts)
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	loop := `for _op_vault in personal 'Team Shared' 'it'\''s'; do`
+	if !strings.Contains(envrc, loop) {
+		t.Fatalf("expected %q in .envrc:\n%s", loop, envrc)
+	}
+	if strings.Contains(envrc, "workspace-${WORKSPACE_PROFILE}") {
+		t.Error("configured vaults should replace the default vault")
+	}
+
+	// The loop visits every vault in order, each as one word
+	out, err := exec.Command("bash", "-c", loop+` echo "$_op_vault"; done`).Output()
+	if err != nil {
+		t.Skipf("bash unavailable: %v", err)
+	}
+	if want := "personal\nTeam Shared\nit's\n"; string(out) != want {
+		t.Errorf("loop visits %q, want %q", out, want)
+	}
+}
+
+func TestEnvrcOptions_OpFieldFilter(t *testing.T) {
+	if got := (EnvrcOptions{}).OpFieldFilter(); got != "" {
+		t.Errorf("default filter = %q, want none", got)
+	}
+	if got := (EnvrcOptions{}).OpFieldLabel(); got != ".label" {
+		t.Errorf("default label = %q", got)
+	}
+
+	opts := EnvrcOptions{OpFieldPrefix: "env:", OpExcludeFields: []string{"URL", "it's"}}
+	want := ` and (.label | startswith("env:")) and ((.label | ascii_downcase) as $l | ["url", "it\u0027s"] | index($l)) == null`
+	if got := opts.OpFieldFilter(); got != want {
+		t.Errorf("OpFieldFilter() = %q, want %q", got, want)
+	}
+	if got := opts.OpFieldLabel(); got != `(.label | ltrimstr("env:"))` {
+		t.Errorf("OpFieldLabel() = %q", got)
+	}
+
+	envrc, err := NewSource().RenderEnvrcWith("acme", "basic", opts)
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	if !strings.Contains(envrc, `.type != "OTP"`+want+") |") || !strings.Contains(envrc, `($t + "_" + (.label | ltrimstr("env:"))`) {
+		t.Errorf("filter not rendered into the discovery block:\n%s", envrc)
+	}
+}
+
+func TestEnvrcOptions_OpFieldFilterRunsInJq(t *testing.T) {
+	jq, err := exec.LookPath("jq")
+	if err != nil {
+		t.Skip("jq not installed")
+	}
+	item := `{"title": "github", "fields": [
+		{"id": "a", "label": "env:token", "value": "t0k", "type": "CONCEALED"},
+		{"id": "b", "label": "username", "value": "dev", "type": "STRING"},
+		{"id": "c", "label": "env:url", "value": "https://x", "type": "STRING"},
+		{"id": "notesPlain", "label": "notesPlain", "value": "hi", "type": "STRING"}
+	]}`
+
+	tests := []struct {
+		opts EnvrcOptions
+		want string
+	}{
+		{EnvrcOptions{}, "GITHUB_ENV_TOKEN='t0k'\nGITHUB_USERNAME='dev'\nGITHUB_ENV_URL='https://x'\n"},
+		{EnvrcOptions{OpFieldPrefix: "env:"}, "GITHUB_TOKEN='t0k'\nGITHUB_URL='https://x'\n"},
+		{EnvrcOptions{OpFieldPrefix: "env:", OpExcludeFields: []string{"ENV:URL"}}, "GITHUB_TOKEN='t0k'\n"},
+	}
+	for _, tt := range tests {
+		envrc, err := NewSource().RenderEnvrcWith("acme", "basic", tt.opts)
+		if err != nil {
+			t.Fatalf("RenderEnvrcWith() error: %v", err)
+		}
+		// The jq program is the single-quoted argument of jq -r
+		start := strings.Index(envrc, "jq -r '\n")
+		if start < 0 {
+			t.Fatalf("no jq program in .envrc:\n%s", envrc)
+		}
+		program := envrc[start+len("jq -r '"):]
+		program = program[:strings.Index(program, "'")]
+
+		cmd := exec.Command(jq, "-r", program)
+		cmd.Stdin = strings.NewReader(item)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("jq failed: %v\n%s\n%s", err, out, program)
+		}
+		if string(out) != tt.want {
+			t.Errorf("%+v exported:\n%s\nwant:\n%s", tt.opts, out, tt.want)
+		}
+	}
+}
+
+func TestWorkspaceHomeLine_ResolvesSymlinks(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	real := filepath.Join(tmpDir, "real")
+	link := filepath.Join(tmpDir, "link")
+	if err := os.MkdirAll(filepath.Join(real, "code"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(real, ".envrc"), []byte(WorkspaceHomeLine+"\nprintf %s \"$WORKSPACE_HOME\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sourced through the symlink, the way direnv sources it, and from a
+	// subdirectory of the profile
+	for _, script := range []string{"cd " + link + " && source ./.envrc", "cd " + link + "/code && source ../.envrc"} {
+		out, err := exec.Command(bash, "-c", script).Output()
+		if err != nil {
+			t.Fatalf("bash -c %q: %v", script, err)
+		}
+		if string(out) != real {
+			t.Errorf("bash -c %q: WORKSPACE_HOME = %q, want %q", script, out, real)
+		}
+	}
+}
+
+func TestSource_RenderEnvrcCreated(t *testing.T) {
+	created := time.Date(2024, 3, 9, 14, 5, 7, 0, time.FixedZone("CET", 3600))
+	render := func() string {
+		envrc, err := NewSource().RenderEnvrcWith("acme", "work", EnvrcOptions{Created: created})
+		if err != nil {
+			t.Fatalf("RenderEnvrcWith() error: %v", err)
+		}
+		return envrc
+	}
+
+	first := render()
+	lines := strings.Split(first, "\n")
+	if len(lines) < 4 || lines[3] != "# Created: 2024-03-09 13:05:07 UTC" {
+		t.Errorf("header = %q", lines[:4])
+	}
+	if render() != first {
+		t.Error("rendering with a fixed time should be reproducible")
+	}
+
+	// Without a time of its own, the header takes the source's clock
+	envrc, err := NewSource().WithClock(clock.NewFake(created)).RenderEnvrcWith("acme", "work", EnvrcOptions{})
+	if err != nil {
+		t.Fatalf("RenderEnvrcWith() error: %v", err)
+	}
+	if envrc != first {
+		t.Errorf("rendering with the source's clock differs:\n%s", envrc)
+	}
+}
+
+func TestSource_RenderGitconfigIncludes(t *testing.T) {
+	gitconfig, err := NewSource().RenderGitconfigWith("acme", "work", "Jane", "jane@acme.example", GitconfigOptions{
+		Includes: []string{"~/team/gitconfig", "/etc/acme/gitconfig"},
+	})
+	if err != nil {
+		t.Fatalf("RenderGitconfigWith() error: %v", err)
+	}
+	first := strings.Index(gitconfig, "[include]\n    path = ~/team/gitconfig\n")
+	second := strings.Index(gitconfig, "[include]\n    path = /etc/acme/gitconfig\n")
+	user := strings.Index(gitconfig, "[user]")
+	if first < 0 || second < first || user < second {
+		t.Errorf("includes should come in order before [user]:\n%s", gitconfig)
+	}
+
+	plain, _ := NewSource().RenderGitconfig("acme", "work", "Jane", "jane@acme.example")
+	if strings.Contains(plain, "[include]") {
+		t.Errorf("no include expected without Includes:\n%s", plain)
+	}
+}
@@ -1,11 +1,14 @@
 package templates
 
 import (
-	"bytes"
-	_ "embed"
+	"embed"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"text/template"
 	"time"
+
+	"github.com/neverprepared/shell-profile-manager/internal/util"
 )
 
 //go:embed envrc.tpl
@@ -17,11 +20,158 @@ var envTemplate string
 //go:embed gitconfig.tpl
 var gitconfigTemplate string
 
+// Built-in partials, included with {{template "<name>" .}}
+//
+//go:embed partials/*.tpl
+var embeddedPartials embed.FS
+
+// ProfileTemplate describes a built-in profile template and the defaults it
+// renders, so tools can preview a template without rendering it
+type ProfileTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"` // iTerm2 tab color
+
+	// CredentialCacheTimeout is the git credential cache timeout in seconds,
+	// 0 if the template does not cache credentials
+	CredentialCacheTimeout int `json:"credentialCacheTimeout"`
+}
+
+// builtinTemplates lists the profile templates in the order they are offered.
+// Keep Color and CredentialCacheTimeout in sync with the template files.
+var builtinTemplates = []ProfileTemplate{
+	{Name: "basic", Description: "Minimal configuration", Color: "#7e7f80"},
+	{Name: "personal", Description: "Personal projects", Color: "#19baff", CredentialCacheTimeout: 3600},
+	{Name: "work", Description: "Work projects", Color: "#28c940", CredentialCacheTimeout: 7200},
+	{Name: "client", Description: "Client projects", Color: "#ff9500", CredentialCacheTimeout: 3600},
+}
+
+// Builtin returns the built-in profile templates
+func Builtin() []ProfileTemplate {
+	return append([]ProfileTemplate(nil), builtinTemplates...)
+}
+
+// Names returns the names of the built-in profile templates
+func Names() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for _, t := range builtinTemplates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// IsValid reports whether name is a built-in profile template
+func IsValid(name string) bool {
+	for _, t := range builtinTemplates {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedAtLayout formats EnvrcData.CreatedAt
+const CreatedAtLayout = "2006-01-02 15:04:05 UTC"
+
+// PathVars are the variables a PATH_add directory may refer to; any other
+// $ in it is taken literally
+var PathVars = []string{"WORKSPACE_HOME", "WORKSPACE_PROFILE", "HOME"}
+
+// QuotePath quotes a PATH_add directory for the .envrc, as envrc.tpl does
+func QuotePath(dir string) string {
+	return util.ShellQuoteExpand(dir, PathVars...)
+}
+
+// The .envrc lines setting WORKSPACE_HOME. direnv sources the .envrc from
+// its own directory, so BASH_SOURCE locates it wherever the shell is, and
+// pwd -P resolves a symlinked profile to its real directory.
+const (
+	WorkspaceHomeLine    = `export WORKSPACE_HOME="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd -P)"`
+	PWDWorkspaceHomeLine = `export WORKSPACE_HOME="$PWD"`
+)
+
+// funcs are the functions available to every template file
+var funcs = template.FuncMap{
+	"shellQuote": util.ShellQuote,
+	"quotePath":  QuotePath,
+}
+
 // EnvrcData holds the data for rendering the .envrc template
 type EnvrcData struct {
 	ProfileName string
 	Template    string
 	CreatedAt   string
+	EnvrcOptions
+}
+
+// EnvrcOptions are optional features of the generated .envrc
+type EnvrcOptions struct {
+	EncryptCache bool     // Keep the resolved secrets cache encrypted at rest
+	PathAdd      []string // Directories prepended to PATH after bin/
+	Watch        bool     // Reload direnv and rebuild the cache when .env changes
+	LocatedHome  bool     // Derive WORKSPACE_HOME from the .envrc's location, not $PWD
+	OpAccount    string   // 1Password account (op --account) to discover secrets in; the default account if empty
+
+	// OpFieldPrefix limits the exported 1Password fields to those whose
+	// label starts with it, such as "env:"; it is left out of the name
+	OpFieldPrefix string
+	// OpExcludeFields are 1Password field labels never exported, ignoring case
+	OpExcludeFields []string
+	// IncludeTOTP exports the current code of each item's one-time password
+	// field, fetched with op item get --otp on every load and never cached
+	IncludeTOTP bool
+	// Vaults are the 1Password vaults secrets are discovered in, in order,
+	// later vaults overriding earlier ones; workspace-<profile> if empty
+	Vaults []string
+
+	// Created is stamped in the header; the current time of the source's
+	// clock if zero. Set it, or the clock, for reproducible output.
+	Created time.Time
+}
+
+// OpFieldFilter returns the jq conditions a 1Password field must meet to be
+// exported, on top of being non-empty and not an OTP, each as " and ..."
+func (o EnvrcOptions) OpFieldFilter() string {
+	var b strings.Builder
+	if o.OpFieldPrefix != "" {
+		fmt.Fprintf(&b, " and (.label | startswith(%s))", jqString(o.OpFieldPrefix))
+	}
+	if len(o.OpExcludeFields) > 0 {
+		labels := make([]string, len(o.OpExcludeFields))
+		for i, label := range o.OpExcludeFields {
+			labels[i] = jqString(strings.ToLower(label))
+		}
+		fmt.Fprintf(&b, " and ((.label | ascii_downcase) as $l | [%s] | index($l)) == null", strings.Join(labels, ", "))
+	}
+	return b.String()
+}
+
+// OpFieldLabel returns the jq expression for the part of a 1Password field's
+// label used in its variable name
+func (o EnvrcOptions) OpFieldLabel() string {
+	if o.OpFieldPrefix == "" {
+		return ".label"
+	}
+	return fmt.Sprintf("(.label | ltrimstr(%s))", jqString(o.OpFieldPrefix))
+}
+
+// OpVaults returns the vaults the .envrc loops over as shell words
+func (o EnvrcOptions) OpVaults() string {
+	if len(o.Vaults) == 0 {
+		return `"workspace-${WORKSPACE_PROFILE}"`
+	}
+	words := make([]string, len(o.Vaults))
+	for i, vault := range o.Vaults {
+		words[i] = util.ShellQuote(vault)
+	}
+	return strings.Join(words, " ")
+}
+
+// jqString returns s as a jq string literal that can sit inside the single
+// quotes the .envrc passes the jq program in
+func jqString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return strings.ReplaceAll(string(quoted), "'", `\u0027`)
 }
 
 // EnvData holds the data for rendering the .env template
@@ -36,75 +186,38 @@ type GitconfigData struct {
 	Template    string
 	GitName     string
 	GitEmail    string
+	GitconfigOptions
 }
 
-// RenderEnvrc renders the .envrc template with the provided data
-func RenderEnvrc(profileName, templateType string) (string, error) {
-	tmpl, err := template.New("envrc").Parse(envrcTemplate)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse .envrc template: %w", err)
-	}
+// GitconfigOptions are optional features of the generated .gitconfig
+type GitconfigOptions struct {
+	// Includes are shared configs included before the profile's own
+	// settings, so the profile's settings win
+	Includes []string
 
-	data := EnvrcData{
-		ProfileName: profileName,
-		Template:    templateType,
-		CreatedAt:   time.Now().UTC().Format("2006-01-02 15:04:05 UTC"),
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to render .envrc template: %w", err)
-	}
-
-	return buf.String(), nil
+	DefaultBranch string // init.defaultBranch; DefaultBranchName if empty
 }
 
-// RenderEnv renders the .env template with the provided data
-func RenderEnv(profileName, templateType string) (string, error) {
-	tmpl, err := template.New("env").Parse(envTemplate)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse .env template: %w", err)
-	}
+// DefaultBranchName is the init.defaultBranch of generated .gitconfig files
+const DefaultBranchName = "main"
 
-	data := EnvData{
-		ProfileName: profileName,
-		Template:    templateType,
-	}
+// Placeholder git identity written to .gitconfig when none is given
+const (
+	DefaultGitName  = "Your Name"
+	DefaultGitEmail = "your.email@example.com"
+)
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to render .env template: %w", err)
-	}
+// RenderEnvrc renders the embedded .envrc template with the provided data
+func RenderEnvrc(profileName, templateType string) (string, error) {
+	return NewSource().RenderEnvrc(profileName, templateType)
+}
 
-	return buf.String(), nil
+// RenderEnv renders the embedded .env template with the provided data
+func RenderEnv(profileName, templateType string) (string, error) {
+	return NewSource().RenderEnv(profileName, templateType)
 }
 
-// RenderGitconfig renders the .gitconfig template with the provided data
+// RenderGitconfig renders the embedded .gitconfig template with the provided data
 func RenderGitconfig(profileName, templateType, gitName, gitEmail string) (string, error) {
-	tmpl, err := template.New("gitconfig").Parse(gitconfigTemplate)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse .gitconfig template: %w", err)
-	}
-
-	// Default values if not provided
-	if gitName == "" {
-		gitName = "Your Name"
-	}
-	if gitEmail == "" {
-		gitEmail = "your.email@example.com"
-	}
-
-	data := GitconfigData{
-		ProfileName: profileName,
-		Template:    templateType,
-		GitName:     gitName,
-		GitEmail:    gitEmail,
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to render .gitconfig template: %w", err)
-	}
-
-	return buf.String(), nil
+	return NewSource().RenderGitconfig(profileName, templateType, gitName, gitEmail)
 }
@@ -1,8 +1,11 @@
 package templates
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/neverprepared/shell-profile-manager/internal/managed"
 )
 
 func TestRenderEnvrc(t *testing.T) {
@@ -139,6 +142,62 @@ func TestRenderEnv(t *testing.T) {
 	}
 }
 
+func TestRender_ManagedBlock(t *testing.T) {
+	renderers := map[string]func(string, string) (string, error){
+		EnvrcFile: RenderEnvrc,
+		EnvFile:   RenderEnv,
+	}
+	for file, render := range renderers {
+		got, err := render("test-profile", "personal")
+		if err != nil {
+			t.Fatalf("%s: render error = %v", file, err)
+		}
+		sections, err := managed.Split(got)
+		if err != nil {
+			t.Fatalf("%s: managed.Split() error = %v", file, err)
+		}
+		if sections.After != "" {
+			t.Errorf("%s: unexpected content after the managed block: %q", file, sections.After)
+		}
+
+		// User content around the block survives regeneration
+		edited := sections.Before + "# mine\nMY_VAR=1\n" + managed.Fence("STALE=1\n") + "\nOTHER=2\n"
+		regenerated, err := managed.Regenerate(edited, got)
+		if err != nil {
+			t.Fatalf("%s: managed.Regenerate() error = %v", file, err)
+		}
+		want := sections.Before + "# mine\nMY_VAR=1\n" + managed.Fence(sections.Managed) + "\nOTHER=2\n"
+		if regenerated != want {
+			t.Errorf("%s: regenerated =\n%s\nwant\n%s", file, regenerated, want)
+		}
+	}
+}
+
+func TestBuiltin_MatchesTemplateFiles(t *testing.T) {
+	for _, tmpl := range Builtin() {
+		envrc, err := RenderEnvrc("test-profile", tmpl.Name)
+		if err != nil {
+			t.Fatalf("RenderEnvrc(%s) error = %v", tmpl.Name, err)
+		}
+		if !strings.Contains(envrc, "("+tmpl.Color+")") {
+			t.Errorf("%s: .envrc does not set tab color %s", tmpl.Name, tmpl.Color)
+		}
+
+		gitconfig, err := RenderGitconfig("test-profile", tmpl.Name, "Test", "test@example.com")
+		if err != nil {
+			t.Fatalf("RenderGitconfig(%s) error = %v", tmpl.Name, err)
+		}
+		hasCache := strings.Contains(gitconfig, "helper = cache")
+		want := fmt.Sprintf("helper = cache --timeout=%d", tmpl.CredentialCacheTimeout)
+		if tmpl.CredentialCacheTimeout == 0 && hasCache {
+			t.Errorf("%s: .gitconfig caches credentials but CredentialCacheTimeout is 0", tmpl.Name)
+		}
+		if tmpl.CredentialCacheTimeout > 0 && !strings.Contains(gitconfig, want) {
+			t.Errorf("%s: .gitconfig missing %q", tmpl.Name, want)
+		}
+	}
+}
+
 func TestRenderGitconfig(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,334 @@
+package tools
+
+import "strings"
+
+// EnvVar is an environment variable written to a profile's .env
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+
+	// Optional variables are documented (commented out) in the .env template
+	// and not added by update, but still never belong in .envrc
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Tool describes the profile layout managed for one tool: the directories
+// created in every profile, the variables pointing the tool at them, and the
+// .gitignore patterns that keep its credentials out of version control
+type Tool struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Description string   `json:"description"`
+	Dirs        []string `json:"directories,omitempty"`
+	EnvVars     []EnvVar `json:"envVars,omitempty"`
+	Gitignore   []string `json:"gitignore,omitempty"`
+
+	// Caches are paths holding cached tokens and other state that can be
+	// deleted without losing configuration
+	Caches []string `json:"caches,omitempty"`
+
+	// DeprecatedEnvVars are managed variables the tool no longer uses.
+	// Update removes them from .env; user variables are never removed.
+	DeprecatedEnvVars []string `json:"deprecatedEnvVars,omitempty"`
+
+	// HomeFiles are the tool's files in a user's $HOME that can seed a new
+	// profile (create --copy-from-home)
+	HomeFiles []HomeFile `json:"homeFiles,omitempty"`
+
+	// Cloud tools are left out of minimal profiles (create --no-cloud)
+	Cloud bool `json:"cloud,omitempty"`
+
+	// Examples are credential variables the tool reads, documented commented
+	// out in .env.example under ExampleTitle. They are never written to .env.
+	ExampleTitle string   `json:"exampleTitle,omitempty"`
+	Examples     []EnvVar `json:"examples,omitempty"`
+}
+
+// HomeFile maps a file under $HOME to its place in a profile
+type HomeFile struct {
+	Home       string `json:"home"`                 // Relative to $HOME
+	Profile    string `json:"profile"`              // Relative to the profile
+	Credential bool   `json:"credential,omitempty"` // Only copied on request, kept 0600
+}
+
+// registry is the single list of managed tools. Create, update and schema all
+// read from it, so adding a tool here adds it everywhere.
+var registry = []Tool{
+	{
+		Name:        "git",
+		Description: "Profile-specific git identity and SSH command",
+		EnvVars: []EnvVar{
+			{Name: "GIT_CONFIG_GLOBAL", Value: "$WORKSPACE_HOME/.gitconfig"},
+			{Name: "GIT_SSH_COMMAND", Value: "ssh -F $WORKSPACE_HOME/.ssh/config"},
+		},
+	},
+	{
+		Name:        "ssh",
+		Description: "Profile-specific SSH config and keys",
+		Dirs:        []string{".ssh"},
+		Gitignore:   []string{".ssh/id_*", ".ssh/*.pem", ".ssh/*.key", ".ssh/known_hosts"},
+	},
+	{
+		Name:        "xdg",
+		Description: "XDG base directory for XDG-compliant tools",
+		EnvVars: []EnvVar{
+			{Name: "XDG_CONFIG_HOME", Value: "$WORKSPACE_HOME/.config"},
+		},
+	},
+	{
+		Name:        "1password",
+		Description: "1Password SSH agent configuration",
+		Dirs:        []string{".config/1Password"},
+		EnvVars: []EnvVar{
+			{Name: "SSH_AUTH_SOCK", Value: "$HOME/Library/Group Containers/2BUA8C4S2C.com.1password/t/agent.sock"},
+		},
+	},
+	{
+		Name:        "aws",
+		Cloud:       true,
+		Description: "AWS CLI and SDK config and credentials",
+		Dirs:        []string{".aws"},
+		EnvVars: []EnvVar{
+			{Name: "AWS_CONFIG_FILE", Value: "$WORKSPACE_HOME/.aws/config"},
+			{Name: "AWS_SHARED_CREDENTIALS_FILE", Value: "$WORKSPACE_HOME/.aws/credentials"},
+		},
+		Gitignore: []string{".aws/credentials", ".aws/cli/cache", ".aws/sso/cache"},
+		Caches:    []string{".aws/cli/cache", ".aws/sso/cache"},
+		HomeFiles: []HomeFile{
+			{Home: ".aws/config", Profile: ".aws/config"},
+			{Home: ".aws/credentials", Profile: ".aws/credentials", Credential: true},
+		},
+		ExampleTitle: "AWS credentials",
+		Examples: []EnvVar{
+			{Name: "AWS_ACCESS_KEY_ID", Value: "your-access-key"},
+			{Name: "AWS_SECRET_ACCESS_KEY", Value: "your-secret-key"},
+			{Name: "AWS_DEFAULT_REGION", Value: "us-east-1"},
+		},
+	},
+	{
+		Name:        "kubernetes",
+		Cloud:       true,
+		Aliases:     []string{"kube", "kubectl"},
+		Description: "kubectl kubeconfig",
+		Dirs:        []string{".kube"},
+		EnvVars: []EnvVar{
+			{Name: "KUBECONFIG", Value: "$WORKSPACE_HOME/.kube/config"},
+		},
+		Gitignore: []string{".kube/cache", ".kube/http-cache"},
+		Caches:    []string{".kube/cache", ".kube/http-cache"},
+		HomeFiles: []HomeFile{{Home: ".kube/config", Profile: ".kube/config"}},
+	},
+	{
+		Name:        "terraform",
+		Cloud:       true,
+		Description: "Terraform CLI config and plugin cache",
+		EnvVars: []EnvVar{
+			{Name: "TF_CLI_CONFIG_FILE", Value: "$WORKSPACE_HOME/.terraformrc"},
+			{Name: "TF_PLUGIN_CACHE_DIR", Value: "$WORKSPACE_HOME/.terraform.d/plugin-cache", Optional: true},
+		},
+		Gitignore: []string{".terraform/", ".terraform.lock.hcl", "*.tfstate", "*.tfstate.*", "*.tfvars", ".terraform.d/plugin-cache/", ".terraform.d/checkpoint_cache", ".terraform.d/checkpoint_signature"},
+		HomeFiles: []HomeFile{{Home: ".terraformrc", Profile: ".terraformrc"}},
+	},
+	{
+		Name:        "azure",
+		Cloud:       true,
+		Description: "Azure CLI config directory",
+		Dirs:        []string{".azure"},
+		EnvVars: []EnvVar{
+			{Name: "AZURE_CONFIG_DIR", Value: "$WORKSPACE_HOME/.azure"},
+		},
+		Gitignore: []string{".azure/config", ".azure/clouds.config", ".azure/accessTokens.json", ".azure/msal_token_cache.json", ".azure/azureProfile.json"},
+		HomeFiles: []HomeFile{
+			{Home: ".azure/config", Profile: ".azure/config"},
+			{Home: ".azure/clouds.config", Profile: ".azure/clouds.config"},
+			{Home: ".azure/azureProfile.json", Profile: ".azure/azureProfile.json", Credential: true},
+			{Home: ".azure/msal_token_cache.json", Profile: ".azure/msal_token_cache.json", Credential: true},
+		},
+		ExampleTitle: "Azure credentials (optional - can also use 'az login')",
+		Examples: []EnvVar{
+			{Name: "AZURE_CLIENT_ID", Value: "your-client-id"},
+			{Name: "AZURE_CLIENT_SECRET", Value: "your-client-secret"},
+			{Name: "AZURE_TENANT_ID", Value: "your-tenant-id"},
+			{Name: "AZURE_SUBSCRIPTION_ID", Value: "your-subscription-id"},
+		},
+	},
+	{
+		Name:        "gcloud",
+		Cloud:       true,
+		Description: "Google Cloud SDK config directory",
+		Dirs:        []string{".gcloud"},
+		EnvVars: []EnvVar{
+			{Name: "CLOUDSDK_CONFIG", Value: "$WORKSPACE_HOME/.gcloud"},
+		},
+		Gitignore: []string{".gcloud/configurations/", ".gcloud/credentials", ".gcloud/access_tokens.db", ".gcloud/legacy_credentials/", ".gcloud/logs/"},
+		Caches:    []string{".gcloud/logs"},
+		HomeFiles: []HomeFile{
+			{Home: ".config/gcloud/active_config", Profile: ".gcloud/active_config"},
+			{Home: ".config/gcloud/configurations/config_default", Profile: ".gcloud/configurations/config_default"},
+			{Home: ".config/gcloud/credentials.db", Profile: ".gcloud/credentials.db", Credential: true},
+			{Home: ".config/gcloud/access_tokens.db", Profile: ".gcloud/access_tokens.db", Credential: true},
+		},
+		ExampleTitle: "Google Cloud credentials (optional - can also use 'gcloud auth login')",
+		Examples: []EnvVar{
+			{Name: "GOOGLE_APPLICATION_CREDENTIALS", Value: "/path/to/service-account-key.json"},
+			{Name: "GCP_PROJECT", Value: "your-project-id"},
+			{Name: "GCP_REGION", Value: "us-central1"},
+			{Name: "GCP_ZONE", Value: "us-central1-a"},
+		},
+	},
+	{
+		Name:        "claude",
+		Description: "Claude Code config directory",
+		Dirs:        []string{".config/claude"},
+		EnvVars: []EnvVar{
+			{Name: "CLAUDE_CONFIG_DIR", Value: "$WORKSPACE_HOME/.config/claude"},
+		},
+		Gitignore:    []string{".config/claude/"},
+		ExampleTitle: "Claude Code / Anthropic API credentials",
+		Examples: []EnvVar{
+			{Name: "ANTHROPIC_API_KEY", Value: "your-anthropic-api-key"},
+		},
+	},
+	{
+		Name:        "gemini",
+		Description: "Gemini CLI config directory",
+		Dirs:        []string{".config/gemini"},
+		EnvVars: []EnvVar{
+			{Name: "GEMINI_CONFIG_DIR", Value: "$WORKSPACE_HOME/.config/gemini"},
+		},
+		Gitignore:    []string{".config/gemini/"},
+		ExampleTitle: "Gemini CLI / Google AI API credentials",
+		Examples: []EnvVar{
+			{Name: "GEMINI_API_KEY", Value: "your-gemini-api-key"},
+			{Name: "GOOGLE_AI_API_KEY", Value: "your-google-ai-api-key"},
+		},
+	},
+}
+
+// workspaceDirs are created in every profile but belong to no single tool
+var workspaceDirs = []string{"bin", "code"}
+
+// All returns every registered tool in order
+func All() []Tool {
+	return append([]Tool(nil), registry...)
+}
+
+// Lookup returns the tool with the given name or alias
+func Lookup(name string) (Tool, bool) {
+	for _, tool := range registry {
+		if tool.Name == name {
+			return tool, true
+		}
+		for _, alias := range tool.Aliases {
+			if alias == name {
+				return tool, true
+			}
+		}
+	}
+	return Tool{}, false
+}
+
+// Dirs returns every directory created in a profile: tool directories
+// followed by the general workspace directories
+func Dirs() []string {
+	var dirs []string
+	for _, tool := range registry {
+		dirs = append(dirs, tool.Dirs...)
+	}
+	return append(dirs, workspaceDirs...)
+}
+
+// LocalDirs returns Dirs without the cloud tools' directories
+func LocalDirs() []string {
+	var dirs []string
+	for _, tool := range registry {
+		if !tool.Cloud {
+			dirs = append(dirs, tool.Dirs...)
+		}
+	}
+	return append(dirs, workspaceDirs...)
+}
+
+// cloudEnvPrefixes cover the credential variables of the cloud tools that
+// profiles document but do not manage
+var cloudEnvPrefixes = []string{"AWS_", "AZURE_", "ARM_", "GOOGLE_", "GCP_", "CLOUDSDK_", "KUBE", "TF_"}
+
+// IsCloudEnvVar reports whether a variable belongs to a cloud tool
+func IsCloudEnvVar(name string) bool {
+	for _, tool := range registry {
+		if !tool.Cloud {
+			continue
+		}
+		for _, v := range tool.EnvVars {
+			if v.Name == name {
+				return true
+			}
+		}
+	}
+	for _, prefix := range cloudEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCloudPath reports whether a profile path or .gitignore pattern belongs
+// to a cloud tool: one of its patterns or anything under its directories
+func IsCloudPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, tool := range registry {
+		if !tool.Cloud {
+			continue
+		}
+		for _, pattern := range tool.Gitignore {
+			if path == strings.TrimSuffix(pattern, "/") {
+				return true
+			}
+		}
+		for _, dir := range tool.Dirs {
+			if path == dir || strings.HasPrefix(path, dir+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EnvVars returns every managed environment variable, including optional ones
+func EnvVars() []EnvVar {
+	var vars []EnvVar
+	for _, tool := range registry {
+		vars = append(vars, tool.EnvVars...)
+	}
+	return vars
+}
+
+// RequiredEnvVars returns the managed variables that every .env must define
+func RequiredEnvVars() []EnvVar {
+	var vars []EnvVar
+	for _, v := range EnvVars() {
+		if !v.Optional {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// EnvVarNames returns the names of every managed environment variable
+func EnvVarNames() []string {
+	var names []string
+	for _, v := range EnvVars() {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// DeprecatedEnvVarNames returns the names of every deprecated managed variable
+func DeprecatedEnvVarNames() []string {
+	var names []string
+	for _, tool := range registry {
+		names = append(names, tool.DeprecatedEnvVars...)
+	}
+	return names
+}
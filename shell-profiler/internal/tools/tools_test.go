@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"testing"
+)
+
+func TestRegistry_NoDuplicates(t *testing.T) {
+	names := make(map[string]bool)
+	for _, tool := range All() {
+		if names[tool.Name] {
+			t.Errorf("duplicate tool %q", tool.Name)
+		}
+		names[tool.Name] = true
+	}
+
+	dirs := make(map[string]bool)
+	for _, dir := range Dirs() {
+		if dirs[dir] {
+			t.Errorf("duplicate directory %q", dir)
+		}
+		dirs[dir] = true
+	}
+
+	vars := make(map[string]bool)
+	for _, name := range EnvVarNames() {
+		if vars[name] {
+			t.Errorf("duplicate env var %q", name)
+		}
+		vars[name] = true
+	}
+}
+
+func TestDirs_IncludesWorkspaceDirs(t *testing.T) {
+	dirs := Dirs()
+	want := map[string]bool{".ssh": false, ".aws": false, "bin": false, "code": false}
+	for _, dir := range dirs {
+		if _, ok := want[dir]; ok {
+			want[dir] = true
+		}
+	}
+	for dir, found := range want {
+		if !found {
+			t.Errorf("Dirs() missing %q", dir)
+		}
+	}
+}
+
+func TestLocalDirs_OmitsCloudTools(t *testing.T) {
+	for _, dir := range LocalDirs() {
+		if IsCloudPath(dir) {
+			t.Errorf("LocalDirs() includes cloud directory %q", dir)
+		}
+	}
+	for _, path := range []string{".aws", ".kube/cache", ".gcloud/configurations", "*.tfstate"} {
+		if !IsCloudPath(path) {
+			t.Errorf("IsCloudPath(%q) = false", path)
+		}
+	}
+	for _, path := range []string{".ssh", ".ssh/id_*", ".config/claude/", ".awsome"} {
+		if IsCloudPath(path) {
+			t.Errorf("IsCloudPath(%q) = true", path)
+		}
+	}
+}
+
+func TestRequiredEnvVars_ExcludesOptional(t *testing.T) {
+	for _, v := range RequiredEnvVars() {
+		if v.Optional {
+			t.Errorf("RequiredEnvVars() returned optional var %q", v.Name)
+		}
+		if v.Name == "TF_PLUGIN_CACHE_DIR" {
+			t.Error("TF_PLUGIN_CACHE_DIR is optional and should not be required")
+		}
+	}
+
+	found := false
+	for _, name := range EnvVarNames() {
+		if name == "TF_PLUGIN_CACHE_DIR" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("EnvVarNames() should include optional vars")
+	}
+}
+
+func TestDeprecatedEnvVarNames_NotManaged(t *testing.T) {
+	managed := make(map[string]bool)
+	for _, name := range EnvVarNames() {
+		managed[name] = true
+	}
+	for _, name := range DeprecatedEnvVarNames() {
+		if managed[name] {
+			t.Errorf("%s is both managed and deprecated", name)
+		}
+	}
+}
@@ -3,18 +3,61 @@ package ui
 import (
 	"fmt"
 	"os"
+
+	"golang.org/x/term"
 )
 
-// ANSI color codes
-const (
-	ColorReset  = "\033[0m"
-	ColorRed    = "\033[0;31m"
-	ColorGreen  = "\033[0;32m"
-	ColorYellow = "\033[1;33m"
-	ColorBlue   = "\033[0;34m"
-	ColorCyan   = "\033[0;36m"
+// ANSI color codes. They are empty while colors are disabled, so they can be
+// used directly in format strings.
+var (
+	ColorReset  string
+	ColorRed    string
+	ColorGreen  string
+	ColorYellow string
+	ColorBlue   string
+	ColorCyan   string
 )
 
+var colorEnabled bool
+
+// stdoutIsTerminal reports whether stdout is a terminal; replaced in tests
+var stdoutIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func init() {
+	SetColorEnabled(shouldColor())
+}
+
+// shouldColor reports whether output should be colored by default: only on
+// a terminal and only when NO_COLOR (https://no-color.org) is not set
+func shouldColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return stdoutIsTerminal()
+}
+
+// SetColorEnabled turns ANSI colors on or off for all ui output
+func SetColorEnabled(enabled bool) {
+	colorEnabled = enabled
+	if enabled {
+		ColorReset = "\033[0m"
+		ColorRed = "\033[0;31m"
+		ColorGreen = "\033[0;32m"
+		ColorYellow = "\033[1;33m"
+		ColorBlue = "\033[0;34m"
+		ColorCyan = "\033[0;36m"
+		return
+	}
+	ColorReset, ColorRed, ColorGreen, ColorYellow, ColorBlue, ColorCyan = "", "", "", "", "", ""
+}
+
+// ColorEnabled reports whether ANSI colors are in use
+func ColorEnabled() bool {
+	return colorEnabled
+}
+
 func PrintError(msg string) {
 	fmt.Fprintf(os.Stderr, "%sERROR: %s%s\n", ColorRed, msg, ColorReset)
 }
@@ -30,3 +73,9 @@ func PrintInfo(msg string) {
 func PrintWarning(msg string) {
 	fmt.Printf("%sWARNING: %s%s\n", ColorYellow, msg, ColorReset)
 }
+
+// PrintWarningStderr is PrintWarning on stderr, for commands whose stdout
+// must stay parseable
+func PrintWarningStderr(msg string) {
+	fmt.Fprintf(os.Stderr, "%sWARNING: %s%s\n", ColorYellow, msg, ColorReset)
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// withTerminal fakes whether stdout is a terminal and re-runs color
+// detection, restoring the previous state when the test ends
+func withTerminal(t *testing.T, isTerminal bool) {
+	t.Helper()
+	origDetect, origEnabled := stdoutIsTerminal, colorEnabled
+	t.Cleanup(func() {
+		stdoutIsTerminal = origDetect
+		SetColorEnabled(origEnabled)
+	})
+	stdoutIsTerminal = func() bool { return isTerminal }
+	SetColorEnabled(shouldColor())
+}
+
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestColors_OnTerminal(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	withTerminal(t, true)
+
+	out := captureOutput(t, func() { PrintSuccess("done") })
+	if !strings.HasPrefix(out, "\033[0;32mSUCCESS: done\033[0m") {
+		t.Errorf("expected green output on a terminal, got %q", out)
+	}
+	out = captureOutput(t, func() { PrintWarning("careful") })
+	if !strings.Contains(out, "\033[1;33m") {
+		t.Errorf("expected yellow warning on a terminal, got %q", out)
+	}
+}
+
+func TestColors_NoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	withTerminal(t, true)
+
+	if ColorEnabled() {
+		t.Error("NO_COLOR should disable colors")
+	}
+	out := captureOutput(t, func() { PrintInfo("plain") })
+	if out != "INFO: plain\n" {
+		t.Errorf("output = %q, want plain text", out)
+	}
+}
+
+func TestColors_NotTerminal(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	withTerminal(t, false)
+
+	out := captureOutput(t, func() {
+		PrintSuccess("piped")
+		PrintWarning("piped")
+	})
+	if strings.Contains(out, "\033[") {
+		t.Errorf("piped output should not contain color codes, got %q", out)
+	}
+}
+
+func TestSetColorEnabled_Override(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	withTerminal(t, true)
+
+	SetColorEnabled(false)
+	if ColorGreen != "" || ColorReset != "" {
+		t.Error("SetColorEnabled(false) should clear color codes")
+	}
+}
+
+func TestSymbols_ASCII(t *testing.T) {
+	orig := ASCIISymbols()
+	t.Cleanup(func() { SetASCIISymbols(orig) })
+
+	SetASCIISymbols(true)
+	if SymbolOK != "[OK]" || SymbolWarn != "[WARN]" || SymbolErr != "[ERR]" {
+		t.Errorf("ASCII symbols = %q %q %q", SymbolOK, SymbolWarn, SymbolErr)
+	}
+	SetASCIISymbols(false)
+	if SymbolOK != "✓" || SymbolWarn != "⚠" || SymbolErr != "✗" {
+		t.Errorf("Unicode symbols = %q %q %q", SymbolOK, SymbolWarn, SymbolErr)
+	}
+}
+
+func TestSymbols_LocaleDetection(t *testing.T) {
+	tests := []struct {
+		lcAll, lang, term string
+		want              bool
+	}{
+		{"", "en_US.UTF-8", "xterm-256color", true},
+		{"", "de_DE.utf8", "xterm", true},
+		{"C", "en_US.UTF-8", "xterm", false},
+		{"", "", "xterm", false},
+		{"", "en_US.UTF-8", "dumb", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_CTYPE", "")
+		t.Setenv("LANG", tt.lang)
+		t.Setenv("TERM", tt.term)
+		if got := unicodeLocale(); got != tt.want {
+			t.Errorf("unicodeLocale() with LC_ALL=%q LANG=%q TERM=%q = %v, want %v", tt.lcAll, tt.lang, tt.term, got, tt.want)
+		}
+	}
+}
@@ -2,10 +2,32 @@ package ui
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/term"
+
+	"github.com/neverprepared/shell-profile-manager/internal/templates"
 )
 
+// assumeYes answers every Confirm prompt affirmatively (the global --yes flag)
+var assumeYes bool
+
+// SetAssumeYes makes Confirm return true without prompting
+func SetAssumeYes(yes bool) {
+	assumeYes = yes
+}
+
+// AssumeYes reports whether prompts are being answered automatically
+func AssumeYes() bool {
+	return assumeYes
+}
+
+// IsInteractive reports whether stdin is a terminal that can answer prompts
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
 // SelectProfile prompts the user to select a profile from a list
 func SelectProfile(profiles []string, message string) (string, error) {
 	if len(profiles) == 0 {
@@ -28,16 +50,19 @@ func SelectProfile(profiles []string, message string) (string, error) {
 
 // SelectTemplate prompts the user to select a template
 func SelectTemplate() (string, error) {
+	var options []string
+	names := make(map[string]string)
+	for _, t := range templates.Builtin() {
+		option := fmt.Sprintf("%s - %s", t.Name, t.Description)
+		options = append(options, option)
+		names[option] = t.Name
+	}
+
 	var selected string
 	prompt := &survey.Select{
 		Message: "Select template:",
-		Options: []string{
-			"basic - Minimal configuration",
-			"personal - Personal projects",
-			"work - Work projects",
-			"client - Client projects",
-		},
-		Default: "basic - Minimal configuration",
+		Options: options,
+		Default: options[0],
 	}
 
 	err := survey.AskOne(prompt, &selected)
@@ -46,16 +71,10 @@ func SelectTemplate() (string, error) {
 	}
 
 	// Extract template name from selection
-	switch selected {
-	case "personal - Personal projects":
-		return "personal", nil
-	case "work - Work projects":
-		return "work", nil
-	case "client - Client projects":
-		return "client", nil
-	default:
-		return "basic", nil
+	if name, ok := names[selected]; ok {
+		return name, nil
 	}
+	return "basic", nil
 }
 
 // Input prompts the user for text input
@@ -76,6 +95,11 @@ func Input(message string, defaultVal string) (string, error) {
 
 // Confirm prompts the user for yes/no confirmation
 func Confirm(message string, defaultVal bool) (bool, error) {
+	if assumeYes {
+		fmt.Printf("? %s Yes (--yes)\n", message)
+		return true, nil
+	}
+
 	var result bool
 	prompt := &survey.Confirm{
 		Message: message,
@@ -0,0 +1,30 @@
+package ui
+
+import "testing"
+
+func TestConfirm_AssumeYes(t *testing.T) {
+	SetAssumeYes(true)
+	defer SetAssumeYes(false)
+
+	for _, defaultVal := range []bool{true, false} {
+		confirmed, err := Confirm("Proceed?", defaultVal)
+		if err != nil {
+			t.Fatalf("Confirm() error: %v", err)
+		}
+		if !confirmed {
+			t.Errorf("Confirm(default=%v) = false, want true under --yes", defaultVal)
+		}
+	}
+}
+
+func TestSetAssumeYes(t *testing.T) {
+	defer SetAssumeYes(false)
+
+	if AssumeYes() {
+		t.Fatal("AssumeYes() should default to false")
+	}
+	SetAssumeYes(true)
+	if !AssumeYes() {
+		t.Error("AssumeYes() = false after SetAssumeYes(true)")
+	}
+}
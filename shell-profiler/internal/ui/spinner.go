@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// asciiSpinnerFrames replace spinnerFrames along with the ASCII status symbols
+var asciiSpinnerFrames = []string{"|", "/", "-", "\\"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// Spinner shows progress for a long-running operation. On a terminal it
+// animates in place; otherwise Start prints the message once so piped output
+// stays readable. Like the status symbols and colors, it sticks to ASCII and
+// plain text where those are in use. Stop is safe to call at any time, so
+// callers can defer it to cover error paths.
+type Spinner struct {
+	out        io.Writer
+	isTerminal bool
+
+	mu   sync.Mutex
+	msg  string
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSpinner returns a spinner writing to stdout
+func NewSpinner() *Spinner {
+	return newSpinner(os.Stdout, stdoutIsTerminal())
+}
+
+func newSpinner(out io.Writer, isTerminal bool) *Spinner {
+	return &Spinner{out: out, isTerminal: isTerminal}
+}
+
+// Start shows msg, replacing any message already being shown
+func (s *Spinner) Start(msg string) {
+	s.Stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msg = msg
+
+	if !s.isTerminal {
+		fmt.Fprintf(s.out, "%s...\n", msg)
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.animate(msg, s.stop, s.done)
+}
+
+// Stop ends the animation and clears the spinner line
+func (s *Spinner) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (s *Spinner) animate(msg string, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	frames := spinnerFrames
+	if ASCIISymbols() {
+		frames = asciiSpinnerFrames
+	}
+	// Without escape codes the line is cleared by overwriting it
+	clearLine := "\r\033[K"
+	if !ColorEnabled() {
+		blank := strings.Repeat(" ", cellWidth(frames[0]+" "+msg))
+		clearLine = "\r" + blank + "\r"
+	}
+
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		fmt.Fprintf(s.out, "\r%s%s%s %s", ColorCyan, frames[frame%len(frames)], ColorReset, msg)
+		select {
+		case <-stop:
+			// Clear the line so the next output starts clean
+			fmt.Fprint(s.out, clearLine)
+			return
+		case <-ticker.C:
+		}
+	}
+}
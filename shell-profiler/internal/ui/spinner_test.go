@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for use by the spinner goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSpinner_NonTerminalPrintsOnce(t *testing.T) {
+	var out syncBuffer
+	s := newSpinner(&out, false)
+
+	s.Start("Fetching templates")
+	time.Sleep(3 * spinnerInterval)
+	s.Stop()
+
+	if got := out.String(); got != "Fetching templates...\n" {
+		t.Errorf("output = %q, want a single line", got)
+	}
+}
+
+// setOutputStyle switches colors and ASCII symbols for the rest of a test
+func setOutputStyle(t *testing.T, color, ascii bool) {
+	t.Helper()
+	prevColor, prevASCII := ColorEnabled(), ASCIISymbols()
+	SetColorEnabled(color)
+	SetASCIISymbols(ascii)
+	t.Cleanup(func() {
+		SetColorEnabled(prevColor)
+		SetASCIISymbols(prevASCII)
+	})
+}
+
+func TestSpinner_TerminalAnimatesAndClears(t *testing.T) {
+	setOutputStyle(t, true, false)
+	var out syncBuffer
+	s := newSpinner(&out, true)
+
+	s.Start("Working")
+	time.Sleep(2 * spinnerInterval)
+	s.Stop()
+
+	got := out.String()
+	if !strings.Contains(got, "Working") || !strings.Contains(got, spinnerFrames[0]) {
+		t.Errorf("expected animated frames, got %q", got)
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("Stop should clear the spinner line, got %q", got)
+	}
+
+	// Nothing is written once stopped
+	before := out.String()
+	time.Sleep(2 * spinnerInterval)
+	if out.String() != before {
+		t.Error("spinner kept writing after Stop")
+	}
+}
+
+func TestSpinner_ASCIIWithoutColor(t *testing.T) {
+	setOutputStyle(t, false, true)
+	var out syncBuffer
+	s := newSpinner(&out, true)
+
+	s.Start("Working")
+	time.Sleep(2 * spinnerInterval)
+	s.Stop()
+
+	got := out.String()
+	if !strings.Contains(got, "\r| Working") {
+		t.Errorf("expected ASCII frames, got %q", got)
+	}
+	for _, frame := range spinnerFrames {
+		if strings.Contains(got, frame) {
+			t.Errorf("Unicode frame %q in ASCII output %q", frame, got)
+		}
+	}
+	if strings.Contains(got, "\033") {
+		t.Errorf("escape codes in uncolored output %q", got)
+	}
+	if !strings.HasSuffix(got, "\r"+strings.Repeat(" ", len("| Working"))+"\r") {
+		t.Errorf("Stop should blank the spinner line, got %q", got)
+	}
+}
+
+func TestSpinner_OutOfOrderCalls(t *testing.T) {
+	for _, isTerminal := range []bool{true, false} {
+		var out syncBuffer
+		s := newSpinner(&out, isTerminal)
+
+		s.Stop() // before Start
+		s.Start("one")
+		s.Start("two") // restart without Stop
+		s.Stop()
+		s.Stop() // twice
+
+		var zero Spinner
+		zero.Stop()
+	}
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"os"
+	"strings"
+)
+
+// Status symbols. Like the colors, they are meant to be used directly in
+// format strings, and switch to ASCII where Unicode may not render.
+var (
+	SymbolOK   string
+	SymbolWarn string
+	SymbolErr  string
+)
+
+var asciiSymbols bool
+
+func init() {
+	SetASCIISymbols(!unicodeLocale())
+}
+
+// unicodeLocale reports whether the locale, as direnv and the shell see it,
+// is UTF-8 and the terminal is not a dumb one
+func unicodeLocale() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := strings.ToLower(os.Getenv(name)); value != "" {
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return false
+}
+
+// SetASCIISymbols switches the status symbols between Unicode glyphs and
+// [OK]/[WARN]/[ERR]
+func SetASCIISymbols(ascii bool) {
+	asciiSymbols = ascii
+	if ascii {
+		SymbolOK, SymbolWarn, SymbolErr = "[OK]", "[WARN]", "[ERR]"
+		return
+	}
+	SymbolOK, SymbolWarn, SymbolErr = "✓", "⚠", "✗"
+}
+
+// ASCIISymbols reports whether ASCII status symbols are in use
+func ASCIISymbols() bool {
+	return asciiSymbols
+}
@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+	"golang.org/x/text/width"
+)
+
+// Output formats of the read commands (--format)
+const (
+	FormatTable = "table" // Aligned columns with a header, colored on a terminal
+	FormatPlain = "plain" // Tab-separated fields, no header or color, for awk and cut
+	FormatJSON  = "json"
+)
+
+// ParseFormat validates a --format value; empty means FormatTable
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return FormatTable, nil
+	case FormatTable, FormatPlain, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format: %s (use table, plain or json)", format)
+}
+
+// Table is tabular output that can be rendered in any of the output formats.
+// Cells may contain color codes; they are ignored when aligning and dropped
+// from plain and JSON output.
+type Table struct {
+	Headers []string // Column headers; none for a table without a header row
+	Rows    [][]string
+
+	// Indent prefixes every line of table output
+	Indent string
+	// MaxWidth is the width table output is truncated to. Zero means the
+	// terminal's width when stdout is a terminal, and no limit otherwise.
+	MaxWidth int
+}
+
+// minColumnWidth is the narrowest a column is truncated to
+const minColumnWidth = 4
+
+// terminalWidth returns the width of the terminal on stdout, or 0 if stdout
+// is not a terminal; replaced in tests
+var terminalWidth = func() int {
+	if !stdoutIsTerminal() {
+		return 0
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// NewTable returns an empty table with the given column headers
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow appends a row. With headers, missing cells are left empty.
+func (t *Table) AddRow(cells ...string) {
+	row := make([]string, max(len(t.Headers), len(cells)))
+	copy(row, cells)
+	t.Rows = append(t.Rows, row)
+}
+
+// Render writes the table to w in format. JSON output is an array of
+// objects keyed by the lower-cased headers.
+func (t *Table) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatPlain:
+		for _, row := range t.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = plainCell(cell)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(cells, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatJSON:
+		content, err := json.MarshalIndent(t.Records(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(content))
+		return err
+	}
+
+	columns := len(t.Headers)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	widths := make([]int, columns)
+	for i, header := range t.Headers {
+		widths[i] = cellWidth(header)
+	}
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], cellWidth(cell))
+		}
+	}
+
+	maxWidth := t.MaxWidth
+	if maxWidth == 0 {
+		maxWidth = terminalWidth()
+	}
+	if maxWidth > 0 {
+		shrinkColumns(widths, maxWidth-cellWidth(t.Indent))
+	}
+
+	if len(t.Headers) > 0 {
+		if _, err := fmt.Fprintf(w, "%s%s%s%s\n", t.Indent, ColorBlue, alignRow(t.Headers, widths), ColorReset); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.Rows {
+		if _, err := fmt.Fprintln(w, t.Indent+alignRow(row, widths)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Records returns the rows as objects keyed by the lower-cased headers,
+// without color codes, for embedding the table in larger JSON output
+func (t *Table) Records() []map[string]string {
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(t.Headers))
+		for i, header := range t.Headers {
+			record[jsonKey(header)] = stripANSI(row[i])
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// shrinkColumns narrows the widest columns, down to minColumnWidth, until
+// the row with its separators fits in maxWidth
+func shrinkColumns(widths []int, maxWidth int) {
+	total := 2 * (len(widths) - 1)
+	for _, width := range widths {
+		total += width
+	}
+	for total > maxWidth {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// RenderFields writes the fields of a single record to w in format: two
+// columns for table and plain, a JSON object in field order for json
+func RenderFields(w io.Writer, fields [][2]string, format string) error {
+	if format != FormatJSON {
+		table := NewTable("FIELD", "VALUE")
+		for _, field := range fields {
+			table.AddRow(field[0], field[1])
+		}
+		return table.Render(w, format)
+	}
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i, field := range fields {
+		key, _ := json.Marshal(field[0])
+		value, _ := json.Marshal(field[1])
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", key, value)
+	}
+	b.WriteString("\n}")
+	_, err := fmt.Fprintln(w, b.String())
+	return err
+}
+
+// alignRow truncates each cell to its column width and pads all but the last
+func alignRow(cells []string, widths []int) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		cell = truncateCell(cell, widths[i])
+		b.WriteString(cell)
+		if i < len(cells)-1 {
+			b.WriteString(strings.Repeat(" ", widths[i]-cellWidth(cell)+2))
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// ansiPattern matches the color codes used in cells
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripANSI removes color codes from s
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// cellWidth is the number of terminal columns s takes: color codes and
+// combining marks take none, East Asian wide characters two
+func cellWidth(s string) int {
+	n := 0
+	for _, r := range stripANSI(s) {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth is the number of terminal columns r takes
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// truncateCell shortens a cell wider than maxWidth, marking the cut with an
+// ellipsis. Truncated cells lose their color codes.
+func truncateCell(cell string, maxWidth int) string {
+	if cellWidth(cell) <= maxWidth {
+		return cell
+	}
+	ellipsis := "…"
+	if asciiSymbols {
+		ellipsis = "~"
+	}
+	var b strings.Builder
+	used := cellWidth(ellipsis)
+	for _, r := range stripANSI(cell) {
+		if used+runeWidth(r) > maxWidth {
+			break
+		}
+		b.WriteRune(r)
+		used += runeWidth(r)
+	}
+	return b.String() + ellipsis
+}
+
+// plainCell keeps a cell on one line and inside its field, without color
+func plainCell(cell string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ").Replace(stripANSI(cell))
+}
+
+// jsonKey turns a column header such as "GIT EMAIL" into git_email
+func jsonKey(header string) string {
+	return strings.ToLower(strings.ReplaceAll(header, " ", "_"))
+}
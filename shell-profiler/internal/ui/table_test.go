@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sampleTable() *Table {
+	table := NewTable("NAME", "GIT EMAIL")
+	table.AddRow("acme", "dev@acme.example")
+	table.AddRow("personal-projects", "me@example.com")
+	table.AddRow("tabbed", "a\tb")
+	return table
+}
+
+func TestTable_PlainIsTabSeparated(t *testing.T) {
+	withTerminal(t, true)
+	t.Setenv("NO_COLOR", "")
+	SetColorEnabled(true)
+
+	var buf bytes.Buffer
+	if err := sampleTable().Render(&buf, FormatPlain); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "\033") {
+		t.Errorf("plain output contains ANSI escapes: %q", out)
+	}
+	want := "acme\tdev@acme.example\npersonal-projects\tme@example.com\ntabbed\ta b\n"
+	if out != want {
+		t.Errorf("plain output = %q, want %q", out, want)
+	}
+}
+
+func TestTable_AlignsColumns(t *testing.T) {
+	withTerminal(t, false)
+
+	var buf bytes.Buffer
+	if err := sampleTable().Render(&buf, FormatTable); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header and 3 rows, got:\n%s", buf.String())
+	}
+	column := strings.Index(lines[0], "GIT EMAIL")
+	for _, line := range lines {
+		if line != strings.TrimRight(line, " ") {
+			t.Errorf("trailing spaces in %q", line)
+		}
+	}
+	for _, line := range lines[1:] {
+		if len(line) <= column || line[column-1] != ' ' || line[column] == ' ' {
+			t.Errorf("second column does not start at %d in %q", column, line)
+		}
+	}
+	if lines[1] != "acme               dev@acme.example" {
+		t.Errorf("row = %q", lines[1])
+	}
+}
+
+func TestTable_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleTable().Render(&buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(records) != 3 || records[0]["name"] != "acme" || records[0]["git_email"] != "dev@acme.example" {
+		t.Errorf("records = %v", records)
+	}
+}
+
+func TestRenderFields_JSONKeepsOrder(t *testing.T) {
+	var buf bytes.Buffer
+	fields := [][2]string{{"name", "acme"}, {"home", "/p/acme"}}
+	if err := RenderFields(&buf, fields, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"name\": \"acme\",\n  \"home\": \"/p/acme\"\n}\n"
+	if buf.String() != want {
+		t.Errorf("RenderFields() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if format, err := ParseFormat(""); err != nil || format != FormatTable {
+		t.Errorf("ParseFormat(\"\") = %q, %v", format, err)
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestTable_AlignsWideAndColoredCells(t *testing.T) {
+	withTerminal(t, false)
+
+	table := &Table{}
+	table.AddRow("東京", "wide")
+	table.AddRow("\033[0;36mab\033[0m", "colored")
+	table.AddRow("cafe\u0301", "combining")
+	var buf bytes.Buffer
+	if err := table.Render(&buf, FormatTable); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"東京  wide",
+		"\033[0;36mab\033[0m    colored",
+		"cafe\u0301  combining",
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d rows and no header, got:\n%s", len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("row %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestTable_TruncatesToMaxWidth(t *testing.T) {
+	withTerminal(t, false)
+	SetASCIISymbols(false)
+	t.Cleanup(func() { SetASCIISymbols(!unicodeLocale()) })
+
+	table := &Table{Headers: []string{"NAME", "PATH"}, MaxWidth: 20}
+	table.AddRow("acme", "/home/dev/workspaces/profiles/acme")
+	table.AddRow("x", "/p")
+	var buf bytes.Buffer
+	if err := table.Render(&buf, FormatTable); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAME  PATH\nacme  /home/dev/wor…\nx     /p\n"
+	if buf.String() != want {
+		t.Errorf("truncated table = %q, want %q", buf.String(), want)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if cellWidth(line) > 20 {
+			t.Errorf("%q is wider than 20 columns", line)
+		}
+	}
+}
+
+func TestTable_TruncatesWideCharacters(t *testing.T) {
+	if got := truncateCell("東京都庁", 6); got != "東京…" && got != "東京~" {
+		t.Errorf("truncateCell() = %q, want 東京 and an ellipsis", got)
+	}
+	if got := cellWidth(truncateCell("東京都庁", 6)); got > 6 {
+		t.Errorf("truncated width = %d, want at most 6", got)
+	}
+}
+
+func TestTable_PlainDropsColor(t *testing.T) {
+	table := &Table{}
+	table.AddRow("\033[0;36macme\033[0m", "1")
+	var buf bytes.Buffer
+	if err := table.Render(&buf, FormatPlain); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "acme\t1\n" {
+		t.Errorf("plain output = %q", buf.String())
+	}
+}
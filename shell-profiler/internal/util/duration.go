@@ -0,0 +1,56 @@
+// Package util holds small helpers shared by commands and the CLI
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationUnits are the units accepted by ParseHumanDuration
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseHumanDuration parses durations such as 30d, 2w, 12h or 1d12h: one or
+// more whole numbers, each followed by a unit of s, m, h, d (day) or w (week).
+// Unlike time.ParseDuration it has days and weeks, and rejects negatives.
+func ParseHumanDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration: expected e.g. 30d, 2w or 12h")
+	}
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+		if digits == 0 {
+			return 0, fmt.Errorf("invalid duration %q: expected a number before %q", s, rest)
+		}
+		unitLen := len(rest[digits:]) - len(strings.TrimLeft(rest[digits:], "abcdefghijklmnopqrstuvwxyz"))
+		if unitLen == 0 {
+			return 0, fmt.Errorf("invalid duration %q: missing unit after %s (use s, m, h, d or w)", s, rest[:digits])
+		}
+
+		unit, ok := durationUnits[rest[digits:digits+unitLen]]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q: unknown unit %q (use s, m, h, d or w)", s, rest[digits:digits+unitLen])
+		}
+		n, err := strconv.ParseInt(rest[:digits], 10, 64)
+		if err != nil || n > int64(math.MaxInt64/unit) || total > math.MaxInt64-time.Duration(n)*unit {
+			return 0, fmt.Errorf("invalid duration %q: too large", s)
+		}
+		total += time.Duration(n) * unit
+		rest = rest[digits+unitLen:]
+	}
+	return total, nil
+}
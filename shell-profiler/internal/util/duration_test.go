@@ -0,0 +1,62 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHumanDuration(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"45s", 45 * time.Second},
+		{"30m", 30 * time.Minute},
+		{"12h", 12 * time.Hour},
+		{"30d", 30 * day},
+		{"2w", 14 * day},
+		{"0d", 0},
+		{"1d12h", day + 12*time.Hour},
+		{"1w2d3h4m5s", 9*day + 3*time.Hour + 4*time.Minute + 5*time.Second},
+		{"90m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseHumanDuration(tt.in)
+			if err != nil {
+				t.Fatalf("ParseHumanDuration(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHumanDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHumanDuration_Errors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", "empty duration"},
+		{"-3d", "must not be negative"},
+		{"3y", `unknown unit "y"`},
+		{"5ms", `unknown unit "ms"`},
+		{"d", "expected a number"},
+		{"12", "missing unit"},
+		{"1d12", "missing unit"},
+		{"1.5h", "missing unit"},
+		{"1d 2h", "expected a number"},
+		{"99999999999999w", "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := ParseHumanDuration(tt.in)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseHumanDuration(%q) error = %v, want it to mention %q", tt.in, err, tt.wantErr)
+			}
+		})
+	}
+}
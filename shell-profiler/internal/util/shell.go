@@ -0,0 +1,151 @@
+package util
+
+import (
+	"strings"
+)
+
+// shellSafe reports whether every byte of s can appear unquoted in a shell
+// word without changing its meaning
+func shellSafe(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("@%+=:,./_-", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ShellQuote returns s as a single shell word with no expansion at all:
+// unchanged if it only holds safe characters, otherwise single-quoted, with
+// each embedded single quote ending the quoted part, escaped and reopening
+// it. direnv's dotenv reads single quotes the same way.
+func ShellQuote(s string) string {
+	if s != "" && shellSafe(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShellQuoteExpand returns s double-quoted as a single shell word in which
+// references to the named variables, as $NAME or ${NAME}, still expand. Every
+// other $, along with backticks, double quotes and backslashes, is escaped,
+// so it is taken literally.
+func ShellQuoteExpand(s string, vars ...string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '$':
+			if n := expandableRef(s[i:], vars); n > 0 {
+				b.WriteString(s[i : i+n])
+				i += n - 1
+				continue
+			}
+			b.WriteString(`\$`)
+		case '`', '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// expandableRef returns the length of the $NAME or ${NAME} reference to one
+// of vars at the start of s, or 0
+func expandableRef(s string, vars []string) int {
+	for _, name := range vars {
+		if strings.HasPrefix(s, "${"+name+"}") {
+			return len(name) + 3
+		}
+		if strings.HasPrefix(s, "$"+name) {
+			// $NAME must not run on into a longer identifier
+			next := len(name) + 1
+			if next == len(s) || !isIdentByte(s[next]) {
+				return next
+			}
+		}
+	}
+	return 0
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ShellUnquote reverses ShellQuote and ShellQuoteExpand for a single word,
+// leaving expandable references in place. Words that are not quoted are
+// returned unchanged.
+func ShellUnquote(s string) string {
+	switch {
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], `'\''`, "'")
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		var b strings.Builder
+		inner := s[1 : len(s)-1]
+		for i := 0; i < len(inner); i++ {
+			if inner[i] == '\\' && i+1 < len(inner) && strings.IndexByte("$`\"\\", inner[i+1]) >= 0 {
+				i++
+			}
+			b.WriteByte(inner[i])
+		}
+		return b.String()
+	}
+	return s
+}
+
+// SSHQuote returns s as a single ssh_config argument: unchanged if it holds
+// no whitespace, quotes or backslashes, otherwise double-quoted with any
+// double quote or backslash escaped, as OpenSSH splits arguments
+func SSHQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// SSHFields splits an ssh_config value into its arguments the way OpenSSH
+// does: on whitespace outside double or single quotes, with a backslash
+// escaping the next quote, backslash or space
+func SSHFields(s string) []string {
+	var fields []string
+	var b strings.Builder
+	inField := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(`"' \`, s[i+1]) >= 0:
+			i++
+			b.WriteByte(s[i])
+			inField = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inField = true
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+				inField = false
+			}
+		default:
+			b.WriteByte(c)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
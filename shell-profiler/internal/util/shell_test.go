@@ -0,0 +1,132 @@
+package util
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// shellWords are values that must survive quoting byte for byte
+var shellWords = []string{
+	"plain",
+	"/opt/acme/bin",
+	"",
+	"with space",
+	"it's",
+	`say "hi"`,
+	"`id`",
+	"$(id)",
+	"$HOME",
+	`back\slash`,
+	"semi;colon&and|pipe",
+	"*glob?",
+	"tab\there",
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"/opt/acme/bin", "/opt/acme/bin"},
+		{"", "''"},
+		{"with space", "'with space'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShellQuoteExpand(t *testing.T) {
+	vars := []string{"WORKSPACE_HOME", "HOME"}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"bin", `"bin"`},
+		{"$WORKSPACE_HOME/bin", `"$WORKSPACE_HOME/bin"`},
+		{"${HOME}/bin", `"${HOME}/bin"`},
+		{"$HOMEDIR/bin", `"\$HOMEDIR/bin"`},
+		{"$OTHER", `"\$OTHER"`},
+		{"$(id)", `"\$(id)"`},
+		{"`id`", "\"\\`id\\`\""},
+		{`a"b\c`, `"a\"b\\c"`},
+	}
+	for _, tt := range tests {
+		if got := ShellQuoteExpand(tt.in, vars...); got != tt.want {
+			t.Errorf("ShellQuoteExpand(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShellUnquote_RoundTrip(t *testing.T) {
+	for _, s := range shellWords {
+		if got := ShellUnquote(ShellQuote(s)); got != s {
+			t.Errorf("ShellUnquote(ShellQuote(%q)) = %q", s, got)
+		}
+		if got := ShellUnquote(ShellQuoteExpand(s, "HOME")); got != s {
+			t.Errorf("ShellUnquote(ShellQuoteExpand(%q)) = %q", s, got)
+		}
+	}
+}
+
+func TestShellQuote_Bash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	for _, s := range shellWords {
+		if s == "$HOME" {
+			continue // Expands under ShellQuoteExpand by design
+		}
+		for _, quoted := range []string{ShellQuote(s), ShellQuoteExpand(s, "HOME")} {
+			out, err := exec.Command(bash, "-c", "printf %s "+quoted).Output()
+			if err != nil {
+				t.Fatalf("bash failed for %s: %v", quoted, err)
+			}
+			if string(out) != s {
+				t.Errorf("bash read %s as %q, want %q", quoted, out, s)
+			}
+		}
+	}
+}
+
+func TestSSHQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/home/dev/.ssh/known_hosts", "/home/dev/.ssh/known_hosts"},
+		{"${WORKSPACE_HOME}/.ssh/id_ed25519", "${WORKSPACE_HOME}/.ssh/id_ed25519"},
+		{"/Users/dev/My Profiles/acme/.ssh/known_hosts", `"/Users/dev/My Profiles/acme/.ssh/known_hosts"`},
+		{"", `""`},
+		{`a"b\c`, `"a\"b\\c"`},
+	}
+	for _, tt := range tests {
+		if got := SSHQuote(tt.in); got != tt.want {
+			t.Errorf("SSHQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSSHFields(t *testing.T) {
+	got := SSHFields(`"/a b/known_hosts" /c/known_hosts2  'd e' f\ g "h\"i"`)
+	want := []string{"/a b/known_hosts", "/c/known_hosts2", "d e", "f g", `h"i`}
+	if len(got) != len(want) {
+		t.Fatalf("SSHFields() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SSHFields()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	for _, s := range shellWords {
+		if fields := SSHFields(SSHQuote(s)); len(fields) != 1 || fields[0] != s {
+			t.Errorf("SSHFields(SSHQuote(%q)) = %q", s, fields)
+		}
+	}
+}